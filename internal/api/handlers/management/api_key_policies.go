@@ -2,6 +2,7 @@ package management
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strings"
 
@@ -41,11 +42,32 @@ func (h *Handler) PutAPIKeyPolicies(c *gin.Context) {
 		arr = obj.Items
 	}
 
+	if err := validateAPIKeyPoliciesFailover(arr); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	h.cfg.APIKeyPolicies = append([]config.APIKeyPolicy(nil), arr...)
 	h.cfg.SanitizeAPIKeyPolicies()
 	h.persist(c)
 }
 
+// validateAPIKeyPoliciesFailover runs config.ValidateFailoverPolicy over every
+// policy's Providers map up front, so a request introducing a failover cycle
+// is rejected with a clear error instead of having SanitizeAPIKeyPolicies
+// silently drop the offending policy's Providers map later.
+func validateAPIKeyPoliciesFailover(policies []config.APIKeyPolicy) error {
+	for _, p := range policies {
+		if len(p.Failover.Providers) == 0 {
+			continue
+		}
+		if err := config.ValidateFailoverPolicy(p.Failover.Providers); err != nil {
+			return fmt.Errorf("api-key %q: %w", p.APIKey, err)
+		}
+	}
+	return nil
+}
+
 func (h *Handler) PatchAPIKeyPolicies(c *gin.Context) {
 	if h == nil || h.cfg == nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "config unavailable"})
@@ -53,11 +75,12 @@ func (h *Handler) PatchAPIKeyPolicies(c *gin.Context) {
 	}
 
 	type policyPatch struct {
-		ExcludedModels    *[]string       `json:"excluded-models"`
-		AllowClaudeOpus46 *bool           `json:"allow-claude-opus-4-6"`
-		DailyLimits       *map[string]int `json:"daily-limits"`
-		DailyBudgetUSD    *float64        `json:"daily-budget-usd"`
-		APIKey            *string         `json:"api-key"`
+		ExcludedModels    *[]string                   `json:"excluded-models"`
+		AllowClaudeOpus46 *bool                        `json:"allow-claude-opus-4-6"`
+		DailyLimits       *map[string]int              `json:"daily-limits"`
+		DailyBudgetUSD    *float64                     `json:"daily-budget-usd"`
+		Failover          *config.APIKeyFailoverPolicy `json:"failover"`
+		APIKey            *string                      `json:"api-key"`
 	}
 	var body struct {
 		APIKey string       `json:"api-key"`
@@ -115,6 +138,14 @@ func (h *Handler) PatchAPIKeyPolicies(c *gin.Context) {
 	if body.Value.DailyBudgetUSD != nil {
 		entry.DailyBudgetUSD = *body.Value.DailyBudgetUSD
 	}
+	if body.Value.Failover != nil {
+		entry.Failover = *body.Value.Failover
+	}
+
+	if err := validateAPIKeyPoliciesFailover([]config.APIKeyPolicy{entry}); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	if targetIndex >= 0 {
 		h.cfg.APIKeyPolicies[targetIndex] = entry
@@ -0,0 +1,92 @@
+package management
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/audit"
+)
+
+// GetAudit streams the audit log as JSON, optionally filtered by api-key
+// (matched against the record's stored api_key_hash via audit.HashAPIKey)
+// and by from/to (inclusive RFC3339 timestamps). It also reports whether
+// the hash chain verifies across the full, unfiltered log, since filtering
+// client-side would otherwise make a broken chain look intact.
+func (h *Handler) GetAudit(c *gin.Context) {
+	if h == nil || h.auditLog == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "audit log unavailable"})
+		return
+	}
+
+	all, err := h.auditLog.Records(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	chainOK, brokenAt := audit.VerifyChain(all)
+
+	entries := all
+	if apiKey := strings.TrimSpace(c.Query("api-key")); apiKey != "" {
+		hash := audit.HashAPIKey(apiKey)
+		filtered := make([]audit.Record, 0, len(entries))
+		for _, r := range entries {
+			if r.APIKeyHash == hash {
+				filtered = append(filtered, r)
+			}
+		}
+		entries = filtered
+	}
+	if from := strings.TrimSpace(c.Query("from")); from != "" {
+		entries = filterAuditFrom(entries, from)
+	}
+	if to := strings.TrimSpace(c.Query("to")); to != "" {
+		entries = filterAuditTo(entries, to)
+	}
+
+	resp := gin.H{"entries": entries, "chain_verified": chainOK}
+	if !chainOK {
+		resp["chain_broken_at"] = brokenAt
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func filterAuditFrom(entries []audit.Record, from string) []audit.Record {
+	t, err := parseAuditTime(from)
+	if err != nil {
+		return entries
+	}
+	out := make([]audit.Record, 0, len(entries))
+	for _, r := range entries {
+		if !r.Timestamp.Before(t) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+func filterAuditTo(entries []audit.Record, to string) []audit.Record {
+	t, err := parseAuditTime(to)
+	if err != nil {
+		return entries
+	}
+	out := make([]audit.Record, 0, len(entries))
+	for _, r := range entries {
+		if !r.Timestamp.After(t) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// parseAuditTime accepts either a full RFC3339 timestamp or a bare
+// "YYYY-MM-DD" day, the same pair of shapes /admin endpoints elsewhere in
+// this package accept for from/to query parameters.
+func parseAuditTime(raw string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", raw)
+}
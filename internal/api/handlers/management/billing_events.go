@@ -0,0 +1,127 @@
+package management
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/billing"
+)
+
+// GetBillingEvents returns the most recent BillingEvents still held in the
+// event bus's in-memory ring buffer, newest first, optionally narrowed by
+// api-key, model, since/until (RFC3339 or "YYYY-MM-DD"), and paginated with
+// offset/limit.
+func (h *Handler) GetBillingEvents(c *gin.Context) {
+	if h == nil || h.billingEventBus == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "billing event bus unavailable"})
+		return
+	}
+
+	filter, err := parseBillingEventFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"events": h.billingEventBus.Events(filter)})
+}
+
+// GetBillingEventsStream streams every BillingEvent published from here on
+// as a server-sent "data: {...}\n\n" frame, until the client disconnects.
+func (h *Handler) GetBillingEventsStream(c *gin.Context) {
+	if h == nil || h.billingEventBus == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "billing event bus unavailable"})
+		return
+	}
+
+	ch, unsubscribe := h.billingEventBus.Subscribe()
+	defer unsubscribe()
+
+	c.Writer.Header().Set("Content-Type", "text/event-stream")
+	c.Writer.Header().Set("Cache-Control", "no-cache")
+	c.Writer.Header().Set("Connection", "keep-alive")
+	c.Status(http.StatusOK)
+
+	flusher, canFlush := c.Writer.(http.Flusher)
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case evt, ok := <-ch:
+			if !ok {
+				return
+			}
+			body, err := json.Marshal(evt)
+			if err != nil {
+				continue
+			}
+			_, _ = fmt.Fprintf(c.Writer, "data: %s\n\n", body)
+			if canFlush {
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+// GetBillingStats reports every (api key, model) pair's running totals from
+// the EventAggregator, sorted by api key then model.
+func (h *Handler) GetBillingStats(c *gin.Context) {
+	if h == nil || h.billingAggregator == nil {
+		c.JSON(http.StatusOK, gin.H{"stats": []any{}})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"stats": h.billingAggregator.Snapshot()})
+}
+
+func parseBillingEventFilter(c *gin.Context) (billing.BillingEventFilter, error) {
+	filter := billing.BillingEventFilter{
+		APIKey: strings.TrimSpace(c.Query("api-key")),
+		Model:  strings.TrimSpace(c.Query("model")),
+	}
+
+	if raw := strings.TrimSpace(c.Query("since")); raw != "" {
+		since, err := parseBillingEventTime(raw)
+		if err != nil {
+			return filter, fmt.Errorf("invalid since: %w", err)
+		}
+		filter.Since = since
+	}
+	if raw := strings.TrimSpace(c.Query("until")); raw != "" {
+		until, err := parseBillingEventTime(raw)
+		if err != nil {
+			return filter, fmt.Errorf("invalid until: %w", err)
+		}
+		filter.Until = until
+	}
+	if raw := strings.TrimSpace(c.Query("offset")); raw != "" {
+		offset, err := strconv.Atoi(raw)
+		if err != nil {
+			return filter, fmt.Errorf("invalid offset: %w", err)
+		}
+		filter.Offset = offset
+	}
+	if raw := strings.TrimSpace(c.Query("limit")); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil {
+			return filter, fmt.Errorf("invalid limit: %w", err)
+		}
+		filter.Limit = limit
+	}
+	return filter, nil
+}
+
+// parseBillingEventTime accepts either a full RFC3339 timestamp or a bare
+// "YYYY-MM-DD" day, the same pair of shapes parseAuditTime accepts for
+// /admin audit from/to query parameters.
+func parseBillingEventTime(raw string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", raw)
+}
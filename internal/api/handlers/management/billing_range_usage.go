@@ -0,0 +1,90 @@
+package management
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/billing"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/policy"
+)
+
+// GetRangeUsage reports usage aggregated across every API key for
+// [from, to] (inclusive "YYYY-MM-DD" day keys), broken down by day, model,
+// provider, and API key. With no from/to it defaults to the current week
+// (China Standard Time); pass range=month for the current calendar month
+// instead. top-n and sort-by (cost, tokens, requests) additionally narrow
+// each breakdown to its top entries, for dashboards that only want a
+// leaderboard rather than the full set.
+func (h *Handler) GetRangeUsage(c *gin.Context) {
+	if h == nil || h.billingStore == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "billing store unavailable"})
+		return
+	}
+
+	fromDay := strings.TrimSpace(c.Query("from"))
+	toDay := strings.TrimSpace(c.Query("to"))
+
+	var (
+		report billing.RangeUsageReport
+		err    error
+	)
+	now := time.Now()
+	switch {
+	case fromDay != "" && toDay != "":
+		report, err = billing.GetRangeUsageReport(c.Request.Context(), h.billingStore, fromDay, toDay)
+	case strings.EqualFold(strings.TrimSpace(c.Query("range")), "month"):
+		report, err = billing.GetMonthlyRangeUsageReport(c.Request.Context(), h.billingStore, now)
+	default:
+		report, err = billing.GetWeeklyRangeUsageReport(c.Request.Context(), h.billingStore, now)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if topN, ok := parseTopN(c.Query("top-n")); ok {
+		sortBy := c.Query("sort-by")
+		report.ByDay = billing.TopN(report.ByDay, sortBy, topN)
+		report.ByModel = billing.TopN(report.ByModel, sortBy, topN)
+		report.ByProvider = billing.TopN(report.ByProvider, sortBy, topN)
+		report.ByAPIKey = billing.TopN(report.ByAPIKey, sortBy, topN)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"usage": report})
+}
+
+func parseTopN(raw string) (int, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0, false
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// PostBillingReconcile recomputes stored usage cost for every row recorded
+// on day at the price in effect at the end of that day, for use after a
+// retroactive price correction (see billing.Reconcile).
+func (h *Handler) PostBillingReconcile(c *gin.Context) {
+	if h == nil || h.billingStore == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "billing store unavailable"})
+		return
+	}
+
+	day := strings.TrimSpace(c.Query("day"))
+	if day == "" {
+		day = policy.DayKeyChina(time.Now())
+	}
+
+	if err := billing.Reconcile(c.Request.Context(), h.billingStore, day); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "day": day})
+}
@@ -0,0 +1,126 @@
+package management
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/billing"
+)
+
+// GetBudgets lists every configured budget.
+func (h *Handler) GetBudgets(c *gin.Context) {
+	if h == nil || h.billingStore == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "billing store unavailable"})
+		return
+	}
+	budgets, err := h.billingStore.ListBudgets(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"budgets": budgets})
+}
+
+// GetBudgetsStatus lists every configured budget alongside its current
+// period's spend.
+func (h *Handler) GetBudgetsStatus(c *gin.Context) {
+	if h == nil || h.billingStore == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "billing store unavailable"})
+		return
+	}
+	statuses, err := billing.NewBudgetManager(h.billingStore).Status(c.Request.Context(), time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"budgets": statuses})
+}
+
+// PutBudget creates (when "id" is omitted) or replaces a configured budget.
+func (h *Handler) PutBudget(c *gin.Context) {
+	if h == nil || h.billingStore == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "billing store unavailable"})
+		return
+	}
+
+	var body struct {
+		ID               string   `json:"id"`
+		Scope            string   `json:"scope"`
+		APIKey           string   `json:"api_key"`
+		Model            string   `json:"model"`
+		Period           string   `json:"period"`
+		LimitUSD         *float64 `json:"limit_usd"`
+		Action           string   `json:"action"`
+		SoftThresholdPct float64  `json:"soft_threshold_pct"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	switch strings.TrimSpace(body.Scope) {
+	case billing.BudgetScopeKey, billing.BudgetScopeModel, billing.BudgetScopeKeyModel, billing.BudgetScopeGlobal:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "scope must be one of key, model, key+model, global"})
+		return
+	}
+	switch strings.TrimSpace(body.Period) {
+	case billing.BudgetPeriodDay, billing.BudgetPeriodWeek, billing.BudgetPeriodMonth, billing.BudgetPeriodRolling30:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "period must be one of day, week, month, rolling_30d"})
+		return
+	}
+	switch strings.TrimSpace(body.Action) {
+	case billing.BudgetActionBlock, billing.BudgetActionWarn, billing.BudgetActionThrottle:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "action must be one of block, warn, throttle"})
+		return
+	}
+	if body.LimitUSD == nil || *body.LimitUSD <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit_usd must be > 0"})
+		return
+	}
+
+	budget := billing.Budget{
+		ID:               strings.TrimSpace(body.ID),
+		Scope:            strings.TrimSpace(body.Scope),
+		APIKey:           strings.TrimSpace(body.APIKey),
+		Model:            strings.TrimSpace(body.Model),
+		Period:           strings.TrimSpace(body.Period),
+		LimitUSD:         *body.LimitUSD,
+		Action:           strings.TrimSpace(body.Action),
+		SoftThresholdPct: body.SoftThresholdPct,
+	}
+	saved, err := h.billingStore.UpsertBudget(c.Request.Context(), budget)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"budget": saved})
+}
+
+// DeleteBudget removes a configured budget, identified by the "id" query
+// parameter, along with its spend history.
+func (h *Handler) DeleteBudget(c *gin.Context) {
+	if h == nil || h.billingStore == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "billing store unavailable"})
+		return
+	}
+	id := strings.TrimSpace(c.Query("id"))
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is required"})
+		return
+	}
+	deleted, err := h.billingStore.DeleteBudget(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, gin.H{"error": "item not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
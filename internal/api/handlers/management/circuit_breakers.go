@@ -0,0 +1,19 @@
+package management
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetCircuitBreakers reports the current state of every (provider, model)
+// pair handlers.CircuitBreaker has seen a call for, so operators can see
+// which upstreams are currently short-circuited without scraping
+// cliproxy_circuit_breaker_state from the metrics endpoint.
+func (h *Handler) GetCircuitBreakers(c *gin.Context) {
+	if h == nil || h.circuitBreaker == nil {
+		c.JSON(http.StatusOK, gin.H{"circuit-breakers": []any{}})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"circuit-breakers": h.circuitBreaker.Snapshot()})
+}
@@ -0,0 +1,198 @@
+package management
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/billing/invoice"
+)
+
+// PostInvoices generates a draft invoice for a single API key over
+// [from, to] (inclusive "YYYY-MM-DD" day keys), priced at the rate in
+// effect when each day's usage was recorded rather than today's rate. An
+// optional "tenant" label and JSON-encoded "discounts" array
+// ([{"label":"...","percent":10}] or {"amount_micro_usd":...}) are applied
+// to the generated invoice.
+func (h *Handler) PostInvoices(c *gin.Context) {
+	if h == nil || h.billingStore == nil || h.invoiceStore == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invoice store unavailable"})
+		return
+	}
+
+	apiKey := strings.TrimSpace(c.PostForm("api-key"))
+	if apiKey == "" {
+		apiKey = strings.TrimSpace(c.Query("api-key"))
+	}
+	if apiKey == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "api-key is required"})
+		return
+	}
+	fromDay := strings.TrimSpace(c.PostForm("from"))
+	if fromDay == "" {
+		fromDay = strings.TrimSpace(c.Query("from"))
+	}
+	toDay := strings.TrimSpace(c.PostForm("to"))
+	if toDay == "" {
+		toDay = strings.TrimSpace(c.Query("to"))
+	}
+
+	var body struct {
+		Tenant    string             `json:"tenant"`
+		Discounts []invoice.Discount `json:"discounts"`
+	}
+	_ = c.ShouldBindJSON(&body)
+
+	inv, err := invoice.Generate(c.Request.Context(), h.billingStore, invoice.GenerateOptions{
+		Owner:     apiKey,
+		Tenant:    body.Tenant,
+		FromDay:   fromDay,
+		ToDay:     toDay,
+		Discounts: body.Discounts,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	created, err := h.invoiceStore.Create(c.Request.Context(), inv)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"invoice": created})
+}
+
+// GetInvoices lists every generated invoice, optionally narrowed to a
+// single API key via ?api-key=.
+func (h *Handler) GetInvoices(c *gin.Context) {
+	if h == nil || h.invoiceStore == nil {
+		c.JSON(http.StatusOK, gin.H{"invoices": []any{}})
+		return
+	}
+	list, err := h.invoiceStore.List(c.Request.Context(), strings.TrimSpace(c.Query("api-key")))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"invoices": list})
+}
+
+// GetInvoice returns a single invoice by id.
+func (h *Handler) GetInvoice(c *gin.Context) {
+	inv, ok, err := h.lookupInvoice(c, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invoice not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"invoice": inv})
+}
+
+// GetInvoiceJSON is GetInvoice under the GET /invoices/:id.json route shape:
+// the ".json" suffix on the path parameter is stripped before lookup.
+func (h *Handler) GetInvoiceJSON(c *gin.Context) {
+	id := strings.TrimSuffix(c.Param("id"), ".json")
+	inv, ok, err := h.lookupInvoice(c, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invoice not found"})
+		return
+	}
+	c.JSON(http.StatusOK, inv)
+}
+
+// GetInvoicePDF renders the invoice at GET /invoices/:id.pdf as a PDF
+// document, using the issuer/tenant metadata configured in the main config.
+func (h *Handler) GetInvoicePDF(c *gin.Context) {
+	id := strings.TrimSuffix(c.Param("id"), ".pdf")
+	inv, ok, err := h.lookupInvoice(c, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invoice not found"})
+		return
+	}
+
+	body, err := invoice.RenderPDF(inv, h.invoiceIssuer())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, "application/pdf", body)
+}
+
+// DeleteInvoice removes a draft or finalized invoice by id.
+func (h *Handler) DeleteInvoice(c *gin.Context) {
+	if h == nil || h.invoiceStore == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invoice store unavailable"})
+		return
+	}
+	deleted, err := h.invoiceStore.Delete(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invoice not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// PostInvoiceFinalize locks an invoice's price snapshot in place: once
+// finalized, a later UpsertModelPrice call can never retroactively change
+// what the invoice says it charged, since Finalize only stamps its status
+// and timestamp - the Prices snapshot was already captured at generation
+// time.
+func (h *Handler) PostInvoiceFinalize(c *gin.Context) {
+	if h == nil || h.invoiceStore == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invoice store unavailable"})
+		return
+	}
+	inv, ok, err := h.lookupInvoice(c, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invoice not found"})
+		return
+	}
+
+	finalized := invoice.Finalize(inv, time.Now())
+	if err := h.invoiceStore.Update(c.Request.Context(), finalized); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"invoice": finalized})
+}
+
+func (h *Handler) lookupInvoice(c *gin.Context, id string) (invoice.Invoice, bool, error) {
+	if h == nil || h.invoiceStore == nil {
+		return invoice.Invoice{}, false, nil
+	}
+	return h.invoiceStore.Get(c.Request.Context(), id)
+}
+
+// invoiceIssuer resolves the issuer/tenant metadata printed on a rendered
+// PDF from the main config, falling back to an empty Issuer (an unbranded
+// invoice) when unset.
+func (h *Handler) invoiceIssuer() invoice.Issuer {
+	if h == nil || h.cfg == nil {
+		return invoice.Issuer{}
+	}
+	return invoice.Issuer{
+		Name:    strings.TrimSpace(h.cfg.Invoice.IssuerName),
+		Address: strings.TrimSpace(h.cfg.Invoice.IssuerAddress),
+	}
+}
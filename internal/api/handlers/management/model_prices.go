@@ -5,14 +5,33 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/audit"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/billing"
 )
 
+// GetModelPrices lists every model's current price. An optional ?at=
+// (RFC3339 or "YYYY-MM-DD") resolves each model's price as it stood at
+// that instant instead of now, mirroring ListPriceHistory's effective-dated
+// semantics.
 func (h *Handler) GetModelPrices(c *gin.Context) {
 	if h == nil || h.billingStore == nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "billing store unavailable"})
 		return
 	}
+	if at := strings.TrimSpace(c.Query("at")); at != "" {
+		t, err := parseAuditTime(at)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid at"})
+			return
+		}
+		prices, err := h.billingStore.ListModelPricesAt(c.Request.Context(), t.Unix())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"prices": prices})
+		return
+	}
 	prices, err := h.billingStore.ListModelPrices(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -21,6 +40,52 @@ func (h *Handler) GetModelPrices(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"prices": prices})
 }
 
+// GetModelPriceHistory lists every saved price for the model named in the
+// ":model" path parameter, oldest to newest.
+func (h *Handler) GetModelPriceHistory(c *gin.Context) {
+	if h == nil || h.billingStore == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "billing store unavailable"})
+		return
+	}
+	model := strings.TrimSpace(c.Param("model"))
+	if model == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "model is required"})
+		return
+	}
+	history, err := h.billingStore.ListPriceHistory(c.Request.Context(), model)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"history": history})
+}
+
+// GetPriceAudit lists every recorded PutModelPrice/DeleteModelPrice audit
+// entry, oldest to newest.
+func (h *Handler) GetPriceAudit(c *gin.Context) {
+	if h == nil || h.billingStore == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "billing store unavailable"})
+		return
+	}
+	entries, err := h.billingStore.ListPriceAudit(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"audit": entries})
+}
+
+// priceAuditActor derives a non-secret-revealing actor identifier for a
+// price-audit entry from the request's Authorization header, the same hash
+// the audit log uses to key records to an API key without logging it.
+func priceAuditActor(c *gin.Context) string {
+	raw := strings.TrimSpace(c.GetHeader("Authorization"))
+	if raw == "" {
+		return "unknown"
+	}
+	return audit.HashAPIKey(raw)
+}
+
 func (h *Handler) PutModelPrice(c *gin.Context) {
 	if h == nil || h.billingStore == nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "billing store unavailable"})
@@ -32,6 +97,8 @@ func (h *Handler) PutModelPrice(c *gin.Context) {
 		PromptUSDPer1M     *float64 `json:"prompt_usd_per_1m"`
 		CompletionUSDPer1M *float64 `json:"completion_usd_per_1m"`
 		CachedUSDPer1M     *float64 `json:"cached_usd_per_1m"`
+		EffectiveFrom      string   `json:"effective_from"`
+		Reason             string   `json:"reason"`
 	}
 	if err := c.ShouldBindJSON(&body); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
@@ -58,13 +125,39 @@ func (h *Handler) PutModelPrice(c *gin.Context) {
 		return
 	}
 
-	if err := h.billingStore.UpsertModelPrice(c.Request.Context(), model, price); err != nil {
+	var effectiveFrom int64
+	if raw := strings.TrimSpace(body.EffectiveFrom); raw != "" {
+		t, err := parseAuditTime(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid effective_from"})
+			return
+		}
+		effectiveFrom = t.Unix()
+	}
+
+	if err := h.billingStore.UpsertModelPriceWithAudit(c.Request.Context(), model, price, effectiveFrom, priceAuditActor(c), strings.TrimSpace(body.Reason)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"status": "ok"})
 }
 
+// PostModelPricesSync triggers an on-demand PriceCatalogSyncer.Sync and
+// reports what changed, so an operator can verify a catalog update before
+// the next scheduled sync picks it up.
+func (h *Handler) PostModelPricesSync(c *gin.Context) {
+	if h == nil || h.priceCatalogSyncer == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "price catalog syncer unavailable"})
+		return
+	}
+	result, err := h.priceCatalogSyncer.Sync(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"sync": result})
+}
+
 func (h *Handler) DeleteModelPrice(c *gin.Context) {
 	if h == nil || h.billingStore == nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "billing store unavailable"})
@@ -75,7 +168,8 @@ func (h *Handler) DeleteModelPrice(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "model is required"})
 		return
 	}
-	deleted, err := h.billingStore.DeleteModelPrice(c.Request.Context(), model)
+	reason := strings.TrimSpace(c.Query("reason"))
+	deleted, err := h.billingStore.DeleteModelPriceWithAudit(c.Request.Context(), model, priceAuditActor(c), reason)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
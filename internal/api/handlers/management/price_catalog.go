@@ -0,0 +1,23 @@
+package management
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetPricingCatalog lists every tier loaded from the configured
+// billing.PriceCatalog (the tiered, effective-dated pricing file, not the
+// flat per-model prices served by GetModelPrices).
+func (h *Handler) GetPricingCatalog(c *gin.Context) {
+	if h == nil || h.priceCatalog == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "price catalog unavailable"})
+		return
+	}
+	tiers, err := h.priceCatalog.Tiers()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tiers": tiers})
+}
@@ -0,0 +1,79 @@
+package management
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/billing"
+)
+
+// PostModelPricesImport bulk-upserts model prices from the request body,
+// accepting either a JSON array of entries (application/json) or a CSV body
+// (text/csv, "model,prompt_usd_per_1m,completion_usd_per_1m,cached_usd_per_1m"
+// with an optional "effective_from" column). ?dry_run=1 returns the
+// added/updated/unchanged/errors diff without writing anything; otherwise
+// the whole batch is applied in one store transaction, or rejected entirely
+// if any row fails validation.
+func (h *Handler) PostModelPricesImport(c *gin.Context) {
+	if h == nil || h.billingStore == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "billing store unavailable"})
+		return
+	}
+
+	var entries []billing.PriceImportEntry
+	if strings.Contains(c.ContentType(), "csv") {
+		parsed, err := billing.ParsePriceImportCSV(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		entries = parsed
+	} else {
+		if err := c.ShouldBindJSON(&entries); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+			return
+		}
+	}
+
+	dryRun := c.Query("dry_run") == "1"
+	reason := strings.TrimSpace(c.Query("reason"))
+
+	result, err := h.billingStore.ImportModelPrices(c.Request.Context(), entries, priceAuditActor(c), reason, dryRun)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "result": result})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"result": result})
+}
+
+// GetModelPricesExport exports every model's current price (DefaultPrices
+// merged with saved overrides, as ListModelPrices already does) as
+// ?format=csv or ?format=json, defaulting to json.
+func (h *Handler) GetModelPricesExport(c *gin.Context) {
+	if h == nil || h.billingStore == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "billing store unavailable"})
+		return
+	}
+
+	prices, err := h.billingStore.ListModelPrices(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch strings.ToLower(c.DefaultQuery("format", "json")) {
+	case "csv":
+		c.Writer.Header().Set("Content-Type", "text/csv")
+		if err := billing.ExportModelPricesCSV(prices, c.Writer); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+	case "json":
+		c.Writer.Header().Set("Content-Type", "application/json")
+		if err := billing.ExportModelPricesJSON(prices, c.Writer); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported format"})
+	}
+}
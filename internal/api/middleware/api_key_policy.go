@@ -2,15 +2,20 @@ package middleware
 
 import (
 	"bytes"
+	"context"
+	"fmt"
 	"io"
 	"math"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/audit"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/billing"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/metrics"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/policy"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
 	"github.com/tidwall/gjson"
@@ -23,7 +28,21 @@ const (
 
 // APIKeyPolicyMiddleware enforces per-client API key restrictions and quotas.
 // It assumes AuthMiddleware already stored the authenticated key as gin context value "apiKey".
-func APIKeyPolicyMiddleware(getConfig func() *config.Config, limiter *policy.SQLiteDailyLimiter, costReader billing.DailyCostReader) gin.HandlerFunc {
+// limiter may be backed by any policy.DailyLimiter implementation (SQLite, Postgres, or in-memory),
+// selected via config, so daily counters can be shared across proxy replicas.
+// store is used both to read committed daily cost and, when a policy sets
+// DailyBudgetReserveUSD, to hold a budget reservation for the lifetime of the
+// request so concurrent in-flight requests are accounted for. reg, if
+// non-nil, is fed the api key's remaining daily budget/limit gauges on every
+// enforcement pass. rateLimiter, if non-nil, additionally enforces the
+// smoothed per-minute request/token budgets from RequestsPerMinute,
+// TokensPerMinute, and ModelRateLimits, on top of the hard daily counters.
+// auditLog, if non-nil, records every downgrade/denial/limit/budget
+// decision made below for compliance review via GET /admin/audit. budgets,
+// if non-nil, additionally enforces the configured multi-scope
+// billing.Budget set (GET/PUT /billing/budgets) on top of the per-key
+// DailyBudgetUSD check above.
+func APIKeyPolicyMiddleware(getConfig func() *config.Config, limiter policy.DailyLimiter, store billing.Store, reg *metrics.Registry, rateLimiter *policy.TokenBucketLimiter, auditLog *audit.Log, budgets *billing.BudgetManager) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if c == nil || c.Request == nil {
 			return
@@ -60,14 +79,14 @@ func APIKeyPolicyMiddleware(getConfig func() *config.Config, limiter *policy.SQL
 
 		// 0) Daily budget limits (USD) - based on persisted usage cost.
 		if policyEntry != nil && policyEntry.DailyBudgetUSD > 0 {
-			if costReader == nil {
+			if store == nil {
 				body := handlers.BuildErrorResponseBody(http.StatusInternalServerError, "billing store unavailable")
 				c.Abort()
 				c.Data(http.StatusInternalServerError, "application/json", body)
 				return
 			}
 			dayKey := policy.DayKeyChina(time.Now())
-			spentMicro, errSpent := costReader.GetDailyCostMicroUSD(c.Request.Context(), apiKey, dayKey)
+			spentMicro, errSpent := store.GetDailyCostMicroUSD(c.Request.Context(), apiKey, dayKey)
 			if errSpent != nil {
 				body := handlers.BuildErrorResponseBody(http.StatusInternalServerError, errSpent.Error())
 				c.Abort()
@@ -75,12 +94,42 @@ func APIKeyPolicyMiddleware(getConfig func() *config.Config, limiter *policy.SQL
 				return
 			}
 			budgetMicro := int64(math.Round(policyEntry.DailyBudgetUSD * 1_000_000))
+			if budgetMicro > 0 {
+				remainingMicro := budgetMicro - spentMicro
+				if remainingMicro < 0 {
+					remainingMicro = 0
+				}
+				reg.SetDailyBudgetRemainingUSD(apiKey, billing.MicroUSDToUSD(remainingMicro))
+			}
 			if budgetMicro > 0 && spentMicro >= budgetMicro {
+				reg.ObserveDailyBudgetRejection(apiKey)
+				_ = auditLog.Append(c.Request.Context(), apiKey, audit.Entry{
+					Decision:     audit.DecisionBudgetExceeded,
+					Reason:       fmt.Sprintf("spent %d >= budget %d micro-usd", spentMicro, budgetMicro),
+					CostMicroUSD: spentMicro,
+				})
 				body := handlers.BuildErrorResponseBody(http.StatusTooManyRequests, "daily budget exceeded")
 				c.Abort()
 				c.Data(http.StatusTooManyRequests, "application/json", body)
 				return
 			}
+
+			// Hold a reservation for the lifetime of this request so that
+			// other requests starting concurrently, before this one commits
+			// its actual usage, still see it as in-flight spend.
+			if policyEntry.DailyBudgetReserveUSD > 0 {
+				reserveMicro := int64(math.Round(policyEntry.DailyBudgetReserveUSD * 1_000_000))
+				reservationID, errReserve := store.ReserveBudget(c.Request.Context(), apiKey, dayKey, reserveMicro)
+				if errReserve != nil {
+					body := handlers.BuildErrorResponseBody(http.StatusInternalServerError, errReserve.Error())
+					c.Abort()
+					c.Data(http.StatusInternalServerError, "application/json", body)
+					return
+				}
+				defer func() {
+					_ = store.ReleaseReservation(context.Background(), reservationID)
+				}()
+			}
 		}
 
 		bodyBytes, err := io.ReadAll(c.Request.Body)
@@ -101,6 +150,13 @@ func APIKeyPolicyMiddleware(getConfig func() *config.Config, limiter *policy.SQL
 		// 1) Transparent model downgrade rules.
 		if policyEntry != nil && !policyEntry.AllowsClaudeOpus46() {
 			if rewritten, changed := policy.DowngradeClaudeOpus46(effectiveModel); changed {
+				reg.ObserveOpus46Downgrade(apiKey)
+				_ = auditLog.Append(c.Request.Context(), apiKey, audit.Entry{
+					Decision:    audit.DecisionModelDowngraded,
+					ModelBefore: effectiveModel,
+					ModelAfter:  rewritten,
+					Reason:      "allow-claude-opus-4-6 disabled by api key policy",
+				})
 				effectiveModel = rewritten
 			}
 		}
@@ -116,6 +172,11 @@ func APIKeyPolicyMiddleware(getConfig func() *config.Config, limiter *policy.SQL
 				}
 			}
 			if denied {
+				_ = auditLog.Append(c.Request.Context(), apiKey, audit.Entry{
+					Decision:    audit.DecisionModelDenied,
+					ModelBefore: effectiveModel,
+					Reason:      "model matched an excluded-models pattern",
+				})
 				body := handlers.BuildErrorResponseBody(http.StatusForbidden, "model access denied by api key policy")
 				c.Abort()
 				c.Data(http.StatusForbidden, "application/json", body)
@@ -123,6 +184,85 @@ func APIKeyPolicyMiddleware(getConfig func() *config.Config, limiter *policy.SQL
 			}
 		}
 
+		// 2.5) Smoothed per-minute request/token rate limits.
+		rateLimitModelKey := policy.NormaliseModelKey(effectiveModel)
+		if policyEntry != nil && rateLimiter != nil {
+			rpm, tpm, burst := policyEntry.RateLimitFor(rateLimitModelKey)
+
+			reqDecision, errReq := rateLimiter.AllowRequest(c.Request.Context(), apiKey, rateLimitModelKey, rpm, burst)
+			if errReq != nil {
+				body := handlers.BuildErrorResponseBody(http.StatusInternalServerError, errReq.Error())
+				c.Abort()
+				c.Data(http.StatusInternalServerError, "application/json", body)
+				return
+			}
+			writeRateLimitHeaders(c, "requests", reqDecision)
+			if !reqDecision.Allowed {
+				_ = auditLog.Append(c.Request.Context(), apiKey, audit.Entry{
+					Decision:    audit.DecisionRateLimited,
+					ModelBefore: effectiveModel,
+					Reason:      "request-per-minute limit exceeded",
+				})
+				c.Header("Retry-After", strconv.Itoa(int(math.Ceil(reqDecision.RetryAfter.Seconds()))))
+				body := handlers.BuildErrorResponseBody(http.StatusTooManyRequests, "request rate limit exceeded")
+				c.Abort()
+				c.Data(http.StatusTooManyRequests, "application/json", body)
+				return
+			}
+
+			if tpm > 0 {
+				tokDecision, errTok := rateLimiter.ReserveTokens(c.Request.Context(), apiKey, rateLimitModelKey, tpm, burst, policy.DefaultTokenReservationEstimate)
+				if errTok != nil {
+					body := handlers.BuildErrorResponseBody(http.StatusInternalServerError, errTok.Error())
+					c.Abort()
+					c.Data(http.StatusInternalServerError, "application/json", body)
+					return
+				}
+				writeRateLimitHeaders(c, "tokens", tokDecision)
+				if !tokDecision.Allowed {
+					_ = auditLog.Append(c.Request.Context(), apiKey, audit.Entry{
+						Decision:    audit.DecisionRateLimited,
+						ModelBefore: effectiveModel,
+						Reason:      "token-per-minute limit exceeded",
+					})
+					c.Header("Retry-After", strconv.Itoa(int(math.Ceil(tokDecision.RetryAfter.Seconds()))))
+					body := handlers.BuildErrorResponseBody(http.StatusTooManyRequests, "token rate limit exceeded")
+					c.Abort()
+					c.Data(http.StatusTooManyRequests, "application/json", body)
+					return
+				}
+			}
+		}
+
+		// 2.6) Configured multi-scope budgets (key, model, key+model, global).
+		// Unlike the DailyBudgetUSD check in 0), these have no pre-request cost
+		// estimate to project, so Evaluate only looks at spend already
+		// committed for the current period.
+		if budgets != nil {
+			verdict, errEval := budgets.Evaluate(c.Request.Context(), apiKey, policy.NormaliseModelKey(effectiveModel), 0, time.Now())
+			if errEval != nil {
+				body := handlers.BuildErrorResponseBody(http.StatusInternalServerError, errEval.Error())
+				c.Abort()
+				c.Data(http.StatusInternalServerError, "application/json", body)
+				return
+			}
+			if verdict.Blocked {
+				_ = auditLog.Append(c.Request.Context(), apiKey, audit.Entry{
+					Decision:    audit.DecisionBudgetExceeded,
+					ModelBefore: effectiveModel,
+					Reason:      "configured budget exceeded",
+				})
+				c.Header("X-Budget-Exceeded", "true")
+				body := handlers.BuildErrorResponseBody(http.StatusTooManyRequests, "budget exceeded")
+				c.Abort()
+				c.Data(http.StatusTooManyRequests, "application/json", body)
+				return
+			}
+			if verdict.Warning || verdict.Throttled {
+				c.Header("X-Budget-Warning", "true")
+			}
+		}
+
 		// 3) Daily usage limits.
 		if policyEntry != nil && len(policyEntry.DailyLimits) > 0 {
 			modelKey := policy.NormaliseModelKey(effectiveModel)
@@ -135,14 +275,25 @@ func APIKeyPolicyMiddleware(getConfig func() *config.Config, limiter *policy.SQL
 					return
 				}
 				dayKey := policy.DayKeyChina(time.Now())
-				_, allowed, errConsume := limiter.Consume(c.Request.Context(), apiKey, limitKey, dayKey, limit)
+				count, allowed, errConsume := limiter.Consume(c.Request.Context(), apiKey, limitKey, dayKey, limit)
 				if errConsume != nil {
 					body := handlers.BuildErrorResponseBody(http.StatusInternalServerError, errConsume.Error())
 					c.Abort()
 					c.Data(http.StatusInternalServerError, "application/json", body)
 					return
 				}
+				remaining := limit - count
+				if remaining < 0 {
+					remaining = 0
+				}
+				reg.SetDailyLimitRemaining(apiKey, limitKey, remaining)
 				if !allowed {
+					reg.ObserveDailyLimitRejection(apiKey, limitKey)
+					_ = auditLog.Append(c.Request.Context(), apiKey, audit.Entry{
+						Decision:    audit.DecisionDailyLimitHit,
+						ModelBefore: effectiveModel,
+						Reason:      fmt.Sprintf("daily limit %d reached for %s", limit, limitKey),
+					})
 					body := handlers.BuildErrorResponseBody(http.StatusTooManyRequests, "daily model limit exceeded")
 					c.Abort()
 					c.Data(http.StatusTooManyRequests, "application/json", body)
@@ -164,6 +315,16 @@ func APIKeyPolicyMiddleware(getConfig func() *config.Config, limiter *policy.SQL
 	}
 }
 
+// writeRateLimitHeaders surfaces scope-prefixed X-RateLimit-* headers (e.g.
+// X-RateLimit-Limit-Requests, X-RateLimit-Remaining-Requests) so clients can
+// distinguish the request-count and token-count buckets and back off
+// intelligently instead of only learning about throttling from a 429 body.
+func writeRateLimitHeaders(c *gin.Context, scope string, d policy.RateLimitDecision) {
+	suffix := strings.ToUpper(scope[:1]) + scope[1:]
+	c.Header(fmt.Sprintf("X-RateLimit-Limit-%s", suffix), strconv.Itoa(int(d.Limit)))
+	c.Header(fmt.Sprintf("X-RateLimit-Remaining-%s", suffix), strconv.Itoa(int(math.Max(0, d.Remaining))))
+}
+
 func resolveDailyLimit(p *config.APIKeyPolicy, modelKey string) (limit int, limitKey string) {
 	if p == nil || len(p.DailyLimits) == 0 {
 		return 0, ""
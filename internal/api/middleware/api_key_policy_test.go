@@ -2,13 +2,16 @@ package middleware
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/billing"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/policy"
 	"github.com/tidwall/gjson"
@@ -28,7 +31,7 @@ func TestAPIKeyPolicyMiddleware_DowngradesOpus46(t *testing.T) {
 		c.Set("apiKey", "k")
 		c.Next()
 	})
-	r.Use(APIKeyPolicyMiddleware(func() *config.Config { return cfg }, nil))
+	r.Use(APIKeyPolicyMiddleware(func() *config.Config { return cfg }, nil, nil, nil, nil, nil, nil))
 	r.POST("/v1/chat/completions", func(c *gin.Context) {
 		body, _ := io.ReadAll(c.Request.Body)
 		model := gjson.GetBytes(body, "model").String()
@@ -61,7 +64,7 @@ func TestAPIKeyPolicyMiddleware_ExcludedModelDenied(t *testing.T) {
 		c.Set("apiKey", "k")
 		c.Next()
 	})
-	r.Use(APIKeyPolicyMiddleware(func() *config.Config { return cfg }, nil))
+	r.Use(APIKeyPolicyMiddleware(func() *config.Config { return cfg }, nil, nil, nil, nil, nil, nil))
 	r.POST("/v1/messages", func(c *gin.Context) {
 		c.JSON(200, gin.H{"ok": true})
 	})
@@ -100,7 +103,7 @@ func TestAPIKeyPolicyMiddleware_DailyLimit(t *testing.T) {
 		c.Set("apiKey", "k")
 		c.Next()
 	})
-	r.Use(APIKeyPolicyMiddleware(func() *config.Config { return cfg }, limiter))
+	r.Use(APIKeyPolicyMiddleware(func() *config.Config { return cfg }, limiter, nil, nil, nil, nil, nil))
 	r.POST("/v1/chat/completions", func(c *gin.Context) {
 		c.JSON(200, gin.H{"ok": true})
 	})
@@ -121,4 +124,154 @@ func TestAPIKeyPolicyMiddleware_DailyLimit(t *testing.T) {
 	}
 }
 
+func TestAPIKeyPolicyMiddleware_DailyBudgetExceeded(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := billing.NewMemoryStore()
+	defer store.Close()
+
+	cfg := &config.Config{
+		APIKeyPolicies: []config.APIKeyPolicy{
+			{APIKey: "k", DailyBudgetUSD: 1},
+		},
+	}
+	cfg.SanitizeAPIKeyPolicies()
+
+	dayKey := policy.DayKeyChina(time.Now())
+	if err := store.AddUsage(context.Background(), "k", "claude-opus-4-6", dayKey, billing.DailyUsageRow{CostMicroUSD: 1_000_000}); err != nil {
+		t.Fatalf("AddUsage: %v", err)
+	}
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("apiKey", "k")
+		c.Next()
+	})
+	r.Use(APIKeyPolicyMiddleware(func() *config.Config { return cfg }, nil, store, nil, nil, nil, nil))
+	r.POST("/v1/chat/completions", func(c *gin.Context) {
+		c.JSON(200, gin.H{"ok": true})
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", bytes.NewBufferString(`{"model":"claude-opus-4-6"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+}
+
+func TestAPIKeyPolicyMiddleware_DailyBudgetReservationReleasedAfterRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := billing.NewMemoryStore()
+	defer store.Close()
+
+	cfg := &config.Config{
+		APIKeyPolicies: []config.APIKeyPolicy{
+			{APIKey: "k", DailyBudgetUSD: 1, DailyBudgetReserveUSD: 0.75},
+		},
+	}
+	cfg.SanitizeAPIKeyPolicies()
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("apiKey", "k")
+		c.Next()
+	})
+	r.Use(APIKeyPolicyMiddleware(func() *config.Config { return cfg }, nil, store, nil, nil, nil, nil))
+	r.POST("/v1/chat/completions", func(c *gin.Context) {
+		c.JSON(200, gin.H{"ok": true})
+	})
+
+	makeReq := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", bytes.NewBufferString(`{"model":"claude-opus-4-6"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// Each request reserves 0.75 USD but releases it once it finishes, so a
+	// second request run after the first completes should still pass.
+	if w := makeReq(); w.Code != http.StatusOK {
+		t.Fatalf("first request status=%d body=%s", w.Code, w.Body.String())
+	}
+	if w := makeReq(); w.Code != http.StatusOK {
+		t.Fatalf("second request status=%d body=%s", w.Code, w.Body.String())
+	}
+
+	dayKey := policy.DayKeyChina(time.Now())
+	spent, err := store.GetDailyCostMicroUSD(context.Background(), "k", dayKey)
+	if err != nil {
+		t.Fatalf("GetDailyCostMicroUSD: %v", err)
+	}
+	if spent != 0 {
+		t.Fatalf("expected reservations to be released after each request, got spent=%d", spent)
+	}
+}
+
+func TestAPIKeyPolicyMiddleware_ConfiguredBudgetBlocksAndWarns(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := billing.NewMemoryStore()
+	defer store.Close()
+	budgets := billing.NewBudgetManager(store)
+
+	cfg := &config.Config{}
+	cfg.SanitizeAPIKeyPolicies()
+
+	if _, err := budgets.Upsert(context.Background(), billing.Budget{
+		Scope:            billing.BudgetScopeKey,
+		APIKey:           "k",
+		Period:           billing.BudgetPeriodDay,
+		LimitUSD:         1,
+		Action:           billing.BudgetActionBlock,
+		SoftThresholdPct: 50,
+	}); err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("apiKey", "k")
+		c.Next()
+	})
+	r.Use(APIKeyPolicyMiddleware(func() *config.Config { return cfg }, nil, store, nil, nil, nil, budgets))
+	r.POST("/v1/chat/completions", func(c *gin.Context) {
+		c.JSON(200, gin.H{"ok": true})
+	})
+
+	makeReq := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", bytes.NewBufferString(`{"model":"claude-opus-4-6"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := makeReq(); w.Code != http.StatusOK {
+		t.Fatalf("request before any spend: status=%d body=%s", w.Code, w.Body.String())
+	}
+
+	if err := budgets.Charge(context.Background(), "k", "claude-opus-4-6", 600_000, time.Now()); err != nil {
+		t.Fatalf("Charge: %v", err)
+	}
+	w := makeReq()
+	if w.Code != http.StatusOK {
+		t.Fatalf("request at soft threshold: status=%d body=%s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("X-Budget-Warning") != "true" {
+		t.Fatalf("expected X-Budget-Warning header, got headers=%v", w.Header())
+	}
+
+	if err := budgets.Charge(context.Background(), "k", "claude-opus-4-6", 500_000, time.Now()); err != nil {
+		t.Fatalf("Charge(2): %v", err)
+	}
+	w = makeReq()
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("request over limit: status=%d body=%s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("X-Budget-Exceeded") != "true" {
+		t.Fatalf("expected X-Budget-Exceeded header, got headers=%v", w.Header())
+	}
+}
+
 func boolPtr(v bool) *bool { return &v }
@@ -0,0 +1,143 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+)
+
+// MTLSAuthMiddleware resolves the client's apiKey/apiKeyPolicy from the peer
+// certificate presented during the TLS handshake, for requests that arrive
+// without an Authorization header. It sets the same "apiKey"/"apiKeyPolicy"
+// gin context keys AuthMiddleware would, so APIKeyPolicyMiddleware and
+// BaseAPIHandlers downstream work unchanged regardless of which scheme
+// authenticated the request. mtlsCfg is read fresh via getMTLSConfig on
+// every request so a config reload takes effect without a restart.
+//
+// It must run after the server's TLS listener has already verified the
+// certificate chain against mtls.ca-file; this middleware only maps an
+// already-trusted certificate to a configured policy, it does not itself
+// validate the chain.
+func MTLSAuthMiddleware(getConfig func() *config.Config, getMTLSConfig func() config.MTLSConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c == nil || c.Request == nil {
+			return
+		}
+		mtlsCfg := config.MTLSConfig{}
+		if getMTLSConfig != nil {
+			mtlsCfg = getMTLSConfig()
+		}
+		if !mtlsCfg.Enabled {
+			c.Next()
+			return
+		}
+		cfg := (*config.Config)(nil)
+		if getConfig != nil {
+			cfg = getConfig()
+		}
+		if cfg == nil {
+			c.Next()
+			return
+		}
+
+		// An Authorization header, if present, takes precedence; leave it
+		// to whatever header-based auth middleware already ran.
+		if strings.TrimSpace(c.GetHeader("Authorization")) != "" {
+			c.Next()
+			return
+		}
+
+		if c.Request.TLS == nil || len(c.Request.TLS.PeerCertificates) == 0 {
+			c.Next()
+			return
+		}
+
+		leaf := c.Request.TLS.PeerCertificates[0]
+		sum := sha256.Sum256(leaf.Raw)
+		fingerprint := hex.EncodeToString(sum[:])
+
+		p := cfg.FindAPIKeyPolicyByClientCert(fingerprint, leaf.Subject.CommonName)
+		if p == nil {
+			body := handlers.BuildErrorResponseBody(http.StatusUnauthorized, "unrecognized client certificate")
+			c.Abort()
+			c.Data(http.StatusUnauthorized, "application/json", body)
+			return
+		}
+
+		c.Set("apiKey", p.APIKey)
+		copyPolicy := *p
+		c.Set(apiKeyPolicyContextKey, &copyPolicy)
+		c.Next()
+	}
+}
+
+// RequireClientCertMiddleware rejects, with 401, any request for an API key
+// listed in mtls.require-for-keys that wasn't authenticated via a client
+// certificate belonging to that same key (e.g. it presented an Authorization
+// header instead, no credential at all, or a certificate that resolves to a
+// different key's policy). Run it after both AuthMiddleware and
+// MTLSAuthMiddleware so "apiKey" reflects whichever scheme resolved it.
+//
+// It re-resolves the presented leaf certificate's policy the same way
+// MTLSAuthMiddleware does rather than trusting "a certificate was
+// presented": apiKey may have been set by a bearer token instead (see
+// MTLSAuthMiddleware's Authorization-header precedence), and a certificate
+// merely being chain-trusted by mtls.ca-file says nothing about which key it
+// belongs to.
+func RequireClientCertMiddleware(getConfig func() *config.Config, getMTLSConfig func() config.MTLSConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c == nil || c.Request == nil {
+			return
+		}
+		mtlsCfg := config.MTLSConfig{}
+		if getMTLSConfig != nil {
+			mtlsCfg = getMTLSConfig()
+		}
+		if !mtlsCfg.Enabled {
+			c.Next()
+			return
+		}
+
+		apiKey := strings.TrimSpace(c.GetString("apiKey"))
+		if !mtlsCfg.RequiresCertFor(apiKey) {
+			c.Next()
+			return
+		}
+
+		reject := func() {
+			body := handlers.BuildErrorResponseBody(http.StatusUnauthorized, "client certificate required for this api key")
+			c.Abort()
+			c.Data(http.StatusUnauthorized, "application/json", body)
+		}
+
+		if c.Request.TLS == nil || len(c.Request.TLS.PeerCertificates) == 0 {
+			reject()
+			return
+		}
+
+		cfg := (*config.Config)(nil)
+		if getConfig != nil {
+			cfg = getConfig()
+		}
+		if cfg == nil {
+			reject()
+			return
+		}
+
+		leaf := c.Request.TLS.PeerCertificates[0]
+		sum := sha256.Sum256(leaf.Raw)
+		fingerprint := hex.EncodeToString(sum[:])
+
+		p := cfg.FindAPIKeyPolicyByClientCert(fingerprint, leaf.Subject.CommonName)
+		if p == nil || p.APIKey != apiKey {
+			reject()
+			return
+		}
+		c.Next()
+	}
+}
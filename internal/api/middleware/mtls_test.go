@@ -0,0 +1,227 @@
+package middleware
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func selfSignedCert(t *testing.T, cn string) *x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("ParseCertificate: %v", err)
+	}
+	return cert
+}
+
+func TestMTLSAuthMiddleware_ResolvesPolicyByFingerprint(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cert := selfSignedCert(t, "unused-cn")
+	sum := sha256.Sum256(cert.Raw)
+	fingerprint := hex.EncodeToString(sum[:])
+
+	cfg := &config.Config{
+		APIKeyPolicies: []config.APIKeyPolicy{
+			{APIKey: "k", ClientCertFingerprints: []string{fingerprint}},
+		},
+	}
+	cfg.SanitizeAPIKeyPolicies()
+
+	r := gin.New()
+	r.Use(MTLSAuthMiddleware(
+		func() *config.Config { return cfg },
+		func() config.MTLSConfig { return config.MTLSConfig{Enabled: true} },
+	))
+	r.GET("/v1/models", func(c *gin.Context) {
+		c.JSON(200, gin.H{"apiKey": c.GetString("apiKey")})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+	req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	if got := w.Body.String(); !strings.Contains(got, `"k"`) {
+		t.Fatalf("body=%s, want apiKey=k", got)
+	}
+}
+
+func TestMTLSAuthMiddleware_RejectsUnknownCert(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cert := selfSignedCert(t, "unknown")
+	cfg := &config.Config{}
+
+	r := gin.New()
+	r.Use(MTLSAuthMiddleware(
+		func() *config.Config { return cfg },
+		func() config.MTLSConfig { return config.MTLSConfig{Enabled: true} },
+	))
+	r.GET("/v1/models", func(c *gin.Context) {
+		c.JSON(200, gin.H{"ok": true})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+	req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("status=%d, want 401", w.Code)
+	}
+}
+
+func TestMTLSAuthMiddleware_SkipsWhenDisabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{}
+
+	r := gin.New()
+	r.Use(MTLSAuthMiddleware(
+		func() *config.Config { return cfg },
+		func() config.MTLSConfig { return config.MTLSConfig{Enabled: false} },
+	))
+	r.GET("/v1/models", func(c *gin.Context) {
+		c.JSON(200, gin.H{"ok": true})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status=%d, want middleware to be a no-op when disabled", w.Code)
+	}
+}
+
+func TestRequireClientCertMiddleware_RejectsKeyWithoutCert(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mtlsCfg := config.MTLSConfig{Enabled: true, RequireForKeys: []string{"k"}}
+	cfg := &config.Config{}
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("apiKey", "k")
+		c.Next()
+	})
+	r.Use(RequireClientCertMiddleware(func() *config.Config { return cfg }, func() config.MTLSConfig { return mtlsCfg }))
+	r.GET("/v1/models", func(c *gin.Context) {
+		c.JSON(200, gin.H{"ok": true})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("status=%d, want 401 for a require-for-keys entry with no client cert", w.Code)
+	}
+}
+
+// TestRequireClientCertMiddleware_RejectsStolenTokenWithUnrelatedCert covers
+// the scenario where a request carries a stolen/leaked bearer token for a
+// require-for-keys key (so AuthMiddleware already set apiKey="k") alongside
+// a client certificate that is chain-trusted but belongs to a *different*,
+// unrelated key's policy. That combination must still be rejected: "a
+// certificate was presented" is not the same as "k's certificate was
+// presented".
+func TestRequireClientCertMiddleware_RejectsStolenTokenWithUnrelatedCert(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cert := selfSignedCert(t, "other-key-cn")
+	sum := sha256.Sum256(cert.Raw)
+	fingerprint := hex.EncodeToString(sum[:])
+
+	cfg := &config.Config{
+		APIKeyPolicies: []config.APIKeyPolicy{
+			{APIKey: "other-key", ClientCertFingerprints: []string{fingerprint}},
+		},
+	}
+	cfg.SanitizeAPIKeyPolicies()
+	mtlsCfg := config.MTLSConfig{Enabled: true, RequireForKeys: []string{"k"}}
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("apiKey", "k")
+		c.Next()
+	})
+	r.Use(RequireClientCertMiddleware(func() *config.Config { return cfg }, func() config.MTLSConfig { return mtlsCfg }))
+	r.GET("/v1/models", func(c *gin.Context) {
+		c.JSON(200, gin.H{"ok": true})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+	req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("status=%d, want 401 when the presented certificate belongs to a different key", w.Code)
+	}
+}
+
+// TestRequireClientCertMiddleware_AllowsMatchingCert covers the legitimate
+// case: the presented certificate resolves to the same key AuthMiddleware
+// already set as apiKey.
+func TestRequireClientCertMiddleware_AllowsMatchingCert(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cert := selfSignedCert(t, "k-cn")
+	sum := sha256.Sum256(cert.Raw)
+	fingerprint := hex.EncodeToString(sum[:])
+
+	cfg := &config.Config{
+		APIKeyPolicies: []config.APIKeyPolicy{
+			{APIKey: "k", ClientCertFingerprints: []string{fingerprint}},
+		},
+	}
+	cfg.SanitizeAPIKeyPolicies()
+	mtlsCfg := config.MTLSConfig{Enabled: true, RequireForKeys: []string{"k"}}
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("apiKey", "k")
+		c.Next()
+	})
+	r.Use(RequireClientCertMiddleware(func() *config.Config { return cfg }, func() config.MTLSConfig { return mtlsCfg }))
+	r.GET("/v1/models", func(c *gin.Context) {
+		c.JSON(200, gin.H{"ok": true})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+	req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s, want 200 for a cert matching the resolved apiKey", w.Code, w.Body.String())
+	}
+}
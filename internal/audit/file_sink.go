@@ -0,0 +1,118 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// FileSink appends JSONL audit records to a local file, opened once and
+// kept open for the lifetime of the sink (mirroring how SQLiteDailyLimiter
+// and friends hold a single long-lived handle rather than reopening per
+// call).
+type FileSink struct {
+	mu   sync.Mutex
+	path string
+	f    *os.File
+}
+
+var (
+	_ Sink   = (*FileSink)(nil)
+	_ Reader = (*FileSink)(nil)
+)
+
+// NewFileSink opens (creating if necessary) path for append.
+func NewFileSink(path string) (*FileSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("audit: open %s: %w", path, err)
+	}
+	return &FileSink{path: path, f: f}, nil
+}
+
+func (s *FileSink) Write(ctx context.Context, r Record) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	body = append(body, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.f.Write(body)
+	return err
+}
+
+// Read parses every line in the file back into Records, in append order.
+func (s *FileSink) Read(ctx context.Context) ([]Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.Open(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("audit: open %s: %w", s.path, err)
+	}
+	defer f.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var r Record
+		if err := json.Unmarshal(line, &r); err != nil {
+			return nil, fmt.Errorf("audit: parse record: %w", err)
+		}
+		records = append(records, r)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// LastHash returns the Hash of the final record in path, or "" if the file
+// is empty or does not yet exist, so a restarted process can resume the
+// chain with NewLog(sink, lastHash) instead of silently starting a new one.
+func LastHash(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	defer f.Close()
+
+	var last string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		last = string(line)
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	if last == "" {
+		return "", nil
+	}
+	var r Record
+	if err := json.Unmarshal([]byte(last), &r); err != nil {
+		return "", fmt.Errorf("audit: parse last record: %w", err)
+	}
+	return r.Hash, nil
+}
+
+func (s *FileSink) Close() error {
+	return s.f.Close()
+}
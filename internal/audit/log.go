@@ -0,0 +1,107 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Sink persists completed, hash-chained audit Records. Implementations are
+// expected to be append-only: Write must never rewrite or delete a prior
+// record, since that is exactly what the hash chain exists to detect.
+type Sink interface {
+	Write(ctx context.Context, r Record) error
+	Close() error
+}
+
+// Reader is implemented by Sinks that can also enumerate their own history,
+// so the /admin/audit endpoint can serve and verify past records. Sinks
+// that only forward events onward (syslog, webhook) do not implement it.
+type Reader interface {
+	Read(ctx context.Context) ([]Record, error)
+}
+
+// Log appends Decision events to a Sink, threading the hash chain between
+// calls. A Log must not be copied after first use.
+type Log struct {
+	mu       sync.Mutex
+	sink     Sink
+	prevHash string
+}
+
+// NewLog wraps sink, starting the hash chain from seedHash (pass "" to
+// start a fresh chain, or the Hash of the last record previously written to
+// sink to resume one across a process restart).
+func NewLog(sink Sink, seedHash string) *Log {
+	return &Log{sink: sink, prevHash: seedHash}
+}
+
+// Close releases the underlying sink.
+func (l *Log) Close() error {
+	if l == nil || l.sink == nil {
+		return nil
+	}
+	return l.sink.Close()
+}
+
+// Entry is the caller-supplied subset of Record fields; Append fills in
+// Timestamp, APIKeyHash (from apiKey), PrevHash, and Hash.
+type Entry struct {
+	ModelBefore  string
+	ModelAfter   string
+	Decision     Decision
+	Reason       string
+	AuthID       string
+	CostMicroUSD int64
+}
+
+// Append hash-chains e onto the log and writes it to the sink. apiKey is
+// hashed before it ever reaches a Record.
+func (l *Log) Append(ctx context.Context, apiKey string, e Entry) error {
+	if l == nil {
+		return nil
+	}
+	if l.sink == nil {
+		return fmt.Errorf("audit: log has no sink")
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	r := Record{
+		Timestamp:    time.Now().UTC(),
+		APIKeyHash:   HashAPIKey(apiKey),
+		ModelBefore:  e.ModelBefore,
+		ModelAfter:   e.ModelAfter,
+		Decision:     e.Decision,
+		Reason:       e.Reason,
+		AuthID:       e.AuthID,
+		CostMicroUSD: e.CostMicroUSD,
+		PrevHash:     l.prevHash,
+	}
+	hash, err := computeHash(l.prevHash, r)
+	if err != nil {
+		return err
+	}
+	r.Hash = hash
+
+	if err := l.sink.Write(ctx, r); err != nil {
+		return err
+	}
+	l.prevHash = hash
+	return nil
+}
+
+// Records returns every record the underlying sink has stored, if it
+// supports reading. Returns an error for write-only sinks (syslog, webhook).
+func (l *Log) Records(ctx context.Context) ([]Record, error) {
+	if l == nil || l.sink == nil {
+		return nil, fmt.Errorf("audit: log has no sink")
+	}
+	reader, ok := l.sink.(Reader)
+	if !ok {
+		return nil, fmt.Errorf("audit: sink %T does not support reading back records", l.sink)
+	}
+	return reader.Read(ctx)
+}
@@ -0,0 +1,132 @@
+package audit
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestLog_AppendChainsHashesAndVerifies(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := NewFileSink(filepath.Join(dir, "audit.jsonl"))
+	if err != nil {
+		t.Fatalf("NewFileSink: %v", err)
+	}
+	defer sink.Close()
+
+	log := NewLog(sink, "")
+	ctx := context.Background()
+
+	if err := log.Append(ctx, "key-a", Entry{Decision: DecisionModelDenied, ModelBefore: "m1", Reason: "excluded"}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if err := log.Append(ctx, "key-a", Entry{Decision: DecisionBudgetExceeded, CostMicroUSD: 42}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+
+	records, err := log.Records(ctx)
+	if err != nil {
+		t.Fatalf("Records: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("len(records)=%d, want 2", len(records))
+	}
+	if records[0].PrevHash != "" {
+		t.Fatalf("first record PrevHash=%q, want empty", records[0].PrevHash)
+	}
+	if records[1].PrevHash != records[0].Hash {
+		t.Fatalf("second record PrevHash=%q, want %q", records[1].PrevHash, records[0].Hash)
+	}
+	if records[0].APIKeyHash != HashAPIKey("key-a") || records[0].APIKeyHash == "key-a" {
+		t.Fatalf("APIKeyHash not a hash of the raw key: %q", records[0].APIKeyHash)
+	}
+
+	ok, brokenAt := VerifyChain(records)
+	if !ok {
+		t.Fatalf("VerifyChain failed at index %d", brokenAt)
+	}
+}
+
+func TestVerifyChain_DetectsTampering(t *testing.T) {
+	l := NewLog(&memSink{}, "")
+	ctx := context.Background()
+	_ = l.Append(ctx, "key", Entry{Decision: DecisionModelDenied})
+	_ = l.Append(ctx, "key", Entry{Decision: DecisionBudgetExceeded})
+
+	records := l.sink.(*memSink).records
+	records[0].Reason = "tampered"
+
+	ok, brokenAt := VerifyChain(records)
+	if ok {
+		t.Fatal("expected tampering to be detected")
+	}
+	if brokenAt != 0 {
+		t.Fatalf("brokenAt=%d, want 0", brokenAt)
+	}
+}
+
+type memSink struct {
+	records []Record
+}
+
+func (m *memSink) Write(ctx context.Context, r Record) error {
+	m.records = append(m.records, r)
+	return nil
+}
+
+func (m *memSink) Close() error { return nil }
+
+func TestLastHash_EmptyWhenFileMissing(t *testing.T) {
+	h, err := LastHash(filepath.Join(t.TempDir(), "missing.jsonl"))
+	if err != nil {
+		t.Fatalf("LastHash: %v", err)
+	}
+	if h != "" {
+		t.Fatalf("h=%q, want empty", h)
+	}
+}
+
+func TestLastHash_ResumesChainAcrossRestart(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audit.jsonl")
+
+	sink, err := NewFileSink(path)
+	if err != nil {
+		t.Fatalf("NewFileSink: %v", err)
+	}
+	log := NewLog(sink, "")
+	if err := log.Append(context.Background(), "key", Entry{Decision: DecisionModelDenied}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	sink.Close()
+
+	seed, err := LastHash(path)
+	if err != nil {
+		t.Fatalf("LastHash: %v", err)
+	}
+	if seed == "" {
+		t.Fatal("expected a non-empty seed hash after one append")
+	}
+
+	sink2, err := NewFileSink(path)
+	if err != nil {
+		t.Fatalf("NewFileSink: %v", err)
+	}
+	defer sink2.Close()
+	log2 := NewLog(sink2, seed)
+	if err := log2.Append(context.Background(), "key", Entry{Decision: DecisionBudgetExceeded}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+
+	records, err := log2.Records(context.Background())
+	if err != nil {
+		t.Fatalf("Records: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("len(records)=%d, want 2", len(records))
+	}
+	ok, brokenAt := VerifyChain(records)
+	if !ok {
+		t.Fatalf("VerifyChain failed at %d after restart", brokenAt)
+	}
+}
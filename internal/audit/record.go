@@ -0,0 +1,84 @@
+// Package audit provides an append-only, tamper-evident log of policy
+// decisions (model downgrades, access denials, quota/budget rejections)
+// and auth failovers, for compliance review. Each record is hash-chained
+// to the one before it so an operator can detect truncation or tampering
+// in the underlying sink.
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// Decision enumerates the kinds of event Log.Append callers may record.
+type Decision string
+
+const (
+	DecisionModelDowngraded Decision = "model_downgraded"
+	DecisionModelDenied     Decision = "model_denied"
+	DecisionDailyLimitHit   Decision = "daily_limit_hit"
+	DecisionBudgetExceeded  Decision = "budget_exceeded"
+	DecisionRateLimited     Decision = "rate_limited"
+	DecisionAuthFailover    Decision = "auth_failover"
+	DecisionAuthCooldown    Decision = "auth_cooldown"
+)
+
+// Record is one hash-chained audit log entry. Field order matches the
+// wire/JSONL order and is load-bearing: computeHash depends on
+// encoding/json preserving struct field order.
+type Record struct {
+	Timestamp    time.Time `json:"ts"`
+	APIKeyHash   string    `json:"api_key_hash"`
+	ModelBefore  string    `json:"model_before,omitempty"`
+	ModelAfter   string    `json:"model_after,omitempty"`
+	Decision     Decision  `json:"decision"`
+	Reason       string    `json:"reason,omitempty"`
+	AuthID       string    `json:"auth_id,omitempty"`
+	CostMicroUSD int64     `json:"cost_microusd,omitempty"`
+	PrevHash     string    `json:"prev_hash"`
+	Hash         string    `json:"hash"`
+}
+
+// HashAPIKey is the package's standard, non-reversible transform from a raw
+// API key to the APIKeyHash field, so the audit log never holds a
+// reversible credential at rest.
+func HashAPIKey(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// computeHash returns sha256(prevHash || canonicalJSON(r)) with r.Hash
+// cleared, matching the chain construction every Sink/Log call site uses.
+func computeHash(prevHash string, r Record) (string, error) {
+	r.Hash = ""
+	body, err := json.Marshal(r)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	h.Write([]byte(prevHash))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// VerifyChain re-derives each record's hash from its predecessor and
+// reports whether the chain is intact. On the first break, ok is false and
+// brokenAt is the index of the first record whose stored hash does not
+// match its recomputed hash (or whose prev_hash does not match the prior
+// record's hash). records is assumed to be in chain order (oldest first).
+func VerifyChain(records []Record) (ok bool, brokenAt int) {
+	prevHash := ""
+	for i, r := range records {
+		if r.PrevHash != prevHash {
+			return false, i
+		}
+		want, err := computeHash(prevHash, r)
+		if err != nil || want != r.Hash {
+			return false, i
+		}
+		prevHash = r.Hash
+	}
+	return true, -1
+}
@@ -0,0 +1,42 @@
+//go:build !windows
+
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"log/syslog"
+)
+
+// SyslogSink forwards each record as a single JSON line to the local or
+// remote syslog daemon. It is write-only: syslog has no notion of replaying
+// history, so SyslogSink does not implement Reader and /admin/audit cannot
+// serve from it.
+type SyslogSink struct {
+	w *syslog.Writer
+}
+
+var _ Sink = (*SyslogSink)(nil)
+
+// NewSyslogSink dials the syslog daemon at network/raddr (raddr may be ""
+// to use the local syslog socket) and tags entries with the given tag,
+// logging at LOG_INFO under the LOG_AUTH facility.
+func NewSyslogSink(network, raddr, tag string) (*SyslogSink, error) {
+	w, err := syslog.Dial(network, raddr, syslog.LOG_INFO|syslog.LOG_AUTH, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &SyslogSink{w: w}, nil
+}
+
+func (s *SyslogSink) Write(ctx context.Context, r Record) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return s.w.Info(string(body))
+}
+
+func (s *SyslogSink) Close() error {
+	return s.w.Close()
+}
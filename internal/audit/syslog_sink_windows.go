@@ -0,0 +1,10 @@
+//go:build windows
+
+package audit
+
+import "fmt"
+
+// NewSyslogSink is unavailable on windows, which has no syslog daemon.
+func NewSyslogSink(network, raddr, tag string) (Sink, error) {
+	return nil, fmt.Errorf("audit: syslog sink is not supported on windows")
+}
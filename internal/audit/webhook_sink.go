@@ -0,0 +1,55 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// WebhookSink POSTs each record as a JSON body to a configured URL. It is
+// write-only, like SyslogSink: the remote endpoint owns retention, so
+// WebhookSink does not implement Reader.
+type WebhookSink struct {
+	url    string
+	client *http.Client
+}
+
+var _ Sink = (*WebhookSink)(nil)
+
+// NewWebhookSink posts to url using client, or a new http.Client with a 10s
+// timeout when client is nil.
+func NewWebhookSink(url string, client *http.Client) *WebhookSink {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &WebhookSink{url: url, client: client}
+}
+
+func (s *WebhookSink) Write(ctx context.Context, r Record) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("audit: webhook sink: unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *WebhookSink) Close() error {
+	return nil
+}
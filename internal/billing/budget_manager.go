@@ -0,0 +1,194 @@
+package billing
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// BudgetVerdict is BudgetManager.Evaluate's result: whether the request
+// should proceed, and the status of every Budget that matched it.
+type BudgetVerdict struct {
+	// Blocked is true when at least one matching Budget with Action ==
+	// BudgetActionBlock has reached its limit; the request should be refused.
+	Blocked bool
+	// Throttled is true when at least one matching Budget with Action ==
+	// BudgetActionThrottle has reached its limit; the caller may choose to
+	// slow the request down rather than refuse it outright.
+	Throttled bool
+	// Warning is true when at least one matching Budget is at or above its
+	// SoftThresholdPct but has not reached its limit, or when a matching
+	// Budget with Action == BudgetActionWarn has reached its limit.
+	Warning bool
+	// Statuses holds the current BudgetStatus for every Budget that matches
+	// the (apiKey, model) pair being evaluated, in Store.ListBudgets order.
+	Statuses []BudgetStatus
+}
+
+// BudgetManager enforces configured Budgets on top of a billing Store: it
+// resolves which budgets apply to a given (apiKey, model) request, projects
+// spend against their limits before the request runs, and records actual
+// cost against them afterward. It mirrors StreamBudgetGuard's
+// estimate-then-commit shape but generalises it from a single per-key daily
+// cap to arbitrarily scoped, arbitrarily periodised budgets.
+type BudgetManager struct {
+	store Store
+}
+
+// NewBudgetManager returns a BudgetManager persisting budgets and spend via
+// store.
+func NewBudgetManager(store Store) *BudgetManager {
+	return &BudgetManager{store: store}
+}
+
+// Upsert creates or replaces a configured budget. See Store.UpsertBudget.
+func (m *BudgetManager) Upsert(ctx context.Context, b Budget) (Budget, error) {
+	if m == nil || m.store == nil {
+		return Budget{}, errRequired("store")
+	}
+	return m.store.UpsertBudget(ctx, b)
+}
+
+// Delete removes a configured budget. See Store.DeleteBudget.
+func (m *BudgetManager) Delete(ctx context.Context, id string) (bool, error) {
+	if m == nil || m.store == nil {
+		return false, errRequired("store")
+	}
+	return m.store.DeleteBudget(ctx, id)
+}
+
+// List returns every configured budget. See Store.ListBudgets.
+func (m *BudgetManager) List(ctx context.Context) ([]Budget, error) {
+	if m == nil || m.store == nil {
+		return nil, errRequired("store")
+	}
+	return m.store.ListBudgets(ctx)
+}
+
+// Status reports every configured budget's current-period spend as of now.
+// See GET /billing/budgets/status.
+func (m *BudgetManager) Status(ctx context.Context, now time.Time) ([]BudgetStatus, error) {
+	if m == nil || m.store == nil {
+		return nil, errRequired("store")
+	}
+	budgets, err := m.store.ListBudgets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]BudgetStatus, 0, len(budgets))
+	for _, b := range budgets {
+		status, err := m.statusFor(ctx, b, now)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Evaluate projects estimatedCostMicroUSD against every Budget matching
+// (apiKey, model) and reports whether the request should be blocked,
+// throttled, or merely warned about. It does not itself record any spend;
+// call Charge once the request's actual cost is known.
+func (m *BudgetManager) Evaluate(ctx context.Context, apiKey, model string, estimatedCostMicroUSD int64, now time.Time) (BudgetVerdict, error) {
+	var verdict BudgetVerdict
+	if m == nil || m.store == nil {
+		return verdict, errRequired("store")
+	}
+	budgets, err := m.store.ListBudgets(ctx)
+	if err != nil {
+		return verdict, err
+	}
+	for _, b := range budgets {
+		if !budgetMatches(b, apiKey, model) {
+			continue
+		}
+		status, err := m.statusFor(ctx, b, now)
+		if err != nil {
+			return verdict, err
+		}
+		projectedUSD := status.SpentUSD + microUSDToUSD(estimatedCostMicroUSD)
+		status.Exceeded = b.LimitUSD > 0 && projectedUSD >= b.LimitUSD
+		status.SoftWarning = !status.Exceeded && b.SoftThresholdPct > 0 && b.LimitUSD > 0 &&
+			projectedUSD >= b.LimitUSD*b.SoftThresholdPct/100
+
+		verdict.Statuses = append(verdict.Statuses, status)
+		if status.Exceeded {
+			switch b.Action {
+			case BudgetActionBlock:
+				verdict.Blocked = true
+			case BudgetActionThrottle:
+				verdict.Throttled = true
+			case BudgetActionWarn:
+				verdict.Warning = true
+			}
+		} else if status.SoftWarning {
+			verdict.Warning = true
+		}
+	}
+	return verdict, nil
+}
+
+// Charge records actualCostMicroUSD against every Budget matching (apiKey,
+// model) for now's period. Call it once a request's real cost is known,
+// after a successful Evaluate.
+func (m *BudgetManager) Charge(ctx context.Context, apiKey, model string, actualCostMicroUSD int64, now time.Time) error {
+	if m == nil || m.store == nil {
+		return errRequired("store")
+	}
+	if actualCostMicroUSD <= 0 {
+		return nil
+	}
+	budgets, err := m.store.ListBudgets(ctx)
+	if err != nil {
+		return err
+	}
+	for _, b := range budgets {
+		if !budgetMatches(b, apiKey, model) {
+			continue
+		}
+		periodKey := budgetPeriodKey(b.Period, b.CreatedAt, now)
+		if _, err := m.store.ChargeBudgetSpend(ctx, b.ID, periodKey, actualCostMicroUSD); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *BudgetManager) statusFor(ctx context.Context, b Budget, now time.Time) (BudgetStatus, error) {
+	periodKey := budgetPeriodKey(b.Period, b.CreatedAt, now)
+	spentMicro, err := m.store.GetBudgetSpend(ctx, b.ID, periodKey)
+	if err != nil {
+		return BudgetStatus{}, err
+	}
+	spentUSD := microUSDToUSD(spentMicro)
+	remaining := b.LimitUSD - spentUSD
+	if remaining < 0 {
+		remaining = 0
+	}
+	return BudgetStatus{
+		Budget:       b,
+		PeriodKey:    periodKey,
+		SpentUSD:     spentUSD,
+		RemainingUSD: remaining,
+		Exceeded:     b.LimitUSD > 0 && spentUSD >= b.LimitUSD,
+		SoftWarning:  b.SoftThresholdPct > 0 && b.LimitUSD > 0 && spentUSD >= b.LimitUSD*b.SoftThresholdPct/100,
+	}, nil
+}
+
+// budgetMatches reports whether b's scope applies to a request made with
+// apiKey against model.
+func budgetMatches(b Budget, apiKey, model string) bool {
+	switch b.Scope {
+	case BudgetScopeKey:
+		return strings.EqualFold(b.APIKey, apiKey)
+	case BudgetScopeModel:
+		return strings.EqualFold(b.Model, model)
+	case BudgetScopeKeyModel:
+		return strings.EqualFold(b.APIKey, apiKey) && strings.EqualFold(b.Model, model)
+	case BudgetScopeGlobal:
+		return true
+	default:
+		return false
+	}
+}
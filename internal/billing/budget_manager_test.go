@@ -0,0 +1,113 @@
+package billing
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBudgetManager_EvaluateBlocksAtLimitAndWarnsAtThreshold(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	now := time.Date(2026, 2, 13, 12, 0, 0, 0, time.UTC)
+
+	mgr := NewBudgetManager(store)
+	saved, err := mgr.Upsert(ctx, Budget{
+		Scope:            BudgetScopeKey,
+		APIKey:           "k",
+		Period:           BudgetPeriodDay,
+		LimitUSD:         10,
+		Action:           BudgetActionBlock,
+		SoftThresholdPct: 80,
+	})
+	if err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+
+	verdict, err := mgr.Evaluate(ctx, "k", "claude-opus-4-5", 0, now)
+	if err != nil {
+		t.Fatalf("Evaluate(empty): %v", err)
+	}
+	if verdict.Blocked || verdict.Warning {
+		t.Fatalf("verdict on empty spend=%+v", verdict)
+	}
+
+	if err := mgr.Charge(ctx, "k", "claude-opus-4-5", 8_500_000, now); err != nil {
+		t.Fatalf("Charge: %v", err)
+	}
+	verdict, err = mgr.Evaluate(ctx, "k", "claude-opus-4-5", 0, now)
+	if err != nil {
+		t.Fatalf("Evaluate(warning): %v", err)
+	}
+	if verdict.Blocked || !verdict.Warning {
+		t.Fatalf("verdict at soft threshold=%+v", verdict)
+	}
+
+	if err := mgr.Charge(ctx, "k", "claude-opus-4-5", 2_000_000, now); err != nil {
+		t.Fatalf("Charge(2): %v", err)
+	}
+	verdict, err = mgr.Evaluate(ctx, "k", "claude-opus-4-5", 0, now)
+	if err != nil {
+		t.Fatalf("Evaluate(blocked): %v", err)
+	}
+	if !verdict.Blocked {
+		t.Fatalf("verdict after exceeding limit=%+v", verdict)
+	}
+
+	// An unrelated api key never matches this key-scoped budget.
+	verdict, err = mgr.Evaluate(ctx, "other", "claude-opus-4-5", 0, now)
+	if err != nil {
+		t.Fatalf("Evaluate(other key): %v", err)
+	}
+	if verdict.Blocked || verdict.Warning || len(verdict.Statuses) != 0 {
+		t.Fatalf("verdict for unrelated key=%+v", verdict)
+	}
+
+	statuses, err := mgr.Status(ctx, now)
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	if len(statuses) != 1 || statuses[0].ID != saved.ID || !statuses[0].Exceeded {
+		t.Fatalf("statuses=%+v", statuses)
+	}
+}
+
+func TestBudgetManager_PeriodRolloverResetsSpend(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	mgr := NewBudgetManager(store)
+
+	if _, err := mgr.Upsert(ctx, Budget{
+		Scope:    BudgetScopeGlobal,
+		Period:   BudgetPeriodDay,
+		LimitUSD: 1,
+		Action:   BudgetActionBlock,
+	}); err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+
+	// 15:00 UTC is 23:00 in China Standard Time (UTC+8); two hours later
+	// crosses into the next China-time calendar day, so budgetPeriodKey's
+	// "day" period (which uses policy.DayKeyChina) rolls over between them.
+	day1 := time.Date(2026, 2, 13, 15, 0, 0, 0, time.UTC)
+	day2 := day1.Add(2 * time.Hour)
+
+	if err := mgr.Charge(ctx, "k", "m", 1_000_000, day1); err != nil {
+		t.Fatalf("Charge(day1): %v", err)
+	}
+	verdict, err := mgr.Evaluate(ctx, "k", "m", 0, day1)
+	if err != nil {
+		t.Fatalf("Evaluate(day1): %v", err)
+	}
+	if !verdict.Blocked {
+		t.Fatalf("expected day1 to be blocked, got %+v", verdict)
+	}
+
+	verdict, err = mgr.Evaluate(ctx, "k", "m", 0, day2)
+	if err != nil {
+		t.Fatalf("Evaluate(day2): %v", err)
+	}
+	if verdict.Blocked {
+		t.Fatalf("expected day2's fresh period to be unblocked, got %+v", verdict)
+	}
+}
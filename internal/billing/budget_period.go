@@ -0,0 +1,43 @@
+package billing
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/policy"
+)
+
+// budgetPeriodKey returns the key identifying which period bucket now falls
+// into for a Budget with the given period, so BudgetManager can persist and
+// look up spend per-period via Store.ChargeBudgetSpend/GetBudgetSpend. Day
+// and week keys use China Standard Time, matching GetWeeklyUsageReport and
+// GetMonthlyUsageReport's rollup convention.
+func budgetPeriodKey(period string, createdAt int64, now time.Time) string {
+	switch period {
+	case BudgetPeriodWeek:
+		from, _ := policy.WeekRangeChina(now)
+		return from
+	case BudgetPeriodMonth:
+		from, _ := policy.MonthRangeChina(now)
+		return from[:len("2006-01")]
+	case BudgetPeriodRolling30:
+		// rolling_30d is approximated as fixed, non-overlapping 30-day buckets
+		// anchored to the budget's CreatedAt, not a true sliding window: spend
+		// resets every 30 days from when the budget was created rather than
+		// continuously dropping off usage older than 30 days. This keeps the
+		// period key a cheap function of (createdAt, now) instead of requiring
+		// a scan of historical usage on every check.
+		anchor := time.Unix(createdAt, 0).UTC()
+		if createdAt == 0 {
+			anchor = now.UTC()
+		}
+		elapsed := now.UTC().Sub(anchor)
+		bucket := int64(0)
+		if elapsed > 0 {
+			bucket = int64(elapsed / (30 * 24 * time.Hour))
+		}
+		return "r" + strconv.FormatInt(bucket, 10)
+	default: // BudgetPeriodDay and any unrecognised period fall back to daily.
+		return policy.DayKeyChina(now)
+	}
+}
@@ -0,0 +1,66 @@
+package billing
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// BudgetResetScheduler periodically garbage-collects budget_spend rows from
+// periods that have already rolled over. It does not itself "reset" a
+// budget's counter - ChargeBudgetSpend/GetBudgetSpend's per-periodKey keying
+// already makes period rollover implicit and correct with no store writes -
+// it only reclaims space from stale rows, the same way an expired-session GC
+// would, rather than letting budget_spend grow without bound.
+type BudgetResetScheduler struct {
+	store    Store
+	interval time.Duration
+	retain   time.Duration
+}
+
+// NewBudgetResetScheduler returns a scheduler that, once Start is called,
+// prunes budget_spend rows older than retain every interval. A retain of, say,
+// 95 days comfortably outlives every built-in period (including
+// BudgetPeriodRolling30's 30-day buckets) so a GC pass never races a budget
+// that is still being read.
+func NewBudgetResetScheduler(store Store, interval, retain time.Duration) *BudgetResetScheduler {
+	return &BudgetResetScheduler{store: store, interval: interval, retain: retain}
+}
+
+// RunOnce prunes budget_spend rows last updated before now minus retain.
+func (s *BudgetResetScheduler) RunOnce(ctx context.Context) {
+	if s == nil || s.store == nil || s.retain <= 0 {
+		return
+	}
+	cutoff := time.Now().UTC().Add(-s.retain).Unix()
+	pruned, err := s.store.PruneBudgetSpend(ctx, cutoff)
+	if err != nil {
+		log.WithError(err).Warn("billing: budget spend prune failed")
+		return
+	}
+	if pruned > 0 {
+		log.WithField("rows", pruned).Info("billing: pruned stale budget spend rows")
+	}
+}
+
+// Start runs an initial RunOnce, then one more every interval until ctx is
+// cancelled. It is a no-op if interval <= 0.
+func (s *BudgetResetScheduler) Start(ctx context.Context) {
+	if s == nil || s.interval <= 0 {
+		return
+	}
+	s.RunOnce(ctx)
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.RunOnce(ctx)
+			}
+		}
+	}()
+}
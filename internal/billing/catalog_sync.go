@@ -0,0 +1,174 @@
+package billing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/policy"
+)
+
+// CatalogAdapter parses one upstream pricing document format into the
+// PriceMicroUSDPer1M shape UpsertCatalogPrices expects. Community catalogs
+// disagree on field names and units, so each publisher gets its own adapter
+// rather than forcing one schema on PriceCatalogSyncer.
+type CatalogAdapter interface {
+	// Parse decodes body (the upstream document's raw bytes) into a map of
+	// raw model name to price. Keys need not be pre-normalised;
+	// PriceCatalogSyncer normalises them via policy.NormaliseModelKey.
+	Parse(body []byte) (map[string]PriceMicroUSDPer1M, error)
+}
+
+// genericCatalogEntry is one row of the GenericCatalogAdapter's document
+// schema.
+type genericCatalogEntry struct {
+	Model              string  `json:"model"`
+	PromptUSDPer1M     float64 `json:"prompt_usd_per_1m"`
+	CompletionUSDPer1M float64 `json:"completion_usd_per_1m"`
+	CachedUSDPer1M     float64 `json:"cached_usd_per_1m"`
+}
+
+// GenericCatalogAdapter parses the catalog's own minimal schema:
+//
+//	{"models": [{"model": "...", "prompt_usd_per_1m": 5, "completion_usd_per_1m": 25, "cached_usd_per_1m": 0.5}]}
+//
+// It exists as the default, always-available adapter; community catalogs
+// with a different schema should implement their own CatalogAdapter.
+type GenericCatalogAdapter struct{}
+
+func (GenericCatalogAdapter) Parse(body []byte) (map[string]PriceMicroUSDPer1M, error) {
+	var doc struct {
+		Models []genericCatalogEntry `json:"models"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("billing catalog: parse generic document: %w", err)
+	}
+	out := make(map[string]PriceMicroUSDPer1M, len(doc.Models))
+	for _, entry := range doc.Models {
+		if entry.Model == "" {
+			continue
+		}
+		out[entry.Model] = PriceMicroUSDPer1M{
+			Prompt:     USDPer1MToMicroUSDPer1M(entry.PromptUSDPer1M),
+			Completion: USDPer1MToMicroUSDPer1M(entry.CompletionUSDPer1M),
+			Cached:     USDPer1MToMicroUSDPer1M(entry.CachedUSDPer1M),
+		}
+	}
+	return out, nil
+}
+
+// CatalogSyncResult summarises one PriceCatalogSyncer.Sync call.
+type CatalogSyncResult struct {
+	Skipped bool                          `json:"skipped"` // true if the document etag was unchanged (304)
+	ETag    string                        `json:"etag"`
+	Diff    map[string]PriceMicroUSDPer1M `json:"diff,omitempty"` // models added or changed by this sync
+}
+
+// PriceCatalogSyncer periodically refreshes the billing Store's catalog
+// price tier from an upstream pricing document. It is safe to call Sync
+// concurrently with request-time ResolvePriceMicro lookups: UpsertCatalogPrices
+// replaces the whole tier in one store-level transaction.
+type PriceCatalogSyncer struct {
+	store      Store
+	sourceURL  string
+	adapter    CatalogAdapter
+	httpClient *http.Client
+}
+
+// NewPriceCatalogSyncer returns a syncer that fetches sourceURL and parses it
+// with adapter. If adapter is nil, GenericCatalogAdapter{} is used.
+func NewPriceCatalogSyncer(store Store, sourceURL string, adapter CatalogAdapter) *PriceCatalogSyncer {
+	if adapter == nil {
+		adapter = GenericCatalogAdapter{}
+	}
+	return &PriceCatalogSyncer{
+		store:      store,
+		sourceURL:  sourceURL,
+		adapter:    adapter,
+		httpClient: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// Sync fetches the catalog document, and if it differs from the last synced
+// etag, stages and swaps it into store's catalog price tier. A 304 response
+// (or an unchanged etag) is reported as CatalogSyncResult.Skipped with no
+// store writes.
+func (p *PriceCatalogSyncer) Sync(ctx context.Context) (CatalogSyncResult, error) {
+	if p == nil || p.store == nil {
+		return CatalogSyncResult{}, errRequired("store")
+	}
+	if p.sourceURL == "" {
+		return CatalogSyncResult{}, errRequired("sourceURL")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.sourceURL, nil)
+	if err != nil {
+		return CatalogSyncResult{}, fmt.Errorf("billing catalog: build request: %w", err)
+	}
+	if lastETag, err := p.store.GetCatalogETag(ctx); err != nil {
+		return CatalogSyncResult{}, fmt.Errorf("billing catalog: load last etag: %w", err)
+	} else if lastETag != "" {
+		req.Header.Set("If-None-Match", lastETag)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return CatalogSyncResult{}, fmt.Errorf("billing catalog: fetch document: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusNotModified {
+		lastETag, _ := p.store.GetCatalogETag(ctx)
+		return CatalogSyncResult{Skipped: true, ETag: lastETag}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return CatalogSyncResult{}, fmt.Errorf("billing catalog: unexpected status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return CatalogSyncResult{}, fmt.Errorf("billing catalog: read document: %w", err)
+	}
+	etag := resp.Header.Get("ETag")
+	if lastETag, err := p.store.GetCatalogETag(ctx); err != nil {
+		return CatalogSyncResult{}, fmt.Errorf("billing catalog: load last etag: %w", err)
+	} else if etag != "" && etag == lastETag {
+		return CatalogSyncResult{Skipped: true, ETag: etag}, nil
+	}
+
+	parsed, err := p.adapter.Parse(body)
+	if err != nil {
+		return CatalogSyncResult{}, err
+	}
+	normalised := make(map[string]PriceMicroUSDPer1M, len(parsed))
+	for rawModel, price := range parsed {
+		key := policy.NormaliseModelKey(rawModel)
+		if key == "" {
+			continue
+		}
+		normalised[key] = price
+	}
+
+	current, err := p.store.ListModelPrices(ctx)
+	if err != nil {
+		return CatalogSyncResult{}, fmt.Errorf("billing catalog: list current prices: %w", err)
+	}
+	before := modelPricesByModel(current)
+	diff := make(map[string]PriceMicroUSDPer1M, len(normalised))
+	for key, price := range normalised {
+		old, ok := before[key]
+		if ok && (old.Source == "saved" || (old.Source == "catalog" && USDPer1MToMicroUSDPer1M(old.PromptUSDPer1M) == price.Prompt &&
+			USDPer1MToMicroUSDPer1M(old.CompletionUSDPer1M) == price.Completion && USDPer1MToMicroUSDPer1M(old.CachedUSDPer1M) == price.Cached)) {
+			continue
+		}
+		diff[key] = price
+	}
+
+	if err := p.store.UpsertCatalogPrices(ctx, normalised, etag); err != nil {
+		return CatalogSyncResult{}, fmt.Errorf("billing catalog: upsert prices: %w", err)
+	}
+	return CatalogSyncResult{ETag: etag, Diff: diff}, nil
+}
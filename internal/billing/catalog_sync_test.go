@@ -0,0 +1,85 @@
+package billing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPriceCatalogSyncer_SyncUpsertsAndRespectsPinnedPrices(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	pinned := "claude-opus-4-5-20251101"
+	if err := store.UpsertModelPrice(ctx, pinned, PriceMicroUSDPer1M{Prompt: 1, Completion: 2, Cached: 3}); err != nil {
+		t.Fatalf("UpsertModelPrice: %v", err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", "v1")
+		w.Write([]byte(`{"models":[
+			{"model":"` + pinned + `","prompt_usd_per_1m":9,"completion_usd_per_1m":9,"cached_usd_per_1m":9},
+			{"model":"gpt-5-high","prompt_usd_per_1m":3,"completion_usd_per_1m":12,"cached_usd_per_1m":0.3}
+		]}`))
+	}))
+	defer server.Close()
+
+	syncer := NewPriceCatalogSyncer(store, server.URL, nil)
+	result, err := syncer.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if result.Skipped || result.ETag != "v1" {
+		t.Fatalf("result=%+v", result)
+	}
+
+	// The pinned model keeps its saved price; only the new model lands in
+	// the catalog tier.
+	price, source, _, err := store.ResolvePriceMicro(ctx, pinned)
+	if err != nil {
+		t.Fatalf("ResolvePriceMicro(pinned): %v", err)
+	}
+	if source != "saved" || price.Prompt != 1 {
+		t.Fatalf("pinned price=%+v source=%q", price, source)
+	}
+
+	price2, source2, _, err := store.ResolvePriceMicro(ctx, "gpt-5-high")
+	if err != nil {
+		t.Fatalf("ResolvePriceMicro(catalog): %v", err)
+	}
+	if source2 != "catalog" || price2.Completion != 12_000_000 {
+		t.Fatalf("catalog price=%+v source=%q", price2, source2)
+	}
+}
+
+func TestPriceCatalogSyncer_Sync_SkipsUnchangedETag(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.Header.Get("If-None-Match") == "v1" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", "v1")
+		w.Write([]byte(`{"models":[{"model":"gpt-5-high","prompt_usd_per_1m":3,"completion_usd_per_1m":12,"cached_usd_per_1m":0.3}]}`))
+	}))
+	defer server.Close()
+
+	syncer := NewPriceCatalogSyncer(store, server.URL, nil)
+	if _, err := syncer.Sync(ctx); err != nil {
+		t.Fatalf("Sync #1: %v", err)
+	}
+	result, err := syncer.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Sync #2: %v", err)
+	}
+	if !result.Skipped {
+		t.Fatalf("expected second sync to be skipped, got %+v", result)
+	}
+	if calls != 2 {
+		t.Fatalf("calls=%d", calls)
+	}
+}
@@ -41,3 +41,29 @@ func costMicroUSD(tokens int64, microUSDPer1M int64) int64 {
 	// Round to nearest micro-USD at the end.
 	return (tokens*microUSDPer1M + tokensPerMillion/2) / tokensPerMillion
 }
+
+// usageCostMicroUSD prices one usage row's token counts at price, using the
+// same split UsagePersistPlugin.HandleUsage uses: cached input tokens are
+// priced at price.Cached, the remaining (non-cached) input tokens and all
+// completion tokens (output + reasoning) at price.Prompt / price.Completion
+// respectively.
+func usageCostMicroUSD(price PriceMicroUSDPer1M, inputTokens, outputTokens, reasoningTokens, cachedTokens int64) int64 {
+	promptTokens := inputTokens - cachedTokens
+	if promptTokens < 0 {
+		promptTokens = 0
+	}
+	completionTokens := outputTokens + reasoningTokens
+
+	cost := costMicroUSD(promptTokens, price.Prompt)
+	cost += costMicroUSD(cachedTokens, price.Cached)
+	cost += costMicroUSD(completionTokens, price.Completion)
+	return cost
+}
+
+// UsageCostMicroUSD is the exported form of usageCostMicroUSD, for callers
+// outside this package that need to price a partial or estimated token
+// count against a resolved price (e.g. a streaming mid-flight budget
+// guard), not just a finished UsagePersistPlugin.HandleUsage record.
+func UsageCostMicroUSD(price PriceMicroUSDPer1M, inputTokens, outputTokens, reasoningTokens, cachedTokens int64) int64 {
+	return usageCostMicroUSD(price, inputTokens, outputTokens, reasoningTokens, cachedTokens)
+}
@@ -0,0 +1,133 @@
+package billing
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// AggregateStats is one (api key, model) pair's running totals, as reported
+// by GET /billing/stats.
+type AggregateStats struct {
+	APIKey                string `json:"api_key"`
+	Model                 string `json:"model"`
+	TotalEvents           int64  `json:"total_events"`
+	TotalOutputTokens     int64  `json:"total_output_tokens"`
+	TotalCostMicroUSD     int64  `json:"total_cost_micro_usd"`
+	ActiveDurationSeconds int64  `json:"active_duration_seconds"`
+}
+
+type eventAggregateKey struct {
+	apiKey string
+	model  string
+}
+
+// eventAggregateCounters holds one (api key, model) pair's running totals.
+// Every field is updated with atomic adds or compare-and-swap loops rather
+// than a mutex, since EventAggregator.Record is expected to run once per
+// chargeable request - too hot a path to serialize behind a single lock
+// shared across every (key, model) pair.
+type eventAggregateCounters struct {
+	totalEvents       atomic.Int64
+	totalOutputTokens atomic.Int64
+	totalCostMicroUSD atomic.Int64
+	firstSeenUnix     atomic.Int64
+	lastSeenUnix      atomic.Int64
+}
+
+// EventAggregator maintains running (api key, model) counters - total
+// events, total output tokens, total spent micro-USD, and active duration -
+// over a concurrent map, so GET /billing/stats never has to re-walk raw
+// usage rows or replay the event ring buffer to answer a dashboard query.
+type EventAggregator struct {
+	counters sync.Map // eventAggregateKey -> *eventAggregateCounters
+}
+
+// NewEventAggregator returns an empty EventAggregator.
+func NewEventAggregator() *EventAggregator {
+	return &EventAggregator{}
+}
+
+// Record folds evt into its (api key, model) pair's running totals.
+func (a *EventAggregator) Record(evt BillingEvent) {
+	if a == nil {
+		return
+	}
+	key := eventAggregateKey{apiKey: evt.APIKey, model: evt.Model}
+	actual, _ := a.counters.LoadOrStore(key, &eventAggregateCounters{})
+	c := actual.(*eventAggregateCounters)
+
+	c.totalEvents.Add(1)
+	c.totalOutputTokens.Add(evt.CompletionTokens)
+	c.totalCostMicroUSD.Add(evt.CostMicroUSD)
+
+	ts := evt.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	casMin(&c.firstSeenUnix, ts.Unix())
+	casMax(&c.lastSeenUnix, ts.Unix())
+}
+
+// casMin compare-and-swaps dst down to v if dst is unset (0) or greater
+// than v.
+func casMin(dst *atomic.Int64, v int64) {
+	for {
+		cur := dst.Load()
+		if cur != 0 && cur <= v {
+			return
+		}
+		if dst.CompareAndSwap(cur, v) {
+			return
+		}
+	}
+}
+
+// casMax compare-and-swaps dst up to v if dst is less than v.
+func casMax(dst *atomic.Int64, v int64) {
+	for {
+		cur := dst.Load()
+		if cur >= v {
+			return
+		}
+		if dst.CompareAndSwap(cur, v) {
+			return
+		}
+	}
+}
+
+// Snapshot returns every (api key, model) pair's current totals, sorted by
+// APIKey then Model for deterministic output.
+func (a *EventAggregator) Snapshot() []AggregateStats {
+	if a == nil {
+		return nil
+	}
+	var out []AggregateStats
+	a.counters.Range(func(k, v any) bool {
+		key := k.(eventAggregateKey)
+		c := v.(*eventAggregateCounters)
+		first := c.firstSeenUnix.Load()
+		last := c.lastSeenUnix.Load()
+		var activeSeconds int64
+		if last > first {
+			activeSeconds = last - first
+		}
+		out = append(out, AggregateStats{
+			APIKey:                key.apiKey,
+			Model:                 key.model,
+			TotalEvents:           c.totalEvents.Load(),
+			TotalOutputTokens:     c.totalOutputTokens.Load(),
+			TotalCostMicroUSD:     c.totalCostMicroUSD.Load(),
+			ActiveDurationSeconds: activeSeconds,
+		})
+		return true
+	})
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].APIKey != out[j].APIKey {
+			return out[i].APIKey < out[j].APIKey
+		}
+		return out[i].Model < out[j].Model
+	})
+	return out
+}
@@ -0,0 +1,56 @@
+package billing
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEventAggregator_RecordAccumulatesPerKeyModel(t *testing.T) {
+	agg := NewEventAggregator()
+	base := time.Unix(1_700_000_000, 0)
+
+	agg.Record(BillingEvent{APIKey: "key-a", Model: "gpt-4o", CompletionTokens: 10, CostMicroUSD: 100, Timestamp: base})
+	agg.Record(BillingEvent{APIKey: "key-a", Model: "gpt-4o", CompletionTokens: 20, CostMicroUSD: 200, Timestamp: base.Add(30 * time.Second)})
+	agg.Record(BillingEvent{APIKey: "key-b", Model: "gpt-4o", CompletionTokens: 5, CostMicroUSD: 50, Timestamp: base})
+
+	stats := agg.Snapshot()
+	if len(stats) != 2 {
+		t.Fatalf("len(Snapshot)=%d, want 2", len(stats))
+	}
+
+	// Sorted by APIKey then Model: key-a before key-b.
+	a := stats[0]
+	if a.APIKey != "key-a" || a.TotalEvents != 2 || a.TotalOutputTokens != 30 || a.TotalCostMicroUSD != 300 {
+		t.Fatalf("stats[0]=%+v, want key-a totals 2/30/300", a)
+	}
+	if a.ActiveDurationSeconds != 30 {
+		t.Fatalf("stats[0].ActiveDurationSeconds=%d, want 30", a.ActiveDurationSeconds)
+	}
+}
+
+func TestEventAggregator_NilAggregatorIsSafe(t *testing.T) {
+	var agg *EventAggregator
+	agg.Record(BillingEvent{APIKey: "key-a"})
+	if got := agg.Snapshot(); got != nil {
+		t.Fatalf("Snapshot on nil aggregator=%+v, want nil", got)
+	}
+}
+
+func TestEventAggregator_RecordIsConcurrencySafe(t *testing.T) {
+	agg := NewEventAggregator()
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			agg.Record(BillingEvent{APIKey: "key-a", Model: "gpt-4o", CompletionTokens: 1, CostMicroUSD: 1})
+		}()
+	}
+	wg.Wait()
+
+	stats := agg.Snapshot()
+	if len(stats) != 1 || stats[0].TotalEvents != 100 {
+		t.Fatalf("stats=%+v, want one entry with TotalEvents=100", stats)
+	}
+}
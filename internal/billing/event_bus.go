@@ -0,0 +1,205 @@
+package billing
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// BillingEvent is one chargeable request's full accounting record, as
+// reported to a BillingEventBus by UsagePersistPlugin.HandleUsage. It is the
+// source record behind the management API's GET /billing/events (history),
+// GET /billing/events/stream (SSE), and GET /billing/stats (EventAggregator)
+// endpoints.
+type BillingEvent struct {
+	Timestamp        time.Time `json:"timestamp"`
+	APIKey           string    `json:"api_key"`
+	Provider         string    `json:"provider"`
+	Model            string    `json:"model"`
+	PromptTokens     int64     `json:"prompt_tokens"`
+	CompletionTokens int64     `json:"completion_tokens"`
+	CachedTokens     int64     `json:"cached_tokens"`
+	CostMicroUSD     int64     `json:"cost_micro_usd"`
+	Failed           bool      `json:"failed"`
+
+	// RequestID, LatencyMS, and FinishReason are populated on a best-effort
+	// basis: HandleUsage's coreusage.Record does not currently carry them,
+	// so they are left zero-valued until that record is extended to report
+	// them.
+	RequestID    string `json:"request_id,omitempty"`
+	LatencyMS    int64  `json:"latency_ms,omitempty"`
+	FinishReason string `json:"finish_reason,omitempty"`
+}
+
+// BillingEventSink persists BillingEvents beyond BillingEventBus's in-memory
+// ring buffer. SQLiteEventSink is the first implementation; a Postgres sink
+// can satisfy the same interface later without BillingEventBus itself
+// changing.
+type BillingEventSink interface {
+	PersistBillingEvent(ctx context.Context, evt BillingEvent) error
+}
+
+// BillingEventFilter narrows BillingEventBus.Events to a page of events
+// matching every non-zero field. Limit <= 0 means "no limit".
+type BillingEventFilter struct {
+	APIKey string
+	Model  string
+	Since  time.Time
+	Until  time.Time
+	Offset int
+	Limit  int
+}
+
+func (f BillingEventFilter) matches(evt BillingEvent) bool {
+	if f.APIKey != "" && f.APIKey != evt.APIKey {
+		return false
+	}
+	if f.Model != "" && f.Model != evt.Model {
+		return false
+	}
+	if !f.Since.IsZero() && evt.Timestamp.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && evt.Timestamp.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+const defaultBillingEventRingCapacity = 10_000
+
+// BillingEventBus fans out BillingEvents to a bounded in-memory ring buffer
+// (backing GET /billing/events's history/pagination), any number of live
+// SSE subscribers (GET /billing/events/stream), and, if configured, a
+// BillingEventSink for durable storage.
+type BillingEventBus struct {
+	mu       sync.Mutex
+	ring     []BillingEvent
+	next     int
+	full     bool
+	capacity int
+
+	subs    map[int]chan BillingEvent
+	nextSub int
+
+	sink BillingEventSink
+}
+
+// NewBillingEventBus returns a BillingEventBus whose ring buffer holds the
+// most recent capacity events. capacity <= 0 falls back to
+// defaultBillingEventRingCapacity.
+func NewBillingEventBus(capacity int) *BillingEventBus {
+	if capacity <= 0 {
+		capacity = defaultBillingEventRingCapacity
+	}
+	return &BillingEventBus{
+		ring:     make([]BillingEvent, capacity),
+		capacity: capacity,
+		subs:     make(map[int]chan BillingEvent),
+	}
+}
+
+// WithSink attaches sink so every Publish call also persists durably.
+// Returns b for chaining at construction time.
+func (b *BillingEventBus) WithSink(sink BillingEventSink) *BillingEventBus {
+	if b == nil {
+		return b
+	}
+	b.sink = sink
+	return b
+}
+
+// Publish records evt in the ring buffer, forwards it to every live
+// subscriber (dropping it for a subscriber whose channel is full rather than
+// blocking the publisher), and persists it via b.sink if configured. Sink
+// errors are not surfaced: a durability failure must not interrupt request
+// handling, consistent with how Store.AddUsage failures are already handled
+// in UsagePersistPlugin.HandleUsage.
+func (b *BillingEventBus) Publish(ctx context.Context, evt BillingEvent) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	b.ring[b.next] = evt
+	b.next++
+	if b.next >= b.capacity {
+		b.next = 0
+		b.full = true
+	}
+	for _, ch := range b.subs {
+		select {
+		case ch <- evt:
+		default:
+		}
+	}
+	b.mu.Unlock()
+
+	if b.sink != nil {
+		_ = b.sink.PersistBillingEvent(ctx, evt)
+	}
+}
+
+// Subscribe registers a new live subscriber and returns its event channel
+// plus an unsubscribe func that must be called once the caller stops
+// reading (e.g. when an SSE client disconnects), so Publish stops silently
+// dropping events for a channel nobody drains anymore.
+func (b *BillingEventBus) Subscribe() (<-chan BillingEvent, func()) {
+	ch := make(chan BillingEvent, 64)
+	b.mu.Lock()
+	id := b.nextSub
+	b.nextSub++
+	b.subs[id] = ch
+	b.mu.Unlock()
+
+	return ch, func() {
+		b.mu.Lock()
+		if sub, ok := b.subs[id]; ok {
+			delete(b.subs, id)
+			close(sub)
+		}
+		b.mu.Unlock()
+	}
+}
+
+// Events returns every buffered event matching filter, newest first, with
+// filter.Offset/Limit applied after filtering.
+func (b *BillingEventBus) Events(filter BillingEventFilter) []BillingEvent {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	ordered := b.orderedLocked()
+	b.mu.Unlock()
+
+	matched := make([]BillingEvent, 0, len(ordered))
+	for i := len(ordered) - 1; i >= 0; i-- {
+		if filter.matches(ordered[i]) {
+			matched = append(matched, ordered[i])
+		}
+	}
+
+	if filter.Offset > 0 {
+		if filter.Offset >= len(matched) {
+			return []BillingEvent{}
+		}
+		matched = matched[filter.Offset:]
+	}
+	if filter.Limit > 0 && filter.Limit < len(matched) {
+		matched = matched[:filter.Limit]
+	}
+	return matched
+}
+
+// orderedLocked returns the ring buffer's contents in publish order. Callers
+// must hold b.mu.
+func (b *BillingEventBus) orderedLocked() []BillingEvent {
+	if !b.full {
+		out := make([]BillingEvent, b.next)
+		copy(out, b.ring[:b.next])
+		return out
+	}
+	out := make([]BillingEvent, b.capacity)
+	copy(out, b.ring[b.next:])
+	copy(out[b.capacity-b.next:], b.ring[:b.next])
+	return out
+}
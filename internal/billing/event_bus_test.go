@@ -0,0 +1,100 @@
+package billing
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBillingEventBus_PublishSubscribeDelivers(t *testing.T) {
+	bus := NewBillingEventBus(10)
+	ch, unsubscribe := bus.Subscribe()
+	defer unsubscribe()
+
+	evt := BillingEvent{APIKey: "key-a", Model: "gpt-4o", CostMicroUSD: 100}
+	bus.Publish(context.Background(), evt)
+
+	select {
+	case got := <-ch:
+		if got.APIKey != "key-a" || got.CostMicroUSD != 100 {
+			t.Fatalf("got %+v, want APIKey=key-a CostMicroUSD=100", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for subscriber delivery")
+	}
+}
+
+func TestBillingEventBus_UnsubscribeClosesChannel(t *testing.T) {
+	bus := NewBillingEventBus(10)
+	ch, unsubscribe := bus.Subscribe()
+	unsubscribe()
+
+	if _, ok := <-ch; ok {
+		t.Fatal("expected channel to be closed after unsubscribe")
+	}
+}
+
+func TestBillingEventBus_EventsWrapsAroundRingBuffer(t *testing.T) {
+	bus := NewBillingEventBus(3)
+	for i := 0; i < 5; i++ {
+		bus.Publish(context.Background(), BillingEvent{APIKey: "key-a", Model: "m", CostMicroUSD: int64(i)})
+	}
+
+	got := bus.Events(BillingEventFilter{})
+	if len(got) != 3 {
+		t.Fatalf("len(Events)=%d, want 3 (ring capacity)", len(got))
+	}
+	// Newest first: the last three published were costs 2, 3, 4.
+	want := []int64{4, 3, 2}
+	for i, w := range want {
+		if got[i].CostMicroUSD != w {
+			t.Fatalf("Events[%d].CostMicroUSD=%d, want %d", i, got[i].CostMicroUSD, w)
+		}
+	}
+}
+
+func TestBillingEventBus_EventsFiltersByAPIKeyAndModel(t *testing.T) {
+	bus := NewBillingEventBus(10)
+	bus.Publish(context.Background(), BillingEvent{APIKey: "key-a", Model: "gpt-4o"})
+	bus.Publish(context.Background(), BillingEvent{APIKey: "key-b", Model: "gpt-4o"})
+	bus.Publish(context.Background(), BillingEvent{APIKey: "key-a", Model: "claude-3"})
+
+	got := bus.Events(BillingEventFilter{APIKey: "key-a", Model: "gpt-4o"})
+	if len(got) != 1 || got[0].APIKey != "key-a" || got[0].Model != "gpt-4o" {
+		t.Fatalf("got %+v, want exactly one key-a/gpt-4o event", got)
+	}
+}
+
+func TestBillingEventBus_EventsAppliesOffsetAndLimit(t *testing.T) {
+	bus := NewBillingEventBus(10)
+	for i := 0; i < 5; i++ {
+		bus.Publish(context.Background(), BillingEvent{APIKey: "key-a", CostMicroUSD: int64(i)})
+	}
+
+	got := bus.Events(BillingEventFilter{Offset: 1, Limit: 2})
+	if len(got) != 2 {
+		t.Fatalf("len(Events)=%d, want 2", len(got))
+	}
+	if got[0].CostMicroUSD != 3 || got[1].CostMicroUSD != 2 {
+		t.Fatalf("got costs %d,%d, want 3,2", got[0].CostMicroUSD, got[1].CostMicroUSD)
+	}
+}
+
+type recordingSink struct {
+	events []BillingEvent
+}
+
+func (s *recordingSink) PersistBillingEvent(_ context.Context, evt BillingEvent) error {
+	s.events = append(s.events, evt)
+	return nil
+}
+
+func TestBillingEventBus_PublishPersistsToSink(t *testing.T) {
+	sink := &recordingSink{}
+	bus := NewBillingEventBus(10).WithSink(sink)
+	bus.Publish(context.Background(), BillingEvent{APIKey: "key-a"})
+
+	if len(sink.events) != 1 || sink.events[0].APIKey != "key-a" {
+		t.Fatalf("sink.events=%+v, want one key-a event", sink.events)
+	}
+}
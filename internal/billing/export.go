@@ -0,0 +1,211 @@
+package billing
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExportFormat selects the serialization ExportUsage writes.
+type ExportFormat string
+
+const (
+	ExportFormatCSV    ExportFormat = "csv"
+	ExportFormatNDJSON ExportFormat = "ndjson"
+)
+
+// ExportFilter selects which usage rows ExportUsage streams.
+type ExportFilter struct {
+	APIKey  string
+	FromDay string
+	ToDay   string
+}
+
+var usageRowCSVHeader = []string{
+	"api_key", "model", "day",
+	"requests", "failed_requests",
+	"input_tokens", "output_tokens", "reasoning_tokens", "cached_tokens", "total_tokens",
+	"cost_micro_usd", "updated_at",
+}
+
+// ExportUsage streams filter's matching per-model daily usage rows to w in
+// the requested format, ordered the same way Store.ListUsageRows returns
+// them (by day, then model).
+func ExportUsage(ctx context.Context, store Store, filter ExportFilter, format ExportFormat, w io.Writer) error {
+	if store == nil {
+		return errRequired("store")
+	}
+	rows, err := store.ListUsageRows(ctx, filter.APIKey, filter.FromDay, filter.ToDay)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case ExportFormatCSV:
+		return exportUsageCSV(rows, w)
+	case ExportFormatNDJSON:
+		return exportUsageNDJSON(rows, w)
+	default:
+		return fmt.Errorf("billing export: unsupported format %q", format)
+	}
+}
+
+func exportUsageCSV(rows []DailyUsageRow, w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(usageRowCSVHeader); err != nil {
+		return fmt.Errorf("billing export: write csv header: %w", err)
+	}
+	for _, row := range rows {
+		record := []string{
+			row.APIKey, row.Model, row.Day,
+			strconv.FormatInt(row.Requests, 10), strconv.FormatInt(row.FailedRequests, 10),
+			strconv.FormatInt(row.InputTokens, 10), strconv.FormatInt(row.OutputTokens, 10),
+			strconv.FormatInt(row.ReasoningTokens, 10), strconv.FormatInt(row.CachedTokens, 10), strconv.FormatInt(row.TotalTokens, 10),
+			strconv.FormatInt(row.CostMicroUSD, 10), strconv.FormatInt(row.UpdatedAt, 10),
+		}
+		if err := cw.Write(record); err != nil {
+			return fmt.Errorf("billing export: write csv row: %w", err)
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("billing export: flush csv: %w", err)
+	}
+	return nil
+}
+
+func exportUsageNDJSON(rows []DailyUsageRow, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	for _, row := range rows {
+		if err := enc.Encode(row); err != nil {
+			return fmt.Errorf("billing export: write ndjson row: %w", err)
+		}
+	}
+	return nil
+}
+
+var modelPriceCSVHeader = []string{
+	"model", "prompt_usd_per_1m", "completion_usd_per_1m", "cached_usd_per_1m", "source", "updated_at",
+}
+
+// ExportModelPricesCSV writes prices (as returned by Store.ListModelPrices)
+// to w as CSV, ordered by model, including the Source column so a reviewer
+// can tell a saved override from a built-in default.
+func ExportModelPricesCSV(prices []ModelPrice, w io.Writer) error {
+	sorted := append([]ModelPrice(nil), prices...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Model < sorted[j].Model })
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(modelPriceCSVHeader); err != nil {
+		return fmt.Errorf("billing export: write csv header: %w", err)
+	}
+	for _, p := range sorted {
+		record := []string{
+			p.Model,
+			strconv.FormatFloat(p.PromptUSDPer1M, 'f', -1, 64),
+			strconv.FormatFloat(p.CompletionUSDPer1M, 'f', -1, 64),
+			strconv.FormatFloat(p.CachedUSDPer1M, 'f', -1, 64),
+			p.Source,
+			strconv.FormatInt(p.UpdatedAt, 10),
+		}
+		if err := cw.Write(record); err != nil {
+			return fmt.Errorf("billing export: write csv row: %w", err)
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("billing export: flush csv: %w", err)
+	}
+	return nil
+}
+
+// ExportModelPricesJSON writes prices to w as a JSON array, ordered by model.
+func ExportModelPricesJSON(prices []ModelPrice, w io.Writer) error {
+	sorted := append([]ModelPrice(nil), prices...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Model < sorted[j].Model })
+	if err := json.NewEncoder(w).Encode(sorted); err != nil {
+		return fmt.Errorf("billing export: write json: %w", err)
+	}
+	return nil
+}
+
+// ParsePriceImportCSV reads a bulk price import CSV body: a header row naming
+// its columns (order-independent; "model", "prompt_usd_per_1m",
+// "completion_usd_per_1m", and "cached_usd_per_1m" are required, an
+// "effective_from" column - RFC3339 or "YYYY-MM-DD" - is optional) followed
+// by one row per model.
+func ParsePriceImportCSV(r io.Reader) ([]PriceImportEntry, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("billing import: read csv header: %w", err)
+	}
+	col := make(map[string]int, len(header))
+	for i, name := range header {
+		col[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"model", "prompt_usd_per_1m", "completion_usd_per_1m", "cached_usd_per_1m"} {
+		if _, ok := col[required]; !ok {
+			return nil, fmt.Errorf("billing import: csv header missing required column %q", required)
+		}
+	}
+	effectiveFromCol, hasEffectiveFrom := col["effective_from"]
+
+	var entries []PriceImportEntry
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("billing import: read csv row: %w", err)
+		}
+		entry := PriceImportEntry{Model: field(record, col["model"])}
+		entry.PromptUSDPer1M, err = strconv.ParseFloat(field(record, col["prompt_usd_per_1m"]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("billing import: parse prompt_usd_per_1m for %q: %w", entry.Model, err)
+		}
+		entry.CompletionUSDPer1M, err = strconv.ParseFloat(field(record, col["completion_usd_per_1m"]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("billing import: parse completion_usd_per_1m for %q: %w", entry.Model, err)
+		}
+		entry.CachedUSDPer1M, err = strconv.ParseFloat(field(record, col["cached_usd_per_1m"]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("billing import: parse cached_usd_per_1m for %q: %w", entry.Model, err)
+		}
+		if hasEffectiveFrom {
+			if raw := strings.TrimSpace(field(record, effectiveFromCol)); raw != "" {
+				t, err := parsePriceImportTime(raw)
+				if err != nil {
+					return nil, fmt.Errorf("billing import: parse effective_from for %q: %w", entry.Model, err)
+				}
+				entry.EffectiveFrom = t.Unix()
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func field(record []string, i int) string {
+	if i < 0 || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+// parsePriceImportTime accepts an RFC3339 timestamp or a bare "YYYY-MM-DD"
+// date for the CSV import's optional effective_from column.
+func parsePriceImportTime(raw string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", raw)
+}
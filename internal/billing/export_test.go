@@ -0,0 +1,92 @@
+package billing
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestExportUsage_CSVAndNDJSON(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	apiKey := "key-1"
+	if err := store.AddUsage(ctx, apiKey, "model-a", "2026-02-09", DailyUsageRow{Requests: 1, TotalTokens: 10, CostMicroUSD: 100}); err != nil {
+		t.Fatalf("AddUsage: %v", err)
+	}
+
+	var csvBuf strings.Builder
+	if err := ExportUsage(ctx, store, ExportFilter{APIKey: apiKey, FromDay: "2026-02-01", ToDay: "2026-02-28"}, ExportFormatCSV, &csvBuf); err != nil {
+		t.Fatalf("ExportUsage(csv): %v", err)
+	}
+	lines := strings.Split(strings.TrimRight(csvBuf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("csv lines=%v", lines)
+	}
+	if !strings.HasPrefix(lines[1], "key-1,model-a,2026-02-09,1,0,0,0,0,0,10,100,") {
+		t.Fatalf("csv row=%q", lines[1])
+	}
+
+	var ndjsonBuf strings.Builder
+	if err := ExportUsage(ctx, store, ExportFilter{APIKey: apiKey, FromDay: "2026-02-01", ToDay: "2026-02-28"}, ExportFormatNDJSON, &ndjsonBuf); err != nil {
+		t.Fatalf("ExportUsage(ndjson): %v", err)
+	}
+	if !strings.Contains(ndjsonBuf.String(), `"model":"model-a"`) {
+		t.Fatalf("ndjson output=%q", ndjsonBuf.String())
+	}
+}
+
+func TestExportUsage_UnsupportedFormat(t *testing.T) {
+	store := NewMemoryStore()
+	var buf strings.Builder
+	err := ExportUsage(context.Background(), store, ExportFilter{APIKey: "k", FromDay: "2026-02-01", ToDay: "2026-02-28"}, ExportFormat("xml"), &buf)
+	if err == nil {
+		t.Fatal("expected error for unsupported format")
+	}
+}
+
+func TestParsePriceImportCSV_ParsesRowsAndEffectiveFrom(t *testing.T) {
+	body := "model,prompt_usd_per_1m,completion_usd_per_1m,cached_usd_per_1m,effective_from\n" +
+		"gpt-x,1.5,3,0.5,2026-03-01\n" +
+		"claude-y,2,4,1,\n"
+	entries, err := ParsePriceImportCSV(strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("ParsePriceImportCSV: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("entries=%v", entries)
+	}
+	if entries[0].Model != "gpt-x" || entries[0].PromptUSDPer1M != 1.5 || entries[0].EffectiveFrom == 0 {
+		t.Fatalf("entries[0]=%+v", entries[0])
+	}
+	if entries[1].Model != "claude-y" || entries[1].EffectiveFrom != 0 {
+		t.Fatalf("entries[1]=%+v", entries[1])
+	}
+}
+
+func TestParsePriceImportCSV_MissingColumnErrors(t *testing.T) {
+	_, err := ParsePriceImportCSV(strings.NewReader("model,prompt_usd_per_1m\ngpt-x,1\n"))
+	if err == nil {
+		t.Fatal("expected error for missing required column")
+	}
+}
+
+func TestExportModelPricesCSVAndJSON(t *testing.T) {
+	prices := []ModelPrice{
+		{Model: "gpt-x", PromptUSDPer1M: 1, CompletionUSDPer1M: 2, CachedUSDPer1M: 0.5, Source: "saved", UpdatedAt: 100},
+	}
+	var csvBuf strings.Builder
+	if err := ExportModelPricesCSV(prices, &csvBuf); err != nil {
+		t.Fatalf("ExportModelPricesCSV: %v", err)
+	}
+	if !strings.Contains(csvBuf.String(), "gpt-x,1,2,0.5,saved,100") {
+		t.Fatalf("csv output=%q", csvBuf.String())
+	}
+
+	var jsonBuf strings.Builder
+	if err := ExportModelPricesJSON(prices, &jsonBuf); err != nil {
+		t.Fatalf("ExportModelPricesJSON: %v", err)
+	}
+	if !strings.Contains(jsonBuf.String(), `"model":"gpt-x"`) {
+		t.Fatalf("json output=%q", jsonBuf.String())
+	}
+}
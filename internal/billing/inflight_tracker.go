@@ -0,0 +1,77 @@
+package billing
+
+import "sync"
+
+// InFlightTracker maintains a running, not-yet-persisted cost estimate per
+// API key for requests that are still executing, keyed by (apiKey,
+// requestToken) so concurrent streaming requests under the same API key
+// each carry their own running estimate instead of clobbering one another.
+//
+// It is deliberately separate from Store.ReserveBudget: ReserveBudget holds
+// one fixed estimate for a request's whole lifetime, set once before the
+// request starts. InFlightTracker instead lets a caller keep replacing that
+// estimate as better information arrives mid-request, e.g. a streaming
+// completion's own cumulative usage field parsed out of its SSE chunks.
+type InFlightTracker struct {
+	mu    sync.Mutex
+	spent map[string]map[string]int64 // apiKey -> requestToken -> running micro-USD estimate
+}
+
+// NewInFlightTracker returns an empty InFlightTracker.
+func NewInFlightTracker() *InFlightTracker {
+	return &InFlightTracker{spent: make(map[string]map[string]int64)}
+}
+
+// Set replaces requestToken's running estimate under apiKey with microUSD.
+// requestToken identifies one in-flight request (e.g. its request ID); two
+// concurrent requests under the same apiKey must pass different tokens or
+// they will overwrite each other's estimate.
+func (t *InFlightTracker) Set(apiKey, requestToken string, microUSD int64) {
+	if t == nil || apiKey == "" || requestToken == "" {
+		return
+	}
+	t.mu.Lock()
+	if t.spent == nil {
+		t.spent = make(map[string]map[string]int64)
+	}
+	perRequest, ok := t.spent[apiKey]
+	if !ok {
+		perRequest = make(map[string]int64)
+		t.spent[apiKey] = perRequest
+	}
+	perRequest[requestToken] = microUSD
+	t.mu.Unlock()
+}
+
+// Snapshot returns apiKey's current running estimate, summed across every
+// in-flight request under that key, or 0 if none is set.
+func (t *InFlightTracker) Snapshot(apiKey string) int64 {
+	if t == nil || apiKey == "" {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var total int64
+	for _, microUSD := range t.spent[apiKey] {
+		total += microUSD
+	}
+	return total
+}
+
+// Clear removes requestToken's running estimate under apiKey, e.g. once that
+// request's actual usage has been persisted and its in-flight estimate is no
+// longer needed. Other requests still in flight under the same apiKey are
+// unaffected.
+func (t *InFlightTracker) Clear(apiKey, requestToken string) {
+	if t == nil || apiKey == "" || requestToken == "" {
+		return
+	}
+	t.mu.Lock()
+	if perRequest, ok := t.spent[apiKey]; ok {
+		delete(perRequest, requestToken)
+		if len(perRequest) == 0 {
+			delete(t.spent, apiKey)
+		}
+	}
+	t.mu.Unlock()
+}
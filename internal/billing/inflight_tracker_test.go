@@ -0,0 +1,65 @@
+package billing
+
+import "testing"
+
+func TestInFlightTracker_SetSnapshotClear(t *testing.T) {
+	tracker := NewInFlightTracker()
+
+	if got := tracker.Snapshot("key-a"); got != 0 {
+		t.Fatalf("Snapshot on unset key=%d, want 0", got)
+	}
+
+	tracker.Set("key-a", "req-1", 1_500_000)
+	if got := tracker.Snapshot("key-a"); got != 1_500_000 {
+		t.Fatalf("Snapshot=%d, want 1_500_000", got)
+	}
+
+	tracker.Set("key-a", "req-1", 2_000_000)
+	if got := tracker.Snapshot("key-a"); got != 2_000_000 {
+		t.Fatalf("Snapshot after overwrite=%d, want 2_000_000", got)
+	}
+
+	tracker.Clear("key-a", "req-1")
+	if got := tracker.Snapshot("key-a"); got != 0 {
+		t.Fatalf("Snapshot after Clear=%d, want 0", got)
+	}
+}
+
+// TestInFlightTracker_ConcurrentRequestsAccumulateIndependently covers the
+// burst scenario a budget guard needs to catch: two concurrent streaming
+// requests under the same API key must not clobber each other's running
+// estimate, and finishing one must not wipe out the other's still-in-flight
+// estimate.
+func TestInFlightTracker_ConcurrentRequestsAccumulateIndependently(t *testing.T) {
+	tracker := NewInFlightTracker()
+
+	tracker.Set("key-a", "req-1", 1_000_000)
+	tracker.Set("key-a", "req-2", 3_000_000)
+	if got := tracker.Snapshot("key-a"); got != 4_000_000 {
+		t.Fatalf("Snapshot with two in-flight requests=%d, want 4_000_000", got)
+	}
+
+	tracker.Set("key-a", "req-1", 1_500_000)
+	if got := tracker.Snapshot("key-a"); got != 4_500_000 {
+		t.Fatalf("Snapshot after req-1 update=%d, want 4_500_000", got)
+	}
+
+	tracker.Clear("key-a", "req-1")
+	if got := tracker.Snapshot("key-a"); got != 3_000_000 {
+		t.Fatalf("Snapshot after clearing req-1=%d, want req-2's 3_000_000 to survive", got)
+	}
+
+	tracker.Clear("key-a", "req-2")
+	if got := tracker.Snapshot("key-a"); got != 0 {
+		t.Fatalf("Snapshot after clearing both requests=%d, want 0", got)
+	}
+}
+
+func TestInFlightTracker_NilTrackerIsSafe(t *testing.T) {
+	var tracker *InFlightTracker
+	tracker.Set("key", "req-1", 100)
+	tracker.Clear("key", "req-1")
+	if got := tracker.Snapshot("key"); got != 0 {
+		t.Fatalf("Snapshot on nil tracker=%d, want 0", got)
+	}
+}
@@ -0,0 +1,226 @@
+// Package invoice turns model prices and recorded usage into period
+// invoices: a per-owner statement broken down by model, priced at the rate
+// that was actually in effect when the usage happened rather than the rate
+// in effect when the invoice was generated.
+package invoice
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/billing"
+)
+
+// Status is an Invoice's lifecycle stage.
+type Status string
+
+const (
+	// StatusDraft invoices may still be regenerated or deleted; their Prices
+	// snapshot is informational only.
+	StatusDraft Status = "draft"
+	// StatusFinalized invoices are immutable: Finalize locks in Prices, so a
+	// later UpsertModelPrice call can never retroactively change what an
+	// already-issued invoice says it charged.
+	StatusFinalized Status = "finalized"
+)
+
+// LineItem is one model's token subtotals within an Invoice's period, each
+// priced against the PriceSnapshot entry for that model and day.
+type LineItem struct {
+	Model              string `json:"model"`
+	PromptTokens       int64  `json:"prompt_tokens"`
+	CompletionTokens   int64  `json:"completion_tokens"`
+	CachedTokens       int64  `json:"cached_tokens"`
+	PromptMicroUSD     int64  `json:"prompt_micro_usd"`
+	CompletionMicroUSD int64  `json:"completion_micro_usd"`
+	CachedMicroUSD     int64  `json:"cached_micro_usd"`
+	SubtotalMicroUSD   int64  `json:"subtotal_micro_usd"`
+}
+
+// PriceSnapshot records the rate actually applied to one model's usage on
+// one day while building an Invoice, so a finalized Invoice can carry an
+// immutable record of what it charged independent of the live price table.
+type PriceSnapshot struct {
+	Model string                     `json:"model"`
+	Day   string                     `json:"day"`
+	Price billing.PriceMicroUSDPer1M `json:"price"`
+}
+
+// Discount is a flat or percentage adjustment applied to an Invoice's
+// subtotal. AmountMicroUSD and Percent are mutually exclusive; if both are
+// set, AmountMicroUSD wins.
+type Discount struct {
+	Label          string  `json:"label"`
+	AmountMicroUSD int64   `json:"amount_micro_usd,omitempty"`
+	Percent        float64 `json:"percent,omitempty"`
+}
+
+func (d Discount) amountMicroUSD(subtotalMicroUSD int64) int64 {
+	if d.AmountMicroUSD != 0 {
+		return d.AmountMicroUSD
+	}
+	if d.Percent != 0 {
+		return int64(float64(subtotalMicroUSD) * d.Percent / 100)
+	}
+	return 0
+}
+
+// Invoice is one owner's (an API key, or a labelled tenant) billed usage for
+// [FromDay, ToDay], broken down into per-model LineItems priced via the
+// Prices snapshot taken at generation time.
+type Invoice struct {
+	ID               string          `json:"id"`
+	Owner            string          `json:"owner"`
+	Tenant           string          `json:"tenant,omitempty"`
+	FromDay          string          `json:"from_day"`
+	ToDay            string          `json:"to_day"`
+	Status           Status          `json:"status"`
+	LineItems        []LineItem      `json:"line_items"`
+	Prices           []PriceSnapshot `json:"prices"`
+	Discounts        []Discount      `json:"discounts,omitempty"`
+	SubtotalMicroUSD int64           `json:"subtotal_micro_usd"`
+	DiscountMicroUSD int64           `json:"discount_micro_usd"`
+	TotalMicroUSD    int64           `json:"total_micro_usd"`
+	CreatedAtUnix    int64           `json:"created_at_unix"`
+	FinalizedAtUnix  int64           `json:"finalized_at_unix,omitempty"`
+}
+
+// GenerateOptions configures Generate.
+type GenerateOptions struct {
+	// Owner is the API key the invoice is generated for.
+	Owner string
+	// Tenant optionally labels the invoice for a human-facing owner name
+	// (e.g. a company account an API key belongs to), independent of Owner.
+	Tenant string
+	// FromDay and ToDay are inclusive "YYYY-MM-DD" day keys, the same range
+	// shape GetRangeUsageReport accepts.
+	FromDay string
+	ToDay   string
+	// Discounts, if any, are applied to the generated invoice's subtotal.
+	Discounts []Discount
+	// Now stamps CreatedAtUnix; callers pass it explicitly since this
+	// package must stay deterministic for tests. Zero means time.Now().
+	Now time.Time
+}
+
+// Generate builds a draft Invoice for opts.Owner's usage over
+// [opts.FromDay, opts.ToDay], grouped by model and priced at the rate in
+// effect on the day each usage row was recorded (via
+// billing.Store.ResolvePriceMicroAt), not store's current rate - the same
+// re-pricing billing.Reconcile uses for retroactive price corrections.
+func Generate(ctx context.Context, store billing.Store, opts GenerateOptions) (Invoice, error) {
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	inv := Invoice{
+		Owner:         opts.Owner,
+		Tenant:        opts.Tenant,
+		FromDay:       opts.FromDay,
+		ToDay:         opts.ToDay,
+		Status:        StatusDraft,
+		Discounts:     opts.Discounts,
+		CreatedAtUnix: now.Unix(),
+	}
+	if store == nil {
+		return inv, fmt.Errorf("invoice: store is required")
+	}
+	if opts.Owner == "" {
+		return inv, fmt.Errorf("invoice: owner is required")
+	}
+
+	rows, err := store.ListUsageRows(ctx, opts.Owner, opts.FromDay, opts.ToDay)
+	if err != nil {
+		return inv, err
+	}
+
+	items := make(map[string]*LineItem)
+	var order []string
+	priceCache := make(map[string]billing.PriceMicroUSDPer1M)
+	var prices []PriceSnapshot
+
+	for _, row := range rows {
+		atUnix, err := billing.DayEndUnix(row.Day)
+		if err != nil {
+			return inv, err
+		}
+
+		cacheKey := row.Model + "\x00" + row.Day
+		price, ok := priceCache[cacheKey]
+		if !ok {
+			price, _, _, err = store.ResolvePriceMicroAt(ctx, row.Model, atUnix)
+			if err != nil {
+				return inv, err
+			}
+			priceCache[cacheKey] = price
+			prices = append(prices, PriceSnapshot{Model: row.Model, Day: row.Day, Price: price})
+		}
+
+		item, ok := items[row.Model]
+		if !ok {
+			item = &LineItem{Model: row.Model}
+			items[row.Model] = item
+			order = append(order, row.Model)
+		}
+
+		promptTokens := row.InputTokens - row.CachedTokens
+		if promptTokens < 0 {
+			promptTokens = 0
+		}
+		completionTokens := row.OutputTokens + row.ReasoningTokens
+
+		item.PromptTokens += promptTokens
+		item.CompletionTokens += completionTokens
+		item.CachedTokens += row.CachedTokens
+		item.PromptMicroUSD += billing.UsageCostMicroUSD(price, promptTokens, 0, 0, 0)
+		item.CompletionMicroUSD += billing.UsageCostMicroUSD(price, 0, row.OutputTokens, row.ReasoningTokens, 0)
+		item.CachedMicroUSD += billing.UsageCostMicroUSD(price, 0, 0, 0, row.CachedTokens)
+	}
+
+	sort.Strings(order)
+	lineItems := make([]LineItem, 0, len(order))
+	var subtotal int64
+	for _, model := range order {
+		item := items[model]
+		item.SubtotalMicroUSD = item.PromptMicroUSD + item.CompletionMicroUSD + item.CachedMicroUSD
+		subtotal += item.SubtotalMicroUSD
+		lineItems = append(lineItems, *item)
+	}
+	sort.Slice(prices, func(i, j int) bool {
+		if prices[i].Model != prices[j].Model {
+			return prices[i].Model < prices[j].Model
+		}
+		return prices[i].Day < prices[j].Day
+	})
+
+	inv.LineItems = lineItems
+	inv.Prices = prices
+	inv.SubtotalMicroUSD = subtotal
+
+	var discountTotal int64
+	for _, d := range opts.Discounts {
+		discountTotal += d.amountMicroUSD(subtotal)
+	}
+	if discountTotal > subtotal {
+		discountTotal = subtotal
+	}
+	inv.DiscountMicroUSD = discountTotal
+	inv.TotalMicroUSD = subtotal - discountTotal
+
+	return inv, nil
+}
+
+// Finalize marks inv finalized: its Prices snapshot is already immutable by
+// construction (Generate captures it once, at generation time), so
+// Finalize's only job is to lock the Status and stamp FinalizedAtUnix
+// against further regeneration.
+func Finalize(inv Invoice, now time.Time) Invoice {
+	if now.IsZero() {
+		now = time.Now()
+	}
+	inv.Status = StatusFinalized
+	inv.FinalizedAtUnix = now.Unix()
+	return inv
+}
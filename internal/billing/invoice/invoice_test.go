@@ -0,0 +1,130 @@
+package invoice
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/billing"
+)
+
+func TestGenerate_PricesEachDayAtTheRateInEffectThen(t *testing.T) {
+	store := billing.NewMemoryStore()
+	ctx := context.Background()
+
+	initialFrom := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC).Unix()
+	if err := store.UpsertModelPriceWithAudit(ctx, "claude-3-opus", billing.PriceMicroUSDPer1M{Prompt: 1_000_000, Completion: 2_000_000, Cached: 500_000}, initialFrom, "test", "initial price"); err != nil {
+		t.Fatalf("UpsertModelPriceWithAudit: %v", err)
+	}
+	if err := store.AddUsage(ctx, "key-1", "claude-3-opus", "2026-02-09", billing.DailyUsageRow{
+		Requests: 1, InputTokens: 1000, OutputTokens: 500, CachedTokens: 200, TotalTokens: 1700,
+	}); err != nil {
+		t.Fatalf("AddUsage day 1: %v", err)
+	}
+
+	// Price change effective during day 2 (after day 1 ends); it must not
+	// affect day 1's line item.
+	changeFrom := time.Date(2026, 2, 10, 0, 0, 0, 0, time.UTC).Unix()
+	if err := store.UpsertModelPriceWithAudit(ctx, "claude-3-opus", billing.PriceMicroUSDPer1M{Prompt: 2_000_000, Completion: 4_000_000, Cached: 1_000_000}, changeFrom, "test", "price change"); err != nil {
+		t.Fatalf("UpsertModelPriceWithAudit (change): %v", err)
+	}
+	if err := store.AddUsage(ctx, "key-1", "claude-3-opus", "2026-02-10", billing.DailyUsageRow{
+		Requests: 1, InputTokens: 1000, OutputTokens: 500, CachedTokens: 0, TotalTokens: 1500,
+	}); err != nil {
+		t.Fatalf("AddUsage day 2: %v", err)
+	}
+
+	inv, err := Generate(ctx, store, GenerateOptions{
+		Owner:   "key-1",
+		FromDay: "2026-02-09",
+		ToDay:   "2026-02-10",
+		Now:     time.Unix(1_700_000_000, 0),
+	})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+
+	if inv.Status != StatusDraft {
+		t.Fatalf("Status=%q, want draft", inv.Status)
+	}
+	if len(inv.LineItems) != 1 {
+		t.Fatalf("LineItems=%+v, want exactly one (both days are the same model)", inv.LineItems)
+	}
+	item := inv.LineItems[0]
+	if item.PromptTokens != 1800 || item.CompletionTokens != 1000 || item.CachedTokens != 200 {
+		t.Fatalf("item=%+v, want PromptTokens=1800 CompletionTokens=1000 CachedTokens=200", item)
+	}
+	// Day 1: 800 prompt @1e6 + 500 completion @2e6 + 200 cached @0.5e6 = 800+1000+100 = 1900
+	// Day 2: 1000 prompt @2e6 + 500 completion @4e6 = 2000+2000 = 4000
+	wantSubtotal := int64(1900 + 4000)
+	if item.SubtotalMicroUSD != wantSubtotal {
+		t.Fatalf("SubtotalMicroUSD=%d, want %d", item.SubtotalMicroUSD, wantSubtotal)
+	}
+	if inv.SubtotalMicroUSD != wantSubtotal || inv.TotalMicroUSD != wantSubtotal {
+		t.Fatalf("inv subtotal/total=%d/%d, want %d/%d", inv.SubtotalMicroUSD, inv.TotalMicroUSD, wantSubtotal, wantSubtotal)
+	}
+	if len(inv.Prices) != 2 {
+		t.Fatalf("Prices=%+v, want one snapshot per day", inv.Prices)
+	}
+}
+
+func TestGenerate_AppliesFlatAndPercentDiscounts(t *testing.T) {
+	store := billing.NewMemoryStore()
+	ctx := context.Background()
+
+	priceFrom := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC).Unix()
+	if err := store.UpsertModelPriceWithAudit(ctx, "gpt-4o", billing.PriceMicroUSDPer1M{Prompt: 1_000_000, Completion: 1_000_000}, priceFrom, "test", "initial price"); err != nil {
+		t.Fatalf("UpsertModelPriceWithAudit: %v", err)
+	}
+	if err := store.AddUsage(ctx, "key-1", "gpt-4o", "2026-02-09", billing.DailyUsageRow{
+		Requests: 1, InputTokens: 1_000_000, OutputTokens: 0, TotalTokens: 1_000_000,
+	}); err != nil {
+		t.Fatalf("AddUsage: %v", err)
+	}
+
+	inv, err := Generate(ctx, store, GenerateOptions{
+		Owner:   "key-1",
+		FromDay: "2026-02-09",
+		ToDay:   "2026-02-09",
+		Discounts: []Discount{
+			{Label: "loyalty", Percent: 10},
+			{Label: "credit", AmountMicroUSD: 50_000},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+
+	if inv.SubtotalMicroUSD != 1_000_000 {
+		t.Fatalf("SubtotalMicroUSD=%d, want 1_000_000", inv.SubtotalMicroUSD)
+	}
+	wantDiscount := int64(100_000 + 50_000) // 10% of 1_000_000 + flat 50_000
+	if inv.DiscountMicroUSD != wantDiscount {
+		t.Fatalf("DiscountMicroUSD=%d, want %d", inv.DiscountMicroUSD, wantDiscount)
+	}
+	if inv.TotalMicroUSD != inv.SubtotalMicroUSD-wantDiscount {
+		t.Fatalf("TotalMicroUSD=%d, want %d", inv.TotalMicroUSD, inv.SubtotalMicroUSD-wantDiscount)
+	}
+}
+
+func TestGenerate_RequiresStoreAndOwner(t *testing.T) {
+	if _, err := Generate(context.Background(), nil, GenerateOptions{Owner: "key-1"}); err == nil {
+		t.Fatal("expected error for nil store")
+	}
+	if _, err := Generate(context.Background(), billing.NewMemoryStore(), GenerateOptions{}); err == nil {
+		t.Fatal("expected error for empty owner")
+	}
+}
+
+func TestFinalize_LocksStatusAndStampsTimestamp(t *testing.T) {
+	inv := Invoice{ID: "inv-1", Status: StatusDraft}
+	now := time.Unix(1_700_000_000, 0)
+
+	finalized := Finalize(inv, now)
+	if finalized.Status != StatusFinalized {
+		t.Fatalf("Status=%q, want finalized", finalized.Status)
+	}
+	if finalized.FinalizedAtUnix != now.Unix() {
+		t.Fatalf("FinalizedAtUnix=%d, want %d", finalized.FinalizedAtUnix, now.Unix())
+	}
+}
@@ -0,0 +1,80 @@
+package invoice
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// Issuer carries the issuer/tenant metadata printed on a rendered PDF,
+// sourced from the operator's main config rather than hardcoded here.
+type Issuer struct {
+	Name    string
+	Address string
+}
+
+// RenderPDF renders inv as a single-page PDF invoice: issuer/tenant header,
+// a per-model line item table, and the subtotal/discount/total summary.
+func RenderPDF(inv Invoice, issuer Issuer) ([]byte, error) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddPage()
+
+	pdf.SetFont("Arial", "B", 16)
+	pdf.CellFormat(0, 10, "Invoice", "", 1, "L", false, 0, "")
+
+	pdf.SetFont("Arial", "", 11)
+	if issuer.Name != "" {
+		pdf.CellFormat(0, 6, issuer.Name, "", 1, "L", false, 0, "")
+	}
+	if issuer.Address != "" {
+		pdf.CellFormat(0, 6, issuer.Address, "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	owner := inv.Owner
+	if inv.Tenant != "" {
+		owner = fmt.Sprintf("%s (%s)", inv.Tenant, inv.Owner)
+	}
+	pdf.CellFormat(0, 6, fmt.Sprintf("Invoice ID: %s", inv.ID), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 6, fmt.Sprintf("Billed to: %s", owner), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 6, fmt.Sprintf("Period: %s to %s", inv.FromDay, inv.ToDay), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 6, fmt.Sprintf("Status: %s", inv.Status), "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	pdf.SetFont("Arial", "B", 10)
+	widths := []float64{60, 30, 30, 30, 35}
+	headers := []string{"Model", "Prompt tok.", "Completion tok.", "Cached tok.", "Subtotal (USD)"}
+	for i, header := range headers {
+		pdf.CellFormat(widths[i], 7, header, "1", 0, "L", false, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Arial", "", 10)
+	for _, item := range inv.LineItems {
+		pdf.CellFormat(widths[0], 7, item.Model, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[1], 7, fmt.Sprintf("%d", item.PromptTokens), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[2], 7, fmt.Sprintf("%d", item.CompletionTokens), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[3], 7, fmt.Sprintf("%d", item.CachedTokens), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[4], 7, formatUSD(item.SubtotalMicroUSD), "1", 0, "R", false, 0, "")
+		pdf.Ln(-1)
+	}
+	pdf.Ln(4)
+
+	for _, d := range inv.Discounts {
+		pdf.CellFormat(0, 6, fmt.Sprintf("Discount - %s: -%s", d.Label, formatUSD(d.amountMicroUSD(inv.SubtotalMicroUSD))), "", 1, "R", false, 0, "")
+	}
+	pdf.SetFont("Arial", "B", 11)
+	pdf.CellFormat(0, 6, fmt.Sprintf("Subtotal: %s", formatUSD(inv.SubtotalMicroUSD)), "", 1, "R", false, 0, "")
+	pdf.CellFormat(0, 6, fmt.Sprintf("Total: %s", formatUSD(inv.TotalMicroUSD)), "", 1, "R", false, 0, "")
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("invoice pdf: render: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func formatUSD(microUSD int64) string {
+	return fmt.Sprintf("$%.2f", float64(microUSD)/1_000_000)
+}
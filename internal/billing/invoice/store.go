@@ -0,0 +1,219 @@
+package invoice
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	_ "modernc.org/sqlite"
+)
+
+// Store persists Invoices. SQLiteStore is the first implementation; a
+// Postgres store can satisfy the same interface later without the
+// management API's call sites changing.
+type Store interface {
+	Create(ctx context.Context, inv Invoice) (Invoice, error)
+	Get(ctx context.Context, id string) (Invoice, bool, error)
+	List(ctx context.Context, owner string) ([]Invoice, error)
+	Update(ctx context.Context, inv Invoice) error
+	Delete(ctx context.Context, id string) (bool, error)
+}
+
+// SQLiteStore is a Store backed by its own SQLite database, independent of
+// billing.SQLiteStore's, since invoices are generated from - but outlive -
+// any one usage/price snapshot.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore opens (creating if necessary) the SQLite database at path
+// and ensures its invoices table exists.
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	trimmed := strings.TrimSpace(path)
+	if trimmed == "" {
+		return nil, fmt.Errorf("invoice sqlite: path is required")
+	}
+	abs, err := filepath.Abs(trimmed)
+	if err != nil {
+		return nil, fmt.Errorf("invoice sqlite: resolve path: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(abs), 0o700); err != nil {
+		return nil, fmt.Errorf("invoice sqlite: create directory: %w", err)
+	}
+
+	dsn := fmt.Sprintf("file:%s?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(ON)", abs)
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invoice sqlite: open database: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("invoice sqlite: ping database: %w", err)
+	}
+
+	store := &SQLiteStore{db: db}
+	if err := store.ensureSchema(ctx); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return store, nil
+}
+
+func (s *SQLiteStore) Close() error {
+	if s == nil || s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+func (s *SQLiteStore) ensureSchema(ctx context.Context) error {
+	stmts := []string{
+		`
+		CREATE TABLE IF NOT EXISTS invoices (
+			id TEXT NOT NULL PRIMARY KEY,
+			owner TEXT NOT NULL,
+			status TEXT NOT NULL,
+			document TEXT NOT NULL,
+			created_at INTEGER NOT NULL,
+			updated_at INTEGER NOT NULL
+		)
+		`,
+		`CREATE INDEX IF NOT EXISTS idx_invoices_owner ON invoices (owner)`,
+	}
+	for _, stmt := range stmts {
+		if _, err := s.db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("invoice sqlite: ensure schema: %w", err)
+		}
+	}
+	return nil
+}
+
+// Create assigns inv a new ID and persists it.
+func (s *SQLiteStore) Create(ctx context.Context, inv Invoice) (Invoice, error) {
+	if s == nil || s.db == nil {
+		return inv, fmt.Errorf("invoice sqlite: not initialized")
+	}
+	inv.ID = uuid.NewString()
+	if err := s.upsert(ctx, inv); err != nil {
+		return inv, err
+	}
+	return inv, nil
+}
+
+// Update overwrites the stored Invoice with the same ID as inv.
+func (s *SQLiteStore) Update(ctx context.Context, inv Invoice) error {
+	if s == nil || s.db == nil {
+		return fmt.Errorf("invoice sqlite: not initialized")
+	}
+	return s.upsert(ctx, inv)
+}
+
+func (s *SQLiteStore) upsert(ctx context.Context, inv Invoice) error {
+	document, err := json.Marshal(inv)
+	if err != nil {
+		return fmt.Errorf("invoice sqlite: marshal invoice: %w", err)
+	}
+	now := time.Now().UTC().Unix()
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO invoices (id, owner, status, document, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			owner = excluded.owner,
+			status = excluded.status,
+			document = excluded.document,
+			updated_at = excluded.updated_at
+	`, inv.ID, inv.Owner, string(inv.Status), string(document), now, now)
+	if err != nil {
+		return fmt.Errorf("invoice sqlite: persist invoice: %w", err)
+	}
+	return nil
+}
+
+// Get returns the Invoice with id, or ok=false if none exists.
+func (s *SQLiteStore) Get(ctx context.Context, id string) (Invoice, bool, error) {
+	if s == nil || s.db == nil {
+		return Invoice{}, false, fmt.Errorf("invoice sqlite: not initialized")
+	}
+	var document string
+	err := s.db.QueryRowContext(ctx, `SELECT document FROM invoices WHERE id = ?`, id).Scan(&document)
+	if err == sql.ErrNoRows {
+		return Invoice{}, false, nil
+	}
+	if err != nil {
+		return Invoice{}, false, fmt.Errorf("invoice sqlite: get invoice: %w", err)
+	}
+	var inv Invoice
+	if err := json.Unmarshal([]byte(document), &inv); err != nil {
+		return Invoice{}, false, fmt.Errorf("invoice sqlite: unmarshal invoice: %w", err)
+	}
+	return inv, true, nil
+}
+
+// List returns every stored Invoice, optionally narrowed to owner, newest
+// first. owner == "" returns every owner's invoices.
+func (s *SQLiteStore) List(ctx context.Context, owner string) ([]Invoice, error) {
+	if s == nil || s.db == nil {
+		return nil, fmt.Errorf("invoice sqlite: not initialized")
+	}
+	var (
+		rows *sql.Rows
+		err  error
+	)
+	if owner == "" {
+		rows, err = s.db.QueryContext(ctx, `SELECT document FROM invoices ORDER BY created_at DESC`)
+	} else {
+		rows, err = s.db.QueryContext(ctx, `SELECT document FROM invoices WHERE owner = ? ORDER BY created_at DESC`, owner)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invoice sqlite: list invoices: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]Invoice, 0)
+	for rows.Next() {
+		var document string
+		if err := rows.Scan(&document); err != nil {
+			return nil, fmt.Errorf("invoice sqlite: scan invoice: %w", err)
+		}
+		var inv Invoice
+		if err := json.Unmarshal([]byte(document), &inv); err != nil {
+			return nil, fmt.Errorf("invoice sqlite: unmarshal invoice: %w", err)
+		}
+		out = append(out, inv)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("invoice sqlite: list invoices: %w", err)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].CreatedAtUnix > out[j].CreatedAtUnix })
+	return out, nil
+}
+
+// Delete removes the Invoice with id, reporting whether it existed.
+func (s *SQLiteStore) Delete(ctx context.Context, id string) (bool, error) {
+	if s == nil || s.db == nil {
+		return false, fmt.Errorf("invoice sqlite: not initialized")
+	}
+	result, err := s.db.ExecContext(ctx, `DELETE FROM invoices WHERE id = ?`, id)
+	if err != nil {
+		return false, fmt.Errorf("invoice sqlite: delete invoice: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("invoice sqlite: delete invoice: %w", err)
+	}
+	return n > 0, nil
+}
+
+var _ Store = (*SQLiteStore)(nil)
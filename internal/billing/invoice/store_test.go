@@ -0,0 +1,64 @@
+package invoice
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestSQLiteStore_CreateGetListDelete(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewSQLiteStore(filepath.Join(dir, "invoices.sqlite"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	created, err := store.Create(ctx, Invoice{Owner: "key-1", Status: StatusDraft, TotalMicroUSD: 100})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if created.ID == "" {
+		t.Fatal("Create did not assign an ID")
+	}
+
+	got, ok, err := store.Get(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !ok || got.Owner != "key-1" || got.TotalMicroUSD != 100 {
+		t.Fatalf("Get=%+v, ok=%v, want owner=key-1 total=100", got, ok)
+	}
+
+	got.Status = StatusFinalized
+	if err := store.Update(ctx, got); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	got, _, err = store.Get(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("Get after update: %v", err)
+	}
+	if got.Status != StatusFinalized {
+		t.Fatalf("Status=%q after Update, want finalized", got.Status)
+	}
+
+	list, err := store.List(ctx, "key-1")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("List=%+v, want one invoice", list)
+	}
+
+	deleted, err := store.Delete(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if !deleted {
+		t.Fatal("Delete reported not found")
+	}
+	if _, ok, err := store.Get(ctx, created.ID); err != nil || ok {
+		t.Fatalf("Get after delete: ok=%v, err=%v, want ok=false", ok, err)
+	}
+}
@@ -0,0 +1,754 @@
+package billing
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/policy"
+)
+
+// reservation is a held-but-not-yet-committed budget estimate.
+type reservation struct {
+	apiKey            string
+	day               string
+	estimatedMicroUSD int64
+}
+
+// MemoryStore is a process-local Store implementation backed by plain maps.
+// It is useful for tests and single-process deployments that do not need
+// usage counters to survive a restart or to be shared across replicas.
+type MemoryStore struct {
+	mu           sync.RWMutex
+	priceHistory map[string][]PriceHistoryEntry // key: normalised model, ordered by EffectiveAt ascending
+	catalog      map[string]PriceMicroUSDPer1M  // key: normalised model, from the last UpsertCatalogPrices
+	catalogETag  string
+	usage        map[string]DailyUsageRow // key: apiKey + "\x00" + model + "\x00" + day
+	reservations map[string]reservation   // key: reservation id
+	priceAudit   []PriceAuditEntry
+	budgets      map[string]Budget           // key: budget id
+	budgetSpend  map[string]budgetSpendEntry // key: budget id + "\x00" + period key
+}
+
+// budgetSpendEntry is one (budget, period) spend counter plus when it was
+// last charged, so PruneBudgetSpend can garbage-collect rows from periods
+// that have already rolled over.
+type budgetSpendEntry struct {
+	microUSD  int64
+	updatedAt int64
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		priceHistory: make(map[string][]PriceHistoryEntry),
+		catalog:      make(map[string]PriceMicroUSDPer1M),
+		usage:        make(map[string]DailyUsageRow),
+		reservations: make(map[string]reservation),
+		budgets:      make(map[string]Budget),
+		budgetSpend:  make(map[string]budgetSpendEntry),
+	}
+}
+
+func (s *MemoryStore) Close() error { return nil }
+
+func usageKey(apiKey, model, day string) string {
+	return apiKey + "\x00" + model + "\x00" + day
+}
+
+func (s *MemoryStore) UpsertModelPrice(ctx context.Context, model string, price PriceMicroUSDPer1M) error {
+	key := policy.NormaliseModelKey(model)
+	if key == "" {
+		return errRequired("model")
+	}
+	if price.Prompt < 0 || price.Completion < 0 || price.Cached < 0 {
+		return errInvalid("price")
+	}
+	now := nowUnixUTC()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.priceHistory[key] = append(s.priceHistory[key], PriceHistoryEntry{
+		Model:              key,
+		PromptUSDPer1M:     microUSDPer1MToUSDPer1M(price.Prompt),
+		CompletionUSDPer1M: microUSDPer1MToUSDPer1M(price.Completion),
+		CachedUSDPer1M:     microUSDPer1MToUSDPer1M(price.Cached),
+		EffectiveAt:        now,
+		CreatedAt:          now,
+	})
+	return nil
+}
+
+func (s *MemoryStore) DeleteModelPrice(ctx context.Context, model string) (bool, error) {
+	key := policy.NormaliseModelKey(model)
+	if key == "" {
+		return false, errRequired("model")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.priceHistory[key]; !ok {
+		return false, nil
+	}
+	delete(s.priceHistory, key)
+	return true, nil
+}
+
+// UpsertModelPriceWithAudit is UpsertModelPrice plus a PriceAuditEntry
+// recording the price that was in effect immediately before this call (if
+// any) and the actor/reason behind it. See Store.UpsertModelPriceWithAudit.
+func (s *MemoryStore) UpsertModelPriceWithAudit(ctx context.Context, model string, price PriceMicroUSDPer1M, effectiveFrom int64, actor, reason string) error {
+	key := policy.NormaliseModelKey(model)
+	if key == "" {
+		return errRequired("model")
+	}
+	if price.Prompt < 0 || price.Completion < 0 || price.Cached < 0 {
+		return errInvalid("price")
+	}
+	now := nowUnixUTC()
+	effectiveAt := effectiveFrom
+	if effectiveAt == 0 {
+		effectiveAt = now
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old, hadOld := s.latestPriceLocked(key, now)
+	s.priceHistory[key] = append(s.priceHistory[key], PriceHistoryEntry{
+		Model:              key,
+		PromptUSDPer1M:     microUSDPer1MToUSDPer1M(price.Prompt),
+		CompletionUSDPer1M: microUSDPer1MToUSDPer1M(price.Completion),
+		CachedUSDPer1M:     microUSDPer1MToUSDPer1M(price.Cached),
+		EffectiveAt:        effectiveAt,
+		CreatedAt:          now,
+	})
+
+	entry := PriceAuditEntry{
+		ID:            uuid.NewString(),
+		Model:         key,
+		Action:        "upsert",
+		Actor:         actor,
+		Reason:        reason,
+		NewPrice:      &price,
+		EffectiveFrom: effectiveAt,
+		CreatedAt:     now,
+	}
+	if hadOld {
+		oldPrice := priceMicroFromHistoryEntry(old)
+		entry.OldPrice = &oldPrice
+	}
+	s.priceAudit = append(s.priceAudit, entry)
+	return nil
+}
+
+// DeleteModelPriceWithAudit is DeleteModelPrice plus a PriceAuditEntry
+// recording the price that was removed and who removed it. See
+// Store.DeleteModelPriceWithAudit.
+func (s *MemoryStore) DeleteModelPriceWithAudit(ctx context.Context, model, actor, reason string) (bool, error) {
+	key := policy.NormaliseModelKey(model)
+	if key == "" {
+		return false, errRequired("model")
+	}
+	now := nowUnixUTC()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old, hadOld := s.latestPriceLocked(key, now)
+	if _, ok := s.priceHistory[key]; !ok {
+		return false, nil
+	}
+	delete(s.priceHistory, key)
+
+	entry := PriceAuditEntry{
+		ID:            uuid.NewString(),
+		Model:         key,
+		Action:        "delete",
+		Actor:         actor,
+		Reason:        reason,
+		EffectiveFrom: now,
+		CreatedAt:     now,
+	}
+	if hadOld {
+		oldPrice := priceMicroFromHistoryEntry(old)
+		entry.OldPrice = &oldPrice
+	}
+	s.priceAudit = append(s.priceAudit, entry)
+	return true, nil
+}
+
+// latestPriceLocked returns the entry with the greatest EffectiveAt <= atUnix
+// for key, if any. Callers must hold s.mu for reading.
+func (s *MemoryStore) latestPriceLocked(key string, atUnix int64) (PriceHistoryEntry, bool) {
+	var best PriceHistoryEntry
+	found := false
+	for _, entry := range s.priceHistory[key] {
+		if entry.EffectiveAt > atUnix {
+			continue
+		}
+		// >= (not >) so that, among entries sharing the same EffectiveAt
+		// second, the one appended later (i.e. the more recent upsert) wins.
+		if !found || entry.EffectiveAt >= best.EffectiveAt {
+			best = entry
+			found = true
+		}
+	}
+	return best, found
+}
+
+func (s *MemoryStore) ResolvePriceMicro(ctx context.Context, model string) (PriceMicroUSDPer1M, string, int64, error) {
+	return s.ResolvePriceMicroAt(ctx, model, nowUnixUTC())
+}
+
+// ResolvePriceMicroAt resolves model's price as it stood at atUnix. See
+// Store.ResolvePriceMicroAt.
+func (s *MemoryStore) ResolvePriceMicroAt(ctx context.Context, model string, atUnix int64) (PriceMicroUSDPer1M, string, int64, error) {
+	modelKey := policy.NormaliseModelKey(model)
+	if modelKey == "" {
+		return PriceMicroUSDPer1M{}, "", 0, errRequired("model")
+	}
+	baseKey := policy.StripThinkingVariant(modelKey)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if saved, ok := s.latestPriceLocked(modelKey, atUnix); ok {
+		return priceMicroFromHistoryEntry(saved), "saved", saved.EffectiveAt, nil
+	}
+	if baseKey != "" && baseKey != modelKey {
+		if saved, ok := s.latestPriceLocked(baseKey, atUnix); ok {
+			return priceMicroFromHistoryEntry(saved), "saved", saved.EffectiveAt, nil
+		}
+	}
+	if v, ok := s.catalog[modelKey]; ok {
+		return v, "catalog", 0, nil
+	}
+	if baseKey != "" && baseKey != modelKey {
+		if v, ok := s.catalog[baseKey]; ok {
+			return v, "catalog", 0, nil
+		}
+	}
+	if v, ok := DefaultPrices[modelKey]; ok {
+		return v, "default", 0, nil
+	}
+	if baseKey != "" && baseKey != modelKey {
+		if v, ok := DefaultPrices[baseKey]; ok {
+			return v, "default", 0, nil
+		}
+	}
+	return PriceMicroUSDPer1M{}, "missing", 0, nil
+}
+
+// UpsertCatalogPrices replaces the catalog tier. See Store.UpsertCatalogPrices.
+func (s *MemoryStore) UpsertCatalogPrices(ctx context.Context, prices map[string]PriceMicroUSDPer1M, etag string) error {
+	staged := make(map[string]PriceMicroUSDPer1M, len(prices))
+	for rawKey, price := range prices {
+		key := policy.NormaliseModelKey(rawKey)
+		if key == "" {
+			continue
+		}
+		staged[key] = price
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key := range staged {
+		if _, pinned := s.priceHistory[key]; pinned {
+			delete(staged, key)
+		}
+	}
+	s.catalog = staged
+	s.catalogETag = etag
+	return nil
+}
+
+// GetCatalogETag returns the last synced catalog etag. See
+// Store.GetCatalogETag.
+func (s *MemoryStore) GetCatalogETag(ctx context.Context) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.catalogETag, nil
+}
+
+// ListPriceHistory returns model's saved prices, oldest to newest. See
+// Store.ListPriceHistory.
+func (s *MemoryStore) ListPriceHistory(ctx context.Context, model string) ([]PriceHistoryEntry, error) {
+	key := policy.NormaliseModelKey(model)
+	if key == "" {
+		return nil, errRequired("model")
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]PriceHistoryEntry, len(s.priceHistory[key]))
+	copy(out, s.priceHistory[key])
+	sort.Slice(out, func(i, j int) bool { return out[i].EffectiveAt < out[j].EffectiveAt })
+	return out, nil
+}
+
+func priceMicroFromHistoryEntry(p PriceHistoryEntry) PriceMicroUSDPer1M {
+	return PriceMicroUSDPer1M{
+		Prompt:     USDPer1MToMicroUSDPer1M(p.PromptUSDPer1M),
+		Completion: USDPer1MToMicroUSDPer1M(p.CompletionUSDPer1M),
+		Cached:     USDPer1MToMicroUSDPer1M(p.CachedUSDPer1M),
+	}
+}
+
+func (s *MemoryStore) ListModelPrices(ctx context.Context) ([]ModelPrice, error) {
+	now := nowUnixUTC()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	merged := make(map[string]ModelPrice, len(DefaultPrices)+len(s.catalog)+len(s.priceHistory))
+	for k, v := range DefaultPrices {
+		merged[k] = ModelPrice{
+			Model:              k,
+			PromptUSDPer1M:     microUSDPer1MToUSDPer1M(v.Prompt),
+			CompletionUSDPer1M: microUSDPer1MToUSDPer1M(v.Completion),
+			CachedUSDPer1M:     microUSDPer1MToUSDPer1M(v.Cached),
+			Source:             "default",
+		}
+	}
+	for k, v := range s.catalog {
+		merged[k] = ModelPrice{
+			Model:              k,
+			PromptUSDPer1M:     microUSDPer1MToUSDPer1M(v.Prompt),
+			CompletionUSDPer1M: microUSDPer1MToUSDPer1M(v.Completion),
+			CachedUSDPer1M:     microUSDPer1MToUSDPer1M(v.Cached),
+			Source:             "catalog",
+		}
+	}
+	for k := range s.priceHistory {
+		latest, ok := s.latestPriceLocked(k, now)
+		if !ok {
+			continue
+		}
+		merged[k] = ModelPrice{
+			Model:              k,
+			PromptUSDPer1M:     latest.PromptUSDPer1M,
+			CompletionUSDPer1M: latest.CompletionUSDPer1M,
+			CachedUSDPer1M:     latest.CachedUSDPer1M,
+			Source:             "saved",
+			UpdatedAt:          latest.EffectiveAt,
+		}
+	}
+	out := make([]ModelPrice, 0, len(merged))
+	for _, v := range merged {
+		out = append(out, v)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Model < out[j].Model })
+	return out, nil
+}
+
+// ListModelPricesAt is ListModelPrices pinned to atUnix: the "saved" tier
+// only considers price history entries with EffectiveAt <= atUnix. See
+// Store.ListModelPricesAt.
+func (s *MemoryStore) ListModelPricesAt(ctx context.Context, atUnix int64) ([]ModelPrice, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	merged := make(map[string]ModelPrice, len(DefaultPrices)+len(s.catalog)+len(s.priceHistory))
+	for k, v := range DefaultPrices {
+		merged[k] = ModelPrice{
+			Model:              k,
+			PromptUSDPer1M:     microUSDPer1MToUSDPer1M(v.Prompt),
+			CompletionUSDPer1M: microUSDPer1MToUSDPer1M(v.Completion),
+			CachedUSDPer1M:     microUSDPer1MToUSDPer1M(v.Cached),
+			Source:             "default",
+		}
+	}
+	for k, v := range s.catalog {
+		merged[k] = ModelPrice{
+			Model:              k,
+			PromptUSDPer1M:     microUSDPer1MToUSDPer1M(v.Prompt),
+			CompletionUSDPer1M: microUSDPer1MToUSDPer1M(v.Completion),
+			CachedUSDPer1M:     microUSDPer1MToUSDPer1M(v.Cached),
+			Source:             "catalog",
+		}
+	}
+	for k := range s.priceHistory {
+		latest, ok := s.latestPriceLocked(k, atUnix)
+		if !ok {
+			continue
+		}
+		merged[k] = ModelPrice{
+			Model:              k,
+			PromptUSDPer1M:     latest.PromptUSDPer1M,
+			CompletionUSDPer1M: latest.CompletionUSDPer1M,
+			CachedUSDPer1M:     latest.CachedUSDPer1M,
+			Source:             "saved",
+			UpdatedAt:          latest.EffectiveAt,
+		}
+	}
+	out := make([]ModelPrice, 0, len(merged))
+	for _, v := range merged {
+		out = append(out, v)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Model < out[j].Model })
+	return out, nil
+}
+
+// ListPriceAudit returns every recorded price audit entry, oldest to
+// newest. See Store.ListPriceAudit.
+func (s *MemoryStore) ListPriceAudit(ctx context.Context) ([]PriceAuditEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]PriceAuditEntry, len(s.priceAudit))
+	copy(out, s.priceAudit)
+	return out, nil
+}
+
+// ImportModelPrices validates and applies a bulk price import under a single
+// lock, so a bad row can never leave the price table half-updated. See
+// Store.ImportModelPrices.
+func (s *MemoryStore) ImportModelPrices(ctx context.Context, entries []PriceImportEntry, actor, reason string, dryRun bool) (PriceImportResult, error) {
+	current, err := s.ListModelPrices(ctx)
+	if err != nil {
+		return PriceImportResult{}, err
+	}
+	rows, result := planPriceImport(modelPricesByModel(current), entries)
+	result.DryRun = dryRun
+	if dryRun || len(result.Errors) > 0 {
+		if !dryRun {
+			return result, fmt.Errorf("billing memory: import aborted: %d row(s) failed validation", len(result.Errors))
+		}
+		return result, nil
+	}
+
+	now := nowUnixUTC()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, row := range rows {
+		old, hadOld := s.latestPriceLocked(row.model, now)
+		effectiveAt := row.effectiveFrom
+		if effectiveAt == 0 {
+			effectiveAt = now
+		}
+		s.priceHistory[row.model] = append(s.priceHistory[row.model], PriceHistoryEntry{
+			Model:              row.model,
+			PromptUSDPer1M:     microUSDPer1MToUSDPer1M(row.price.Prompt),
+			CompletionUSDPer1M: microUSDPer1MToUSDPer1M(row.price.Completion),
+			CachedUSDPer1M:     microUSDPer1MToUSDPer1M(row.price.Cached),
+			EffectiveAt:        effectiveAt,
+			CreatedAt:          now,
+		})
+
+		price := row.price
+		auditEntry := PriceAuditEntry{
+			ID:            uuid.NewString(),
+			Model:         row.model,
+			Action:        "upsert",
+			Actor:         actor,
+			Reason:        reason,
+			NewPrice:      &price,
+			EffectiveFrom: effectiveAt,
+			CreatedAt:     now,
+		}
+		if hadOld {
+			oldPrice := priceMicroFromHistoryEntry(old)
+			auditEntry.OldPrice = &oldPrice
+		}
+		s.priceAudit = append(s.priceAudit, auditEntry)
+	}
+	return result, nil
+}
+
+func (s *MemoryStore) AddUsage(ctx context.Context, apiKey, model, dayKey string, delta DailyUsageRow) error {
+	modelKey := policy.NormaliseModelKey(model)
+	if apiKey == "" || modelKey == "" || dayKey == "" {
+		return errInvalid("inputs")
+	}
+	if delta.Requests < 0 || delta.FailedRequests < 0 {
+		return errInvalid("request deltas")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := usageKey(apiKey, modelKey, dayKey)
+	row := s.usage[key]
+	row.APIKey = apiKey
+	row.Model = modelKey
+	row.Day = dayKey
+	row.Requests += max64(0, delta.Requests)
+	row.FailedRequests += max64(0, delta.FailedRequests)
+	row.InputTokens += max64(0, delta.InputTokens)
+	row.OutputTokens += max64(0, delta.OutputTokens)
+	row.ReasoningTokens += max64(0, delta.ReasoningTokens)
+	row.CachedTokens += max64(0, delta.CachedTokens)
+	row.TotalTokens += max64(0, delta.TotalTokens)
+	row.CostMicroUSD += max64(0, delta.CostMicroUSD)
+	row.UpdatedAt = nowUnixUTC()
+	s.usage[key] = row
+	return nil
+}
+
+// GetDailyCostMicroUSD returns committed usage cost plus any outstanding
+// budget reservations for (apiKey, dayKey), so an in-flight streaming
+// request that has reserved budget but not yet committed its actual usage
+// still counts against the daily cap.
+func (s *MemoryStore) GetDailyCostMicroUSD(ctx context.Context, apiKey, dayKey string) (int64, error) {
+	if apiKey == "" || dayKey == "" {
+		return 0, errInvalid("inputs")
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var total int64
+	for _, row := range s.usage {
+		if row.APIKey == apiKey && row.Day == dayKey {
+			total += row.CostMicroUSD
+		}
+	}
+	for _, r := range s.reservations {
+		if r.apiKey == apiKey && r.day == dayKey {
+			total += r.estimatedMicroUSD
+		}
+	}
+	return total, nil
+}
+
+// ReserveBudget records a pending spend estimate for (apiKey, dayKey). See
+// Store.ReserveBudget.
+func (s *MemoryStore) ReserveBudget(ctx context.Context, apiKey, dayKey string, estimatedMicroUSD int64) (reservationID string, err error) {
+	if apiKey == "" || dayKey == "" {
+		return "", errInvalid("inputs")
+	}
+	if estimatedMicroUSD < 0 {
+		estimatedMicroUSD = 0
+	}
+	reservationID = uuid.NewString()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reservations[reservationID] = reservation{apiKey: apiKey, day: dayKey, estimatedMicroUSD: estimatedMicroUSD}
+	return reservationID, nil
+}
+
+// CommitReservation clears the reservation and records the actual usage. See
+// Store.CommitReservation.
+func (s *MemoryStore) CommitReservation(ctx context.Context, reservationID, apiKey, model, dayKey string, actual DailyUsageRow) error {
+	if reservationID == "" {
+		return errRequired("reservation id")
+	}
+	if err := s.AddUsage(ctx, apiKey, model, dayKey, actual); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	delete(s.reservations, reservationID)
+	s.mu.Unlock()
+	return nil
+}
+
+// ReleaseReservation drops a reservation without recording any usage. See
+// Store.ReleaseReservation.
+func (s *MemoryStore) ReleaseReservation(ctx context.Context, reservationID string) error {
+	if reservationID == "" {
+		return errRequired("reservation id")
+	}
+	s.mu.Lock()
+	delete(s.reservations, reservationID)
+	s.mu.Unlock()
+	return nil
+}
+
+func budgetSpendKey(id, periodKey string) string { return id + "\x00" + periodKey }
+
+// UpsertBudget creates or replaces a configured budget. See Store.UpsertBudget.
+func (s *MemoryStore) UpsertBudget(ctx context.Context, b Budget) (Budget, error) {
+	if strings.TrimSpace(b.Scope) == "" {
+		return Budget{}, errRequired("scope")
+	}
+	if strings.TrimSpace(b.Period) == "" {
+		return Budget{}, errRequired("period")
+	}
+	if strings.TrimSpace(b.Action) == "" {
+		return Budget{}, errRequired("action")
+	}
+	now := nowUnixUTC()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if strings.TrimSpace(b.ID) == "" {
+		b.ID = uuid.NewString()
+		b.CreatedAt = now
+	} else if existing, ok := s.budgets[b.ID]; ok {
+		b.CreatedAt = existing.CreatedAt
+	} else {
+		b.CreatedAt = now
+	}
+	b.UpdatedAt = now
+	s.budgets[b.ID] = b
+	return b, nil
+}
+
+// DeleteBudget removes a configured budget and its spend history. See
+// Store.DeleteBudget.
+func (s *MemoryStore) DeleteBudget(ctx context.Context, id string) (bool, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return false, errRequired("id")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.budgets[id]; !ok {
+		return false, nil
+	}
+	delete(s.budgets, id)
+	prefix := id + "\x00"
+	for k := range s.budgetSpend {
+		if strings.HasPrefix(k, prefix) {
+			delete(s.budgetSpend, k)
+		}
+	}
+	return true, nil
+}
+
+// ListBudgets returns every configured budget. See Store.ListBudgets.
+func (s *MemoryStore) ListBudgets(ctx context.Context) ([]Budget, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]Budget, 0, len(s.budgets))
+	for _, b := range s.budgets {
+		out = append(out, b)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt < out[j].CreatedAt })
+	return out, nil
+}
+
+// ChargeBudgetSpend adds deltaMicroUSD to budget id's running spend for
+// periodKey. See Store.ChargeBudgetSpend.
+func (s *MemoryStore) ChargeBudgetSpend(ctx context.Context, id, periodKey string, deltaMicroUSD int64) (int64, error) {
+	id = strings.TrimSpace(id)
+	periodKey = strings.TrimSpace(periodKey)
+	if id == "" || periodKey == "" {
+		return 0, errInvalid("id and period_key")
+	}
+	if deltaMicroUSD < 0 {
+		deltaMicroUSD = 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := budgetSpendKey(id, periodKey)
+	entry := s.budgetSpend[key]
+	entry.microUSD += deltaMicroUSD
+	entry.updatedAt = nowUnixUTC()
+	s.budgetSpend[key] = entry
+	return entry.microUSD, nil
+}
+
+// GetBudgetSpend returns budget id's running spend for periodKey. See
+// Store.GetBudgetSpend.
+func (s *MemoryStore) GetBudgetSpend(ctx context.Context, id, periodKey string) (int64, error) {
+	id = strings.TrimSpace(id)
+	periodKey = strings.TrimSpace(periodKey)
+	if id == "" || periodKey == "" {
+		return 0, errInvalid("id and period_key")
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.budgetSpend[budgetSpendKey(id, periodKey)].microUSD, nil
+}
+
+// PruneBudgetSpend deletes budget_spend entries last updated before
+// olderThanUnix. See Store.PruneBudgetSpend.
+func (s *MemoryStore) PruneBudgetSpend(ctx context.Context, olderThanUnix int64) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var pruned int64
+	for k, entry := range s.budgetSpend {
+		if entry.updatedAt < olderThanUnix {
+			delete(s.budgetSpend, k)
+			pruned++
+		}
+	}
+	return pruned, nil
+}
+
+// ListUsageRows returns apiKey's per-model usage rows with day in
+// [fromDay, toDay]. See Store.ListUsageRows.
+func (s *MemoryStore) ListUsageRows(ctx context.Context, apiKey, fromDay, toDay string) ([]DailyUsageRow, error) {
+	if apiKey == "" || fromDay == "" || toDay == "" {
+		return nil, errInvalid("inputs")
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []DailyUsageRow
+	for _, row := range s.usage {
+		if row.APIKey != apiKey || row.Day < fromDay || row.Day > toDay {
+			continue
+		}
+		out = append(out, row)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Day != out[j].Day {
+			return out[i].Day < out[j].Day
+		}
+		return out[i].Model < out[j].Model
+	})
+	return out, nil
+}
+
+// ListUsageRowsAllKeys returns every API key's per-model usage rows with day
+// in [fromDay, toDay]. See Store.ListUsageRowsAllKeys.
+func (s *MemoryStore) ListUsageRowsAllKeys(ctx context.Context, fromDay, toDay string) ([]DailyUsageRow, error) {
+	if fromDay == "" || toDay == "" {
+		return nil, errInvalid("inputs")
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []DailyUsageRow
+	for _, row := range s.usage {
+		if row.Day < fromDay || row.Day > toDay {
+			continue
+		}
+		out = append(out, row)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Day != out[j].Day {
+			return out[i].Day < out[j].Day
+		}
+		if out[i].APIKey != out[j].APIKey {
+			return out[i].APIKey < out[j].APIKey
+		}
+		return out[i].Model < out[j].Model
+	})
+	return out, nil
+}
+
+// SetUsageCostMicro overwrites the stored cost for one usage row. See
+// Store.SetUsageCostMicro.
+func (s *MemoryStore) SetUsageCostMicro(ctx context.Context, apiKey, model, dayKey string, costMicro int64) error {
+	modelKey := policy.NormaliseModelKey(model)
+	if apiKey == "" || modelKey == "" || dayKey == "" {
+		return errInvalid("inputs")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := usageKey(apiKey, modelKey, dayKey)
+	row, ok := s.usage[key]
+	if !ok {
+		return nil
+	}
+	row.CostMicroUSD = max64(0, costMicro)
+	row.UpdatedAt = nowUnixUTC()
+	s.usage[key] = row
+	return nil
+}
+
+func (s *MemoryStore) GetDailyUsageReport(ctx context.Context, apiKey, dayKey string) (DailyUsageReport, error) {
+	report := DailyUsageReport{APIKey: apiKey, Day: dayKey, GeneratedAtUnix: nowUnixUTC()}
+	if apiKey == "" || dayKey == "" {
+		return report, errInvalid("api_key and day are required")
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, row := range s.usage {
+		if row.APIKey != apiKey || row.Day != dayKey {
+			continue
+		}
+		report.TotalCostMicro += row.CostMicroUSD
+		report.TotalRequests += row.Requests
+		report.TotalFailed += row.FailedRequests
+		report.TotalTokens += row.TotalTokens
+		report.Models = append(report.Models, row)
+	}
+	sort.Slice(report.Models, func(i, j int) bool { return report.Models[i].Model < report.Models[j].Model })
+	report.TotalCostUSD = microUSDToUSD(report.TotalCostMicro)
+	return report, nil
+}
@@ -0,0 +1,349 @@
+package billing
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore_ModelPrices_DefaultAndOverride(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	model := "claude-opus-4-5-20251101"
+
+	price, source, _, err := store.ResolvePriceMicro(ctx, model)
+	if err != nil {
+		t.Fatalf("ResolvePriceMicro: %v", err)
+	}
+	if source != "default" {
+		t.Fatalf("source=%q", source)
+	}
+	if price.Prompt == 0 || price.Completion == 0 {
+		t.Fatalf("unexpected default price: %+v", price)
+	}
+
+	override := PriceMicroUSDPer1M{Prompt: 1, Completion: 2, Cached: 3}
+	if err := store.UpsertModelPrice(ctx, model, override); err != nil {
+		t.Fatalf("UpsertModelPrice: %v", err)
+	}
+	price2, source2, _, err := store.ResolvePriceMicro(ctx, model)
+	if err != nil {
+		t.Fatalf("ResolvePriceMicro(override): %v", err)
+	}
+	if source2 != "saved" {
+		t.Fatalf("source=%q", source2)
+	}
+	if price2 != override {
+		t.Fatalf("price=%+v want=%+v", price2, override)
+	}
+}
+
+func TestMemoryStore_ResolvePriceMicroAt_UsesEffectiveDatedHistory(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	model := "claude-opus-4-5-20251101"
+
+	first := PriceMicroUSDPer1M{Prompt: 1, Completion: 2, Cached: 3}
+	if err := store.UpsertModelPrice(ctx, model, first); err != nil {
+		t.Fatalf("UpsertModelPrice(first): %v", err)
+	}
+	firstHistory, err := store.ListPriceHistory(ctx, model)
+	if err != nil {
+		t.Fatalf("ListPriceHistory: %v", err)
+	}
+	if len(firstHistory) != 1 {
+		t.Fatalf("history=%d entries, want 1", len(firstHistory))
+	}
+	firstEffectiveAt := firstHistory[0].EffectiveAt
+
+	// EffectiveAt has one-second resolution; sleep past it so the second
+	// price is unambiguously later than the first.
+	time.Sleep(1100 * time.Millisecond)
+
+	second := PriceMicroUSDPer1M{Prompt: 10, Completion: 20, Cached: 30}
+	if err := store.UpsertModelPrice(ctx, model, second); err != nil {
+		t.Fatalf("UpsertModelPrice(second): %v", err)
+	}
+
+	// Resolving "now" should see the latest price.
+	latest, source, _, err := store.ResolvePriceMicro(ctx, model)
+	if err != nil {
+		t.Fatalf("ResolvePriceMicro: %v", err)
+	}
+	if source != "saved" || latest != second {
+		t.Fatalf("latest=%+v source=%q, want=%+v", latest, source, second)
+	}
+
+	// Resolving as of the first price's effective time should still return
+	// the first price, not the one that superseded it.
+	historical, source, _, err := store.ResolvePriceMicroAt(ctx, model, firstEffectiveAt)
+	if err != nil {
+		t.Fatalf("ResolvePriceMicroAt: %v", err)
+	}
+	if source != "saved" || historical != first {
+		t.Fatalf("historical=%+v source=%q, want=%+v", historical, source, first)
+	}
+
+	history, err := store.ListPriceHistory(ctx, model)
+	if err != nil {
+		t.Fatalf("ListPriceHistory: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("history=%d entries, want 2", len(history))
+	}
+}
+
+func TestMemoryStore_PriceAudit_RecordsUpsertAndDelete(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	model := "claude-opus-4-5-20251101"
+
+	first := PriceMicroUSDPer1M{Prompt: 1, Completion: 2, Cached: 3}
+	if err := store.UpsertModelPriceWithAudit(ctx, model, first, 0, "actor-1", "initial price"); err != nil {
+		t.Fatalf("UpsertModelPriceWithAudit(first): %v", err)
+	}
+	second := PriceMicroUSDPer1M{Prompt: 10, Completion: 20, Cached: 30}
+	if err := store.UpsertModelPriceWithAudit(ctx, model, second, 0, "actor-2", "price hike"); err != nil {
+		t.Fatalf("UpsertModelPriceWithAudit(second): %v", err)
+	}
+	deleted, err := store.DeleteModelPriceWithAudit(ctx, model, "actor-3", "rollback")
+	if err != nil {
+		t.Fatalf("DeleteModelPriceWithAudit: %v", err)
+	}
+	if !deleted {
+		t.Fatal("DeleteModelPriceWithAudit reported not found")
+	}
+
+	entries, err := store.ListPriceAudit(ctx)
+	if err != nil {
+		t.Fatalf("ListPriceAudit: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("entries=%d, want 3", len(entries))
+	}
+
+	if entries[0].Action != "upsert" || entries[0].Actor != "actor-1" || entries[0].OldPrice != nil || entries[0].NewPrice == nil || *entries[0].NewPrice != first {
+		t.Fatalf("entries[0]=%+v", entries[0])
+	}
+	if entries[1].Action != "upsert" || entries[1].Actor != "actor-2" || entries[1].OldPrice == nil || *entries[1].OldPrice != first || entries[1].NewPrice == nil || *entries[1].NewPrice != second {
+		t.Fatalf("entries[1]=%+v", entries[1])
+	}
+	if entries[2].Action != "delete" || entries[2].Actor != "actor-3" || entries[2].Reason != "rollback" || entries[2].OldPrice == nil || *entries[2].OldPrice != second || entries[2].NewPrice != nil {
+		t.Fatalf("entries[2]=%+v", entries[2])
+	}
+}
+
+func TestMemoryStore_ImportModelPrices_DryRunDiffsWithoutWriting(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	model := "claude-opus-4-5-20251101"
+	if err := store.UpsertModelPrice(ctx, model, PriceMicroUSDPer1M{Prompt: 1_000_000, Completion: 2_000_000, Cached: 500_000}); err != nil {
+		t.Fatalf("UpsertModelPrice: %v", err)
+	}
+
+	entries := []PriceImportEntry{
+		{Model: model, PromptUSDPer1M: 5, CompletionUSDPer1M: 10, CachedUSDPer1M: 2.5},
+		{Model: "gpt-new", PromptUSDPer1M: 3, CompletionUSDPer1M: 6, CachedUSDPer1M: 1},
+	}
+	result, err := store.ImportModelPrices(ctx, entries, "actor-1", "rate card sync", true)
+	if err != nil {
+		t.Fatalf("ImportModelPrices(dry run): %v", err)
+	}
+	if len(result.Updated) != 1 || len(result.Added) != 1 || !result.DryRun {
+		t.Fatalf("result=%+v", result)
+	}
+
+	audit, err := store.ListPriceAudit(ctx)
+	if err != nil {
+		t.Fatalf("ListPriceAudit: %v", err)
+	}
+	if len(audit) != 0 {
+		t.Fatalf("dry run should not write audit entries, got %d", len(audit))
+	}
+}
+
+func TestMemoryStore_ImportModelPrices_AbortsWholeBatchOnInvalidRow(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	entries := []PriceImportEntry{
+		{Model: "gpt-new", PromptUSDPer1M: 3, CompletionUSDPer1M: 6, CachedUSDPer1M: 1},
+		{Model: "bad-model", PromptUSDPer1M: -1, CompletionUSDPer1M: 6, CachedUSDPer1M: 1},
+	}
+	result, err := store.ImportModelPrices(ctx, entries, "actor-1", "rate card sync", false)
+	if err == nil {
+		t.Fatal("expected error for batch with an invalid row")
+	}
+	if len(result.Errors) != 1 {
+		t.Fatalf("result.Errors=%+v", result.Errors)
+	}
+
+	prices, err := store.ListModelPrices(ctx)
+	if err != nil {
+		t.Fatalf("ListModelPrices: %v", err)
+	}
+	for _, p := range prices {
+		if p.Model == "gpt-new" {
+			t.Fatalf("valid row must not be applied when another row fails: %+v", p)
+		}
+	}
+}
+
+func TestMemoryStore_ListModelPricesAt_PinsToPastInstant(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	model := "claude-opus-4-5-20251101"
+
+	first := PriceMicroUSDPer1M{Prompt: 1, Completion: 2, Cached: 3}
+	if err := store.UpsertModelPrice(ctx, model, first); err != nil {
+		t.Fatalf("UpsertModelPrice(first): %v", err)
+	}
+	history, err := store.ListPriceHistory(ctx, model)
+	if err != nil {
+		t.Fatalf("ListPriceHistory: %v", err)
+	}
+	firstEffectiveAt := history[0].EffectiveAt
+
+	time.Sleep(1100 * time.Millisecond)
+	second := PriceMicroUSDPer1M{Prompt: 10, Completion: 20, Cached: 30}
+	if err := store.UpsertModelPrice(ctx, model, second); err != nil {
+		t.Fatalf("UpsertModelPrice(second): %v", err)
+	}
+
+	prices, err := store.ListModelPricesAt(ctx, firstEffectiveAt)
+	if err != nil {
+		t.Fatalf("ListModelPricesAt: %v", err)
+	}
+	found := false
+	for _, p := range prices {
+		if p.Model != model {
+			continue
+		}
+		found = true
+		if billing := (PriceMicroUSDPer1M{
+			Prompt:     USDPer1MToMicroUSDPer1M(p.PromptUSDPer1M),
+			Completion: USDPer1MToMicroUSDPer1M(p.CompletionUSDPer1M),
+			Cached:     USDPer1MToMicroUSDPer1M(p.CachedUSDPer1M),
+		}); billing != first {
+			t.Fatalf("ListModelPricesAt price=%+v, want %+v", billing, first)
+		}
+	}
+	if !found {
+		t.Fatalf("ListModelPricesAt did not report %s", model)
+	}
+}
+
+func TestMemoryStore_AddUsageAndDailyCost(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	apiKey := "k"
+	model := "claude-opus-4-5-20251101"
+	day := "2026-02-13"
+
+	if err := store.UpsertModelPrice(ctx, model, PriceMicroUSDPer1M{Prompt: 1_000_000, Completion: 0, Cached: 0}); err != nil {
+		t.Fatalf("UpsertModelPrice: %v", err)
+	}
+
+	if err := store.AddUsage(ctx, apiKey, model, day, DailyUsageRow{
+		Requests:     1,
+		InputTokens:  2,
+		TotalTokens:  2,
+		CostMicroUSD: 2,
+	}); err != nil {
+		t.Fatalf("AddUsage: %v", err)
+	}
+	cost, err := store.GetDailyCostMicroUSD(ctx, apiKey, day)
+	if err != nil {
+		t.Fatalf("GetDailyCostMicroUSD: %v", err)
+	}
+	if cost != 2 {
+		t.Fatalf("cost=%d", cost)
+	}
+
+	report, err := store.GetDailyUsageReport(ctx, apiKey, day)
+	if err != nil {
+		t.Fatalf("GetDailyUsageReport: %v", err)
+	}
+	if report.TotalCostMicro != 2 || report.TotalRequests != 1 || report.TotalTokens != 2 {
+		t.Fatalf("report=%+v", report)
+	}
+	if len(report.Models) != 1 {
+		t.Fatalf("models=%d", len(report.Models))
+	}
+}
+
+func TestMemoryStore_Budgets_UpsertChargeAndPrune(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	saved, err := store.UpsertBudget(ctx, Budget{
+		Scope:    BudgetScopeKey,
+		APIKey:   "k",
+		Period:   BudgetPeriodDay,
+		LimitUSD: 5,
+		Action:   BudgetActionBlock,
+	})
+	if err != nil {
+		t.Fatalf("UpsertBudget: %v", err)
+	}
+	if saved.ID == "" || saved.CreatedAt == 0 || saved.UpdatedAt == 0 {
+		t.Fatalf("saved=%+v", saved)
+	}
+
+	spent, err := store.ChargeBudgetSpend(ctx, saved.ID, "2026-02-13", 1_000_000)
+	if err != nil {
+		t.Fatalf("ChargeBudgetSpend: %v", err)
+	}
+	if spent != 1_000_000 {
+		t.Fatalf("spent=%d", spent)
+	}
+	spent, err = store.ChargeBudgetSpend(ctx, saved.ID, "2026-02-13", 500_000)
+	if err != nil {
+		t.Fatalf("ChargeBudgetSpend(2): %v", err)
+	}
+	if spent != 1_500_000 {
+		t.Fatalf("spent after second charge=%d", spent)
+	}
+
+	got, err := store.GetBudgetSpend(ctx, saved.ID, "2026-02-13")
+	if err != nil {
+		t.Fatalf("GetBudgetSpend: %v", err)
+	}
+	if got != 1_500_000 {
+		t.Fatalf("GetBudgetSpend=%d", got)
+	}
+
+	budgets, err := store.ListBudgets(ctx)
+	if err != nil {
+		t.Fatalf("ListBudgets: %v", err)
+	}
+	if len(budgets) != 1 {
+		t.Fatalf("budgets=%d", len(budgets))
+	}
+
+	pruned, err := store.PruneBudgetSpend(ctx, nowUnixUTC()+3600)
+	if err != nil {
+		t.Fatalf("PruneBudgetSpend: %v", err)
+	}
+	if pruned != 1 {
+		t.Fatalf("pruned=%d, want 1", pruned)
+	}
+	if got, err := store.GetBudgetSpend(ctx, saved.ID, "2026-02-13"); err != nil || got != 0 {
+		t.Fatalf("GetBudgetSpend after prune: got=%d err=%v", got, err)
+	}
+
+	deleted, err := store.DeleteBudget(ctx, saved.ID)
+	if err != nil {
+		t.Fatalf("DeleteBudget: %v", err)
+	}
+	if !deleted {
+		t.Fatal("DeleteBudget reported not found")
+	}
+	budgets, err = store.ListBudgets(ctx)
+	if err != nil {
+		t.Fatalf("ListBudgets after delete: %v", err)
+	}
+	if len(budgets) != 0 {
+		t.Fatalf("budgets after delete=%d", len(budgets))
+	}
+}
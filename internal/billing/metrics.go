@@ -0,0 +1,219 @@
+package billing
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// apiKeyMetricLabel hashes apiKey down to a short, non-reversible label so
+// Prometheus series carry no secret material and stay low-cardinality even
+// across many distinct keys.
+func apiKeyMetricLabel(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+type costSeriesKey struct {
+	apiKeyHash string
+	model      string
+}
+
+type tokenSeriesKey struct {
+	apiKeyHash string
+	model      string
+	kind       string // "input" | "output" | "reasoning" | "cached"
+}
+
+type requestSeriesKey struct {
+	apiKeyHash string
+	model      string
+	status     string // "ok" | "failed"
+}
+
+type priceRefreshSeriesKey struct {
+	provider string
+	status   string // "ok" | "failed"
+}
+
+// MetricsRegistry accumulates billing counters in process memory and renders
+// them as Prometheus/OpenMetrics exposition text. It is updated from the
+// same usage events that feed the Store (see UsagePersistPlugin), so
+// operators can chart spend and throughput without querying the billing
+// database directly.
+type MetricsRegistry struct {
+	mu           sync.Mutex
+	cost         map[costSeriesKey]int64
+	tokens       map[tokenSeriesKey]int64
+	requests     map[requestSeriesKey]int64
+	priceRefresh map[priceRefreshSeriesKey]int64
+}
+
+// NewMetricsRegistry returns an empty MetricsRegistry.
+func NewMetricsRegistry() *MetricsRegistry {
+	return &MetricsRegistry{
+		cost:         make(map[costSeriesKey]int64),
+		tokens:       make(map[tokenSeriesKey]int64),
+		requests:     make(map[requestSeriesKey]int64),
+		priceRefresh: make(map[priceRefreshSeriesKey]int64),
+	}
+}
+
+// Observe records one request's billing outcome. apiKey is hashed before
+// being used as a label; model should already be normalised.
+func (r *MetricsRegistry) Observe(apiKey, model string, failed bool, row DailyUsageRow) {
+	if r == nil {
+		return
+	}
+	hash := apiKeyMetricLabel(apiKey)
+	status := "ok"
+	if failed {
+		status = "failed"
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cost[costSeriesKey{apiKeyHash: hash, model: model}] += row.CostMicroUSD
+	r.tokens[tokenSeriesKey{apiKeyHash: hash, model: model, kind: "input"}] += row.InputTokens
+	r.tokens[tokenSeriesKey{apiKeyHash: hash, model: model, kind: "output"}] += row.OutputTokens
+	r.tokens[tokenSeriesKey{apiKeyHash: hash, model: model, kind: "reasoning"}] += row.ReasoningTokens
+	r.tokens[tokenSeriesKey{apiKeyHash: hash, model: model, kind: "cached"}] += row.CachedTokens
+	r.requests[requestSeriesKey{apiKeyHash: hash, model: model, status: status}]++
+}
+
+// ObservePriceRefresh records one PriceProvider.FetchPrices outcome from a
+// PriceRefresher.RefreshOnce pass.
+func (r *MetricsRegistry) ObservePriceRefresh(provider string, ok bool) {
+	if r == nil {
+		return
+	}
+	status := "ok"
+	if !ok {
+		status = "failed"
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.priceRefresh[priceRefreshSeriesKey{provider: provider, status: status}]++
+}
+
+// WriteExposition renders every series in Prometheus text exposition format.
+func (r *MetricsRegistry) WriteExposition(w io.Writer) error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err := io.WriteString(w, "# HELP cliproxy_billing_cost_micro_usd_total Total billed cost in micro-USD.\n"+
+		"# TYPE cliproxy_billing_cost_micro_usd_total counter\n"); err != nil {
+		return err
+	}
+	costKeys := make([]costSeriesKey, 0, len(r.cost))
+	for k := range r.cost {
+		costKeys = append(costKeys, k)
+	}
+	sort.Slice(costKeys, func(i, j int) bool { return costSeriesLess(costKeys[i], costKeys[j]) })
+	for _, k := range costKeys {
+		if _, err := fmt.Fprintf(w, "cliproxy_billing_cost_micro_usd_total{api_key_hash=%q,model=%q} %d\n", k.apiKeyHash, k.model, r.cost[k]); err != nil {
+			return err
+		}
+	}
+
+	if _, err := io.WriteString(w, "# HELP cliproxy_billing_tokens_total Total tokens processed, by kind.\n"+
+		"# TYPE cliproxy_billing_tokens_total counter\n"); err != nil {
+		return err
+	}
+	tokenKeys := make([]tokenSeriesKey, 0, len(r.tokens))
+	for k := range r.tokens {
+		tokenKeys = append(tokenKeys, k)
+	}
+	sort.Slice(tokenKeys, func(i, j int) bool { return tokenSeriesLess(tokenKeys[i], tokenKeys[j]) })
+	for _, k := range tokenKeys {
+		if _, err := fmt.Fprintf(w, "cliproxy_billing_tokens_total{api_key_hash=%q,model=%q,kind=%q} %d\n", k.apiKeyHash, k.model, k.kind, r.tokens[k]); err != nil {
+			return err
+		}
+	}
+
+	if _, err := io.WriteString(w, "# HELP cliproxy_billing_requests_total Total billed requests, by outcome.\n"+
+		"# TYPE cliproxy_billing_requests_total counter\n"); err != nil {
+		return err
+	}
+	requestKeys := make([]requestSeriesKey, 0, len(r.requests))
+	for k := range r.requests {
+		requestKeys = append(requestKeys, k)
+	}
+	sort.Slice(requestKeys, func(i, j int) bool { return requestSeriesLess(requestKeys[i], requestKeys[j]) })
+	for _, k := range requestKeys {
+		if _, err := fmt.Fprintf(w, "cliproxy_billing_requests_total{api_key_hash=%q,model=%q,status=%q} %d\n", k.apiKeyHash, k.model, k.status, r.requests[k]); err != nil {
+			return err
+		}
+	}
+
+	if _, err := io.WriteString(w, "# HELP cliproxy_billing_price_refresh_total Total PriceProvider refresh attempts, by outcome.\n"+
+		"# TYPE cliproxy_billing_price_refresh_total counter\n"); err != nil {
+		return err
+	}
+	priceRefreshKeys := make([]priceRefreshSeriesKey, 0, len(r.priceRefresh))
+	for k := range r.priceRefresh {
+		priceRefreshKeys = append(priceRefreshKeys, k)
+	}
+	sort.Slice(priceRefreshKeys, func(i, j int) bool { return priceRefreshSeriesLess(priceRefreshKeys[i], priceRefreshKeys[j]) })
+	for _, k := range priceRefreshKeys {
+		if _, err := fmt.Fprintf(w, "cliproxy_billing_price_refresh_total{provider=%q,status=%q} %d\n", k.provider, k.status, r.priceRefresh[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func costSeriesLess(a, b costSeriesKey) bool {
+	if a.apiKeyHash != b.apiKeyHash {
+		return a.apiKeyHash < b.apiKeyHash
+	}
+	return a.model < b.model
+}
+
+func tokenSeriesLess(a, b tokenSeriesKey) bool {
+	if a.apiKeyHash != b.apiKeyHash {
+		return a.apiKeyHash < b.apiKeyHash
+	}
+	if a.model != b.model {
+		return a.model < b.model
+	}
+	return a.kind < b.kind
+}
+
+func requestSeriesLess(a, b requestSeriesKey) bool {
+	if a.apiKeyHash != b.apiKeyHash {
+		return a.apiKeyHash < b.apiKeyHash
+	}
+	if a.model != b.model {
+		return a.model < b.model
+	}
+	return a.status < b.status
+}
+
+func priceRefreshSeriesLess(a, b priceRefreshSeriesKey) bool {
+	if a.provider != b.provider {
+		return a.provider < b.provider
+	}
+	return a.status < b.status
+}
+
+// MetricsHandler returns a gin.HandlerFunc that serves reg's counters in
+// Prometheus/OpenMetrics text exposition format at e.g. GET /metrics.
+func MetricsHandler(reg *MetricsRegistry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if reg == nil {
+			return
+		}
+		_ = reg.WriteExposition(c.Writer)
+	}
+}
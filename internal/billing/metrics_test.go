@@ -0,0 +1,50 @@
+package billing
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMetricsRegistry_ObserveAndWriteExposition(t *testing.T) {
+	reg := NewMetricsRegistry()
+	reg.Observe("sk-test-key", "model-a", false, DailyUsageRow{
+		InputTokens: 10, OutputTokens: 5, CachedTokens: 2, CostMicroUSD: 1234,
+	})
+	reg.Observe("sk-test-key", "model-a", true, DailyUsageRow{
+		InputTokens: 1, OutputTokens: 1, CostMicroUSD: 1,
+	})
+
+	var buf strings.Builder
+	if err := reg.WriteExposition(&buf); err != nil {
+		t.Fatalf("WriteExposition: %v", err)
+	}
+	out := buf.String()
+
+	if strings.Contains(out, "sk-test-key") {
+		t.Fatal("exposition leaked raw api key")
+	}
+	label := apiKeyMetricLabel("sk-test-key")
+	if !strings.Contains(out, `api_key_hash="`+label+`"`) {
+		t.Fatalf("missing hashed label in output:\n%s", out)
+	}
+	if !strings.Contains(out, `cliproxy_billing_cost_micro_usd_total{api_key_hash="`+label+`",model="model-a"} 1235`) {
+		t.Fatalf("unexpected cost series:\n%s", out)
+	}
+	if !strings.Contains(out, `status="ok"} 1`) || !strings.Contains(out, `status="failed"} 1`) {
+		t.Fatalf("unexpected request series:\n%s", out)
+	}
+}
+
+func TestApiKeyMetricLabel_StableAndShort(t *testing.T) {
+	a := apiKeyMetricLabel("same-key")
+	b := apiKeyMetricLabel("same-key")
+	if a != b {
+		t.Fatalf("hash not stable: %q vs %q", a, b)
+	}
+	if len(a) != 12 {
+		t.Fatalf("label length=%d", len(a))
+	}
+	if apiKeyMetricLabel("different-key") == a {
+		t.Fatal("distinct keys hashed to the same label")
+	}
+}
@@ -0,0 +1,1256 @@
+package billing
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/policy"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// PostgresStore is a Store backend for PostgreSQL and CockroachDB (which
+// speaks the same wire protocol and accepts the same upsert statements).
+// Unlike SQLiteStore it is safe to share across multiple proxy replicas,
+// since the database itself arbitrates concurrent writers.
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore opens dsn (a standard "postgres://" connection string)
+// and ensures the billing schema exists.
+func NewPostgresStore(dsn string) (*PostgresStore, error) {
+	trimmed := strings.TrimSpace(dsn)
+	if trimmed == "" {
+		return nil, fmt.Errorf("billing postgres: dsn is required")
+	}
+	db, err := sql.Open("pgx", trimmed)
+	if err != nil {
+		return nil, fmt.Errorf("billing postgres: open database: %w", err)
+	}
+
+	ctx := context.Background()
+	if err := db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("billing postgres: ping database: %w", err)
+	}
+
+	store := &PostgresStore{db: db}
+	if err := store.ensureSchema(ctx); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return store, nil
+}
+
+func (s *PostgresStore) Close() error {
+	if s == nil || s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+func (s *PostgresStore) ensureSchema(ctx context.Context) error {
+	stmts := []string{
+		`
+		CREATE TABLE IF NOT EXISTS model_price_history (
+			id TEXT PRIMARY KEY,
+			model TEXT NOT NULL,
+			prompt_micro_usd_per_1m BIGINT NOT NULL,
+			completion_micro_usd_per_1m BIGINT NOT NULL,
+			cached_micro_usd_per_1m BIGINT NOT NULL,
+			effective_at BIGINT NOT NULL,
+			created_at BIGINT NOT NULL
+		)
+		`,
+		`CREATE INDEX IF NOT EXISTS idx_model_price_history_model_effective ON model_price_history (model, effective_at)`,
+		`
+		CREATE TABLE IF NOT EXISTS model_prices_catalog (
+			model TEXT PRIMARY KEY,
+			prompt_micro_usd_per_1m BIGINT NOT NULL,
+			completion_micro_usd_per_1m BIGINT NOT NULL,
+			cached_micro_usd_per_1m BIGINT NOT NULL,
+			updated_at BIGINT NOT NULL
+		)
+		`,
+		`
+		CREATE TABLE IF NOT EXISTS model_prices_catalog_sync (
+			id INTEGER PRIMARY KEY CHECK (id = 1),
+			etag TEXT NOT NULL,
+			synced_at BIGINT NOT NULL
+		)
+		`,
+		`
+		CREATE TABLE IF NOT EXISTS api_key_model_daily_usage (
+			api_key TEXT NOT NULL,
+			model TEXT NOT NULL,
+			day TEXT NOT NULL,
+			requests BIGINT NOT NULL,
+			failed_requests BIGINT NOT NULL,
+			input_tokens BIGINT NOT NULL,
+			output_tokens BIGINT NOT NULL,
+			reasoning_tokens BIGINT NOT NULL,
+			cached_tokens BIGINT NOT NULL,
+			total_tokens BIGINT NOT NULL,
+			cost_micro_usd BIGINT NOT NULL,
+			updated_at BIGINT NOT NULL,
+			PRIMARY KEY (api_key, model, day)
+		)
+		`,
+		`CREATE INDEX IF NOT EXISTS idx_api_key_model_daily_usage_api_day ON api_key_model_daily_usage (api_key, day)`,
+		`
+		CREATE TABLE IF NOT EXISTS budget_reservations (
+			id TEXT PRIMARY KEY,
+			api_key TEXT NOT NULL,
+			day TEXT NOT NULL,
+			estimated_micro_usd BIGINT NOT NULL,
+			created_at BIGINT NOT NULL
+		)
+		`,
+		`CREATE INDEX IF NOT EXISTS idx_budget_reservations_api_day ON budget_reservations (api_key, day)`,
+		`
+		CREATE TABLE IF NOT EXISTS price_audit (
+			id TEXT PRIMARY KEY,
+			model TEXT NOT NULL,
+			action TEXT NOT NULL,
+			actor TEXT NOT NULL,
+			reason TEXT NOT NULL,
+			old_prompt_micro_usd_per_1m BIGINT,
+			old_completion_micro_usd_per_1m BIGINT,
+			old_cached_micro_usd_per_1m BIGINT,
+			new_prompt_micro_usd_per_1m BIGINT,
+			new_completion_micro_usd_per_1m BIGINT,
+			new_cached_micro_usd_per_1m BIGINT,
+			effective_from BIGINT NOT NULL,
+			created_at BIGINT NOT NULL
+		)
+		`,
+		`CREATE INDEX IF NOT EXISTS idx_price_audit_model_created ON price_audit (model, created_at)`,
+		`
+		CREATE TABLE IF NOT EXISTS budgets (
+			id TEXT PRIMARY KEY,
+			scope TEXT NOT NULL,
+			api_key TEXT NOT NULL,
+			model TEXT NOT NULL,
+			period TEXT NOT NULL,
+			limit_usd DOUBLE PRECISION NOT NULL,
+			action TEXT NOT NULL,
+			soft_threshold_pct DOUBLE PRECISION NOT NULL,
+			created_at BIGINT NOT NULL,
+			updated_at BIGINT NOT NULL
+		)
+		`,
+		`
+		CREATE TABLE IF NOT EXISTS budget_spend (
+			budget_id TEXT NOT NULL,
+			period_key TEXT NOT NULL,
+			spent_micro_usd BIGINT NOT NULL,
+			updated_at BIGINT NOT NULL,
+			PRIMARY KEY (budget_id, period_key)
+		)
+		`,
+	}
+	for _, stmt := range stmts {
+		if _, err := s.db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("billing postgres: ensure schema: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *PostgresStore) UpsertModelPrice(ctx context.Context, model string, price PriceMicroUSDPer1M) error {
+	if s == nil || s.db == nil {
+		return fmt.Errorf("billing postgres: not initialized")
+	}
+	key := policy.NormaliseModelKey(model)
+	if key == "" {
+		return fmt.Errorf("billing postgres: model is required")
+	}
+	if price.Prompt < 0 || price.Completion < 0 || price.Cached < 0 {
+		return fmt.Errorf("billing postgres: invalid price")
+	}
+	now := nowUnixUTC()
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO model_price_history (id, model, prompt_micro_usd_per_1m, completion_micro_usd_per_1m, cached_micro_usd_per_1m, effective_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, uuid.NewString(), key, price.Prompt, price.Completion, price.Cached, now, now)
+	if err != nil {
+		return fmt.Errorf("billing postgres: upsert model price: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) DeleteModelPrice(ctx context.Context, model string) (bool, error) {
+	if s == nil || s.db == nil {
+		return false, fmt.Errorf("billing postgres: not initialized")
+	}
+	key := policy.NormaliseModelKey(model)
+	if key == "" {
+		return false, fmt.Errorf("billing postgres: model is required")
+	}
+	res, err := s.db.ExecContext(ctx, `DELETE FROM model_price_history WHERE model = $1`, key)
+	if err != nil {
+		return false, fmt.Errorf("billing postgres: delete model price: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// UpsertModelPriceWithAudit is UpsertModelPrice plus a price_audit row. See
+// Store.UpsertModelPriceWithAudit.
+func (s *PostgresStore) UpsertModelPriceWithAudit(ctx context.Context, model string, price PriceMicroUSDPer1M, effectiveFrom int64, actor, reason string) error {
+	if s == nil || s.db == nil {
+		return fmt.Errorf("billing postgres: not initialized")
+	}
+	key := policy.NormaliseModelKey(model)
+	if key == "" {
+		return fmt.Errorf("billing postgres: model is required")
+	}
+	if price.Prompt < 0 || price.Completion < 0 || price.Cached < 0 {
+		return fmt.Errorf("billing postgres: invalid price")
+	}
+	now := nowUnixUTC()
+	effectiveAt := effectiveFrom
+	if effectiveAt == 0 {
+		effectiveAt = now
+	}
+
+	old, hadOld, _, err := s.getSavedPriceMicroAt(ctx, key, now)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("billing postgres: begin upsert with audit: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO model_price_history (id, model, prompt_micro_usd_per_1m, completion_micro_usd_per_1m, cached_micro_usd_per_1m, effective_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, uuid.NewString(), key, price.Prompt, price.Completion, price.Cached, effectiveAt, now); err != nil {
+		return fmt.Errorf("billing postgres: upsert model price: %w", err)
+	}
+
+	if err := insertPostgresPriceAudit(ctx, tx, key, "upsert", actor, reason, hadOld, old, true, price, effectiveAt, now); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("billing postgres: commit upsert with audit: %w", err)
+	}
+	return nil
+}
+
+// DeleteModelPriceWithAudit is DeleteModelPrice plus a price_audit row. See
+// Store.DeleteModelPriceWithAudit.
+func (s *PostgresStore) DeleteModelPriceWithAudit(ctx context.Context, model, actor, reason string) (bool, error) {
+	if s == nil || s.db == nil {
+		return false, fmt.Errorf("billing postgres: not initialized")
+	}
+	key := policy.NormaliseModelKey(model)
+	if key == "" {
+		return false, fmt.Errorf("billing postgres: model is required")
+	}
+	now := nowUnixUTC()
+	old, hadOld, _, err := s.getSavedPriceMicroAt(ctx, key, now)
+	if err != nil {
+		return false, err
+	}
+	if !hadOld {
+		return false, nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("billing postgres: begin delete with audit: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM model_price_history WHERE model = $1`, key)
+	if err != nil {
+		return false, fmt.Errorf("billing postgres: delete model price: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	if n == 0 {
+		return false, nil
+	}
+
+	if err := insertPostgresPriceAudit(ctx, tx, key, "delete", actor, reason, true, old, false, PriceMicroUSDPer1M{}, 0, now); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("billing postgres: commit delete with audit: %w", err)
+	}
+	return true, nil
+}
+
+// ImportModelPrices validates and applies a bulk price import. See
+// Store.ImportModelPrices.
+func (s *PostgresStore) ImportModelPrices(ctx context.Context, entries []PriceImportEntry, actor, reason string, dryRun bool) (PriceImportResult, error) {
+	if s == nil || s.db == nil {
+		return PriceImportResult{}, fmt.Errorf("billing postgres: not initialized")
+	}
+	current, err := s.ListModelPrices(ctx)
+	if err != nil {
+		return PriceImportResult{}, err
+	}
+	rows, result := planPriceImport(modelPricesByModel(current), entries)
+	result.DryRun = dryRun
+	if dryRun || len(result.Errors) > 0 {
+		if !dryRun {
+			return result, fmt.Errorf("billing postgres: import aborted: %d row(s) failed validation", len(result.Errors))
+		}
+		return result, nil
+	}
+
+	now := nowUnixUTC()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return result, fmt.Errorf("billing postgres: begin import: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, row := range rows {
+		old, hadOld, _, err := s.getSavedPriceMicroAt(ctx, row.model, now)
+		if err != nil {
+			return result, err
+		}
+		effectiveAt := row.effectiveFrom
+		if effectiveAt == 0 {
+			effectiveAt = now
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO model_price_history (id, model, prompt_micro_usd_per_1m, completion_micro_usd_per_1m, cached_micro_usd_per_1m, effective_at, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+		`, uuid.NewString(), row.model, row.price.Prompt, row.price.Completion, row.price.Cached, effectiveAt, now); err != nil {
+			return result, fmt.Errorf("billing postgres: import row %s: %w", row.model, err)
+		}
+		if err := insertPostgresPriceAudit(ctx, tx, row.model, "upsert", actor, reason, hadOld, old, true, row.price, effectiveAt, now); err != nil {
+			return result, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return result, fmt.Errorf("billing postgres: commit import: %w", err)
+	}
+	return result, nil
+}
+
+func insertPostgresPriceAudit(ctx context.Context, tx *sql.Tx, model, action, actor, reason string, hasOld bool, old PriceMicroUSDPer1M, hasNew bool, newPrice PriceMicroUSDPer1M, effectiveFrom, now int64) error {
+	var oldPrompt, oldCompletion, oldCached, newPrompt, newCompletion, newCached sql.NullInt64
+	if hasOld {
+		oldPrompt = sql.NullInt64{Int64: old.Prompt, Valid: true}
+		oldCompletion = sql.NullInt64{Int64: old.Completion, Valid: true}
+		oldCached = sql.NullInt64{Int64: old.Cached, Valid: true}
+	}
+	if hasNew {
+		newPrompt = sql.NullInt64{Int64: newPrice.Prompt, Valid: true}
+		newCompletion = sql.NullInt64{Int64: newPrice.Completion, Valid: true}
+		newCached = sql.NullInt64{Int64: newPrice.Cached, Valid: true}
+	}
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO price_audit (
+			id, model, action, actor, reason,
+			old_prompt_micro_usd_per_1m, old_completion_micro_usd_per_1m, old_cached_micro_usd_per_1m,
+			new_prompt_micro_usd_per_1m, new_completion_micro_usd_per_1m, new_cached_micro_usd_per_1m,
+			effective_from, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+	`, uuid.NewString(), model, action, actor, reason,
+		oldPrompt, oldCompletion, oldCached,
+		newPrompt, newCompletion, newCached,
+		effectiveFrom, now)
+	if err != nil {
+		return fmt.Errorf("billing postgres: insert price audit: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) getSavedPriceMicroAt(ctx context.Context, modelKey string, atUnix int64) (PriceMicroUSDPer1M, bool, int64, error) {
+	if s == nil || s.db == nil {
+		return PriceMicroUSDPer1M{}, false, 0, fmt.Errorf("billing postgres: not initialized")
+	}
+	row := s.db.QueryRowContext(ctx, `
+		SELECT prompt_micro_usd_per_1m, completion_micro_usd_per_1m, cached_micro_usd_per_1m, effective_at
+		FROM model_price_history
+		WHERE model = $1 AND effective_at <= $2
+		ORDER BY effective_at DESC, created_at DESC
+		LIMIT 1
+	`, modelKey, atUnix)
+	var p, c, cached, effectiveAt int64
+	if err := row.Scan(&p, &c, &cached, &effectiveAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return PriceMicroUSDPer1M{}, false, 0, nil
+		}
+		return PriceMicroUSDPer1M{}, false, 0, fmt.Errorf("billing postgres: query price: %w", err)
+	}
+	return PriceMicroUSDPer1M{Prompt: p, Completion: c, Cached: cached}, true, effectiveAt, nil
+}
+
+func (s *PostgresStore) getCatalogPriceMicro(ctx context.Context, modelKey string) (PriceMicroUSDPer1M, bool, error) {
+	if s == nil || s.db == nil {
+		return PriceMicroUSDPer1M{}, false, fmt.Errorf("billing postgres: not initialized")
+	}
+	row := s.db.QueryRowContext(ctx, `
+		SELECT prompt_micro_usd_per_1m, completion_micro_usd_per_1m, cached_micro_usd_per_1m
+		FROM model_prices_catalog
+		WHERE model = $1
+	`, modelKey)
+	var p, c, cached int64
+	if err := row.Scan(&p, &c, &cached); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return PriceMicroUSDPer1M{}, false, nil
+		}
+		return PriceMicroUSDPer1M{}, false, fmt.Errorf("billing postgres: query catalog price: %w", err)
+	}
+	return PriceMicroUSDPer1M{Prompt: p, Completion: c, Cached: cached}, true, nil
+}
+
+// UpsertCatalogPrices replaces the catalog tier in one transaction
+// (stage-then-swap): the old table contents are dropped and prices are
+// inserted, skipping any model that already has a row in
+// model_price_history. See Store.UpsertCatalogPrices.
+func (s *PostgresStore) UpsertCatalogPrices(ctx context.Context, prices map[string]PriceMicroUSDPer1M, etag string) error {
+	if s == nil || s.db == nil {
+		return fmt.Errorf("billing postgres: not initialized")
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("billing postgres: begin catalog sync: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM model_prices_catalog`); err != nil {
+		return fmt.Errorf("billing postgres: clear catalog: %w", err)
+	}
+	now := nowUnixUTC()
+	for rawKey, price := range prices {
+		key := policy.NormaliseModelKey(rawKey)
+		if key == "" {
+			continue
+		}
+		var pinned int
+		if err := tx.QueryRowContext(ctx, `SELECT COUNT(1) FROM model_price_history WHERE model = $1`, key).Scan(&pinned); err != nil {
+			return fmt.Errorf("billing postgres: check pinned price: %w", err)
+		}
+		if pinned > 0 {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO model_prices_catalog (model, prompt_micro_usd_per_1m, completion_micro_usd_per_1m, cached_micro_usd_per_1m, updated_at)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (model) DO UPDATE SET
+				prompt_micro_usd_per_1m = excluded.prompt_micro_usd_per_1m,
+				completion_micro_usd_per_1m = excluded.completion_micro_usd_per_1m,
+				cached_micro_usd_per_1m = excluded.cached_micro_usd_per_1m,
+				updated_at = excluded.updated_at
+		`, key, price.Prompt, price.Completion, price.Cached, now); err != nil {
+			return fmt.Errorf("billing postgres: upsert catalog price: %w", err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO model_prices_catalog_sync (id, etag, synced_at) VALUES (1, $1, $2)
+		ON CONFLICT (id) DO UPDATE SET etag = excluded.etag, synced_at = excluded.synced_at
+	`, etag, now); err != nil {
+		return fmt.Errorf("billing postgres: record catalog sync: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("billing postgres: commit catalog sync: %w", err)
+	}
+	return nil
+}
+
+// GetCatalogETag returns the last synced catalog etag. See
+// Store.GetCatalogETag.
+func (s *PostgresStore) GetCatalogETag(ctx context.Context) (string, error) {
+	if s == nil || s.db == nil {
+		return "", fmt.Errorf("billing postgres: not initialized")
+	}
+	var etag string
+	err := s.db.QueryRowContext(ctx, `SELECT etag FROM model_prices_catalog_sync WHERE id = 1`).Scan(&etag)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("billing postgres: get catalog etag: %w", err)
+	}
+	return etag, nil
+}
+
+func (s *PostgresStore) ResolvePriceMicro(ctx context.Context, model string) (price PriceMicroUSDPer1M, source string, updatedAt int64, err error) {
+	return s.ResolvePriceMicroAt(ctx, model, nowUnixUTC())
+}
+
+// ResolvePriceMicroAt resolves model's price as it stood at atUnix. See
+// Store.ResolvePriceMicroAt.
+func (s *PostgresStore) ResolvePriceMicroAt(ctx context.Context, model string, atUnix int64) (price PriceMicroUSDPer1M, source string, effectiveAt int64, err error) {
+	modelKey := policy.NormaliseModelKey(model)
+	if modelKey == "" {
+		return PriceMicroUSDPer1M{}, "", 0, fmt.Errorf("billing postgres: model is required")
+	}
+	baseKey := policy.StripThinkingVariant(modelKey)
+	if s != nil && s.db != nil {
+		if saved, ok, effAt, errGet := s.getSavedPriceMicroAt(ctx, modelKey, atUnix); errGet != nil {
+			return PriceMicroUSDPer1M{}, "", 0, errGet
+		} else if ok {
+			return saved, "saved", effAt, nil
+		}
+		if baseKey != "" && baseKey != modelKey {
+			if saved, ok, effAt, errGet := s.getSavedPriceMicroAt(ctx, baseKey, atUnix); errGet != nil {
+				return PriceMicroUSDPer1M{}, "", 0, errGet
+			} else if ok {
+				return saved, "saved", effAt, nil
+			}
+		}
+		if catalog, ok, errGet := s.getCatalogPriceMicro(ctx, modelKey); errGet != nil {
+			return PriceMicroUSDPer1M{}, "", 0, errGet
+		} else if ok {
+			return catalog, "catalog", 0, nil
+		}
+		if baseKey != "" && baseKey != modelKey {
+			if catalog, ok, errGet := s.getCatalogPriceMicro(ctx, baseKey); errGet != nil {
+				return PriceMicroUSDPer1M{}, "", 0, errGet
+			} else if ok {
+				return catalog, "catalog", 0, nil
+			}
+		}
+	}
+	if v, ok := DefaultPrices[modelKey]; ok {
+		return v, "default", 0, nil
+	}
+	if baseKey != "" && baseKey != modelKey {
+		if v, ok := DefaultPrices[baseKey]; ok {
+			return v, "default", 0, nil
+		}
+	}
+	return PriceMicroUSDPer1M{}, "missing", 0, nil
+}
+
+// ListPriceHistory returns model's saved prices, oldest to newest. See
+// Store.ListPriceHistory.
+func (s *PostgresStore) ListPriceHistory(ctx context.Context, model string) ([]PriceHistoryEntry, error) {
+	if s == nil || s.db == nil {
+		return nil, fmt.Errorf("billing postgres: not initialized")
+	}
+	key := policy.NormaliseModelKey(model)
+	if key == "" {
+		return nil, fmt.Errorf("billing postgres: model is required")
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT prompt_micro_usd_per_1m, completion_micro_usd_per_1m, cached_micro_usd_per_1m, effective_at, created_at
+		FROM model_price_history
+		WHERE model = $1
+		ORDER BY effective_at ASC, created_at ASC
+	`, key)
+	if err != nil {
+		return nil, fmt.Errorf("billing postgres: list price history: %w", err)
+	}
+	defer rows.Close()
+	var out []PriceHistoryEntry
+	for rows.Next() {
+		var p, c, cached, effectiveAt, createdAt int64
+		if err := rows.Scan(&p, &c, &cached, &effectiveAt, &createdAt); err != nil {
+			return nil, fmt.Errorf("billing postgres: scan price history: %w", err)
+		}
+		out = append(out, PriceHistoryEntry{
+			Model:              key,
+			PromptUSDPer1M:     microUSDPer1MToUSDPer1M(p),
+			CompletionUSDPer1M: microUSDPer1MToUSDPer1M(c),
+			CachedUSDPer1M:     microUSDPer1MToUSDPer1M(cached),
+			EffectiveAt:        effectiveAt,
+			CreatedAt:          createdAt,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("billing postgres: price history rows: %w", err)
+	}
+	return out, nil
+}
+
+func (s *PostgresStore) ListModelPrices(ctx context.Context) ([]ModelPrice, error) {
+	saved := map[string]ModelPrice{}
+	catalog := map[string]ModelPrice{}
+	if s != nil && s.db != nil {
+		rows, err := s.db.QueryContext(ctx, `
+			SELECT h.model, h.prompt_micro_usd_per_1m, h.completion_micro_usd_per_1m, h.cached_micro_usd_per_1m, h.effective_at
+			FROM model_price_history h
+			INNER JOIN (
+				SELECT model, MAX(effective_at) AS effective_at FROM model_price_history GROUP BY model
+			) latest ON latest.model = h.model AND latest.effective_at = h.effective_at
+			ORDER BY h.model ASC
+		`)
+		if err != nil {
+			return nil, fmt.Errorf("billing postgres: list model prices: %w", err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var model string
+			var p, c, cached, effectiveAt int64
+			if err := rows.Scan(&model, &p, &c, &cached, &effectiveAt); err != nil {
+				return nil, fmt.Errorf("billing postgres: scan model price: %w", err)
+			}
+			saved[model] = ModelPrice{
+				Model:              model,
+				PromptUSDPer1M:     microUSDPer1MToUSDPer1M(p),
+				CompletionUSDPer1M: microUSDPer1MToUSDPer1M(c),
+				CachedUSDPer1M:     microUSDPer1MToUSDPer1M(cached),
+				Source:             "saved",
+				UpdatedAt:          effectiveAt,
+			}
+		}
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("billing postgres: list model prices rows: %w", err)
+		}
+
+		catalogRows, err := s.db.QueryContext(ctx, `
+			SELECT model, prompt_micro_usd_per_1m, completion_micro_usd_per_1m, cached_micro_usd_per_1m, updated_at
+			FROM model_prices_catalog
+		`)
+		if err != nil {
+			return nil, fmt.Errorf("billing postgres: list catalog prices: %w", err)
+		}
+		defer catalogRows.Close()
+		for catalogRows.Next() {
+			var model string
+			var p, c, cached, updatedAt int64
+			if err := catalogRows.Scan(&model, &p, &c, &cached, &updatedAt); err != nil {
+				return nil, fmt.Errorf("billing postgres: scan catalog price: %w", err)
+			}
+			catalog[model] = ModelPrice{
+				Model:              model,
+				PromptUSDPer1M:     microUSDPer1MToUSDPer1M(p),
+				CompletionUSDPer1M: microUSDPer1MToUSDPer1M(c),
+				CachedUSDPer1M:     microUSDPer1MToUSDPer1M(cached),
+				Source:             "catalog",
+				UpdatedAt:          updatedAt,
+			}
+		}
+		if err := catalogRows.Err(); err != nil {
+			return nil, fmt.Errorf("billing postgres: list catalog prices rows: %w", err)
+		}
+	}
+
+	merged := make(map[string]ModelPrice, len(DefaultPrices)+len(catalog)+len(saved))
+	for k, v := range DefaultPrices {
+		merged[k] = ModelPrice{
+			Model:              k,
+			PromptUSDPer1M:     microUSDPer1MToUSDPer1M(v.Prompt),
+			CompletionUSDPer1M: microUSDPer1MToUSDPer1M(v.Completion),
+			CachedUSDPer1M:     microUSDPer1MToUSDPer1M(v.Cached),
+			Source:             "default",
+		}
+	}
+	for k, v := range catalog {
+		merged[k] = v
+	}
+	for k, v := range saved {
+		merged[k] = v
+	}
+	out := make([]ModelPrice, 0, len(merged))
+	for _, v := range merged {
+		out = append(out, v)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Model < out[j].Model })
+	return out, nil
+}
+
+// ListModelPricesAt is ListModelPrices pinned to atUnix. See
+// Store.ListModelPricesAt.
+func (s *PostgresStore) ListModelPricesAt(ctx context.Context, atUnix int64) ([]ModelPrice, error) {
+	saved := map[string]ModelPrice{}
+	catalog := map[string]ModelPrice{}
+	if s != nil && s.db != nil {
+		rows, err := s.db.QueryContext(ctx, `
+			SELECT h.model, h.prompt_micro_usd_per_1m, h.completion_micro_usd_per_1m, h.cached_micro_usd_per_1m, h.effective_at
+			FROM model_price_history h
+			INNER JOIN (
+				SELECT model, MAX(effective_at) AS effective_at FROM model_price_history WHERE effective_at <= $1 GROUP BY model
+			) latest ON latest.model = h.model AND latest.effective_at = h.effective_at
+			WHERE h.effective_at <= $2
+			ORDER BY h.model ASC
+		`, atUnix, atUnix)
+		if err != nil {
+			return nil, fmt.Errorf("billing postgres: list model prices at: %w", err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var model string
+			var p, c, cached, effectiveAt int64
+			if err := rows.Scan(&model, &p, &c, &cached, &effectiveAt); err != nil {
+				return nil, fmt.Errorf("billing postgres: scan model price at: %w", err)
+			}
+			saved[model] = ModelPrice{
+				Model:              model,
+				PromptUSDPer1M:     microUSDPer1MToUSDPer1M(p),
+				CompletionUSDPer1M: microUSDPer1MToUSDPer1M(c),
+				CachedUSDPer1M:     microUSDPer1MToUSDPer1M(cached),
+				Source:             "saved",
+				UpdatedAt:          effectiveAt,
+			}
+		}
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("billing postgres: list model prices at rows: %w", err)
+		}
+
+		catalogRows, err := s.db.QueryContext(ctx, `
+			SELECT model, prompt_micro_usd_per_1m, completion_micro_usd_per_1m, cached_micro_usd_per_1m, updated_at
+			FROM model_prices_catalog
+		`)
+		if err != nil {
+			return nil, fmt.Errorf("billing postgres: list catalog prices: %w", err)
+		}
+		defer catalogRows.Close()
+		for catalogRows.Next() {
+			var model string
+			var p, c, cached, updatedAt int64
+			if err := catalogRows.Scan(&model, &p, &c, &cached, &updatedAt); err != nil {
+				return nil, fmt.Errorf("billing postgres: scan catalog price: %w", err)
+			}
+			catalog[model] = ModelPrice{
+				Model:              model,
+				PromptUSDPer1M:     microUSDPer1MToUSDPer1M(p),
+				CompletionUSDPer1M: microUSDPer1MToUSDPer1M(c),
+				CachedUSDPer1M:     microUSDPer1MToUSDPer1M(cached),
+				Source:             "catalog",
+				UpdatedAt:          updatedAt,
+			}
+		}
+		if err := catalogRows.Err(); err != nil {
+			return nil, fmt.Errorf("billing postgres: list catalog prices rows: %w", err)
+		}
+	}
+
+	merged := make(map[string]ModelPrice, len(DefaultPrices)+len(catalog)+len(saved))
+	for k, v := range DefaultPrices {
+		merged[k] = ModelPrice{
+			Model:              k,
+			PromptUSDPer1M:     microUSDPer1MToUSDPer1M(v.Prompt),
+			CompletionUSDPer1M: microUSDPer1MToUSDPer1M(v.Completion),
+			CachedUSDPer1M:     microUSDPer1MToUSDPer1M(v.Cached),
+			Source:             "default",
+		}
+	}
+	for k, v := range catalog {
+		merged[k] = v
+	}
+	for k, v := range saved {
+		merged[k] = v
+	}
+	out := make([]ModelPrice, 0, len(merged))
+	for _, v := range merged {
+		out = append(out, v)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Model < out[j].Model })
+	return out, nil
+}
+
+// ListPriceAudit returns every recorded price audit entry, oldest to
+// newest. See Store.ListPriceAudit.
+func (s *PostgresStore) ListPriceAudit(ctx context.Context) ([]PriceAuditEntry, error) {
+	if s == nil || s.db == nil {
+		return nil, fmt.Errorf("billing postgres: not initialized")
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, model, action, actor, reason,
+			old_prompt_micro_usd_per_1m, old_completion_micro_usd_per_1m, old_cached_micro_usd_per_1m,
+			new_prompt_micro_usd_per_1m, new_completion_micro_usd_per_1m, new_cached_micro_usd_per_1m,
+			effective_from, created_at
+		FROM price_audit
+		ORDER BY created_at ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("billing postgres: list price audit: %w", err)
+	}
+	defer rows.Close()
+	var out []PriceAuditEntry
+	for rows.Next() {
+		var e PriceAuditEntry
+		var oldPrompt, oldCompletion, oldCached, newPrompt, newCompletion, newCached sql.NullInt64
+		if err := rows.Scan(&e.ID, &e.Model, &e.Action, &e.Actor, &e.Reason,
+			&oldPrompt, &oldCompletion, &oldCached,
+			&newPrompt, &newCompletion, &newCached,
+			&e.EffectiveFrom, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("billing postgres: scan price audit: %w", err)
+		}
+		if oldPrompt.Valid {
+			e.OldPrice = &PriceMicroUSDPer1M{Prompt: oldPrompt.Int64, Completion: oldCompletion.Int64, Cached: oldCached.Int64}
+		}
+		if newPrompt.Valid {
+			e.NewPrice = &PriceMicroUSDPer1M{Prompt: newPrompt.Int64, Completion: newCompletion.Int64, Cached: newCached.Int64}
+		}
+		out = append(out, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("billing postgres: price audit rows: %w", err)
+	}
+	return out, nil
+}
+
+func (s *PostgresStore) AddUsage(ctx context.Context, apiKey, model, dayKey string, delta DailyUsageRow) error {
+	if s == nil || s.db == nil {
+		return fmt.Errorf("billing postgres: not initialized")
+	}
+	apiKey = strings.TrimSpace(apiKey)
+	modelKey := policy.NormaliseModelKey(model)
+	dayKey = strings.TrimSpace(dayKey)
+	if apiKey == "" || modelKey == "" || dayKey == "" {
+		return fmt.Errorf("billing postgres: invalid inputs")
+	}
+	if delta.Requests < 0 || delta.FailedRequests < 0 {
+		return fmt.Errorf("billing postgres: invalid request deltas")
+	}
+
+	now := nowUnixUTC()
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO api_key_model_daily_usage (
+			api_key, model, day,
+			requests, failed_requests,
+			input_tokens, output_tokens, reasoning_tokens, cached_tokens, total_tokens,
+			cost_micro_usd, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (api_key, model, day) DO UPDATE SET
+			requests = api_key_model_daily_usage.requests + excluded.requests,
+			failed_requests = api_key_model_daily_usage.failed_requests + excluded.failed_requests,
+			input_tokens = api_key_model_daily_usage.input_tokens + excluded.input_tokens,
+			output_tokens = api_key_model_daily_usage.output_tokens + excluded.output_tokens,
+			reasoning_tokens = api_key_model_daily_usage.reasoning_tokens + excluded.reasoning_tokens,
+			cached_tokens = api_key_model_daily_usage.cached_tokens + excluded.cached_tokens,
+			total_tokens = api_key_model_daily_usage.total_tokens + excluded.total_tokens,
+			cost_micro_usd = api_key_model_daily_usage.cost_micro_usd + excluded.cost_micro_usd,
+			updated_at = excluded.updated_at
+	`, apiKey, modelKey, dayKey,
+		max64(0, delta.Requests), max64(0, delta.FailedRequests),
+		max64(0, delta.InputTokens), max64(0, delta.OutputTokens), max64(0, delta.ReasoningTokens), max64(0, delta.CachedTokens), max64(0, delta.TotalTokens),
+		max64(0, delta.CostMicroUSD), now,
+	)
+	if err != nil {
+		return fmt.Errorf("billing postgres: add usage: %w", err)
+	}
+	return nil
+}
+
+// GetDailyCostMicroUSD returns committed usage cost plus any outstanding
+// budget reservations for (apiKey, dayKey), so an in-flight streaming
+// request that has reserved budget but not yet committed its actual usage
+// still counts against the daily cap.
+func (s *PostgresStore) GetDailyCostMicroUSD(ctx context.Context, apiKey, dayKey string) (int64, error) {
+	if s == nil || s.db == nil {
+		return 0, fmt.Errorf("billing postgres: not initialized")
+	}
+	apiKey = strings.TrimSpace(apiKey)
+	dayKey = strings.TrimSpace(dayKey)
+	if apiKey == "" || dayKey == "" {
+		return 0, fmt.Errorf("billing postgres: invalid inputs")
+	}
+	row := s.db.QueryRowContext(ctx, `
+		SELECT
+			COALESCE((SELECT SUM(cost_micro_usd) FROM api_key_model_daily_usage WHERE api_key = $1 AND day = $2), 0) +
+			COALESCE((SELECT SUM(estimated_micro_usd) FROM budget_reservations WHERE api_key = $1 AND day = $2), 0)
+	`, apiKey, dayKey)
+	var total int64
+	if err := row.Scan(&total); err != nil {
+		return 0, fmt.Errorf("billing postgres: daily cost: %w", err)
+	}
+	return total, nil
+}
+
+// ReserveBudget records a pending spend estimate for (apiKey, dayKey). See
+// Store.ReserveBudget.
+func (s *PostgresStore) ReserveBudget(ctx context.Context, apiKey, dayKey string, estimatedMicroUSD int64) (reservationID string, err error) {
+	if s == nil || s.db == nil {
+		return "", fmt.Errorf("billing postgres: not initialized")
+	}
+	apiKey = strings.TrimSpace(apiKey)
+	dayKey = strings.TrimSpace(dayKey)
+	if apiKey == "" || dayKey == "" {
+		return "", fmt.Errorf("billing postgres: invalid inputs")
+	}
+	if estimatedMicroUSD < 0 {
+		estimatedMicroUSD = 0
+	}
+	reservationID = uuid.NewString()
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO budget_reservations (id, api_key, day, estimated_micro_usd, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, reservationID, apiKey, dayKey, estimatedMicroUSD, nowUnixUTC())
+	if err != nil {
+		return "", fmt.Errorf("billing postgres: reserve budget: %w", err)
+	}
+	return reservationID, nil
+}
+
+// CommitReservation clears the reservation and records the actual usage. See
+// Store.CommitReservation.
+func (s *PostgresStore) CommitReservation(ctx context.Context, reservationID, apiKey, model, dayKey string, actual DailyUsageRow) error {
+	if s == nil || s.db == nil {
+		return fmt.Errorf("billing postgres: not initialized")
+	}
+	if strings.TrimSpace(reservationID) == "" {
+		return fmt.Errorf("billing postgres: reservation id is required")
+	}
+	if err := s.AddUsage(ctx, apiKey, model, dayKey, actual); err != nil {
+		return err
+	}
+	return s.deleteReservation(ctx, reservationID)
+}
+
+// ReleaseReservation drops a reservation without recording any usage. See
+// Store.ReleaseReservation.
+func (s *PostgresStore) ReleaseReservation(ctx context.Context, reservationID string) error {
+	if strings.TrimSpace(reservationID) == "" {
+		return fmt.Errorf("billing postgres: reservation id is required")
+	}
+	return s.deleteReservation(ctx, reservationID)
+}
+
+func (s *PostgresStore) deleteReservation(ctx context.Context, reservationID string) error {
+	if s == nil || s.db == nil {
+		return fmt.Errorf("billing postgres: not initialized")
+	}
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM budget_reservations WHERE id = $1`, reservationID); err != nil {
+		return fmt.Errorf("billing postgres: clear reservation: %w", err)
+	}
+	return nil
+}
+
+// UpsertBudget creates or replaces a configured budget. See Store.UpsertBudget.
+func (s *PostgresStore) UpsertBudget(ctx context.Context, b Budget) (Budget, error) {
+	if s == nil || s.db == nil {
+		return Budget{}, fmt.Errorf("billing postgres: not initialized")
+	}
+	if strings.TrimSpace(b.Scope) == "" {
+		return Budget{}, fmt.Errorf("billing postgres: scope is required")
+	}
+	if strings.TrimSpace(b.Period) == "" {
+		return Budget{}, fmt.Errorf("billing postgres: period is required")
+	}
+	if strings.TrimSpace(b.Action) == "" {
+		return Budget{}, fmt.Errorf("billing postgres: action is required")
+	}
+	now := nowUnixUTC()
+	if strings.TrimSpace(b.ID) == "" {
+		b.ID = uuid.NewString()
+		b.CreatedAt = now
+	} else {
+		var existingCreatedAt int64
+		err := s.db.QueryRowContext(ctx, `SELECT created_at FROM budgets WHERE id = $1`, b.ID).Scan(&existingCreatedAt)
+		switch {
+		case err == nil:
+			b.CreatedAt = existingCreatedAt
+		case errors.Is(err, sql.ErrNoRows):
+			b.CreatedAt = now
+		default:
+			return Budget{}, fmt.Errorf("billing postgres: lookup budget: %w", err)
+		}
+	}
+	b.UpdatedAt = now
+
+	row := s.db.QueryRowContext(ctx, `
+		INSERT INTO budgets (id, scope, api_key, model, period, limit_usd, action, soft_threshold_pct, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT(id) DO UPDATE SET
+			scope = excluded.scope,
+			api_key = excluded.api_key,
+			model = excluded.model,
+			period = excluded.period,
+			limit_usd = excluded.limit_usd,
+			action = excluded.action,
+			soft_threshold_pct = excluded.soft_threshold_pct,
+			updated_at = excluded.updated_at
+		RETURNING created_at
+	`, b.ID, b.Scope, b.APIKey, b.Model, b.Period, b.LimitUSD, b.Action, b.SoftThresholdPct, b.CreatedAt, b.UpdatedAt)
+	if err := row.Scan(&b.CreatedAt); err != nil {
+		return Budget{}, fmt.Errorf("billing postgres: upsert budget: %w", err)
+	}
+	return b, nil
+}
+
+// DeleteBudget removes a configured budget and its spend history. See
+// Store.DeleteBudget.
+func (s *PostgresStore) DeleteBudget(ctx context.Context, id string) (bool, error) {
+	if s == nil || s.db == nil {
+		return false, fmt.Errorf("billing postgres: not initialized")
+	}
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return false, fmt.Errorf("billing postgres: id is required")
+	}
+	res, err := s.db.ExecContext(ctx, `DELETE FROM budgets WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("billing postgres: delete budget: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	if n == 0 {
+		return false, nil
+	}
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM budget_spend WHERE budget_id = $1`, id); err != nil {
+		return false, fmt.Errorf("billing postgres: clear budget spend: %w", err)
+	}
+	return true, nil
+}
+
+// ListBudgets returns every configured budget. See Store.ListBudgets.
+func (s *PostgresStore) ListBudgets(ctx context.Context) ([]Budget, error) {
+	if s == nil || s.db == nil {
+		return nil, fmt.Errorf("billing postgres: not initialized")
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, scope, api_key, model, period, limit_usd, action, soft_threshold_pct, created_at, updated_at
+		FROM budgets
+		ORDER BY created_at ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("billing postgres: list budgets: %w", err)
+	}
+	defer rows.Close()
+	var out []Budget
+	for rows.Next() {
+		var b Budget
+		if err := rows.Scan(&b.ID, &b.Scope, &b.APIKey, &b.Model, &b.Period, &b.LimitUSD, &b.Action, &b.SoftThresholdPct, &b.CreatedAt, &b.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("billing postgres: scan budget: %w", err)
+		}
+		out = append(out, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("billing postgres: budgets rows: %w", err)
+	}
+	return out, nil
+}
+
+// ChargeBudgetSpend adds deltaMicroUSD to budget id's running spend for
+// periodKey. See Store.ChargeBudgetSpend.
+func (s *PostgresStore) ChargeBudgetSpend(ctx context.Context, id, periodKey string, deltaMicroUSD int64) (int64, error) {
+	if s == nil || s.db == nil {
+		return 0, fmt.Errorf("billing postgres: not initialized")
+	}
+	id = strings.TrimSpace(id)
+	periodKey = strings.TrimSpace(periodKey)
+	if id == "" || periodKey == "" {
+		return 0, fmt.Errorf("billing postgres: id and period_key are required")
+	}
+	if deltaMicroUSD < 0 {
+		deltaMicroUSD = 0
+	}
+	row := s.db.QueryRowContext(ctx, `
+		INSERT INTO budget_spend (budget_id, period_key, spent_micro_usd, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT(budget_id, period_key) DO UPDATE SET
+			spent_micro_usd = budget_spend.spent_micro_usd + excluded.spent_micro_usd,
+			updated_at = excluded.updated_at
+		RETURNING spent_micro_usd
+	`, id, periodKey, deltaMicroUSD, nowUnixUTC())
+	var spent int64
+	if err := row.Scan(&spent); err != nil {
+		return 0, fmt.Errorf("billing postgres: charge budget spend: %w", err)
+	}
+	return spent, nil
+}
+
+// GetBudgetSpend returns budget id's running spend for periodKey. See
+// Store.GetBudgetSpend.
+func (s *PostgresStore) GetBudgetSpend(ctx context.Context, id, periodKey string) (int64, error) {
+	if s == nil || s.db == nil {
+		return 0, fmt.Errorf("billing postgres: not initialized")
+	}
+	id = strings.TrimSpace(id)
+	periodKey = strings.TrimSpace(periodKey)
+	if id == "" || periodKey == "" {
+		return 0, fmt.Errorf("billing postgres: id and period_key are required")
+	}
+	var spent int64
+	row := s.db.QueryRowContext(ctx, `SELECT spent_micro_usd FROM budget_spend WHERE budget_id = $1 AND period_key = $2`, id, periodKey)
+	if err := row.Scan(&spent); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("billing postgres: get budget spend: %w", err)
+	}
+	return spent, nil
+}
+
+// PruneBudgetSpend deletes budget_spend rows last updated before
+// olderThanUnix. See Store.PruneBudgetSpend.
+func (s *PostgresStore) PruneBudgetSpend(ctx context.Context, olderThanUnix int64) (int64, error) {
+	if s == nil || s.db == nil {
+		return 0, fmt.Errorf("billing postgres: not initialized")
+	}
+	res, err := s.db.ExecContext(ctx, `DELETE FROM budget_spend WHERE updated_at < $1`, olderThanUnix)
+	if err != nil {
+		return 0, fmt.Errorf("billing postgres: prune budget spend: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("billing postgres: prune budget spend: %w", err)
+	}
+	return n, nil
+}
+
+// ListUsageRows returns apiKey's per-model usage rows with day in
+// [fromDay, toDay]. See Store.ListUsageRows.
+func (s *PostgresStore) ListUsageRows(ctx context.Context, apiKey, fromDay, toDay string) ([]DailyUsageRow, error) {
+	if s == nil || s.db == nil {
+		return nil, fmt.Errorf("billing postgres: not initialized")
+	}
+	apiKey = strings.TrimSpace(apiKey)
+	fromDay = strings.TrimSpace(fromDay)
+	toDay = strings.TrimSpace(toDay)
+	if apiKey == "" || fromDay == "" || toDay == "" {
+		return nil, fmt.Errorf("billing postgres: invalid inputs")
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT
+			api_key, model, day,
+			requests, failed_requests,
+			input_tokens, output_tokens, reasoning_tokens, cached_tokens, total_tokens,
+			cost_micro_usd, updated_at
+		FROM api_key_model_daily_usage
+		WHERE api_key = $1 AND day >= $2 AND day <= $3
+		ORDER BY day ASC, model ASC
+	`, apiKey, fromDay, toDay)
+	if err != nil {
+		return nil, fmt.Errorf("billing postgres: list usage rows: %w", err)
+	}
+	defer rows.Close()
+	var out []DailyUsageRow
+	for rows.Next() {
+		var row DailyUsageRow
+		if err := rows.Scan(
+			&row.APIKey, &row.Model, &row.Day,
+			&row.Requests, &row.FailedRequests,
+			&row.InputTokens, &row.OutputTokens, &row.ReasoningTokens, &row.CachedTokens, &row.TotalTokens,
+			&row.CostMicroUSD, &row.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("billing postgres: scan usage row: %w", err)
+		}
+		out = append(out, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("billing postgres: usage rows: %w", err)
+	}
+	return out, nil
+}
+
+// ListUsageRowsAllKeys returns every API key's per-model usage rows with day
+// in [fromDay, toDay]. See Store.ListUsageRowsAllKeys.
+func (s *PostgresStore) ListUsageRowsAllKeys(ctx context.Context, fromDay, toDay string) ([]DailyUsageRow, error) {
+	if s == nil || s.db == nil {
+		return nil, fmt.Errorf("billing postgres: not initialized")
+	}
+	fromDay = strings.TrimSpace(fromDay)
+	toDay = strings.TrimSpace(toDay)
+	if fromDay == "" || toDay == "" {
+		return nil, fmt.Errorf("billing postgres: invalid inputs")
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT
+			api_key, model, day,
+			requests, failed_requests,
+			input_tokens, output_tokens, reasoning_tokens, cached_tokens, total_tokens,
+			cost_micro_usd, updated_at
+		FROM api_key_model_daily_usage
+		WHERE day >= $1 AND day <= $2
+		ORDER BY day ASC, api_key ASC, model ASC
+	`, fromDay, toDay)
+	if err != nil {
+		return nil, fmt.Errorf("billing postgres: list usage rows: %w", err)
+	}
+	defer rows.Close()
+	var out []DailyUsageRow
+	for rows.Next() {
+		var row DailyUsageRow
+		if err := rows.Scan(
+			&row.APIKey, &row.Model, &row.Day,
+			&row.Requests, &row.FailedRequests,
+			&row.InputTokens, &row.OutputTokens, &row.ReasoningTokens, &row.CachedTokens, &row.TotalTokens,
+			&row.CostMicroUSD, &row.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("billing postgres: scan usage row: %w", err)
+		}
+		out = append(out, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("billing postgres: usage rows: %w", err)
+	}
+	return out, nil
+}
+
+// SetUsageCostMicro overwrites the stored cost for one usage row. See
+// Store.SetUsageCostMicro.
+func (s *PostgresStore) SetUsageCostMicro(ctx context.Context, apiKey, model, dayKey string, costMicro int64) error {
+	if s == nil || s.db == nil {
+		return fmt.Errorf("billing postgres: not initialized")
+	}
+	apiKey = strings.TrimSpace(apiKey)
+	modelKey := policy.NormaliseModelKey(model)
+	dayKey = strings.TrimSpace(dayKey)
+	if apiKey == "" || modelKey == "" || dayKey == "" {
+		return fmt.Errorf("billing postgres: invalid inputs")
+	}
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE api_key_model_daily_usage
+		SET cost_micro_usd = $1, updated_at = $2
+		WHERE api_key = $3 AND model = $4 AND day = $5
+	`, max64(0, costMicro), nowUnixUTC(), apiKey, modelKey, dayKey)
+	if err != nil {
+		return fmt.Errorf("billing postgres: set usage cost: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) GetDailyUsageReport(ctx context.Context, apiKey, dayKey string) (DailyUsageReport, error) {
+	report := DailyUsageReport{
+		APIKey:          strings.TrimSpace(apiKey),
+		Day:             strings.TrimSpace(dayKey),
+		GeneratedAtUnix: nowUnixUTC(),
+	}
+	if report.APIKey == "" || report.Day == "" {
+		return report, fmt.Errorf("billing postgres: api_key and day are required")
+	}
+	if s == nil || s.db == nil {
+		return report, fmt.Errorf("billing postgres: not initialized")
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT
+			api_key, model, day,
+			requests, failed_requests,
+			input_tokens, output_tokens, reasoning_tokens, cached_tokens, total_tokens,
+			cost_micro_usd, updated_at
+		FROM api_key_model_daily_usage
+		WHERE api_key = $1 AND day = $2
+		ORDER BY model ASC
+	`, report.APIKey, report.Day)
+	if err != nil {
+		return report, fmt.Errorf("billing postgres: query daily usage: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row DailyUsageRow
+		if err := rows.Scan(
+			&row.APIKey, &row.Model, &row.Day,
+			&row.Requests, &row.FailedRequests,
+			&row.InputTokens, &row.OutputTokens, &row.ReasoningTokens, &row.CachedTokens, &row.TotalTokens,
+			&row.CostMicroUSD, &row.UpdatedAt,
+		); err != nil {
+			return report, fmt.Errorf("billing postgres: scan daily usage: %w", err)
+		}
+		report.TotalCostMicro += row.CostMicroUSD
+		report.TotalRequests += row.Requests
+		report.TotalFailed += row.FailedRequests
+		report.TotalTokens += row.TotalTokens
+		report.Models = append(report.Models, row)
+	}
+	if err := rows.Err(); err != nil {
+		return report, fmt.Errorf("billing postgres: daily usage rows: %w", err)
+	}
+	report.TotalCostUSD = microUSDToUSD(report.TotalCostMicro)
+	return report, nil
+}
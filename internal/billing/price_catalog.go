@@ -0,0 +1,233 @@
+package billing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/policy"
+)
+
+// PriceTier is one effective-dated pricing row for a (provider, model)
+// pair. Unlike the flat, single-rate PriceMicroUSDPer1M used by
+// Store.ResolvePriceMicro, a PriceTier additionally scopes by context
+// window size (ContextTierMaxInputTokens) and request kind, so a provider
+// whose rate jumps past e.g. 200k input tokens, or differs for batch
+// requests, can be represented precisely.
+type PriceTier struct {
+	Provider string `yaml:"provider"`
+	Model    string `yaml:"model"`
+
+	// EffectiveFrom/EffectiveTo bound when this tier applies, as a
+	// half-open interval [EffectiveFrom, EffectiveTo). A zero EffectiveTo
+	// means the tier has no scheduled end.
+	EffectiveFrom time.Time `yaml:"effective_from"`
+	EffectiveTo   time.Time `yaml:"effective_to"`
+
+	// ContextTierMaxInputTokens is the upper (inclusive) bound on prompt
+	// input tokens this tier covers; 0 means "no upper bound", i.e. the
+	// catch-all tier for requests past every other tier's bound.
+	ContextTierMaxInputTokens int64 `yaml:"context_tier_max_input_tokens"`
+
+	// RequestKind scopes the tier to a request shape (e.g. "batch"); ""
+	// matches any kind.
+	RequestKind string `yaml:"request_kind"`
+
+	PromptMicro      int64 `yaml:"-"`
+	CachedReadMicro  int64 `yaml:"-"`
+	CachedWriteMicro int64 `yaml:"-"`
+	CompletionMicro  int64 `yaml:"-"`
+	ReasoningMicro   int64 `yaml:"-"`
+
+	PromptUSDPer1M      float64 `yaml:"prompt_usd_per_1m"`
+	CachedReadUSDPer1M  float64 `yaml:"cached_read_usd_per_1m"`
+	CachedWriteUSDPer1M float64 `yaml:"cached_write_usd_per_1m"`
+	CompletionUSDPer1M  float64 `yaml:"completion_usd_per_1m"`
+	ReasoningUSDPer1M   float64 `yaml:"reasoning_usd_per_1m"`
+}
+
+// resolveMicroFields converts the YAML-facing USD/1M fields to the
+// micro-USD/1M fields CostMicroUSD actually prices against.
+func (t *PriceTier) resolveMicroFields() {
+	t.PromptMicro = usdPer1MToMicroUSDPer1M(t.PromptUSDPer1M)
+	t.CachedReadMicro = usdPer1MToMicroUSDPer1M(t.CachedReadUSDPer1M)
+	t.CachedWriteMicro = usdPer1MToMicroUSDPer1M(t.CachedWriteUSDPer1M)
+	t.CompletionMicro = usdPer1MToMicroUSDPer1M(t.CompletionUSDPer1M)
+	t.ReasoningMicro = usdPer1MToMicroUSDPer1M(t.ReasoningUSDPer1M)
+}
+
+func (t PriceTier) coversTime(atUnix int64) bool {
+	if !t.EffectiveFrom.IsZero() && atUnix < t.EffectiveFrom.Unix() {
+		return false
+	}
+	if !t.EffectiveTo.IsZero() && atUnix >= t.EffectiveTo.Unix() {
+		return false
+	}
+	return true
+}
+
+func (t PriceTier) coversInputTokens(inputTokens int64) bool {
+	return t.ContextTierMaxInputTokens <= 0 || inputTokens <= t.ContextTierMaxInputTokens
+}
+
+// CostMicroUSD prices one usage row's token counts at tier. Cached tokens
+// are priced as cache reads, since Record.Detail does not currently
+// distinguish cache-write from cache-read tokens; CachedWriteMicro is
+// exposed on PriceTier for a future Detail field to use, but unused here.
+func (t PriceTier) CostMicroUSD(inputTokens, outputTokens, reasoningTokens, cachedTokens int64) int64 {
+	promptTokens := inputTokens - cachedTokens
+	if promptTokens < 0 {
+		promptTokens = 0
+	}
+	cost := costMicroUSD(promptTokens, t.PromptMicro)
+	cost += costMicroUSD(cachedTokens, t.CachedReadMicro)
+	cost += costMicroUSD(outputTokens, t.CompletionMicro)
+	cost += costMicroUSD(reasoningTokens, t.ReasoningMicro)
+	return cost
+}
+
+// PriceCatalog resolves the PriceTier that applies to one usage record. It
+// is unrelated to PriceCatalogSyncer (catalog_sync.go), which instead
+// fetches a flat, single-rate-per-model price list from a remote source
+// into the Store; PriceCatalog is local, tiered, and effective-dated, for
+// operators who need more than one rate per model.
+type PriceCatalog interface {
+	// Resolve returns the tier covering provider/model at atUnix for a
+	// request with the given input token count and request kind, or
+	// ok=false if no tier matches.
+	Resolve(ctx context.Context, provider, model string, atUnix int64, inputTokens int64, requestKind string) (tier PriceTier, ok bool)
+}
+
+var _ PriceCatalog = (*FilePriceCatalog)(nil)
+
+// FilePriceCatalog is a PriceCatalog backed by a YAML document of
+// PriceTier entries, re-read whenever the file's mtime advances past the
+// last successful load (checked on every Resolve call, so an operator's
+// edit takes effect on the next priced request with no restart).
+type FilePriceCatalog struct {
+	path string
+
+	mu        sync.Mutex
+	lastModAt time.Time
+	tiers     []PriceTier
+	loadErr   error
+}
+
+// NewFilePriceCatalog returns a FilePriceCatalog backed by the YAML
+// document at path. The file is loaded lazily, on the first Resolve call.
+func NewFilePriceCatalog(path string) *FilePriceCatalog {
+	return &FilePriceCatalog{path: path}
+}
+
+// Reload forces a re-read of path regardless of mtime, returning the first
+// load error (if any) without discarding the previously loaded tiers.
+func (c *FilePriceCatalog) Reload(ctx context.Context) error {
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return fmt.Errorf("price catalog: read %s: %w", c.path, err)
+	}
+	var tiers []PriceTier
+	if err := yaml.Unmarshal(data, &tiers); err != nil {
+		return fmt.Errorf("price catalog: parse %s: %w", c.path, err)
+	}
+	for i := range tiers {
+		tiers[i].resolveMicroFields()
+	}
+
+	info, err := os.Stat(c.path)
+	if err != nil {
+		return fmt.Errorf("price catalog: stat %s: %w", c.path, err)
+	}
+
+	c.mu.Lock()
+	c.tiers = tiers
+	c.lastModAt = info.ModTime()
+	c.loadErr = nil
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *FilePriceCatalog) maybeReload() {
+	info, err := os.Stat(c.path)
+	if err != nil {
+		c.mu.Lock()
+		c.loadErr = err
+		c.mu.Unlock()
+		return
+	}
+	c.mu.Lock()
+	unchanged := c.tiers != nil && !info.ModTime().After(c.lastModAt)
+	c.mu.Unlock()
+	if unchanged {
+		return
+	}
+	_ = c.Reload(context.Background())
+}
+
+// Tiers returns a snapshot of every loaded tier, for GET /admin/pricing.
+func (c *FilePriceCatalog) Tiers() ([]PriceTier, error) {
+	c.maybeReload()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.loadErr != nil {
+		return nil, c.loadErr
+	}
+	out := make([]PriceTier, len(c.tiers))
+	copy(out, c.tiers)
+	return out, nil
+}
+
+func (c *FilePriceCatalog) Resolve(ctx context.Context, provider, model string, atUnix int64, inputTokens int64, requestKind string) (PriceTier, bool) {
+	c.maybeReload()
+
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	modelKey := policy.NormaliseModelKey(model)
+	requestKind = strings.ToLower(strings.TrimSpace(requestKind))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var best PriceTier
+	found := false
+	for _, tier := range c.tiers {
+		if tier.Provider != "" && strings.ToLower(tier.Provider) != provider {
+			continue
+		}
+		if !policy.MatchWildcard(strings.ToLower(tier.Model), modelKey) {
+			continue
+		}
+		if tier.RequestKind != "" && strings.ToLower(tier.RequestKind) != requestKind {
+			continue
+		}
+		if !tier.coversTime(atUnix) {
+			continue
+		}
+		if !tier.coversInputTokens(inputTokens) {
+			continue
+		}
+		// Among matching tiers, prefer the tightest (smallest) context
+		// bound so a specific low-context rate wins over a broader
+		// catch-all, then the most recently effective.
+		if !found ||
+			tighterContextBound(tier, best) ||
+			(tier.ContextTierMaxInputTokens == best.ContextTierMaxInputTokens && tier.EffectiveFrom.After(best.EffectiveFrom)) {
+			best, found = tier, true
+		}
+	}
+	return best, found
+}
+
+func tighterContextBound(a, b PriceTier) bool {
+	if a.ContextTierMaxInputTokens <= 0 {
+		return false
+	}
+	if b.ContextTierMaxInputTokens <= 0 {
+		return true
+	}
+	return a.ContextTierMaxInputTokens < b.ContextTierMaxInputTokens
+}
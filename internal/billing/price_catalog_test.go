@@ -0,0 +1,143 @@
+package billing
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeCatalogFile(t *testing.T, dir, body string) string {
+	t.Helper()
+	path := filepath.Join(dir, "prices.yaml")
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return path
+}
+
+func TestFilePriceCatalog_ResolvesTieredRateByInputTokens(t *testing.T) {
+	path := writeCatalogFile(t, t.TempDir(), `
+- provider: anthropic
+  model: "claude-*"
+  context_tier_max_input_tokens: 200000
+  prompt_usd_per_1m: 3
+  completion_usd_per_1m: 15
+- provider: anthropic
+  model: "claude-*"
+  context_tier_max_input_tokens: 0
+  prompt_usd_per_1m: 6
+  completion_usd_per_1m: 30
+`)
+	catalog := NewFilePriceCatalog(path)
+
+	tier, ok := catalog.Resolve(context.Background(), "anthropic", "claude-opus-4-5", time.Now().Unix(), 1000, "")
+	if !ok {
+		t.Fatal("expected a tier to resolve for small input")
+	}
+	if tier.PromptMicro != 3_000_000 {
+		t.Fatalf("PromptMicro=%d, want 3_000_000 (low-context tier)", tier.PromptMicro)
+	}
+
+	tier, ok = catalog.Resolve(context.Background(), "anthropic", "claude-opus-4-5", time.Now().Unix(), 300_000, "")
+	if !ok {
+		t.Fatal("expected the catch-all tier to resolve for large input")
+	}
+	if tier.PromptMicro != 6_000_000 {
+		t.Fatalf("PromptMicro=%d, want 6_000_000 (catch-all tier)", tier.PromptMicro)
+	}
+}
+
+func TestFilePriceCatalog_RespectsEffectiveDateWindow(t *testing.T) {
+	path := writeCatalogFile(t, t.TempDir(), `
+- provider: openai
+  model: gpt-5-high
+  effective_from: 2025-01-01T00:00:00Z
+  effective_to: 2026-01-01T00:00:00Z
+  prompt_usd_per_1m: 2
+- provider: openai
+  model: gpt-5-high
+  effective_from: 2026-01-01T00:00:00Z
+  prompt_usd_per_1m: 4
+`)
+	catalog := NewFilePriceCatalog(path)
+
+	before := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC).Unix()
+	tier, ok := catalog.Resolve(context.Background(), "openai", "gpt-5-high", before, 100, "")
+	if !ok || tier.PromptMicro != 2_000_000 {
+		t.Fatalf("tier=%+v ok=%v, want the 2025 rate", tier, ok)
+	}
+
+	after := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC).Unix()
+	tier, ok = catalog.Resolve(context.Background(), "openai", "gpt-5-high", after, 100, "")
+	if !ok || tier.PromptMicro != 4_000_000 {
+		t.Fatalf("tier=%+v ok=%v, want the 2026 rate", tier, ok)
+	}
+}
+
+func TestFilePriceCatalog_NoMatchReturnsFalse(t *testing.T) {
+	path := writeCatalogFile(t, t.TempDir(), `
+- provider: openai
+  model: gpt-5-high
+  prompt_usd_per_1m: 2
+`)
+	catalog := NewFilePriceCatalog(path)
+
+	if _, ok := catalog.Resolve(context.Background(), "openai", "gpt-4o", time.Now().Unix(), 100, ""); ok {
+		t.Fatal("expected no tier to match an unlisted model")
+	}
+}
+
+func TestFilePriceCatalog_HotReloadsAfterFileChanges(t *testing.T) {
+	dir := t.TempDir()
+	path := writeCatalogFile(t, dir, `
+- provider: openai
+  model: gpt-5-high
+  prompt_usd_per_1m: 2
+`)
+	catalog := NewFilePriceCatalog(path)
+
+	tier, ok := catalog.Resolve(context.Background(), "openai", "gpt-5-high", time.Now().Unix(), 100, "")
+	if !ok || tier.PromptMicro != 2_000_000 {
+		t.Fatalf("tier=%+v ok=%v, want the original rate", tier, ok)
+	}
+
+	// Ensure the mtime strictly advances even on coarse filesystem clocks.
+	future := time.Now().Add(2 * time.Second)
+	if err := os.WriteFile(path, []byte(`
+- provider: openai
+  model: gpt-5-high
+  prompt_usd_per_1m: 9
+`), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatalf("Chtimes: %v", err)
+	}
+
+	tier, ok = catalog.Resolve(context.Background(), "openai", "gpt-5-high", time.Now().Unix(), 100, "")
+	if !ok || tier.PromptMicro != 9_000_000 {
+		t.Fatalf("tier=%+v ok=%v, want the reloaded rate", tier, ok)
+	}
+}
+
+func TestPriceTier_CostMicroUSD_SplitsPromptCachedCompletionReasoning(t *testing.T) {
+	tier := PriceTier{
+		PromptUSDPer1M:     1,
+		CachedReadUSDPer1M: 0.1,
+		CompletionUSDPer1M: 2,
+		ReasoningUSDPer1M:  3,
+	}
+	tier.resolveMicroFields()
+
+	cost := tier.CostMicroUSD(1_000_000, 1_000_000, 1_000_000, 200_000)
+	// prompt: (1_000_000-200_000) tokens @ $1/1M = 800_000 micro-USD
+	// cached: 200_000 tokens @ $0.1/1M = 20_000 micro-USD
+	// completion: 1_000_000 tokens @ $2/1M = 2_000_000 micro-USD
+	// reasoning: 1_000_000 tokens @ $3/1M = 3_000_000 micro-USD
+	want := int64(800_000 + 20_000 + 2_000_000 + 3_000_000)
+	if cost != want {
+		t.Fatalf("cost=%d, want %d", cost, want)
+	}
+}
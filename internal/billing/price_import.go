@@ -0,0 +1,72 @@
+package billing
+
+import (
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/policy"
+)
+
+// priceImportRow is one PriceImportEntry that passed validation, normalised
+// and converted to micro-USD, ready to be applied.
+type priceImportRow struct {
+	model         string
+	price         PriceMicroUSDPer1M
+	effectiveFrom int64
+}
+
+// planPriceImport validates entries against current (the live ListModelPrices
+// table, keyed by normalised model), and reports what each entry would do.
+// Invalid entries are reported in the result's Errors and excluded from the
+// returned rows; callers apply only the returned rows, and only when Errors
+// is empty.
+func planPriceImport(current map[string]ModelPrice, entries []PriceImportEntry) ([]priceImportRow, PriceImportResult) {
+	var result PriceImportResult
+	var rows []priceImportRow
+	for i, e := range entries {
+		model := policy.NormaliseModelKey(e.Model)
+		if model == "" {
+			result.Errors = append(result.Errors, PriceImportRowError{Row: i, Model: e.Model, Message: "model is required"})
+			continue
+		}
+		if e.PromptUSDPer1M < 0 || e.CompletionUSDPer1M < 0 || e.CachedUSDPer1M < 0 {
+			result.Errors = append(result.Errors, PriceImportRowError{Row: i, Model: model, Message: "prompt_usd_per_1m, completion_usd_per_1m, cached_usd_per_1m must be >= 0"})
+			continue
+		}
+
+		newPrice := ModelPrice{
+			Model:              model,
+			PromptUSDPer1M:     e.PromptUSDPer1M,
+			CompletionUSDPer1M: e.CompletionUSDPer1M,
+			CachedUSDPer1M:     e.CachedUSDPer1M,
+			Source:             "saved",
+		}
+		if existing, ok := current[model]; ok {
+			if existing.PromptUSDPer1M == e.PromptUSDPer1M && existing.CompletionUSDPer1M == e.CompletionUSDPer1M && existing.CachedUSDPer1M == e.CachedUSDPer1M {
+				result.Unchanged = append(result.Unchanged, model)
+			} else {
+				result.Updated = append(result.Updated, PriceImportDiff{Model: model, Old: existing, New: newPrice})
+			}
+		} else {
+			result.Added = append(result.Added, newPrice)
+		}
+
+		rows = append(rows, priceImportRow{
+			model: model,
+			price: PriceMicroUSDPer1M{
+				Prompt:     USDPer1MToMicroUSDPer1M(e.PromptUSDPer1M),
+				Completion: USDPer1MToMicroUSDPer1M(e.CompletionUSDPer1M),
+				Cached:     USDPer1MToMicroUSDPer1M(e.CachedUSDPer1M),
+			},
+			effectiveFrom: e.EffectiveFrom,
+		})
+	}
+	return rows, result
+}
+
+// modelPricesByModel indexes prices (as returned by Store.ListModelPrices) by
+// their already-normalised Model field.
+func modelPricesByModel(prices []ModelPrice) map[string]ModelPrice {
+	out := make(map[string]ModelPrice, len(prices))
+	for _, p := range prices {
+		out[p.Model] = p
+	}
+	return out
+}
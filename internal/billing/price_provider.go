@@ -0,0 +1,397 @@
+package billing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/policy"
+)
+
+// maxSanePriceUSDPer1M bounds a single price field so a malformed upstream
+// document (e.g. a units mismatch) can't silently multiply a catalog price
+// by 1000x into the live table.
+const maxSanePriceUSDPer1M = 10_000
+
+// ErrPriceProviderNotModified is returned by PriceProvider.FetchPrices when
+// the provider's document is unchanged since the last successful fetch; the
+// refresher keeps its previously merged entries for that provider as-is.
+var ErrPriceProviderNotModified = errors.New("billing: price provider not modified")
+
+// PriceProvider supplies a catalog of model prices keyed by pattern: either
+// an exact normalised model id, or a policy.MatchWildcard pattern (e.g.
+// "claude-opus-4-*") that prices every matching variant the same way.
+type PriceProvider interface {
+	// Name identifies the provider in logs and metrics, e.g. "file:/etc/prices.json".
+	Name() string
+	// FetchPrices returns the provider's full catalog, or
+	// ErrPriceProviderNotModified if nothing has changed since the last
+	// successful fetch.
+	FetchPrices(ctx context.Context) (map[string]ModelPrice, error)
+}
+
+type priceCatalogEntry struct {
+	Model              string  `json:"model"`
+	PromptUSDPer1M     float64 `json:"prompt_usd_per_1m"`
+	CompletionUSDPer1M float64 `json:"completion_usd_per_1m"`
+	CachedUSDPer1M     float64 `json:"cached_usd_per_1m"`
+}
+
+// parsePriceCatalogDocument decodes the shared price catalog document
+// schema used by FilePriceProvider and HTTPPriceProvider:
+//
+//	{"models": [{"model": "claude-opus-4-*", "prompt_usd_per_1m": 5, "completion_usd_per_1m": 25, "cached_usd_per_1m": 0.5}]}
+func parsePriceCatalogDocument(body []byte) (map[string]ModelPrice, error) {
+	var doc struct {
+		Models []priceCatalogEntry `json:"models"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("parse price catalog document: %w", err)
+	}
+	out := make(map[string]ModelPrice, len(doc.Models))
+	for _, entry := range doc.Models {
+		pattern := strings.TrimSpace(entry.Model)
+		if pattern == "" {
+			continue
+		}
+		out[pattern] = ModelPrice{
+			Model:              pattern,
+			PromptUSDPer1M:     entry.PromptUSDPer1M,
+			CompletionUSDPer1M: entry.CompletionUSDPer1M,
+			CachedUSDPer1M:     entry.CachedUSDPer1M,
+		}
+	}
+	return out, nil
+}
+
+// validatePriceCatalog drops entries with a negative or implausibly large
+// price field, reporting which patterns were dropped so the caller can log
+// them, rather than letting a malformed document poison the live table.
+func validatePriceCatalog(prices map[string]ModelPrice) (valid map[string]ModelPrice, rejected []string) {
+	valid = make(map[string]ModelPrice, len(prices))
+	for pattern, price := range prices {
+		if price.PromptUSDPer1M < 0 || price.CompletionUSDPer1M < 0 || price.CachedUSDPer1M < 0 ||
+			price.PromptUSDPer1M > maxSanePriceUSDPer1M || price.CompletionUSDPer1M > maxSanePriceUSDPer1M || price.CachedUSDPer1M > maxSanePriceUSDPer1M {
+			rejected = append(rejected, pattern)
+			continue
+		}
+		valid[pattern] = price
+	}
+	return valid, rejected
+}
+
+// FilePriceProvider reads a price catalog document from a local path,
+// re-reading only when the file's mtime advances since the last fetch.
+type FilePriceProvider struct {
+	path string
+
+	mu        sync.Mutex
+	lastModAt time.Time
+}
+
+// NewFilePriceProvider returns a PriceProvider backed by the JSON document at path.
+func NewFilePriceProvider(path string) *FilePriceProvider {
+	return &FilePriceProvider{path: path}
+}
+
+func (p *FilePriceProvider) Name() string { return "file:" + p.path }
+
+func (p *FilePriceProvider) FetchPrices(ctx context.Context) (map[string]ModelPrice, error) {
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return nil, fmt.Errorf("billing price file: stat %s: %w", p.path, err)
+	}
+
+	p.mu.Lock()
+	unchanged := !info.ModTime().After(p.lastModAt)
+	p.mu.Unlock()
+	if unchanged {
+		return nil, ErrPriceProviderNotModified
+	}
+
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return nil, fmt.Errorf("billing price file: read %s: %w", p.path, err)
+	}
+	prices, err := parsePriceCatalogDocument(data)
+	if err != nil {
+		return nil, fmt.Errorf("billing price file: %s: %w", p.path, err)
+	}
+
+	p.mu.Lock()
+	p.lastModAt = info.ModTime()
+	p.mu.Unlock()
+	return prices, nil
+}
+
+// HTTPPriceProvider fetches a price catalog document over HTTP, using
+// If-None-Match / ETag to skip re-parsing an unchanged document.
+type HTTPPriceProvider struct {
+	url        string
+	httpClient *http.Client
+
+	mu       sync.Mutex
+	lastETag string
+}
+
+// NewHTTPPriceProvider returns a PriceProvider that fetches url on every FetchPrices call.
+func NewHTTPPriceProvider(url string) *HTTPPriceProvider {
+	return &HTTPPriceProvider{url: url, httpClient: &http.Client{Timeout: 15 * time.Second}}
+}
+
+func (p *HTTPPriceProvider) Name() string { return "http:" + p.url }
+
+func (p *HTTPPriceProvider) FetchPrices(ctx context.Context) (map[string]ModelPrice, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("billing price http: build request: %w", err)
+	}
+
+	p.mu.Lock()
+	lastETag := p.lastETag
+	p.mu.Unlock()
+	if lastETag != "" {
+		req.Header.Set("If-None-Match", lastETag)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("billing price http: fetch %s: %w", p.url, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, ErrPriceProviderNotModified
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("billing price http: %s: unexpected status %d", p.url, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("billing price http: read %s: %w", p.url, err)
+	}
+	etag := resp.Header.Get("ETag")
+	if etag != "" && etag == lastETag {
+		return nil, ErrPriceProviderNotModified
+	}
+
+	prices, err := parsePriceCatalogDocument(body)
+	if err != nil {
+		return nil, fmt.Errorf("billing price http: %s: %w", p.url, err)
+	}
+
+	p.mu.Lock()
+	p.lastETag = etag
+	p.mu.Unlock()
+	return prices, nil
+}
+
+// OverridePriceProvider holds admin-set price overrides. It is meant to be
+// the last provider passed to NewPriceRefresher, so its entries win on key
+// collision regardless of what the file/HTTP providers report.
+type OverridePriceProvider struct {
+	mu                 sync.RWMutex
+	prices             map[string]ModelPrice
+	version            int64
+	lastFetchedVersion int64
+}
+
+// NewOverridePriceProvider returns an empty OverridePriceProvider.
+func NewOverridePriceProvider() *OverridePriceProvider {
+	return &OverridePriceProvider{prices: make(map[string]ModelPrice)}
+}
+
+func (p *OverridePriceProvider) Name() string { return "override" }
+
+// Set installs an admin override for pattern (an exact model id or a
+// policy.MatchWildcard pattern).
+func (p *OverridePriceProvider) Set(pattern string, price ModelPrice) {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return
+	}
+	price.Model = pattern
+	price.Source = "override"
+
+	p.mu.Lock()
+	p.prices[pattern] = price
+	p.version++
+	p.mu.Unlock()
+}
+
+// Delete removes pattern's override, if any.
+func (p *OverridePriceProvider) Delete(pattern string) {
+	p.mu.Lock()
+	if _, ok := p.prices[pattern]; ok {
+		delete(p.prices, pattern)
+		p.version++
+	}
+	p.mu.Unlock()
+}
+
+func (p *OverridePriceProvider) FetchPrices(ctx context.Context) (map[string]ModelPrice, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.version == p.lastFetchedVersion {
+		return nil, ErrPriceProviderNotModified
+	}
+	out := make(map[string]ModelPrice, len(p.prices))
+	for pattern, price := range p.prices {
+		out[pattern] = price
+	}
+	p.lastFetchedVersion = p.version
+	return out, nil
+}
+
+// PriceRefresher merges PriceProvider catalogs into one wildcard-matchable
+// table on a timer. Providers are listed lowest-precedence first: later
+// providers' entries win on a pattern collision, so an OverridePriceProvider
+// should normally be passed last. A provider that returns an error keeps
+// contributing its last successfully fetched entries, so one bad fetch
+// can't blank out part of the live table.
+type PriceRefresher struct {
+	providers []PriceProvider
+	interval  time.Duration
+	metrics   *MetricsRegistry
+
+	mu    sync.RWMutex
+	table map[string]ModelPrice
+}
+
+// NewPriceRefresher returns a refresher over providers, polling every
+// interval when Start is called. metrics may be nil.
+func NewPriceRefresher(interval time.Duration, metrics *MetricsRegistry, providers ...PriceProvider) *PriceRefresher {
+	return &PriceRefresher{
+		providers: append([]PriceProvider(nil), providers...),
+		interval:  interval,
+		metrics:   metrics,
+		table:     make(map[string]ModelPrice),
+	}
+}
+
+// RefreshOnce pulls every provider once, merging successful fetches into the
+// live table and leaving it untouched for any provider whose fetch failed or
+// reported ErrPriceProviderNotModified.
+func (r *PriceRefresher) RefreshOnce(ctx context.Context) {
+	if r == nil {
+		return
+	}
+	r.mu.RLock()
+	merged := make(map[string]ModelPrice, len(r.table))
+	for k, v := range r.table {
+		merged[k] = v
+	}
+	r.mu.RUnlock()
+
+	for _, provider := range r.providers {
+		prices, err := provider.FetchPrices(ctx)
+		if errors.Is(err, ErrPriceProviderNotModified) {
+			continue
+		}
+		if err != nil {
+			log.WithError(err).WithField("provider", provider.Name()).Warn("billing: price provider refresh failed, keeping previous table")
+			r.metrics.ObservePriceRefresh(provider.Name(), false)
+			continue
+		}
+
+		valid, rejected := validatePriceCatalog(prices)
+		if len(rejected) > 0 {
+			log.WithField("provider", provider.Name()).WithField("rejected", rejected).Warn("billing: price provider returned entries outside sane bounds, dropping them")
+		}
+		for pattern, price := range valid {
+			merged[pattern] = price
+		}
+		r.metrics.ObservePriceRefresh(provider.Name(), true)
+		log.WithField("provider", provider.Name()).WithField("models", len(valid)).Info("billing: price provider refreshed")
+	}
+
+	r.mu.Lock()
+	r.table = merged
+	r.mu.Unlock()
+}
+
+// Start runs an initial RefreshOnce, then one more every interval until ctx
+// is cancelled. It is a no-op if interval <= 0.
+func (r *PriceRefresher) Start(ctx context.Context) {
+	if r == nil || r.interval <= 0 {
+		return
+	}
+	r.RefreshOnce(ctx)
+	go func() {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				r.RefreshOnce(ctx)
+			}
+		}
+	}()
+}
+
+// ResolvePrice looks up model in r's merged table: an exact normalised-key
+// match first, falling back to a policy.MatchWildcard pattern scan so a
+// single "claude-opus-4-*" entry prices every minor variant. The second
+// return reports whether any entry matched.
+func (r *PriceRefresher) ResolvePrice(model string) (ModelPrice, bool) {
+	if r == nil {
+		return ModelPrice{}, false
+	}
+	key := policy.NormaliseModelKey(model)
+	if key == "" {
+		return ModelPrice{}, false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if price, ok := r.table[key]; ok {
+		return price, true
+	}
+	for pattern, price := range r.table {
+		if pattern == key {
+			continue
+		}
+		if policy.MatchWildcard(pattern, key) {
+			return price, true
+		}
+	}
+	return ModelPrice{}, false
+}
+
+// defaultPriceRefresher backs the package-level ResolvePrice. It starts
+// empty; config loading wires real providers in via NewPriceRefresher and
+// SetDefaultPriceRefresher.
+var defaultPriceRefresher = NewPriceRefresher(0, nil)
+
+// SetDefaultPriceRefresher replaces the package-level refresher backing
+// ResolvePrice. Call this once at startup after constructing a
+// PriceRefresher with the configured file/HTTP/override providers.
+func SetDefaultPriceRefresher(r *PriceRefresher) {
+	if r == nil {
+		return
+	}
+	defaultPriceRefresher = r
+}
+
+// DefaultPriceRefresher returns the package-level refresher backing ResolvePrice.
+func DefaultPriceRefresher() *PriceRefresher {
+	return defaultPriceRefresher
+}
+
+// ResolvePrice resolves model against the default PriceRefresher's merged
+// table. See PriceRefresher.ResolvePrice.
+func ResolvePrice(model string) (ModelPrice, bool) {
+	return defaultPriceRefresher.ResolvePrice(model)
+}
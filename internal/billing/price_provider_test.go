@@ -0,0 +1,145 @@
+package billing
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type fakePriceProvider struct {
+	name   string
+	prices map[string]ModelPrice
+	err    error
+	calls  int
+}
+
+func (p *fakePriceProvider) Name() string { return p.name }
+
+func (p *fakePriceProvider) FetchPrices(ctx context.Context) (map[string]ModelPrice, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	return p.prices, nil
+}
+
+func TestPriceRefresher_RefreshFailureKeepsPreviousTable(t *testing.T) {
+	good := &fakePriceProvider{name: "good", prices: map[string]ModelPrice{
+		"gpt-5-high": {PromptUSDPer1M: 3, CompletionUSDPer1M: 12, CachedUSDPer1M: 0.3},
+	}}
+	refresher := NewPriceRefresher(0, nil, good)
+	refresher.RefreshOnce(context.Background())
+
+	if _, ok := refresher.ResolvePrice("gpt-5-high"); !ok {
+		t.Fatal("expected gpt-5-high to resolve after first refresh")
+	}
+
+	good.err = errors.New("upstream unavailable")
+	good.prices = nil
+	refresher.RefreshOnce(context.Background())
+
+	price, ok := refresher.ResolvePrice("gpt-5-high")
+	if !ok || price.PromptUSDPer1M != 3 {
+		t.Fatalf("expected previous table to survive a failed refresh, got price=%+v ok=%v", price, ok)
+	}
+}
+
+func TestPriceRefresher_RejectsOutOfBoundsEntries(t *testing.T) {
+	provider := &fakePriceProvider{name: "bad-bounds", prices: map[string]ModelPrice{
+		"claude-opus-4-*": {PromptUSDPer1M: -1, CompletionUSDPer1M: 12, CachedUSDPer1M: 0.3},
+		"gpt-5-high":      {PromptUSDPer1M: 3, CompletionUSDPer1M: 12, CachedUSDPer1M: 0.3},
+	}}
+	refresher := NewPriceRefresher(0, nil, provider)
+	refresher.RefreshOnce(context.Background())
+
+	if _, ok := refresher.ResolvePrice("claude-opus-4-6"); ok {
+		t.Fatal("expected negative-price entry to be rejected")
+	}
+	if _, ok := refresher.ResolvePrice("gpt-5-high"); !ok {
+		t.Fatal("expected the other, in-bounds entry to still land in the table")
+	}
+}
+
+func TestPriceRefresher_WildcardResolution(t *testing.T) {
+	provider := &fakePriceProvider{name: "wildcard", prices: map[string]ModelPrice{
+		"claude-opus-4-*": {PromptUSDPer1M: 15, CompletionUSDPer1M: 75, CachedUSDPer1M: 1.5},
+	}}
+	refresher := NewPriceRefresher(0, nil, provider)
+	refresher.RefreshOnce(context.Background())
+
+	for _, model := range []string{"claude-opus-4-6", "claude-opus-4-5-20251101", "CLAUDE-OPUS-4-7"} {
+		price, ok := refresher.ResolvePrice(model)
+		if !ok || price.CompletionUSDPer1M != 75 {
+			t.Fatalf("ResolvePrice(%q) = %+v, %v; want wildcard match", model, price, ok)
+		}
+	}
+	if _, ok := refresher.ResolvePrice("gpt-5-high"); ok {
+		t.Fatal("expected no match for an unrelated model")
+	}
+}
+
+func TestPriceRefresher_OverrideTakesPrecedence(t *testing.T) {
+	base := &fakePriceProvider{name: "base", prices: map[string]ModelPrice{
+		"gpt-5-high": {PromptUSDPer1M: 3, CompletionUSDPer1M: 12, CachedUSDPer1M: 0.3},
+	}}
+	override := NewOverridePriceProvider()
+	override.Set("gpt-5-high", ModelPrice{PromptUSDPer1M: 1, CompletionUSDPer1M: 1, CachedUSDPer1M: 1})
+
+	// Override listed last: its entry must win on collision regardless of
+	// provider ordering among the rest.
+	refresher := NewPriceRefresher(0, nil, base, override)
+	refresher.RefreshOnce(context.Background())
+
+	price, ok := refresher.ResolvePrice("gpt-5-high")
+	if !ok || price.Source != "override" || price.CompletionUSDPer1M != 1 {
+		t.Fatalf("expected override price to win, got %+v", price)
+	}
+
+	// A second refresh with no override change should not re-report the
+	// override provider, but must still keep serving its price.
+	refresher.RefreshOnce(context.Background())
+	if price, ok := refresher.ResolvePrice("gpt-5-high"); !ok || price.Source != "override" {
+		t.Fatalf("override price should survive an unrelated refresh, got %+v", price)
+	}
+}
+
+func TestFilePriceProvider_SkipsUnchangedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prices.json")
+	if err := os.WriteFile(path, []byte(`{"models":[{"model":"gpt-5-high","prompt_usd_per_1m":3,"completion_usd_per_1m":12,"cached_usd_per_1m":0.3}]}`), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	provider := NewFilePriceProvider(path)
+	prices, err := provider.FetchPrices(context.Background())
+	if err != nil {
+		t.Fatalf("FetchPrices: %v", err)
+	}
+	if len(prices) != 1 {
+		t.Fatalf("prices=%+v", prices)
+	}
+
+	if _, err := provider.FetchPrices(context.Background()); !errors.Is(err, ErrPriceProviderNotModified) {
+		t.Fatalf("expected ErrPriceProviderNotModified on an unchanged file, got %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"models":[{"model":"gpt-5-high","prompt_usd_per_1m":4,"completion_usd_per_1m":12,"cached_usd_per_1m":0.3}]}`), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	// Force the mtime forward so the provider notices the change on the next
+	// fetch even if the write above landed within the filesystem's mtime
+	// resolution of the original write.
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatalf("Chtimes: %v", err)
+	}
+	prices, err = provider.FetchPrices(context.Background())
+	if err != nil {
+		t.Fatalf("FetchPrices after change: %v", err)
+	}
+	if prices["gpt-5-high"].PromptUSDPer1M != 4 {
+		t.Fatalf("expected updated price, got %+v", prices["gpt-5-high"])
+	}
+}
@@ -0,0 +1,178 @@
+package billing
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/policy"
+)
+
+// RangeUsageEntry is one row of a RangeUsageReport breakdown: usage
+// aggregated to a single dimension value (a day, model, provider, or API
+// key) within the report's range.
+type RangeUsageEntry struct {
+	Key            string  `json:"key"`
+	Requests       int64   `json:"requests"`
+	FailedRequests int64   `json:"failed_requests"`
+	TotalTokens    int64   `json:"total_tokens"`
+	CostMicroUSD   int64   `json:"cost_micro_usd"`
+	CostUSD        float64 `json:"cost_usd"`
+}
+
+// RangeUsageReport aggregates usage across every API key for [FromDay, ToDay]
+// (inclusive), broken down by day, model, provider, and API key. It
+// generalises UsageRangeReport (a single API key's per-model totals) into an
+// operator-facing, cross-key view for dashboards.
+type RangeUsageReport struct {
+	FromDay         string            `json:"from_day"`
+	ToDay           string            `json:"to_day"`
+	TotalCostMicro  int64             `json:"total_cost_micro_usd"`
+	TotalCostUSD    float64           `json:"total_cost_usd"`
+	TotalRequests   int64             `json:"total_requests"`
+	TotalFailed     int64             `json:"total_failed_requests"`
+	TotalTokens     int64             `json:"total_tokens"`
+	ByDay           []RangeUsageEntry `json:"by_day"`
+	ByModel         []RangeUsageEntry `json:"by_model"`
+	ByProvider      []RangeUsageEntry `json:"by_provider"`
+	ByAPIKey        []RangeUsageEntry `json:"by_api_key"`
+	GeneratedAtUnix int64             `json:"generated_at_unix"`
+}
+
+// ProviderForModel guesses the provider that serves model from its name,
+// using the same prefix heuristic BaseAPIHandler.getRequestDetails falls
+// back on when the model registry has no match. It is best-effort: usage
+// rows only carry a model name, not the provider that actually served them.
+func ProviderForModel(model string) string {
+	lower := strings.ToLower(policy.NormaliseModelKey(model))
+	switch {
+	case strings.HasPrefix(lower, "claude-"):
+		return "claude"
+	case strings.HasPrefix(lower, "gpt-"), strings.HasPrefix(lower, "o1"), strings.HasPrefix(lower, "o3"), strings.HasPrefix(lower, "o4"), strings.HasPrefix(lower, "chatgpt-"):
+		return "codex"
+	case strings.HasPrefix(lower, "gemini"), strings.HasPrefix(lower, "models/gemini"), strings.HasPrefix(lower, "vertex"), strings.HasPrefix(lower, "aistudio"):
+		return "gemini"
+	case strings.HasPrefix(lower, "qwen"):
+		return "qwen"
+	case strings.HasPrefix(lower, "kimi"):
+		return "kimi"
+	case strings.HasPrefix(lower, "iflow"):
+		return "iflow"
+	default:
+		return "unknown"
+	}
+}
+
+type rangeAggregator struct {
+	byDay      map[string]RangeUsageEntry
+	byModel    map[string]RangeUsageEntry
+	byProvider map[string]RangeUsageEntry
+	byAPIKey   map[string]RangeUsageEntry
+}
+
+func newRangeAggregator() *rangeAggregator {
+	return &rangeAggregator{
+		byDay:      make(map[string]RangeUsageEntry),
+		byModel:    make(map[string]RangeUsageEntry),
+		byProvider: make(map[string]RangeUsageEntry),
+		byAPIKey:   make(map[string]RangeUsageEntry),
+	}
+}
+
+func addRangeEntry(m map[string]RangeUsageEntry, key string, row DailyUsageRow) {
+	agg := m[key]
+	agg.Key = key
+	agg.Requests += row.Requests
+	agg.FailedRequests += row.FailedRequests
+	agg.TotalTokens += row.TotalTokens
+	agg.CostMicroUSD += row.CostMicroUSD
+	m[key] = agg
+}
+
+func sortedRangeEntries(m map[string]RangeUsageEntry) []RangeUsageEntry {
+	out := make([]RangeUsageEntry, 0, len(m))
+	for _, entry := range m {
+		entry.CostUSD = microUSDToUSD(entry.CostMicroUSD)
+		out = append(out, entry)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
+
+// GetRangeUsageReport aggregates usage for every API key with day in
+// [fromDay, toDay] (inclusive, "YYYY-MM-DD" day keys) into one report broken
+// down by day, model, provider, and API key.
+func GetRangeUsageReport(ctx context.Context, store Store, fromDay, toDay string) (RangeUsageReport, error) {
+	report := RangeUsageReport{FromDay: fromDay, ToDay: toDay, GeneratedAtUnix: nowUnixUTC()}
+	if store == nil {
+		return report, errRequired("store")
+	}
+	rows, err := store.ListUsageRowsAllKeys(ctx, fromDay, toDay)
+	if err != nil {
+		return report, err
+	}
+
+	agg := newRangeAggregator()
+	for _, row := range rows {
+		addRangeEntry(agg.byDay, row.Day, row)
+		addRangeEntry(agg.byModel, row.Model, row)
+		addRangeEntry(agg.byProvider, ProviderForModel(row.Model), row)
+		addRangeEntry(agg.byAPIKey, row.APIKey, row)
+
+		report.TotalRequests += row.Requests
+		report.TotalFailed += row.FailedRequests
+		report.TotalTokens += row.TotalTokens
+		report.TotalCostMicro += row.CostMicroUSD
+	}
+
+	report.ByDay = sortedRangeEntries(agg.byDay)
+	report.ByModel = sortedRangeEntries(agg.byModel)
+	report.ByProvider = sortedRangeEntries(agg.byProvider)
+	report.ByAPIKey = sortedRangeEntries(agg.byAPIKey)
+	report.TotalCostUSD = microUSDToUSD(report.TotalCostMicro)
+	return report, nil
+}
+
+// GetWeeklyRangeUsageReport aggregates usage across every API key over the
+// Mon-Sun week (China Standard Time) containing now.
+func GetWeeklyRangeUsageReport(ctx context.Context, store Store, now time.Time) (RangeUsageReport, error) {
+	from, to := policy.WeekRangeChina(now)
+	return GetRangeUsageReport(ctx, store, from, to)
+}
+
+// GetMonthlyRangeUsageReport aggregates usage across every API key over the
+// calendar month (China Standard Time) containing now.
+func GetMonthlyRangeUsageReport(ctx context.Context, store Store, now time.Time) (RangeUsageReport, error) {
+	from, to := policy.MonthRangeChina(now)
+	return GetRangeUsageReport(ctx, store, from, to)
+}
+
+// TopN returns the n entries from entries with the largest value of by
+// ("cost", "tokens", or "requests"), descending. An unrecognised by value is
+// treated as "cost". n <= 0 returns all entries sorted, with no truncation.
+func TopN(entries []RangeUsageEntry, by string, n int) []RangeUsageEntry {
+	out := make([]RangeUsageEntry, len(entries))
+	copy(out, entries)
+
+	var value func(RangeUsageEntry) int64
+	switch strings.ToLower(strings.TrimSpace(by)) {
+	case "tokens":
+		value = func(e RangeUsageEntry) int64 { return e.TotalTokens }
+	case "requests":
+		value = func(e RangeUsageEntry) int64 { return e.Requests }
+	default:
+		value = func(e RangeUsageEntry) int64 { return e.CostMicroUSD }
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if vi, vj := value(out[i]), value(out[j]); vi != vj {
+			return vi > vj
+		}
+		return out[i].Key < out[j].Key
+	})
+	if n > 0 && n < len(out) {
+		out = out[:n]
+	}
+	return out
+}
@@ -0,0 +1,93 @@
+package billing
+
+import (
+	"context"
+	"testing"
+)
+
+func TestGetRangeUsageReport_AggregatesAcrossKeysModelsAndProviders(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	if err := store.AddUsage(ctx, "key-1", "claude-3-opus", "2026-02-09", DailyUsageRow{Requests: 1, TotalTokens: 10, CostMicroUSD: 100}); err != nil {
+		t.Fatalf("AddUsage: %v", err)
+	}
+	if err := store.AddUsage(ctx, "key-2", "gpt-4o", "2026-02-09", DailyUsageRow{Requests: 2, TotalTokens: 20, CostMicroUSD: 200}); err != nil {
+		t.Fatalf("AddUsage: %v", err)
+	}
+	if err := store.AddUsage(ctx, "key-1", "gemini-2.5-pro", "2026-02-10", DailyUsageRow{Requests: 1, FailedRequests: 1, TotalTokens: 5, CostMicroUSD: 50}); err != nil {
+		t.Fatalf("AddUsage: %v", err)
+	}
+	// Outside the requested range; must not be counted.
+	if err := store.AddUsage(ctx, "key-1", "claude-3-opus", "2026-02-20", DailyUsageRow{Requests: 9, TotalTokens: 999, CostMicroUSD: 999}); err != nil {
+		t.Fatalf("AddUsage: %v", err)
+	}
+
+	report, err := GetRangeUsageReport(ctx, store, "2026-02-09", "2026-02-15")
+	if err != nil {
+		t.Fatalf("GetRangeUsageReport: %v", err)
+	}
+	if report.TotalRequests != 4 || report.TotalFailed != 1 || report.TotalTokens != 35 || report.TotalCostMicro != 350 {
+		t.Fatalf("unexpected totals: %+v", report)
+	}
+	if len(report.ByAPIKey) != 2 || len(report.ByModel) != 3 || len(report.ByDay) != 2 {
+		t.Fatalf("unexpected breakdown sizes: keys=%d models=%d days=%d", len(report.ByAPIKey), len(report.ByModel), len(report.ByDay))
+	}
+
+	providers := make(map[string]int64, len(report.ByProvider))
+	for _, e := range report.ByProvider {
+		providers[e.Key] = e.Requests
+	}
+	if providers["claude"] != 1 || providers["codex"] != 2 || providers["gemini"] != 1 {
+		t.Fatalf("unexpected provider breakdown: %+v", report.ByProvider)
+	}
+}
+
+func TestGetRangeUsageReport_RequiresStore(t *testing.T) {
+	if _, err := GetRangeUsageReport(context.Background(), nil, "2026-02-09", "2026-02-15"); err == nil {
+		t.Fatal("expected error for nil store")
+	}
+}
+
+func TestProviderForModel(t *testing.T) {
+	cases := map[string]string{
+		"claude-3-opus":   "claude",
+		"gpt-4o":          "codex",
+		"o3-mini":         "codex",
+		"chatgpt-4o":      "codex",
+		"gemini-2.5-pro":  "gemini",
+		"vertex-gemini":   "gemini",
+		"qwen-max":        "qwen",
+		"kimi-k2":         "kimi",
+		"iflow-1":         "iflow",
+		"some-other-name": "unknown",
+	}
+	for model, want := range cases {
+		if got := ProviderForModel(model); got != want {
+			t.Fatalf("ProviderForModel(%q) = %q, want %q", model, got, want)
+		}
+	}
+}
+
+func TestTopN_SortsDescendingAndTruncates(t *testing.T) {
+	entries := []RangeUsageEntry{
+		{Key: "a", CostMicroUSD: 100, TotalTokens: 5, Requests: 1},
+		{Key: "b", CostMicroUSD: 300, TotalTokens: 1, Requests: 9},
+		{Key: "c", CostMicroUSD: 200, TotalTokens: 50, Requests: 2},
+	}
+
+	top := TopN(entries, "cost", 2)
+	if len(top) != 2 || top[0].Key != "b" || top[1].Key != "c" {
+		t.Fatalf("unexpected TopN(cost)=%+v", top)
+	}
+
+	top = TopN(entries, "tokens", 1)
+	if len(top) != 1 || top[0].Key != "c" {
+		t.Fatalf("unexpected TopN(tokens)=%+v", top)
+	}
+
+	top = TopN(entries, "requests", 0)
+	if len(top) != 3 || top[0].Key != "b" {
+		t.Fatalf("unexpected TopN(requests)=%+v", top)
+	}
+}
@@ -0,0 +1,94 @@
+package billing
+
+import (
+	"context"
+	"time"
+)
+
+// reconcileLocation matches the China Standard Time (UTC+8) convention day
+// keys are stored in throughout this package (see policy.DayKeyChina).
+var reconcileLocation = time.FixedZone("CST", 8*60*60)
+
+// dayEndUnix returns the Unix timestamp of the last instant of day (a
+// "YYYY-MM-DD" key) in China Standard Time, the rate ResolvePriceMicroAt
+// should look up for usage recorded on that day.
+func dayEndUnix(day string) (int64, error) {
+	t, err := time.ParseInLocation("2006-01-02", day, reconcileLocation)
+	if err != nil {
+		return 0, err
+	}
+	return t.Add(24*time.Hour - time.Nanosecond).Unix(), nil
+}
+
+// DayEndUnix is the exported form of dayEndUnix, for callers outside this
+// package (e.g. the invoice subpackage) that need to resolve the same
+// "price in effect at the end of day" timestamp Reconcile uses.
+func DayEndUnix(day string) (int64, error) {
+	return dayEndUnix(day)
+}
+
+// Reconcile recomputes CostMicroUSD for every usage row recorded on day,
+// pricing each model's tokens at the rate that was in effect at the end of
+// that day (via Store.ResolvePriceMicroAt), and writes the corrected cost
+// back via Store.SetUsageCostMicro. Call this after a retroactive price
+// correction (UpsertModelPriceWithAudit or ImportModelPrices with an
+// effectiveFrom in the past) so
+// already-recorded usage reflects the corrected rate rather than the rate
+// that happened to be active when it was first recorded.
+func Reconcile(ctx context.Context, store Store, day string) error {
+	return ReconcileWithCatalog(ctx, store, day, nil)
+}
+
+// ReconcileWithCatalog is Reconcile, additionally consulting catalog (when
+// non-nil) ahead of Store.ResolvePriceMicroAt for each row's model, the same
+// precedence UsagePersistPlugin.HandleUsage uses. Rows are aggregated per
+// day, so the row's total InputTokens (rather than any single request's)
+// stands in for the catalog's context-tier selection; a day that straddles a
+// tier boundary is priced at whichever tier the day's aggregate falls into,
+// which is an approximation Reconcile accepts in exchange for not requiring
+// per-request history to be retained.
+func ReconcileWithCatalog(ctx context.Context, store Store, day string, catalog PriceCatalog) error {
+	if store == nil {
+		return errRequired("store")
+	}
+	if day == "" {
+		return errRequired("day")
+	}
+	atUnix, err := dayEndUnix(day)
+	if err != nil {
+		return err
+	}
+
+	rows, err := store.ListUsageRowsAllKeys(ctx, day, day)
+	if err != nil {
+		return err
+	}
+
+	priceCache := make(map[string]PriceMicroUSDPer1M, len(rows))
+	for _, row := range rows {
+		var costMicro int64
+		priced := false
+		if catalog != nil {
+			if tier, ok := catalog.Resolve(ctx, ProviderForModel(row.Model), row.Model, atUnix, row.InputTokens, ""); ok {
+				costMicro = tier.CostMicroUSD(row.InputTokens, row.OutputTokens, row.ReasoningTokens, row.CachedTokens)
+				priced = true
+			}
+		}
+		if !priced {
+			price, ok := priceCache[row.Model]
+			if !ok {
+				price, _, _, err = store.ResolvePriceMicroAt(ctx, row.Model, atUnix)
+				if err != nil {
+					return err
+				}
+				priceCache[row.Model] = price
+			}
+			costMicro = usageCostMicroUSD(price, row.InputTokens, row.OutputTokens, row.ReasoningTokens, row.CachedTokens)
+		}
+
+		if err := store.SetUsageCostMicro(ctx, row.APIKey, row.Model, row.Day, costMicro); err != nil {
+			return err
+		}
+	}
+	return nil
+}
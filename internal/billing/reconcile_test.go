@@ -0,0 +1,55 @@
+package billing
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestReconcile_RepricesUsageAtCorrectedRate(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	initialFrom := time.Date(2026, 2, 1, 0, 0, 0, 0, reconcileLocation).Unix()
+	if err := store.UpsertModelPriceWithAudit(ctx, "claude-3-opus", PriceMicroUSDPer1M{Prompt: 1_000_000, Completion: 2_000_000, Cached: 500_000}, initialFrom, "test", "initial price"); err != nil {
+		t.Fatalf("UpsertModelPriceWithAudit: %v", err)
+	}
+	if err := store.AddUsage(ctx, "key-1", "claude-3-opus", "2026-02-09", DailyUsageRow{
+		Requests: 1, InputTokens: 1000, OutputTokens: 500, CachedTokens: 200, TotalTokens: 1700, CostMicroUSD: 1,
+	}); err != nil {
+		t.Fatalf("AddUsage: %v", err)
+	}
+
+	// Retroactive price correction: double the completion rate, effective
+	// from the start of the already-recorded usage day.
+	correctionFrom := time.Date(2026, 2, 9, 0, 0, 0, 0, reconcileLocation).Unix()
+	if err := store.UpsertModelPriceWithAudit(ctx, "claude-3-opus", PriceMicroUSDPer1M{Prompt: 1_000_000, Completion: 4_000_000, Cached: 500_000}, correctionFrom, "test", "correction"); err != nil {
+		t.Fatalf("UpsertModelPriceWithAudit (correction): %v", err)
+	}
+
+	if err := Reconcile(ctx, store, "2026-02-09"); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+
+	rows, err := store.ListUsageRowsAllKeys(ctx, "2026-02-09", "2026-02-09")
+	if err != nil {
+		t.Fatalf("ListUsageRowsAllKeys: %v", err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("rows=%+v", rows)
+	}
+
+	want := usageCostMicroUSD(PriceMicroUSDPer1M{Prompt: 1_000_000, Completion: 4_000_000, Cached: 500_000}, 1000, 500, 0, 200)
+	if rows[0].CostMicroUSD != want {
+		t.Fatalf("CostMicroUSD = %d, want %d", rows[0].CostMicroUSD, want)
+	}
+}
+
+func TestReconcile_RequiresStoreAndDay(t *testing.T) {
+	if err := Reconcile(context.Background(), nil, "2026-02-09"); err == nil {
+		t.Fatal("expected error for nil store")
+	}
+	if err := Reconcile(context.Background(), NewMemoryStore(), ""); err == nil {
+		t.Fatal("expected error for empty day")
+	}
+}
@@ -0,0 +1,80 @@
+package billing
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/policy"
+)
+
+// UsageRangeReport aggregates DailyUsageReport across an inclusive span of
+// days, e.g. a week or a calendar month. Models holds one row per model with
+// its usage summed across the whole range.
+type UsageRangeReport struct {
+	APIKey          string          `json:"api_key"`
+	FromDay         string          `json:"from_day"`
+	ToDay           string          `json:"to_day"`
+	TotalCostMicro  int64           `json:"total_cost_micro_usd"`
+	TotalCostUSD    float64         `json:"total_cost_usd"`
+	TotalRequests   int64           `json:"total_requests"`
+	TotalFailed     int64           `json:"total_failed_requests"`
+	TotalTokens     int64           `json:"total_tokens"`
+	Models          []DailyUsageRow `json:"models"`
+	GeneratedAtUnix int64           `json:"generated_at_unix"`
+}
+
+// GetUsageRangeReport aggregates apiKey's usage for every day in
+// [fromDay, toDay] (inclusive, "YYYY-MM-DD" day keys) into one report with a
+// per-model breakdown.
+func GetUsageRangeReport(ctx context.Context, store Store, apiKey, fromDay, toDay string) (UsageRangeReport, error) {
+	report := UsageRangeReport{APIKey: apiKey, FromDay: fromDay, ToDay: toDay, GeneratedAtUnix: nowUnixUTC()}
+	if store == nil {
+		return report, errRequired("store")
+	}
+	rows, err := store.ListUsageRows(ctx, apiKey, fromDay, toDay)
+	if err != nil {
+		return report, err
+	}
+
+	byModel := make(map[string]DailyUsageRow, len(rows))
+	for _, row := range rows {
+		agg := byModel[row.Model]
+		agg.APIKey = row.APIKey
+		agg.Model = row.Model
+		agg.Requests += row.Requests
+		agg.FailedRequests += row.FailedRequests
+		agg.InputTokens += row.InputTokens
+		agg.OutputTokens += row.OutputTokens
+		agg.ReasoningTokens += row.ReasoningTokens
+		agg.CachedTokens += row.CachedTokens
+		agg.TotalTokens += row.TotalTokens
+		agg.CostMicroUSD += row.CostMicroUSD
+		byModel[row.Model] = agg
+
+		report.TotalRequests += row.Requests
+		report.TotalFailed += row.FailedRequests
+		report.TotalTokens += row.TotalTokens
+		report.TotalCostMicro += row.CostMicroUSD
+	}
+	for _, agg := range byModel {
+		report.Models = append(report.Models, agg)
+	}
+	sort.Slice(report.Models, func(i, j int) bool { return report.Models[i].Model < report.Models[j].Model })
+	report.TotalCostUSD = microUSDToUSD(report.TotalCostMicro)
+	return report, nil
+}
+
+// GetWeeklyUsageReport aggregates apiKey's usage over the Mon-Sun week (China
+// Standard Time) containing now.
+func GetWeeklyUsageReport(ctx context.Context, store Store, apiKey string, now time.Time) (UsageRangeReport, error) {
+	from, to := policy.WeekRangeChina(now)
+	return GetUsageRangeReport(ctx, store, apiKey, from, to)
+}
+
+// GetMonthlyUsageReport aggregates apiKey's usage over the calendar month
+// (China Standard Time) containing now.
+func GetMonthlyUsageReport(ctx context.Context, store Store, apiKey string, now time.Time) (UsageRangeReport, error) {
+	from, to := policy.MonthRangeChina(now)
+	return GetUsageRangeReport(ctx, store, apiKey, from, to)
+}
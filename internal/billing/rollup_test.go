@@ -0,0 +1,49 @@
+package billing
+
+import (
+	"context"
+	"testing"
+)
+
+func TestGetUsageRangeReport_AggregatesAcrossDaysAndModels(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	apiKey := "key-1"
+
+	if err := store.AddUsage(ctx, apiKey, "model-a", "2026-02-09", DailyUsageRow{Requests: 1, TotalTokens: 10, CostMicroUSD: 100}); err != nil {
+		t.Fatalf("AddUsage: %v", err)
+	}
+	if err := store.AddUsage(ctx, apiKey, "model-a", "2026-02-10", DailyUsageRow{Requests: 2, TotalTokens: 20, CostMicroUSD: 200}); err != nil {
+		t.Fatalf("AddUsage: %v", err)
+	}
+	if err := store.AddUsage(ctx, apiKey, "model-b", "2026-02-10", DailyUsageRow{Requests: 1, FailedRequests: 1, TotalTokens: 5, CostMicroUSD: 50}); err != nil {
+		t.Fatalf("AddUsage: %v", err)
+	}
+	// Outside the requested range; must not be counted.
+	if err := store.AddUsage(ctx, apiKey, "model-a", "2026-02-20", DailyUsageRow{Requests: 9, TotalTokens: 999, CostMicroUSD: 999}); err != nil {
+		t.Fatalf("AddUsage: %v", err)
+	}
+
+	report, err := GetUsageRangeReport(ctx, store, apiKey, "2026-02-09", "2026-02-15")
+	if err != nil {
+		t.Fatalf("GetUsageRangeReport: %v", err)
+	}
+	if report.TotalRequests != 4 || report.TotalFailed != 1 || report.TotalTokens != 35 || report.TotalCostMicro != 350 {
+		t.Fatalf("unexpected totals: %+v", report)
+	}
+	if len(report.Models) != 2 {
+		t.Fatalf("models=%+v", report.Models)
+	}
+	if report.Models[0].Model != "model-a" || report.Models[0].Requests != 3 {
+		t.Fatalf("model-a row=%+v", report.Models[0])
+	}
+	if report.Models[1].Model != "model-b" || report.Models[1].Requests != 1 {
+		t.Fatalf("model-b row=%+v", report.Models[1])
+	}
+}
+
+func TestGetUsageRangeReport_RequiresStore(t *testing.T) {
+	if _, err := GetUsageRangeReport(context.Background(), nil, "key", "2026-02-09", "2026-02-15"); err == nil {
+		t.Fatal("expected error for nil store")
+	}
+}
@@ -0,0 +1,77 @@
+package billing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// SQLiteEventSink persists BillingEvents into the same SQLite database an
+// existing SQLiteStore already manages, in a dedicated billing_events table.
+// It is the first BillingEventSink implementation; a Postgres sink can
+// satisfy the interface later without BillingEventBus or its callers
+// changing.
+type SQLiteEventSink struct {
+	store *SQLiteStore
+}
+
+// NewSQLiteEventSink ensures the billing_events table exists on store's
+// database and returns a BillingEventSink backed by it.
+func NewSQLiteEventSink(store *SQLiteStore) (*SQLiteEventSink, error) {
+	if store == nil || store.db == nil {
+		return nil, fmt.Errorf("billing sqlite: not initialized")
+	}
+
+	stmts := []string{
+		`
+		CREATE TABLE IF NOT EXISTS billing_events (
+			id TEXT NOT NULL PRIMARY KEY,
+			ts INTEGER NOT NULL,
+			api_key TEXT NOT NULL,
+			provider TEXT NOT NULL,
+			model TEXT NOT NULL,
+			prompt_tokens INTEGER NOT NULL,
+			completion_tokens INTEGER NOT NULL,
+			cached_tokens INTEGER NOT NULL,
+			cost_micro_usd INTEGER NOT NULL,
+			failed INTEGER NOT NULL,
+			request_id TEXT NOT NULL,
+			latency_ms INTEGER NOT NULL,
+			finish_reason TEXT NOT NULL,
+			created_at INTEGER NOT NULL
+		)
+		`,
+		`CREATE INDEX IF NOT EXISTS idx_billing_events_api_key_ts ON billing_events (api_key, ts)`,
+		`CREATE INDEX IF NOT EXISTS idx_billing_events_model_ts ON billing_events (model, ts)`,
+	}
+	for _, stmt := range stmts {
+		if _, err := store.db.Exec(stmt); err != nil {
+			return nil, fmt.Errorf("billing sqlite: ensure billing_events schema: %w", err)
+		}
+	}
+	return &SQLiteEventSink{store: store}, nil
+}
+
+// PersistBillingEvent implements BillingEventSink.
+func (s *SQLiteEventSink) PersistBillingEvent(ctx context.Context, evt BillingEvent) error {
+	if s == nil || s.store == nil || s.store.db == nil {
+		return fmt.Errorf("billing sqlite: not initialized")
+	}
+	_, err := s.store.db.ExecContext(ctx, `
+		INSERT INTO billing_events (
+			id, ts, api_key, provider, model, prompt_tokens, completion_tokens,
+			cached_tokens, cost_micro_usd, failed, request_id, latency_ms,
+			finish_reason, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		uuid.NewString(), evt.Timestamp.Unix(), evt.APIKey, evt.Provider, evt.Model,
+		max64(0, evt.PromptTokens), max64(0, evt.CompletionTokens), max64(0, evt.CachedTokens),
+		max64(0, evt.CostMicroUSD), boolToInt64(evt.Failed), evt.RequestID, evt.LatencyMS,
+		evt.FinishReason, nowUnixUTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("billing sqlite: persist billing event: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package billing
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSQLiteEventSink_PersistBillingEvent(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewSQLiteStore(filepath.Join(dir, "billing.sqlite"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore: %v", err)
+	}
+	defer store.Close()
+
+	sink, err := NewSQLiteEventSink(store)
+	if err != nil {
+		t.Fatalf("NewSQLiteEventSink: %v", err)
+	}
+
+	ctx := context.Background()
+	evt := BillingEvent{
+		Timestamp:        time.Unix(1_700_000_000, 0),
+		APIKey:           "key-a",
+		Provider:         "openai",
+		Model:            "gpt-4o",
+		PromptTokens:     10,
+		CompletionTokens: 20,
+		CostMicroUSD:     300,
+	}
+	if err := sink.PersistBillingEvent(ctx, evt); err != nil {
+		t.Fatalf("PersistBillingEvent: %v", err)
+	}
+
+	var count int
+	if err := store.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM billing_events WHERE api_key = ?", "key-a").Scan(&count); err != nil {
+		t.Fatalf("count rows: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("count=%d, want 1", count)
+	}
+}
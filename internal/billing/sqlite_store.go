@@ -11,6 +11,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/policy"
 	_ "modernc.org/sqlite"
 )
@@ -75,7 +76,19 @@ func (s *SQLiteStore) ensureSchema(ctx context.Context) error {
 
 	stmts := []string{
 		`
-		CREATE TABLE IF NOT EXISTS model_prices (
+		CREATE TABLE IF NOT EXISTS model_price_history (
+			id TEXT NOT NULL PRIMARY KEY,
+			model TEXT NOT NULL,
+			prompt_micro_usd_per_1m INTEGER NOT NULL,
+			completion_micro_usd_per_1m INTEGER NOT NULL,
+			cached_micro_usd_per_1m INTEGER NOT NULL,
+			effective_at INTEGER NOT NULL,
+			created_at INTEGER NOT NULL
+		)
+		`,
+		`CREATE INDEX IF NOT EXISTS idx_model_price_history_model_effective ON model_price_history (model, effective_at)`,
+		`
+		CREATE TABLE IF NOT EXISTS model_prices_catalog (
 			model TEXT NOT NULL PRIMARY KEY,
 			prompt_micro_usd_per_1m INTEGER NOT NULL,
 			completion_micro_usd_per_1m INTEGER NOT NULL,
@@ -84,6 +97,13 @@ func (s *SQLiteStore) ensureSchema(ctx context.Context) error {
 		)
 		`,
 		`
+		CREATE TABLE IF NOT EXISTS model_prices_catalog_sync (
+			id INTEGER NOT NULL PRIMARY KEY CHECK (id = 1),
+			etag TEXT NOT NULL,
+			synced_at INTEGER NOT NULL
+		)
+		`,
+		`
 		CREATE TABLE IF NOT EXISTS api_key_model_daily_usage (
 			api_key TEXT NOT NULL,
 			model TEXT NOT NULL,
@@ -101,6 +121,57 @@ func (s *SQLiteStore) ensureSchema(ctx context.Context) error {
 		)
 		`,
 		`CREATE INDEX IF NOT EXISTS idx_api_key_model_daily_usage_api_day ON api_key_model_daily_usage (api_key, day)`,
+		`
+		CREATE TABLE IF NOT EXISTS budget_reservations (
+			id TEXT NOT NULL PRIMARY KEY,
+			api_key TEXT NOT NULL,
+			day TEXT NOT NULL,
+			estimated_micro_usd INTEGER NOT NULL,
+			created_at INTEGER NOT NULL
+		)
+		`,
+		`CREATE INDEX IF NOT EXISTS idx_budget_reservations_api_day ON budget_reservations (api_key, day)`,
+		`
+		CREATE TABLE IF NOT EXISTS price_audit (
+			id TEXT NOT NULL PRIMARY KEY,
+			model TEXT NOT NULL,
+			action TEXT NOT NULL,
+			actor TEXT NOT NULL,
+			reason TEXT NOT NULL,
+			old_prompt_micro_usd_per_1m INTEGER,
+			old_completion_micro_usd_per_1m INTEGER,
+			old_cached_micro_usd_per_1m INTEGER,
+			new_prompt_micro_usd_per_1m INTEGER,
+			new_completion_micro_usd_per_1m INTEGER,
+			new_cached_micro_usd_per_1m INTEGER,
+			effective_from INTEGER NOT NULL,
+			created_at INTEGER NOT NULL
+		)
+		`,
+		`CREATE INDEX IF NOT EXISTS idx_price_audit_model_created ON price_audit (model, created_at)`,
+		`
+		CREATE TABLE IF NOT EXISTS budgets (
+			id TEXT NOT NULL PRIMARY KEY,
+			scope TEXT NOT NULL,
+			api_key TEXT NOT NULL,
+			model TEXT NOT NULL,
+			period TEXT NOT NULL,
+			limit_usd REAL NOT NULL,
+			action TEXT NOT NULL,
+			soft_threshold_pct REAL NOT NULL,
+			created_at INTEGER NOT NULL,
+			updated_at INTEGER NOT NULL
+		)
+		`,
+		`
+		CREATE TABLE IF NOT EXISTS budget_spend (
+			budget_id TEXT NOT NULL,
+			period_key TEXT NOT NULL,
+			spent_micro_usd INTEGER NOT NULL,
+			updated_at INTEGER NOT NULL,
+			PRIMARY KEY (budget_id, period_key)
+		)
+		`,
 	}
 
 	for _, stmt := range stmts {
@@ -124,14 +195,9 @@ func (s *SQLiteStore) UpsertModelPrice(ctx context.Context, model string, price
 	}
 	now := nowUnixUTC()
 	_, err := s.db.ExecContext(ctx, `
-		INSERT INTO model_prices (model, prompt_micro_usd_per_1m, completion_micro_usd_per_1m, cached_micro_usd_per_1m, updated_at)
-		VALUES (?, ?, ?, ?, ?)
-		ON CONFLICT(model) DO UPDATE SET
-			prompt_micro_usd_per_1m = excluded.prompt_micro_usd_per_1m,
-			completion_micro_usd_per_1m = excluded.completion_micro_usd_per_1m,
-			cached_micro_usd_per_1m = excluded.cached_micro_usd_per_1m,
-			updated_at = excluded.updated_at
-	`, key, price.Prompt, price.Completion, price.Cached, now)
+		INSERT INTO model_price_history (id, model, prompt_micro_usd_per_1m, completion_micro_usd_per_1m, cached_micro_usd_per_1m, effective_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, uuid.NewString(), key, price.Prompt, price.Completion, price.Cached, now, now)
 	if err != nil {
 		return fmt.Errorf("billing sqlite: upsert model price: %w", err)
 	}
@@ -146,7 +212,7 @@ func (s *SQLiteStore) DeleteModelPrice(ctx context.Context, model string) (bool,
 	if key == "" {
 		return false, fmt.Errorf("billing sqlite: model is required")
 	}
-	res, err := s.db.ExecContext(ctx, `DELETE FROM model_prices WHERE model = ?`, key)
+	res, err := s.db.ExecContext(ctx, `DELETE FROM model_price_history WHERE model = ?`, key)
 	if err != nil {
 		return false, fmt.Errorf("billing sqlite: delete model price: %w", err)
 	}
@@ -154,42 +220,334 @@ func (s *SQLiteStore) DeleteModelPrice(ctx context.Context, model string) (bool,
 	return n > 0, nil
 }
 
-func (s *SQLiteStore) getSavedPriceMicro(ctx context.Context, modelKey string) (PriceMicroUSDPer1M, bool, int64, error) {
+// UpsertModelPriceWithAudit is UpsertModelPrice plus a price_audit row
+// recording the price as it stood immediately before this call (if any)
+// and the actor/reason behind it. See Store.UpsertModelPriceWithAudit.
+func (s *SQLiteStore) UpsertModelPriceWithAudit(ctx context.Context, model string, price PriceMicroUSDPer1M, effectiveFrom int64, actor, reason string) error {
+	if s == nil || s.db == nil {
+		return fmt.Errorf("billing sqlite: not initialized")
+	}
+	key := policy.NormaliseModelKey(model)
+	if key == "" {
+		return fmt.Errorf("billing sqlite: model is required")
+	}
+	if price.Prompt < 0 || price.Completion < 0 || price.Cached < 0 {
+		return fmt.Errorf("billing sqlite: invalid price")
+	}
+	now := nowUnixUTC()
+	effectiveAt := effectiveFrom
+	if effectiveAt == 0 {
+		effectiveAt = now
+	}
+
+	old, hadOld, _, err := s.getSavedPriceMicroAt(ctx, key, now)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("billing sqlite: begin upsert with audit: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO model_price_history (id, model, prompt_micro_usd_per_1m, completion_micro_usd_per_1m, cached_micro_usd_per_1m, effective_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, uuid.NewString(), key, price.Prompt, price.Completion, price.Cached, effectiveAt, now); err != nil {
+		return fmt.Errorf("billing sqlite: upsert model price: %w", err)
+	}
+
+	if err := insertPriceAudit(ctx, tx, key, "upsert", actor, reason, hadOld, old, true, price, effectiveAt, now); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("billing sqlite: commit upsert with audit: %w", err)
+	}
+	return nil
+}
+
+// DeleteModelPriceWithAudit is DeleteModelPrice plus a price_audit row
+// recording the price that was removed and who removed it. See
+// Store.DeleteModelPriceWithAudit.
+func (s *SQLiteStore) DeleteModelPriceWithAudit(ctx context.Context, model, actor, reason string) (bool, error) {
+	if s == nil || s.db == nil {
+		return false, fmt.Errorf("billing sqlite: not initialized")
+	}
+	key := policy.NormaliseModelKey(model)
+	if key == "" {
+		return false, fmt.Errorf("billing sqlite: model is required")
+	}
+	now := nowUnixUTC()
+	old, hadOld, _, err := s.getSavedPriceMicroAt(ctx, key, now)
+	if err != nil {
+		return false, err
+	}
+	if !hadOld {
+		return false, nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("billing sqlite: begin delete with audit: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM model_price_history WHERE model = ?`, key)
+	if err != nil {
+		return false, fmt.Errorf("billing sqlite: delete model price: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	if n == 0 {
+		return false, nil
+	}
+
+	if err := insertPriceAudit(ctx, tx, key, "delete", actor, reason, true, old, false, PriceMicroUSDPer1M{}, 0, now); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("billing sqlite: commit delete with audit: %w", err)
+	}
+	return true, nil
+}
+
+// ImportModelPrices validates and applies a bulk price import. See
+// Store.ImportModelPrices.
+func (s *SQLiteStore) ImportModelPrices(ctx context.Context, entries []PriceImportEntry, actor, reason string, dryRun bool) (PriceImportResult, error) {
+	if s == nil || s.db == nil {
+		return PriceImportResult{}, fmt.Errorf("billing sqlite: not initialized")
+	}
+	current, err := s.ListModelPrices(ctx)
+	if err != nil {
+		return PriceImportResult{}, err
+	}
+	rows, result := planPriceImport(modelPricesByModel(current), entries)
+	result.DryRun = dryRun
+	if dryRun || len(result.Errors) > 0 {
+		if !dryRun {
+			return result, fmt.Errorf("billing sqlite: import aborted: %d row(s) failed validation", len(result.Errors))
+		}
+		return result, nil
+	}
+
+	now := nowUnixUTC()
+	// Prior prices are read before BeginTx: the pool holds one connection,
+	// and the transaction keeps it until Commit or Rollback.
+	olds := make([]PriceMicroUSDPer1M, len(rows))
+	hadOlds := make([]bool, len(rows))
+	for i, row := range rows {
+		old, hadOld, _, err := s.getSavedPriceMicroAt(ctx, row.model, now)
+		if err != nil {
+			return result, err
+		}
+		olds[i], hadOlds[i] = old, hadOld
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return result, fmt.Errorf("billing sqlite: begin import: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for i, row := range rows {
+		effectiveAt := row.effectiveFrom
+		if effectiveAt == 0 {
+			effectiveAt = now
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO model_price_history (id, model, prompt_micro_usd_per_1m, completion_micro_usd_per_1m, cached_micro_usd_per_1m, effective_at, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`, uuid.NewString(), row.model, row.price.Prompt, row.price.Completion, row.price.Cached, effectiveAt, now); err != nil {
+			return result, fmt.Errorf("billing sqlite: import row %s: %w", row.model, err)
+		}
+		if err := insertPriceAudit(ctx, tx, row.model, "upsert", actor, reason, hadOlds[i], olds[i], true, row.price, effectiveAt, now); err != nil {
+			return result, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return result, fmt.Errorf("billing sqlite: commit import: %w", err)
+	}
+	return result, nil
+}
+
+func insertPriceAudit(ctx context.Context, tx *sql.Tx, model, action, actor, reason string, hasOld bool, old PriceMicroUSDPer1M, hasNew bool, newPrice PriceMicroUSDPer1M, effectiveFrom, now int64) error {
+	var oldPrompt, oldCompletion, oldCached, newPrompt, newCompletion, newCached sql.NullInt64
+	if hasOld {
+		oldPrompt = sql.NullInt64{Int64: old.Prompt, Valid: true}
+		oldCompletion = sql.NullInt64{Int64: old.Completion, Valid: true}
+		oldCached = sql.NullInt64{Int64: old.Cached, Valid: true}
+	}
+	if hasNew {
+		newPrompt = sql.NullInt64{Int64: newPrice.Prompt, Valid: true}
+		newCompletion = sql.NullInt64{Int64: newPrice.Completion, Valid: true}
+		newCached = sql.NullInt64{Int64: newPrice.Cached, Valid: true}
+	}
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO price_audit (
+			id, model, action, actor, reason,
+			old_prompt_micro_usd_per_1m, old_completion_micro_usd_per_1m, old_cached_micro_usd_per_1m,
+			new_prompt_micro_usd_per_1m, new_completion_micro_usd_per_1m, new_cached_micro_usd_per_1m,
+			effective_from, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, uuid.NewString(), model, action, actor, reason,
+		oldPrompt, oldCompletion, oldCached,
+		newPrompt, newCompletion, newCached,
+		effectiveFrom, now)
+	if err != nil {
+		return fmt.Errorf("billing sqlite: insert price audit: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) getSavedPriceMicroAt(ctx context.Context, modelKey string, atUnix int64) (PriceMicroUSDPer1M, bool, int64, error) {
 	if s == nil || s.db == nil {
 		return PriceMicroUSDPer1M{}, false, 0, fmt.Errorf("billing sqlite: not initialized")
 	}
 	row := s.db.QueryRowContext(ctx, `
-		SELECT prompt_micro_usd_per_1m, completion_micro_usd_per_1m, cached_micro_usd_per_1m, updated_at
-		FROM model_prices
-		WHERE model = ?
-	`, modelKey)
-	var p, c, cached, updated int64
-	if err := row.Scan(&p, &c, &cached, &updated); err != nil {
+		SELECT prompt_micro_usd_per_1m, completion_micro_usd_per_1m, cached_micro_usd_per_1m, effective_at
+		FROM model_price_history
+		WHERE model = ? AND effective_at <= ?
+		ORDER BY effective_at DESC, created_at DESC
+		LIMIT 1
+	`, modelKey, atUnix)
+	var p, c, cached, effectiveAt int64
+	if err := row.Scan(&p, &c, &cached, &effectiveAt); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return PriceMicroUSDPer1M{}, false, 0, nil
 		}
 		return PriceMicroUSDPer1M{}, false, 0, fmt.Errorf("billing sqlite: query price: %w", err)
 	}
-	return PriceMicroUSDPer1M{Prompt: p, Completion: c, Cached: cached}, true, updated, nil
+	return PriceMicroUSDPer1M{Prompt: p, Completion: c, Cached: cached}, true, effectiveAt, nil
+}
+
+func (s *SQLiteStore) getCatalogPriceMicro(ctx context.Context, modelKey string) (PriceMicroUSDPer1M, bool, error) {
+	if s == nil || s.db == nil {
+		return PriceMicroUSDPer1M{}, false, fmt.Errorf("billing sqlite: not initialized")
+	}
+	row := s.db.QueryRowContext(ctx, `
+		SELECT prompt_micro_usd_per_1m, completion_micro_usd_per_1m, cached_micro_usd_per_1m
+		FROM model_prices_catalog
+		WHERE model = ?
+	`, modelKey)
+	var p, c, cached int64
+	if err := row.Scan(&p, &c, &cached); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return PriceMicroUSDPer1M{}, false, nil
+		}
+		return PriceMicroUSDPer1M{}, false, fmt.Errorf("billing sqlite: query catalog price: %w", err)
+	}
+	return PriceMicroUSDPer1M{Prompt: p, Completion: c, Cached: cached}, true, nil
+}
+
+// UpsertCatalogPrices replaces the catalog tier in one transaction
+// (stage-then-swap): the old table contents are dropped and prices are
+// inserted, skipping any model that already has a row in
+// model_price_history. See Store.UpsertCatalogPrices.
+func (s *SQLiteStore) UpsertCatalogPrices(ctx context.Context, prices map[string]PriceMicroUSDPer1M, etag string) error {
+	if s == nil || s.db == nil {
+		return fmt.Errorf("billing sqlite: not initialized")
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("billing sqlite: begin catalog sync: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM model_prices_catalog`); err != nil {
+		return fmt.Errorf("billing sqlite: clear catalog: %w", err)
+	}
+	now := nowUnixUTC()
+	for rawKey, price := range prices {
+		key := policy.NormaliseModelKey(rawKey)
+		if key == "" {
+			continue
+		}
+		var pinned int
+		if err := tx.QueryRowContext(ctx, `SELECT COUNT(1) FROM model_price_history WHERE model = ?`, key).Scan(&pinned); err != nil {
+			return fmt.Errorf("billing sqlite: check pinned price: %w", err)
+		}
+		if pinned > 0 {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO model_prices_catalog (model, prompt_micro_usd_per_1m, completion_micro_usd_per_1m, cached_micro_usd_per_1m, updated_at)
+			VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT (model) DO UPDATE SET
+				prompt_micro_usd_per_1m = excluded.prompt_micro_usd_per_1m,
+				completion_micro_usd_per_1m = excluded.completion_micro_usd_per_1m,
+				cached_micro_usd_per_1m = excluded.cached_micro_usd_per_1m,
+				updated_at = excluded.updated_at
+		`, key, price.Prompt, price.Completion, price.Cached, now); err != nil {
+			return fmt.Errorf("billing sqlite: upsert catalog price: %w", err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO model_prices_catalog_sync (id, etag, synced_at) VALUES (1, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET etag = excluded.etag, synced_at = excluded.synced_at
+	`, etag, now); err != nil {
+		return fmt.Errorf("billing sqlite: record catalog sync: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("billing sqlite: commit catalog sync: %w", err)
+	}
+	return nil
+}
+
+// GetCatalogETag returns the last synced catalog etag. See
+// Store.GetCatalogETag.
+func (s *SQLiteStore) GetCatalogETag(ctx context.Context) (string, error) {
+	if s == nil || s.db == nil {
+		return "", fmt.Errorf("billing sqlite: not initialized")
+	}
+	var etag string
+	err := s.db.QueryRowContext(ctx, `SELECT etag FROM model_prices_catalog_sync WHERE id = 1`).Scan(&etag)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("billing sqlite: get catalog etag: %w", err)
+	}
+	return etag, nil
 }
 
 func (s *SQLiteStore) ResolvePriceMicro(ctx context.Context, model string) (price PriceMicroUSDPer1M, source string, updatedAt int64, err error) {
+	return s.ResolvePriceMicroAt(ctx, model, nowUnixUTC())
+}
+
+// ResolvePriceMicroAt resolves model's price as it stood at atUnix. See
+// Store.ResolvePriceMicroAt.
+func (s *SQLiteStore) ResolvePriceMicroAt(ctx context.Context, model string, atUnix int64) (price PriceMicroUSDPer1M, source string, effectiveAt int64, err error) {
 	modelKey := policy.NormaliseModelKey(model)
 	if modelKey == "" {
 		return PriceMicroUSDPer1M{}, "", 0, fmt.Errorf("billing sqlite: model is required")
 	}
 	baseKey := policy.StripThinkingVariant(modelKey)
 	if s != nil && s.db != nil {
-		if saved, ok, updated, errGet := s.getSavedPriceMicro(ctx, modelKey); errGet != nil {
+		if saved, ok, effAt, errGet := s.getSavedPriceMicroAt(ctx, modelKey, atUnix); errGet != nil {
+			return PriceMicroUSDPer1M{}, "", 0, errGet
+		} else if ok {
+			return saved, "saved", effAt, nil
+		}
+		if baseKey != "" && baseKey != modelKey {
+			if saved, ok, effAt, errGet := s.getSavedPriceMicroAt(ctx, baseKey, atUnix); errGet != nil {
+				return PriceMicroUSDPer1M{}, "", 0, errGet
+			} else if ok {
+				return saved, "saved", effAt, nil
+			}
+		}
+		if catalog, ok, errGet := s.getCatalogPriceMicro(ctx, modelKey); errGet != nil {
 			return PriceMicroUSDPer1M{}, "", 0, errGet
 		} else if ok {
-			return saved, "saved", updated, nil
+			return catalog, "catalog", 0, nil
 		}
 		if baseKey != "" && baseKey != modelKey {
-			if saved, ok, updated, errGet := s.getSavedPriceMicro(ctx, baseKey); errGet != nil {
+			if catalog, ok, errGet := s.getCatalogPriceMicro(ctx, baseKey); errGet != nil {
 				return PriceMicroUSDPer1M{}, "", 0, errGet
 			} else if ok {
-				return saved, "saved", updated, nil
+				return catalog, "catalog", 0, nil
 			}
 		}
 	}
@@ -204,13 +562,58 @@ func (s *SQLiteStore) ResolvePriceMicro(ctx context.Context, model string) (pric
 	return PriceMicroUSDPer1M{}, "missing", 0, nil
 }
 
+// ListPriceHistory returns model's saved prices, oldest to newest. See
+// Store.ListPriceHistory.
+func (s *SQLiteStore) ListPriceHistory(ctx context.Context, model string) ([]PriceHistoryEntry, error) {
+	if s == nil || s.db == nil {
+		return nil, fmt.Errorf("billing sqlite: not initialized")
+	}
+	key := policy.NormaliseModelKey(model)
+	if key == "" {
+		return nil, fmt.Errorf("billing sqlite: model is required")
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT prompt_micro_usd_per_1m, completion_micro_usd_per_1m, cached_micro_usd_per_1m, effective_at, created_at
+		FROM model_price_history
+		WHERE model = ?
+		ORDER BY effective_at ASC, created_at ASC
+	`, key)
+	if err != nil {
+		return nil, fmt.Errorf("billing sqlite: list price history: %w", err)
+	}
+	defer rows.Close()
+	var out []PriceHistoryEntry
+	for rows.Next() {
+		var p, c, cached, effectiveAt, createdAt int64
+		if err := rows.Scan(&p, &c, &cached, &effectiveAt, &createdAt); err != nil {
+			return nil, fmt.Errorf("billing sqlite: scan price history: %w", err)
+		}
+		out = append(out, PriceHistoryEntry{
+			Model:              key,
+			PromptUSDPer1M:     microUSDPer1MToUSDPer1M(p),
+			CompletionUSDPer1M: microUSDPer1MToUSDPer1M(c),
+			CachedUSDPer1M:     microUSDPer1MToUSDPer1M(cached),
+			EffectiveAt:        effectiveAt,
+			CreatedAt:          createdAt,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("billing sqlite: price history rows: %w", err)
+	}
+	return out, nil
+}
+
 func (s *SQLiteStore) ListModelPrices(ctx context.Context) ([]ModelPrice, error) {
 	saved := map[string]ModelPrice{}
+	catalog := map[string]ModelPrice{}
 	if s != nil && s.db != nil {
 		rows, err := s.db.QueryContext(ctx, `
-			SELECT model, prompt_micro_usd_per_1m, completion_micro_usd_per_1m, cached_micro_usd_per_1m, updated_at
-			FROM model_prices
-			ORDER BY model ASC
+			SELECT h.model, h.prompt_micro_usd_per_1m, h.completion_micro_usd_per_1m, h.cached_micro_usd_per_1m, h.effective_at
+			FROM model_price_history h
+			INNER JOIN (
+				SELECT model, MAX(effective_at) AS effective_at FROM model_price_history GROUP BY model
+			) latest ON latest.model = h.model AND latest.effective_at = h.effective_at
+			ORDER BY h.model ASC
 		`)
 		if err != nil {
 			return nil, fmt.Errorf("billing sqlite: list model prices: %w", err)
@@ -218,8 +621,8 @@ func (s *SQLiteStore) ListModelPrices(ctx context.Context) ([]ModelPrice, error)
 		defer rows.Close()
 		for rows.Next() {
 			var model string
-			var p, c, cached, updated int64
-			if err := rows.Scan(&model, &p, &c, &cached, &updated); err != nil {
+			var p, c, cached, effectiveAt int64
+			if err := rows.Scan(&model, &p, &c, &cached, &effectiveAt); err != nil {
 				return nil, fmt.Errorf("billing sqlite: scan model price: %w", err)
 			}
 			saved[model] = ModelPrice{
@@ -228,36 +631,198 @@ func (s *SQLiteStore) ListModelPrices(ctx context.Context) ([]ModelPrice, error)
 				CompletionUSDPer1M: microUSDPer1MToUSDPer1M(c),
 				CachedUSDPer1M:     microUSDPer1MToUSDPer1M(cached),
 				Source:             "saved",
-				UpdatedAt:          updated,
+				UpdatedAt:          effectiveAt,
 			}
 		}
 		if err := rows.Err(); err != nil {
 			return nil, fmt.Errorf("billing sqlite: list model prices rows: %w", err)
 		}
+
+		catalogRows, err := s.db.QueryContext(ctx, `
+			SELECT model, prompt_micro_usd_per_1m, completion_micro_usd_per_1m, cached_micro_usd_per_1m, updated_at
+			FROM model_prices_catalog
+		`)
+		if err != nil {
+			return nil, fmt.Errorf("billing sqlite: list catalog prices: %w", err)
+		}
+		defer catalogRows.Close()
+		for catalogRows.Next() {
+			var model string
+			var p, c, cached, updatedAt int64
+			if err := catalogRows.Scan(&model, &p, &c, &cached, &updatedAt); err != nil {
+				return nil, fmt.Errorf("billing sqlite: scan catalog price: %w", err)
+			}
+			catalog[model] = ModelPrice{
+				Model:              model,
+				PromptUSDPer1M:     microUSDPer1MToUSDPer1M(p),
+				CompletionUSDPer1M: microUSDPer1MToUSDPer1M(c),
+				CachedUSDPer1M:     microUSDPer1MToUSDPer1M(cached),
+				Source:             "catalog",
+				UpdatedAt:          updatedAt,
+			}
+		}
+		if err := catalogRows.Err(); err != nil {
+			return nil, fmt.Errorf("billing sqlite: list catalog prices rows: %w", err)
+		}
 	}
 
-	merged := make([]ModelPrice, 0, len(DefaultPrices)+len(saved))
+	merged := make(map[string]ModelPrice, len(DefaultPrices)+len(catalog)+len(saved))
 	for k, v := range DefaultPrices {
-		if s, ok := saved[k]; ok {
-			merged = append(merged, s)
-			continue
+		merged[k] = ModelPrice{
+			Model:              k,
+			PromptUSDPer1M:     microUSDPer1MToUSDPer1M(v.Prompt),
+			CompletionUSDPer1M: microUSDPer1MToUSDPer1M(v.Completion),
+			CachedUSDPer1M:     microUSDPer1MToUSDPer1M(v.Cached),
+			Source:             "default",
 		}
-		merged = append(merged, ModelPrice{
+	}
+	for k, v := range catalog {
+		merged[k] = v
+	}
+	for k, v := range saved {
+		merged[k] = v
+	}
+	out := make([]ModelPrice, 0, len(merged))
+	for _, v := range merged {
+		out = append(out, v)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Model < out[j].Model })
+	return out, nil
+}
+
+// ListModelPricesAt is ListModelPrices pinned to atUnix: the "saved" tier
+// only considers model_price_history rows with effective_at <= atUnix, so
+// a later price change does not retroactively change what this call
+// reports for a past instant. The catalog and default tiers are not
+// effective-dated and are reported as-is.
+func (s *SQLiteStore) ListModelPricesAt(ctx context.Context, atUnix int64) ([]ModelPrice, error) {
+	saved := map[string]ModelPrice{}
+	catalog := map[string]ModelPrice{}
+	if s != nil && s.db != nil {
+		rows, err := s.db.QueryContext(ctx, `
+			SELECT h.model, h.prompt_micro_usd_per_1m, h.completion_micro_usd_per_1m, h.cached_micro_usd_per_1m, h.effective_at
+			FROM model_price_history h
+			INNER JOIN (
+				SELECT model, MAX(effective_at) AS effective_at FROM model_price_history WHERE effective_at <= ? GROUP BY model
+			) latest ON latest.model = h.model AND latest.effective_at = h.effective_at
+			WHERE h.effective_at <= ?
+			ORDER BY h.model ASC
+		`, atUnix, atUnix)
+		if err != nil {
+			return nil, fmt.Errorf("billing sqlite: list model prices at: %w", err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var model string
+			var p, c, cached, effectiveAt int64
+			if err := rows.Scan(&model, &p, &c, &cached, &effectiveAt); err != nil {
+				return nil, fmt.Errorf("billing sqlite: scan model price at: %w", err)
+			}
+			saved[model] = ModelPrice{
+				Model:              model,
+				PromptUSDPer1M:     microUSDPer1MToUSDPer1M(p),
+				CompletionUSDPer1M: microUSDPer1MToUSDPer1M(c),
+				CachedUSDPer1M:     microUSDPer1MToUSDPer1M(cached),
+				Source:             "saved",
+				UpdatedAt:          effectiveAt,
+			}
+		}
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("billing sqlite: list model prices at rows: %w", err)
+		}
+
+		catalogRows, err := s.db.QueryContext(ctx, `
+			SELECT model, prompt_micro_usd_per_1m, completion_micro_usd_per_1m, cached_micro_usd_per_1m, updated_at
+			FROM model_prices_catalog
+		`)
+		if err != nil {
+			return nil, fmt.Errorf("billing sqlite: list catalog prices: %w", err)
+		}
+		defer catalogRows.Close()
+		for catalogRows.Next() {
+			var model string
+			var p, c, cached, updatedAt int64
+			if err := catalogRows.Scan(&model, &p, &c, &cached, &updatedAt); err != nil {
+				return nil, fmt.Errorf("billing sqlite: scan catalog price: %w", err)
+			}
+			catalog[model] = ModelPrice{
+				Model:              model,
+				PromptUSDPer1M:     microUSDPer1MToUSDPer1M(p),
+				CompletionUSDPer1M: microUSDPer1MToUSDPer1M(c),
+				CachedUSDPer1M:     microUSDPer1MToUSDPer1M(cached),
+				Source:             "catalog",
+				UpdatedAt:          updatedAt,
+			}
+		}
+		if err := catalogRows.Err(); err != nil {
+			return nil, fmt.Errorf("billing sqlite: list catalog prices rows: %w", err)
+		}
+	}
+
+	merged := make(map[string]ModelPrice, len(DefaultPrices)+len(catalog)+len(saved))
+	for k, v := range DefaultPrices {
+		merged[k] = ModelPrice{
 			Model:              k,
 			PromptUSDPer1M:     microUSDPer1MToUSDPer1M(v.Prompt),
 			CompletionUSDPer1M: microUSDPer1MToUSDPer1M(v.Completion),
 			CachedUSDPer1M:     microUSDPer1MToUSDPer1M(v.Cached),
 			Source:             "default",
-		})
+		}
+	}
+	for k, v := range catalog {
+		merged[k] = v
 	}
 	for k, v := range saved {
-		if _, ok := DefaultPrices[k]; ok {
-			continue
+		merged[k] = v
+	}
+	out := make([]ModelPrice, 0, len(merged))
+	for _, v := range merged {
+		out = append(out, v)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Model < out[j].Model })
+	return out, nil
+}
+
+// ListPriceAudit returns every recorded price audit entry, oldest to
+// newest. See Store.ListPriceAudit.
+func (s *SQLiteStore) ListPriceAudit(ctx context.Context) ([]PriceAuditEntry, error) {
+	if s == nil || s.db == nil {
+		return nil, fmt.Errorf("billing sqlite: not initialized")
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, model, action, actor, reason,
+			old_prompt_micro_usd_per_1m, old_completion_micro_usd_per_1m, old_cached_micro_usd_per_1m,
+			new_prompt_micro_usd_per_1m, new_completion_micro_usd_per_1m, new_cached_micro_usd_per_1m,
+			effective_from, created_at
+		FROM price_audit
+		ORDER BY created_at ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("billing sqlite: list price audit: %w", err)
+	}
+	defer rows.Close()
+	var out []PriceAuditEntry
+	for rows.Next() {
+		var e PriceAuditEntry
+		var oldPrompt, oldCompletion, oldCached, newPrompt, newCompletion, newCached sql.NullInt64
+		if err := rows.Scan(&e.ID, &e.Model, &e.Action, &e.Actor, &e.Reason,
+			&oldPrompt, &oldCompletion, &oldCached,
+			&newPrompt, &newCompletion, &newCached,
+			&e.EffectiveFrom, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("billing sqlite: scan price audit: %w", err)
+		}
+		if oldPrompt.Valid {
+			e.OldPrice = &PriceMicroUSDPer1M{Prompt: oldPrompt.Int64, Completion: oldCompletion.Int64, Cached: oldCached.Int64}
 		}
-		merged = append(merged, v)
+		if newPrompt.Valid {
+			e.NewPrice = &PriceMicroUSDPer1M{Prompt: newPrompt.Int64, Completion: newCompletion.Int64, Cached: newCached.Int64}
+		}
+		out = append(out, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("billing sqlite: price audit rows: %w", err)
 	}
-	sort.Slice(merged, func(i, j int) bool { return merged[i].Model < merged[j].Model })
-	return merged, nil
+	return out, nil
 }
 
 func (s *SQLiteStore) AddUsage(ctx context.Context, apiKey, model, dayKey string, delta DailyUsageRow) error {
@@ -303,6 +868,10 @@ func (s *SQLiteStore) AddUsage(ctx context.Context, apiKey, model, dayKey string
 	return nil
 }
 
+// GetDailyCostMicroUSD returns committed usage cost plus any outstanding
+// budget reservations for (apiKey, dayKey), so an in-flight streaming
+// request that has reserved budget but not yet committed its actual usage
+// still counts against the daily cap.
 func (s *SQLiteStore) GetDailyCostMicroUSD(ctx context.Context, apiKey, dayKey string) (int64, error) {
 	if s == nil || s.db == nil {
 		return 0, fmt.Errorf("billing sqlite: not initialized")
@@ -313,10 +882,10 @@ func (s *SQLiteStore) GetDailyCostMicroUSD(ctx context.Context, apiKey, dayKey s
 		return 0, fmt.Errorf("billing sqlite: invalid inputs")
 	}
 	row := s.db.QueryRowContext(ctx, `
-		SELECT COALESCE(SUM(cost_micro_usd), 0)
-		FROM api_key_model_daily_usage
-		WHERE api_key = ? AND day = ?
-	`, apiKey, dayKey)
+		SELECT
+			COALESCE((SELECT SUM(cost_micro_usd) FROM api_key_model_daily_usage WHERE api_key = ? AND day = ?), 0) +
+			COALESCE((SELECT SUM(estimated_micro_usd) FROM budget_reservations WHERE api_key = ? AND day = ?), 0)
+	`, apiKey, dayKey, apiKey, dayKey)
 	var total int64
 	if err := row.Scan(&total); err != nil {
 		return 0, fmt.Errorf("billing sqlite: daily cost: %w", err)
@@ -324,6 +893,354 @@ func (s *SQLiteStore) GetDailyCostMicroUSD(ctx context.Context, apiKey, dayKey s
 	return total, nil
 }
 
+// ReserveBudget records a pending spend estimate for (apiKey, dayKey) so it is
+// reflected by GetDailyCostMicroUSD before the request's actual cost is known
+// (e.g. while a streaming completion is still in flight). The caller must
+// eventually call CommitReservation or ReleaseReservation with the returned
+// reservationID to clear the hold.
+func (s *SQLiteStore) ReserveBudget(ctx context.Context, apiKey, dayKey string, estimatedMicroUSD int64) (reservationID string, err error) {
+	if s == nil || s.db == nil {
+		return "", fmt.Errorf("billing sqlite: not initialized")
+	}
+	apiKey = strings.TrimSpace(apiKey)
+	dayKey = strings.TrimSpace(dayKey)
+	if apiKey == "" || dayKey == "" {
+		return "", fmt.Errorf("billing sqlite: invalid inputs")
+	}
+	if estimatedMicroUSD < 0 {
+		estimatedMicroUSD = 0
+	}
+	reservationID = uuid.NewString()
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO budget_reservations (id, api_key, day, estimated_micro_usd, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, reservationID, apiKey, dayKey, estimatedMicroUSD, nowUnixUTC())
+	if err != nil {
+		return "", fmt.Errorf("billing sqlite: reserve budget: %w", err)
+	}
+	return reservationID, nil
+}
+
+// CommitReservation clears the reservation identified by reservationID and
+// records the actual usage (including its real cost) via AddUsage. The
+// estimate is dropped from budget_reservations at the same time, so the
+// request's hold is replaced by its committed cost with no double counting.
+func (s *SQLiteStore) CommitReservation(ctx context.Context, reservationID, apiKey, model, dayKey string, actual DailyUsageRow) error {
+	if s == nil || s.db == nil {
+		return fmt.Errorf("billing sqlite: not initialized")
+	}
+	if strings.TrimSpace(reservationID) == "" {
+		return fmt.Errorf("billing sqlite: reservation id is required")
+	}
+	if err := s.AddUsage(ctx, apiKey, model, dayKey, actual); err != nil {
+		return err
+	}
+	return s.deleteReservation(ctx, reservationID)
+}
+
+// ReleaseReservation drops a reservation without recording any usage, e.g.
+// when the upstream request failed before any chargeable work happened.
+func (s *SQLiteStore) ReleaseReservation(ctx context.Context, reservationID string) error {
+	if strings.TrimSpace(reservationID) == "" {
+		return fmt.Errorf("billing sqlite: reservation id is required")
+	}
+	return s.deleteReservation(ctx, reservationID)
+}
+
+func (s *SQLiteStore) deleteReservation(ctx context.Context, reservationID string) error {
+	if s == nil || s.db == nil {
+		return fmt.Errorf("billing sqlite: not initialized")
+	}
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM budget_reservations WHERE id = ?`, reservationID); err != nil {
+		return fmt.Errorf("billing sqlite: clear reservation: %w", err)
+	}
+	return nil
+}
+
+// UpsertBudget creates or replaces a configured budget. See Store.UpsertBudget.
+func (s *SQLiteStore) UpsertBudget(ctx context.Context, b Budget) (Budget, error) {
+	if s == nil || s.db == nil {
+		return Budget{}, fmt.Errorf("billing sqlite: not initialized")
+	}
+	if strings.TrimSpace(b.Scope) == "" {
+		return Budget{}, fmt.Errorf("billing sqlite: scope is required")
+	}
+	if strings.TrimSpace(b.Period) == "" {
+		return Budget{}, fmt.Errorf("billing sqlite: period is required")
+	}
+	if strings.TrimSpace(b.Action) == "" {
+		return Budget{}, fmt.Errorf("billing sqlite: action is required")
+	}
+	now := nowUnixUTC()
+	if strings.TrimSpace(b.ID) == "" {
+		b.ID = uuid.NewString()
+		b.CreatedAt = now
+	} else {
+		var existingCreatedAt int64
+		err := s.db.QueryRowContext(ctx, `SELECT created_at FROM budgets WHERE id = ?`, b.ID).Scan(&existingCreatedAt)
+		switch {
+		case err == nil:
+			b.CreatedAt = existingCreatedAt
+		case errors.Is(err, sql.ErrNoRows):
+			b.CreatedAt = now
+		default:
+			return Budget{}, fmt.Errorf("billing sqlite: lookup budget: %w", err)
+		}
+	}
+	b.UpdatedAt = now
+
+	row := s.db.QueryRowContext(ctx, `
+		INSERT INTO budgets (id, scope, api_key, model, period, limit_usd, action, soft_threshold_pct, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			scope = excluded.scope,
+			api_key = excluded.api_key,
+			model = excluded.model,
+			period = excluded.period,
+			limit_usd = excluded.limit_usd,
+			action = excluded.action,
+			soft_threshold_pct = excluded.soft_threshold_pct,
+			updated_at = excluded.updated_at
+		RETURNING created_at
+	`, b.ID, b.Scope, b.APIKey, b.Model, b.Period, b.LimitUSD, b.Action, b.SoftThresholdPct, b.CreatedAt, b.UpdatedAt)
+	if err := row.Scan(&b.CreatedAt); err != nil {
+		return Budget{}, fmt.Errorf("billing sqlite: upsert budget: %w", err)
+	}
+	return b, nil
+}
+
+// DeleteBudget removes a configured budget and its spend history. See
+// Store.DeleteBudget.
+func (s *SQLiteStore) DeleteBudget(ctx context.Context, id string) (bool, error) {
+	if s == nil || s.db == nil {
+		return false, fmt.Errorf("billing sqlite: not initialized")
+	}
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return false, fmt.Errorf("billing sqlite: id is required")
+	}
+	res, err := s.db.ExecContext(ctx, `DELETE FROM budgets WHERE id = ?`, id)
+	if err != nil {
+		return false, fmt.Errorf("billing sqlite: delete budget: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	if n == 0 {
+		return false, nil
+	}
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM budget_spend WHERE budget_id = ?`, id); err != nil {
+		return false, fmt.Errorf("billing sqlite: clear budget spend: %w", err)
+	}
+	return true, nil
+}
+
+// ListBudgets returns every configured budget. See Store.ListBudgets.
+func (s *SQLiteStore) ListBudgets(ctx context.Context) ([]Budget, error) {
+	if s == nil || s.db == nil {
+		return nil, fmt.Errorf("billing sqlite: not initialized")
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, scope, api_key, model, period, limit_usd, action, soft_threshold_pct, created_at, updated_at
+		FROM budgets
+		ORDER BY created_at ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("billing sqlite: list budgets: %w", err)
+	}
+	defer rows.Close()
+	var out []Budget
+	for rows.Next() {
+		var b Budget
+		if err := rows.Scan(&b.ID, &b.Scope, &b.APIKey, &b.Model, &b.Period, &b.LimitUSD, &b.Action, &b.SoftThresholdPct, &b.CreatedAt, &b.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("billing sqlite: scan budget: %w", err)
+		}
+		out = append(out, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("billing sqlite: budgets rows: %w", err)
+	}
+	return out, nil
+}
+
+// ChargeBudgetSpend adds deltaMicroUSD to budget id's running spend for
+// periodKey. See Store.ChargeBudgetSpend.
+func (s *SQLiteStore) ChargeBudgetSpend(ctx context.Context, id, periodKey string, deltaMicroUSD int64) (int64, error) {
+	if s == nil || s.db == nil {
+		return 0, fmt.Errorf("billing sqlite: not initialized")
+	}
+	id = strings.TrimSpace(id)
+	periodKey = strings.TrimSpace(periodKey)
+	if id == "" || periodKey == "" {
+		return 0, fmt.Errorf("billing sqlite: id and period_key are required")
+	}
+	if deltaMicroUSD < 0 {
+		deltaMicroUSD = 0
+	}
+	row := s.db.QueryRowContext(ctx, `
+		INSERT INTO budget_spend (budget_id, period_key, spent_micro_usd, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(budget_id, period_key) DO UPDATE SET
+			spent_micro_usd = spent_micro_usd + excluded.spent_micro_usd,
+			updated_at = excluded.updated_at
+		RETURNING spent_micro_usd
+	`, id, periodKey, deltaMicroUSD, nowUnixUTC())
+	var spent int64
+	if err := row.Scan(&spent); err != nil {
+		return 0, fmt.Errorf("billing sqlite: charge budget spend: %w", err)
+	}
+	return spent, nil
+}
+
+// GetBudgetSpend returns budget id's running spend for periodKey. See
+// Store.GetBudgetSpend.
+func (s *SQLiteStore) GetBudgetSpend(ctx context.Context, id, periodKey string) (int64, error) {
+	if s == nil || s.db == nil {
+		return 0, fmt.Errorf("billing sqlite: not initialized")
+	}
+	id = strings.TrimSpace(id)
+	periodKey = strings.TrimSpace(periodKey)
+	if id == "" || periodKey == "" {
+		return 0, fmt.Errorf("billing sqlite: id and period_key are required")
+	}
+	var spent int64
+	row := s.db.QueryRowContext(ctx, `SELECT spent_micro_usd FROM budget_spend WHERE budget_id = ? AND period_key = ?`, id, periodKey)
+	if err := row.Scan(&spent); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("billing sqlite: get budget spend: %w", err)
+	}
+	return spent, nil
+}
+
+// PruneBudgetSpend deletes budget_spend rows last updated before
+// olderThanUnix. See Store.PruneBudgetSpend.
+func (s *SQLiteStore) PruneBudgetSpend(ctx context.Context, olderThanUnix int64) (int64, error) {
+	if s == nil || s.db == nil {
+		return 0, fmt.Errorf("billing sqlite: not initialized")
+	}
+	res, err := s.db.ExecContext(ctx, `DELETE FROM budget_spend WHERE updated_at < ?`, olderThanUnix)
+	if err != nil {
+		return 0, fmt.Errorf("billing sqlite: prune budget spend: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("billing sqlite: prune budget spend: %w", err)
+	}
+	return n, nil
+}
+
+// ListUsageRows returns apiKey's per-model usage rows with day in
+// [fromDay, toDay]. See Store.ListUsageRows.
+func (s *SQLiteStore) ListUsageRows(ctx context.Context, apiKey, fromDay, toDay string) ([]DailyUsageRow, error) {
+	if s == nil || s.db == nil {
+		return nil, fmt.Errorf("billing sqlite: not initialized")
+	}
+	apiKey = strings.TrimSpace(apiKey)
+	fromDay = strings.TrimSpace(fromDay)
+	toDay = strings.TrimSpace(toDay)
+	if apiKey == "" || fromDay == "" || toDay == "" {
+		return nil, fmt.Errorf("billing sqlite: invalid inputs")
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT
+			api_key, model, day,
+			requests, failed_requests,
+			input_tokens, output_tokens, reasoning_tokens, cached_tokens, total_tokens,
+			cost_micro_usd, updated_at
+		FROM api_key_model_daily_usage
+		WHERE api_key = ? AND day >= ? AND day <= ?
+		ORDER BY day ASC, model ASC
+	`, apiKey, fromDay, toDay)
+	if err != nil {
+		return nil, fmt.Errorf("billing sqlite: list usage rows: %w", err)
+	}
+	defer rows.Close()
+	var out []DailyUsageRow
+	for rows.Next() {
+		var row DailyUsageRow
+		if err := rows.Scan(
+			&row.APIKey, &row.Model, &row.Day,
+			&row.Requests, &row.FailedRequests,
+			&row.InputTokens, &row.OutputTokens, &row.ReasoningTokens, &row.CachedTokens, &row.TotalTokens,
+			&row.CostMicroUSD, &row.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("billing sqlite: scan usage row: %w", err)
+		}
+		out = append(out, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("billing sqlite: usage rows: %w", err)
+	}
+	return out, nil
+}
+
+// ListUsageRowsAllKeys returns every API key's per-model usage rows with day
+// in [fromDay, toDay]. See Store.ListUsageRowsAllKeys.
+func (s *SQLiteStore) ListUsageRowsAllKeys(ctx context.Context, fromDay, toDay string) ([]DailyUsageRow, error) {
+	if s == nil || s.db == nil {
+		return nil, fmt.Errorf("billing sqlite: not initialized")
+	}
+	fromDay = strings.TrimSpace(fromDay)
+	toDay = strings.TrimSpace(toDay)
+	if fromDay == "" || toDay == "" {
+		return nil, fmt.Errorf("billing sqlite: invalid inputs")
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT
+			api_key, model, day,
+			requests, failed_requests,
+			input_tokens, output_tokens, reasoning_tokens, cached_tokens, total_tokens,
+			cost_micro_usd, updated_at
+		FROM api_key_model_daily_usage
+		WHERE day >= ? AND day <= ?
+		ORDER BY day ASC, api_key ASC, model ASC
+	`, fromDay, toDay)
+	if err != nil {
+		return nil, fmt.Errorf("billing sqlite: list usage rows: %w", err)
+	}
+	defer rows.Close()
+	var out []DailyUsageRow
+	for rows.Next() {
+		var row DailyUsageRow
+		if err := rows.Scan(
+			&row.APIKey, &row.Model, &row.Day,
+			&row.Requests, &row.FailedRequests,
+			&row.InputTokens, &row.OutputTokens, &row.ReasoningTokens, &row.CachedTokens, &row.TotalTokens,
+			&row.CostMicroUSD, &row.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("billing sqlite: scan usage row: %w", err)
+		}
+		out = append(out, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("billing sqlite: usage rows: %w", err)
+	}
+	return out, nil
+}
+
+// SetUsageCostMicro overwrites the stored cost for one usage row. See
+// Store.SetUsageCostMicro.
+func (s *SQLiteStore) SetUsageCostMicro(ctx context.Context, apiKey, model, dayKey string, costMicro int64) error {
+	if s == nil || s.db == nil {
+		return fmt.Errorf("billing sqlite: not initialized")
+	}
+	apiKey = strings.TrimSpace(apiKey)
+	modelKey := policy.NormaliseModelKey(model)
+	dayKey = strings.TrimSpace(dayKey)
+	if apiKey == "" || modelKey == "" || dayKey == "" {
+		return fmt.Errorf("billing sqlite: invalid inputs")
+	}
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE api_key_model_daily_usage
+		SET cost_micro_usd = ?, updated_at = ?
+		WHERE api_key = ? AND model = ? AND day = ?
+	`, max64(0, costMicro), nowUnixUTC(), apiKey, modelKey, dayKey)
+	if err != nil {
+		return fmt.Errorf("billing sqlite: set usage cost: %w", err)
+	}
+	return nil
+}
+
 func (s *SQLiteStore) GetDailyUsageReport(ctx context.Context, apiKey, dayKey string) (DailyUsageReport, error) {
 	report := DailyUsageReport{
 		APIKey:          strings.TrimSpace(apiKey),
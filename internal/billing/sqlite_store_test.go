@@ -4,6 +4,7 @@ import (
 	"context"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/policy"
 )
@@ -47,6 +48,111 @@ func TestSQLiteStore_ModelPrices_DefaultAndOverride(t *testing.T) {
 	}
 }
 
+func TestSQLiteStore_ResolvePriceMicroAt_UsesEffectiveDatedHistory(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "billing.sqlite")
+	store, err := NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("NewSQLiteStore: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	model := "claude-opus-4-5-20251101"
+
+	first := PriceMicroUSDPer1M{Prompt: 1, Completion: 2, Cached: 3}
+	if err := store.UpsertModelPrice(ctx, model, first); err != nil {
+		t.Fatalf("UpsertModelPrice(first): %v", err)
+	}
+	history, err := store.ListPriceHistory(ctx, model)
+	if err != nil {
+		t.Fatalf("ListPriceHistory: %v", err)
+	}
+	if len(history) != 1 {
+		t.Fatalf("history=%d entries, want 1", len(history))
+	}
+	firstEffectiveAt := history[0].EffectiveAt
+
+	// EffectiveAt has one-second resolution; sleep past it so the second
+	// price is unambiguously later than the first.
+	time.Sleep(1100 * time.Millisecond)
+
+	second := PriceMicroUSDPer1M{Prompt: 10, Completion: 20, Cached: 30}
+	if err := store.UpsertModelPrice(ctx, model, second); err != nil {
+		t.Fatalf("UpsertModelPrice(second): %v", err)
+	}
+
+	latest, source, _, err := store.ResolvePriceMicro(ctx, model)
+	if err != nil {
+		t.Fatalf("ResolvePriceMicro: %v", err)
+	}
+	if source != "saved" || latest != second {
+		t.Fatalf("latest=%+v source=%q, want=%+v", latest, source, second)
+	}
+
+	historical, source, _, err := store.ResolvePriceMicroAt(ctx, model, firstEffectiveAt)
+	if err != nil {
+		t.Fatalf("ResolvePriceMicroAt: %v", err)
+	}
+	if source != "saved" || historical != first {
+		t.Fatalf("historical=%+v source=%q, want=%+v", historical, source, first)
+	}
+
+	history, err = store.ListPriceHistory(ctx, model)
+	if err != nil {
+		t.Fatalf("ListPriceHistory: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("history=%d entries, want 2", len(history))
+	}
+}
+
+func TestSQLiteStore_PriceAudit_RecordsUpsertAndDelete(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "billing.sqlite")
+	store, err := NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("NewSQLiteStore: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	model := "claude-opus-4-5-20251101"
+
+	first := PriceMicroUSDPer1M{Prompt: 1, Completion: 2, Cached: 3}
+	if err := store.UpsertModelPriceWithAudit(ctx, model, first, 0, "actor-1", "initial price"); err != nil {
+		t.Fatalf("UpsertModelPriceWithAudit(first): %v", err)
+	}
+	second := PriceMicroUSDPer1M{Prompt: 10, Completion: 20, Cached: 30}
+	if err := store.UpsertModelPriceWithAudit(ctx, model, second, 0, "actor-2", "price hike"); err != nil {
+		t.Fatalf("UpsertModelPriceWithAudit(second): %v", err)
+	}
+	deleted, err := store.DeleteModelPriceWithAudit(ctx, model, "actor-3", "rollback")
+	if err != nil {
+		t.Fatalf("DeleteModelPriceWithAudit: %v", err)
+	}
+	if !deleted {
+		t.Fatal("DeleteModelPriceWithAudit reported not found")
+	}
+
+	entries, err := store.ListPriceAudit(ctx)
+	if err != nil {
+		t.Fatalf("ListPriceAudit: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("entries=%d, want 3", len(entries))
+	}
+	if entries[0].Action != "upsert" || entries[0].Actor != "actor-1" || entries[0].OldPrice != nil {
+		t.Fatalf("entries[0]=%+v", entries[0])
+	}
+	if entries[1].Action != "upsert" || entries[1].OldPrice == nil || *entries[1].OldPrice != first {
+		t.Fatalf("entries[1]=%+v", entries[1])
+	}
+	if entries[2].Action != "delete" || entries[2].Reason != "rollback" || entries[2].NewPrice != nil {
+		t.Fatalf("entries[2]=%+v", entries[2])
+	}
+}
+
 func TestSQLiteStore_AddUsageAndDailyCost(t *testing.T) {
 	dir := t.TempDir()
 	dbPath := filepath.Join(dir, "billing.sqlite")
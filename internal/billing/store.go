@@ -0,0 +1,154 @@
+package billing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Store is the storage surface required by the billing subsystem: model
+// pricing and per-API-key daily usage accounting. SQLiteStore, PostgresStore,
+// and MemoryStore all implement it so callers can select a backend via
+// config without changing call sites.
+type Store interface {
+	DailyCostReader
+
+	UpsertModelPrice(ctx context.Context, model string, price PriceMicroUSDPer1M) error
+	DeleteModelPrice(ctx context.Context, model string) (bool, error)
+	ResolvePriceMicro(ctx context.Context, model string) (price PriceMicroUSDPer1M, source string, updatedAt int64, err error)
+	ListModelPrices(ctx context.Context) ([]ModelPrice, error)
+
+	// ResolvePriceMicroAt resolves model's price as it stood at atUnix rather
+	// than the latest saved price, so usage recorded before a later price
+	// change re-prices with the rate that was actually in effect at atUnix.
+	ResolvePriceMicroAt(ctx context.Context, model string, atUnix int64) (price PriceMicroUSDPer1M, source string, effectiveAt int64, err error)
+	// ListPriceHistory returns every saved price for model, ordered oldest to
+	// newest by EffectiveAt.
+	ListPriceHistory(ctx context.Context, model string) ([]PriceHistoryEntry, error)
+	// ListModelPricesAt returns the latest saved (or catalog-default) price
+	// for every model as it stood at atUnix, mirroring ListModelPrices but
+	// pinned to a point in time rather than "now".
+	ListModelPricesAt(ctx context.Context, atUnix int64) ([]ModelPrice, error)
+
+	// UpsertModelPriceWithAudit is UpsertModelPrice plus a PriceAuditEntry
+	// recording who changed the price, to what, and why. effectiveFrom
+	// overrides the history entry's EffectiveAt (use 0 for "now"). actor and
+	// reason are stored on the audit entry only and never affect pricing.
+	UpsertModelPriceWithAudit(ctx context.Context, model string, price PriceMicroUSDPer1M, effectiveFrom int64, actor, reason string) error
+	// DeleteModelPriceWithAudit is DeleteModelPrice plus a PriceAuditEntry
+	// recording who removed the saved price and why.
+	DeleteModelPriceWithAudit(ctx context.Context, model, actor, reason string) (bool, error)
+	// ListPriceAudit returns every recorded price audit entry, ordered oldest
+	// to newest by CreatedAt.
+	ListPriceAudit(ctx context.Context) ([]PriceAuditEntry, error)
+
+	// ImportModelPrices validates every entry, diffs it against the current
+	// ListModelPrices table, and - unless dryRun or any entry fails
+	// validation - applies every valid entry's UpsertModelPriceWithAudit in a
+	// single store transaction, so a bad row can never leave the price table
+	// half-updated. actor and reason are recorded on each resulting
+	// PriceAuditEntry exactly as UpsertModelPriceWithAudit does.
+	ImportModelPrices(ctx context.Context, entries []PriceImportEntry, actor, reason string, dryRun bool) (PriceImportResult, error)
+
+	// UpsertCatalogPrices atomically replaces the catalog-sourced price tier
+	// with prices (keyed by raw model name) and records etag as the synced
+	// document's version. A model with an explicit saved (operator-pinned)
+	// price is left untouched, so a catalog sync can never clobber a manual
+	// override. See PriceCatalogSyncer.
+	UpsertCatalogPrices(ctx context.Context, prices map[string]PriceMicroUSDPer1M, etag string) error
+	// GetCatalogETag returns the etag recorded by the last successful
+	// UpsertCatalogPrices call, or "" if the catalog has never been synced.
+	GetCatalogETag(ctx context.Context) (string, error)
+
+	AddUsage(ctx context.Context, apiKey, model, dayKey string, delta DailyUsageRow) error
+	GetDailyUsageReport(ctx context.Context, apiKey, dayKey string) (DailyUsageReport, error)
+	// ListUsageRows returns every per-model daily usage row for apiKey with
+	// day in [fromDay, toDay] (inclusive). It backs weekly/monthly rollups
+	// and ExportUsage.
+	ListUsageRows(ctx context.Context, apiKey, fromDay, toDay string) ([]DailyUsageRow, error)
+	// ListUsageRowsAllKeys returns every per-API-key, per-model daily usage
+	// row with day in [fromDay, toDay] (inclusive), across every API key. It
+	// backs GetRangeUsageReport's per-provider and per-API-key breakdowns.
+	ListUsageRowsAllKeys(ctx context.Context, fromDay, toDay string) ([]DailyUsageRow, error)
+	// SetUsageCostMicro overwrites the stored CostMicroUSD for one
+	// (apiKey, model, day) row, leaving its other counters untouched.
+	// Reconcile uses it to re-price a day's usage after a retroactive price
+	// change.
+	SetUsageCostMicro(ctx context.Context, apiKey, model, dayKey string, costMicro int64) error
+
+	// ReserveBudget holds estimatedMicroUSD against (apiKey, dayKey) so
+	// GetDailyCostMicroUSD reflects it before the request's actual cost is
+	// known, e.g. while a streaming completion is still in flight. The
+	// returned reservationID must be passed to CommitReservation or
+	// ReleaseReservation once the request finishes.
+	ReserveBudget(ctx context.Context, apiKey, dayKey string, estimatedMicroUSD int64) (reservationID string, err error)
+	// CommitReservation records the actual usage for the reserved request and
+	// clears the reservation, so the estimate is replaced by the real cost
+	// with no double counting.
+	CommitReservation(ctx context.Context, reservationID, apiKey, model, dayKey string, actual DailyUsageRow) error
+	// ReleaseReservation clears a reservation without recording any usage.
+	ReleaseReservation(ctx context.Context, reservationID string) error
+
+	// UpsertBudget creates (when b.ID is empty) or replaces a configured
+	// Budget and returns it with ID/CreatedAt/UpdatedAt populated. See
+	// BudgetManager.
+	UpsertBudget(ctx context.Context, b Budget) (Budget, error)
+	// DeleteBudget removes a configured budget by id, along with its spend
+	// history.
+	DeleteBudget(ctx context.Context, id string) (bool, error)
+	// ListBudgets returns every configured budget.
+	ListBudgets(ctx context.Context) ([]Budget, error)
+	// ChargeBudgetSpend adds deltaMicroUSD to budget id's running spend for
+	// periodKey and returns the new total, persisting it so a restart does
+	// not grant free budget.
+	ChargeBudgetSpend(ctx context.Context, id, periodKey string, deltaMicroUSD int64) (spentMicroUSD int64, err error)
+	// GetBudgetSpend returns budget id's running spend for periodKey (0 if
+	// nothing has been charged yet).
+	GetBudgetSpend(ctx context.Context, id, periodKey string) (spentMicroUSD int64, err error)
+	// PruneBudgetSpend deletes budget_spend rows last updated before
+	// olderThanUnix and returns how many were removed. It is a pure garbage
+	// collector over rows from periods that have already rolled over - period
+	// rollover itself is implicit in ChargeBudgetSpend/GetBudgetSpend's
+	// per-periodKey keying, so this never affects enforcement. See
+	// BudgetResetScheduler.
+	PruneBudgetSpend(ctx context.Context, olderThanUnix int64) (int64, error)
+
+	Close() error
+}
+
+var (
+	_ Store = (*SQLiteStore)(nil)
+	_ Store = (*PostgresStore)(nil)
+	_ Store = (*MemoryStore)(nil)
+)
+
+// StoreConfig selects and configures the billing storage backend.
+type StoreConfig struct {
+	// Driver is one of "sqlite" (default), "postgres", "cockroach", or "memory".
+	// "cockroach" is accepted as an alias for "postgres" since CockroachDB speaks
+	// the PostgreSQL wire protocol and the same upsert statements apply.
+	Driver string
+
+	// Path is the SQLite database file path. Required when Driver is "sqlite".
+	Path string
+
+	// DSN is the connection string for Driver "postgres"/"cockroach".
+	DSN string
+}
+
+// NewStore builds the Store backend selected by cfg.Driver.
+func NewStore(cfg StoreConfig) (Store, error) {
+	switch strings.ToLower(strings.TrimSpace(cfg.Driver)) {
+	case "", "sqlite":
+		return NewSQLiteStore(cfg.Path)
+	case "postgres", "postgresql", "cockroach", "cockroachdb":
+		return NewPostgresStore(cfg.DSN)
+	case "memory", "in-memory", "mem":
+		return NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("billing store: unknown driver %q", cfg.Driver)
+	}
+}
+
+func errRequired(field string) error { return fmt.Errorf("billing memory: %s is required", field) }
+func errInvalid(what string) error   { return fmt.Errorf("billing memory: invalid %s", what) }
@@ -0,0 +1,39 @@
+package billing
+
+import "context"
+
+// StreamBudgetGuard periodically re-checks a streaming request's projected
+// daily cost - committed usage (from a DailyCostReader, typically the
+// billing Store) plus the request's own running in-flight estimate (from an
+// InFlightTracker) - against a policy's daily budget. This lets a long
+// streaming completion be aborted as soon as its projected cost crosses the
+// budget, rather than only being caught by APIKeyPolicyMiddleware's one-time
+// check before the request started.
+type StreamBudgetGuard struct {
+	store    DailyCostReader
+	inFlight *InFlightTracker
+}
+
+// NewStreamBudgetGuard returns a StreamBudgetGuard reading committed cost
+// from store and in-flight estimates from inFlight. Either may be nil: a nil
+// store makes ProjectedCostExceedsBudget a no-op, and a nil inFlight behaves
+// as an always-empty tracker.
+func NewStreamBudgetGuard(store DailyCostReader, inFlight *InFlightTracker) *StreamBudgetGuard {
+	return &StreamBudgetGuard{store: store, inFlight: inFlight}
+}
+
+// ProjectedCostExceedsBudget reports whether apiKey's committed daily cost
+// plus its current in-flight estimate has reached or passed budgetMicroUSD.
+// It returns the projected total alongside the verdict so callers can log or
+// audit it.
+func (g *StreamBudgetGuard) ProjectedCostExceedsBudget(ctx context.Context, apiKey, dayKey string, budgetMicroUSD int64) (exceeded bool, projectedMicroUSD int64, err error) {
+	if g == nil || g.store == nil || budgetMicroUSD <= 0 {
+		return false, 0, nil
+	}
+	committed, err := g.store.GetDailyCostMicroUSD(ctx, apiKey, dayKey)
+	if err != nil {
+		return false, 0, err
+	}
+	projected := committed + g.inFlight.Snapshot(apiKey)
+	return projected >= budgetMicroUSD, projected, nil
+}
@@ -0,0 +1,57 @@
+package billing
+
+import (
+	"context"
+	"testing"
+)
+
+func TestStreamBudgetGuard_ExceedsBudgetCombinesCommittedAndInFlight(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	apiKey, dayKey := "key-a", "2026-01-01"
+
+	if err := store.AddUsage(ctx, apiKey, "gpt-5-high", dayKey, DailyUsageRow{CostMicroUSD: 3_000_000}); err != nil {
+		t.Fatalf("AddUsage: %v", err)
+	}
+
+	tracker := NewInFlightTracker()
+	tracker.Set(apiKey, "req-1", 2_500_000)
+
+	guard := NewStreamBudgetGuard(store, tracker)
+
+	exceeded, projected, err := guard.ProjectedCostExceedsBudget(ctx, apiKey, dayKey, 5_000_000)
+	if err != nil {
+		t.Fatalf("ProjectedCostExceedsBudget: %v", err)
+	}
+	if !exceeded {
+		t.Fatalf("expected 3_000_000+2_500_000 to exceed a 5_000_000 budget, got projected=%d", projected)
+	}
+	if projected != 5_500_000 {
+		t.Fatalf("projected=%d, want 5_500_000", projected)
+	}
+
+	exceeded, _, err = guard.ProjectedCostExceedsBudget(ctx, apiKey, dayKey, 10_000_000)
+	if err != nil {
+		t.Fatalf("ProjectedCostExceedsBudget: %v", err)
+	}
+	if exceeded {
+		t.Fatal("expected projected cost not to exceed a 10_000_000 budget")
+	}
+}
+
+func TestStreamBudgetGuard_NilStoreIsNoOp(t *testing.T) {
+	guard := NewStreamBudgetGuard(nil, NewInFlightTracker())
+	exceeded, projected, err := guard.ProjectedCostExceedsBudget(context.Background(), "key", "2026-01-01", 1_000_000)
+	if err != nil || exceeded || projected != 0 {
+		t.Fatalf("exceeded=%v projected=%d err=%v, want false/0/nil", exceeded, projected, err)
+	}
+}
+
+func TestStreamBudgetGuard_ZeroBudgetNeverExceeds(t *testing.T) {
+	store := NewMemoryStore()
+	guard := NewStreamBudgetGuard(store, NewInFlightTracker())
+	exceeded, _, err := guard.ProjectedCostExceedsBudget(context.Background(), "key", "2026-01-01", 0)
+	if err != nil || exceeded {
+		t.Fatalf("exceeded=%v err=%v, want false/nil for a zero (unset) budget", exceeded, err)
+	}
+}
@@ -19,6 +19,35 @@ type ModelPrice struct {
 	UpdatedAt          int64   `json:"updated_at,omitempty"`
 }
 
+// PriceHistoryEntry is one effective-dated price record for a model. Each
+// UpsertModelPrice call appends a new entry rather than overwriting the
+// previous one, so usage that happened before a price change can still be
+// re-priced with the rate that was actually in effect at the time.
+type PriceHistoryEntry struct {
+	Model              string  `json:"model"`
+	PromptUSDPer1M     float64 `json:"prompt_usd_per_1m"`
+	CompletionUSDPer1M float64 `json:"completion_usd_per_1m"`
+	CachedUSDPer1M     float64 `json:"cached_usd_per_1m"`
+	EffectiveAt        int64   `json:"effective_at"`
+	CreatedAt          int64   `json:"created_at"`
+}
+
+// PriceAuditEntry records one UpsertModelPriceWithAudit or
+// DeleteModelPriceWithAudit call: who made it, what the price was before and
+// after, and why. OldPrice/NewPrice are nil when there was no saved price on
+// that side of the change (e.g. NewPrice is nil for a delete).
+type PriceAuditEntry struct {
+	ID            string              `json:"id"`
+	Model         string              `json:"model"`
+	Action        string              `json:"action"` // "upsert" | "delete"
+	Actor         string              `json:"actor,omitempty"`
+	Reason        string              `json:"reason,omitempty"`
+	OldPrice      *PriceMicroUSDPer1M `json:"old_price,omitempty"`
+	NewPrice      *PriceMicroUSDPer1M `json:"new_price,omitempty"`
+	EffectiveFrom int64               `json:"effective_from,omitempty"`
+	CreatedAt     int64               `json:"created_at"`
+}
+
 type DailyUsageRow struct {
 	APIKey         string `json:"api_key"`
 	Model          string `json:"model"`
@@ -48,4 +77,94 @@ type DailyUsageReport struct {
 	GeneratedAtUnix int64           `json:"generated_at_unix"`
 }
 
+// Budget scopes: which requests a Budget's limit applies to.
+const (
+	BudgetScopeKey      = "key"
+	BudgetScopeModel    = "model"
+	BudgetScopeKeyModel = "key+model"
+	BudgetScopeGlobal   = "global"
+)
+
+// Budget periods: how often a Budget's spend counter resets.
+const (
+	BudgetPeriodDay       = "day"
+	BudgetPeriodWeek      = "week"
+	BudgetPeriodMonth     = "month"
+	BudgetPeriodRolling30 = "rolling_30d"
+)
+
+// Budget actions: what happens once a Budget's limit is reached.
+const (
+	BudgetActionBlock    = "block"
+	BudgetActionWarn     = "warn"
+	BudgetActionThrottle = "throttle"
+)
+
+// Budget is a configured spend cap for some scope (a single API key, a
+// single model, a (key, model) pair, or every request), enforced by
+// BudgetManager. See PUT /billing/budgets.
+type Budget struct {
+	ID               string  `json:"id"`
+	Scope            string  `json:"scope"`
+	APIKey           string  `json:"api_key,omitempty"`
+	Model            string  `json:"model,omitempty"`
+	Period           string  `json:"period"`
+	LimitUSD         float64 `json:"limit_usd"`
+	Action           string  `json:"action"`
+	SoftThresholdPct float64 `json:"soft_threshold_pct,omitempty"`
+	CreatedAt        int64   `json:"created_at,omitempty"`
+	UpdatedAt        int64   `json:"updated_at,omitempty"`
+}
+
+// BudgetStatus is a Budget plus its current period's spend, as reported by
+// GET /billing/budgets/status.
+type BudgetStatus struct {
+	Budget
+	PeriodKey    string  `json:"period_key"`
+	SpentUSD     float64 `json:"spent_usd"`
+	RemainingUSD float64 `json:"remaining_usd"`
+	Exceeded     bool    `json:"exceeded"`
+	SoftWarning  bool    `json:"soft_warning"`
+}
+
+// PriceImportEntry is one row of a bulk price import, parsed from either the
+// JSON array or CSV body accepted by POST /billing/prices/import. A zero
+// EffectiveFrom means "effective now", matching UpsertModelPriceWithAudit.
+type PriceImportEntry struct {
+	Model              string  `json:"model"`
+	PromptUSDPer1M     float64 `json:"prompt_usd_per_1m"`
+	CompletionUSDPer1M float64 `json:"completion_usd_per_1m"`
+	CachedUSDPer1M     float64 `json:"cached_usd_per_1m"`
+	EffectiveFrom      int64   `json:"effective_from,omitempty"`
+}
+
+// PriceImportDiff is one model's before/after price, reported for a row
+// ImportModelPrices changed (or would change, under dry_run).
+type PriceImportDiff struct {
+	Model string     `json:"model"`
+	Old   ModelPrice `json:"old"`
+	New   ModelPrice `json:"new"`
+}
+
+// PriceImportRowError explains why one row of a bulk price import was
+// rejected. Row is the entry's 0-based index in the submitted batch.
+type PriceImportRowError struct {
+	Row     int    `json:"row"`
+	Model   string `json:"model,omitempty"`
+	Message string `json:"message"`
+}
+
+// PriceImportResult is ImportModelPrices' report of what changed (or, under
+// dry_run, would change) in a bulk price import. If Errors is non-empty, a
+// non-dry-run call wrote nothing: ImportModelPrices validates every row
+// before writing any of them, so a single bad row can't leave the price
+// table half-updated.
+type PriceImportResult struct {
+	Added     []ModelPrice          `json:"added"`
+	Updated   []PriceImportDiff     `json:"updated"`
+	Unchanged []string              `json:"unchanged"`
+	Errors    []PriceImportRowError `json:"errors,omitempty"`
+	DryRun    bool                  `json:"dry_run"`
+}
+
 func nowUnixUTC() int64 { return time.Now().UTC().Unix() }
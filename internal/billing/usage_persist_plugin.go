@@ -5,18 +5,101 @@ import (
 	"strings"
 	"time"
 
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/metrics"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/policy"
 	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
 )
 
 type UsagePersistPlugin struct {
-	store *SQLiteStore
+	store           *SQLiteStore
+	metrics         *MetricsRegistry
+	telemetry       *metrics.Registry
+	rateLimiter     *policy.TokenBucketLimiter
+	priceCatalog    PriceCatalog
+	inFlightTracker *InFlightTracker
+	eventBus        *BillingEventBus
+	aggregator      *EventAggregator
+	budgets         *BudgetManager
 }
 
 func NewUsagePersistPlugin(store *SQLiteStore) *UsagePersistPlugin {
 	return &UsagePersistPlugin{store: store}
 }
 
+// WithMetricsRegistry attaches reg so every HandleUsage call also updates the
+// live Prometheus counters it serves. Returns p for chaining at construction
+// time.
+func (p *UsagePersistPlugin) WithMetricsRegistry(reg *MetricsRegistry) *UsagePersistPlugin {
+	p.metrics = reg
+	return p
+}
+
+// WithTelemetry attaches reg so every HandleUsage call also feeds the
+// request/token/cost counters served by internal/metrics's own /metrics
+// endpoint. Returns p for chaining at construction time.
+func (p *UsagePersistPlugin) WithTelemetry(reg *metrics.Registry) *UsagePersistPlugin {
+	p.telemetry = reg
+	return p
+}
+
+// WithRateLimiter attaches limiter so every HandleUsage call settles the
+// token-bucket reservation APIKeyPolicyMiddleware made pre-request against
+// the actual token usage, rather than leaving the bucket charged for
+// policy.DefaultTokenReservationEstimate regardless of the real size of the
+// request. Returns p for chaining at construction time.
+func (p *UsagePersistPlugin) WithRateLimiter(limiter *policy.TokenBucketLimiter) *UsagePersistPlugin {
+	p.rateLimiter = limiter
+	return p
+}
+
+// WithPriceCatalog attaches catalog so HandleUsage prices usage against its
+// effective-dated, per-provider tiers instead of the flat
+// Store.ResolvePriceMicroAt price, whenever catalog has a tier covering the
+// usage. Returns p for chaining at construction time.
+func (p *UsagePersistPlugin) WithPriceCatalog(catalog PriceCatalog) *UsagePersistPlugin {
+	p.priceCatalog = catalog
+	return p
+}
+
+// WithInFlightTracker attaches tracker so HandleUsage clears an API key's
+// running in-flight cost estimate once its real usage has been persisted -
+// including when a streaming request's mid-stream budget guard aborted it
+// early, since the partial usage up to that point is still reported through
+// the normal HandleUsage path. Returns p for chaining at construction time.
+func (p *UsagePersistPlugin) WithInFlightTracker(tracker *InFlightTracker) *UsagePersistPlugin {
+	p.inFlightTracker = tracker
+	return p
+}
+
+// WithEventBus attaches bus so every HandleUsage call also publishes a
+// BillingEvent - feeding the management API's GET /billing/events history,
+// GET /billing/events/stream SSE subscribers, and, if durable sink is
+// configured on bus, a persistent store. Returns p for chaining at
+// construction time.
+func (p *UsagePersistPlugin) WithEventBus(bus *BillingEventBus) *UsagePersistPlugin {
+	p.eventBus = bus
+	return p
+}
+
+// WithEventAggregator attaches agg so every HandleUsage call also folds into
+// its running per-(api key, model) counters, served by GET /billing/stats.
+// Returns p for chaining at construction time.
+func (p *UsagePersistPlugin) WithEventAggregator(agg *EventAggregator) *UsagePersistPlugin {
+	p.aggregator = agg
+	return p
+}
+
+// WithBudgetManager attaches mgr so every HandleUsage call also charges the
+// usage's real cost against any configured billing.Budget matching the
+// request's api key/model, keeping APIKeyPolicyMiddleware's pre-request
+// Evaluate check in sync with actual spend. Returns p for chaining at
+// construction time.
+func (p *UsagePersistPlugin) WithBudgetManager(mgr *BudgetManager) *UsagePersistPlugin {
+	p.budgets = mgr
+	return p
+}
+
 func (p *UsagePersistPlugin) HandleUsage(ctx context.Context, record coreusage.Record) {
 	if p == nil || p.store == nil {
 		return
@@ -44,20 +127,31 @@ func (p *UsagePersistPlugin) HandleUsage(ctx context.Context, record coreusage.R
 		detail.TotalTokens = 0
 	}
 
-	promptTokens := detail.InputTokens - detail.CachedTokens
-	if promptTokens < 0 {
-		promptTokens = 0
+	// Price as of the usage's own timestamp, not "now": if HandleUsage runs
+	// behind (e.g. a slow async queue) after a price change, usage should
+	// still cost what the model actually charged at the time it ran.
+	//
+	// The price catalog, when present, takes precedence over the store's
+	// flat ResolvePriceMicroAt price: it can express context-tiered rates
+	// ResolvePriceMicroAt has no schema for. A usage row outside every
+	// catalog tier (e.g. a provider the catalog doesn't cover yet) still
+	// falls back to the store price, so enabling a catalog never leaves a
+	// model unpriced.
+	var cost int64
+	priced := false
+	if p.priceCatalog != nil {
+		if tier, ok := p.priceCatalog.Resolve(ctx, ProviderForModel(modelKey), modelKey, ts.Unix(), detail.InputTokens, ""); ok {
+			cost = tier.CostMicroUSD(detail.InputTokens, detail.OutputTokens, detail.ReasoningTokens, detail.CachedTokens)
+			priced = true
+		}
 	}
-	completionTokens := detail.OutputTokens + detail.ReasoningTokens
-
-	price, _, _, err := p.store.ResolvePriceMicro(ctx, modelKey)
-	if err != nil {
-		return
+	if !priced {
+		price, _, _, err := p.store.ResolvePriceMicroAt(ctx, modelKey, ts.Unix())
+		if err != nil {
+			return
+		}
+		cost = usageCostMicroUSD(price, detail.InputTokens, detail.OutputTokens, detail.ReasoningTokens, detail.CachedTokens)
 	}
-	cost := int64(0)
-	cost += costMicroUSD(promptTokens, price.Prompt)
-	cost += costMicroUSD(detail.CachedTokens, price.Cached)
-	cost += costMicroUSD(completionTokens, price.Completion)
 
 	delta := DailyUsageRow{
 		Requests:        1,
@@ -70,6 +164,46 @@ func (p *UsagePersistPlugin) HandleUsage(ctx context.Context, record coreusage.R
 		CostMicroUSD:    max64(0, cost),
 	}
 	_ = p.store.AddUsage(ctx, apiKey, modelKey, dayKey, delta)
+	if p.budgets != nil {
+		_ = p.budgets.Charge(ctx, apiKey, modelKey, delta.CostMicroUSD, ts)
+	}
+	p.metrics.Observe(apiKey, modelKey, record.Failed, delta)
+	p.telemetry.ObserveRequest(apiKey, modelKey, ProviderForModel(modelKey), record.Failed, delta.CostMicroUSD,
+		max64(0, delta.InputTokens-delta.CachedTokens), delta.OutputTokens, delta.CachedTokens, delta.ReasoningTokens)
+
+	if p.eventBus != nil || p.aggregator != nil {
+		evt := BillingEvent{
+			Timestamp:        ts,
+			APIKey:           apiKey,
+			Provider:         ProviderForModel(modelKey),
+			Model:            modelKey,
+			PromptTokens:     delta.InputTokens,
+			CompletionTokens: delta.OutputTokens,
+			CachedTokens:     delta.CachedTokens,
+			CostMicroUSD:     delta.CostMicroUSD,
+			Failed:           record.Failed,
+		}
+		if p.eventBus != nil {
+			p.eventBus.Publish(ctx, evt)
+		}
+		if p.aggregator != nil {
+			p.aggregator.Record(evt)
+		}
+	}
+
+	if p.rateLimiter != nil {
+		_ = p.rateLimiter.SettleTokens(ctx, apiKey, modelKey, policy.DefaultTokenReservationEstimate, int(delta.TotalTokens))
+	}
+
+	// requestToken must match whatever handlers.go's streaming budget guard
+	// passed to InFlightTracker.Set for this same request - the request ID
+	// carried on ctx, falling back to apiKey itself when ctx has none, so a
+	// concurrent second request under apiKey isn't cleared by mistake.
+	requestToken := logging.GetRequestID(ctx)
+	if requestToken == "" {
+		requestToken = apiKey
+	}
+	p.inFlightTracker.Clear(apiKey, requestToken)
 }
 
 func boolToInt64(v bool) int64 {
@@ -1,7 +1,9 @@
 package config
 
 import (
+	"fmt"
 	"strings"
+	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/policy"
 )
@@ -32,6 +34,104 @@ type APIKeyPolicy struct {
 	// DailyBudgetUSD defines the maximum daily spend (USD) allowed for this API key.
 	// Values <= 0 are treated as disabled.
 	DailyBudgetUSD float64 `yaml:"daily-budget-usd,omitempty" json:"daily-budget-usd,omitempty"`
+
+	// DailyBudgetReserveUSD is an estimated worst-case cost, reserved against
+	// DailyBudgetUSD for the duration of a single request. It closes the gap
+	// where several expensive requests start concurrently before any of them
+	// have committed usage: each holds a reservation for this amount so the
+	// others see a higher in-flight total. Values <= 0 disable reservation
+	// (the budget check then only sees already-committed usage).
+	DailyBudgetReserveUSD float64 `yaml:"daily-budget-reserve-usd,omitempty" json:"daily-budget-reserve-usd,omitempty"`
+
+	// RequestsPerMinute caps this API key's overall request rate, smoothed
+	// over a rolling minute rather than the hard daily reset of DailyLimits.
+	// Values <= 0 are treated as disabled.
+	RequestsPerMinute int `yaml:"requests-per-minute,omitempty" json:"requests-per-minute,omitempty"`
+
+	// TokensPerMinute caps this API key's overall token throughput, smoothed
+	// over a rolling minute. Values <= 0 are treated as disabled.
+	TokensPerMinute int `yaml:"tokens-per-minute,omitempty" json:"tokens-per-minute,omitempty"`
+
+	// Burst is the token-bucket capacity for both RequestsPerMinute and
+	// TokensPerMinute, i.e. how far a client may exceed the per-minute rate
+	// in a single short spike. Defaults to the respective per-minute value
+	// when <= 0 (no extra burst headroom beyond the steady-state rate).
+	Burst int `yaml:"burst,omitempty" json:"burst,omitempty"`
+
+	// ModelRateLimits overrides RequestsPerMinute/TokensPerMinute/Burst for
+	// specific models. Key is a model ID (case-insensitive). A model not
+	// present here falls back to the key-level limits above.
+	ModelRateLimits map[string]ModelRateLimit `yaml:"model-rate-limits,omitempty" json:"model-rate-limits,omitempty"`
+
+	// ClientCertFingerprints lists SHA-256 hex fingerprints of client
+	// certificates that authenticate as this API key over mTLS, resolved by
+	// FindAPIKeyPolicyByClientCert when a request arrives without an
+	// Authorization header.
+	ClientCertFingerprints []string `yaml:"client-cert-fingerprints,omitempty" json:"client-cert-fingerprints,omitempty"`
+
+	// ClientCertCN is an alternative to ClientCertFingerprints: the exact
+	// Subject Common Name a client certificate must present to authenticate
+	// as this API key. Only consulted when no fingerprint matches.
+	ClientCertCN string `yaml:"client-cert-cn,omitempty" json:"client-cert-cn,omitempty"`
+
+	// MaxConcurrent caps how many of this API key's requests may be in
+	// flight at once, enforced by handlers.ClientLimiter ahead of
+	// dispatch. Values <= 0 are treated as disabled. Unlike
+	// RequestsPerMinute/TokensPerMinute, this bounds concurrency rather
+	// than throughput: it is what keeps one client from alone saturating
+	// the shared auth pool and triggering auth_unavailable for everyone
+	// else.
+	MaxConcurrent int `yaml:"max-concurrent,omitempty" json:"max-concurrent,omitempty"`
+
+	// RequestsPerSecond caps this API key's request rate via
+	// handlers.ClientLimiter's own token bucket, independent of (and
+	// finer-grained than) RequestsPerMinute's per-minute smoothing. Values
+	// <= 0 are treated as disabled.
+	RequestsPerSecond float64 `yaml:"rps,omitempty" json:"rps,omitempty"`
+
+	// ConcurrencyBurst is the token-bucket capacity for RequestsPerSecond.
+	// Defaults to RequestsPerSecond when <= 0.
+	ConcurrencyBurst float64 `yaml:"concurrency-burst,omitempty" json:"concurrency-burst,omitempty"`
+
+	// QueueTimeout bounds how long handlers.ClientLimiter will let a
+	// request wait for a concurrency slot or rate-limit token before
+	// giving up and returning a 429. Zero or negative means wait
+	// indefinitely (bounded only by the request's own context).
+	QueueTimeout time.Duration `yaml:"queue-timeout,omitempty" json:"queue-timeout,omitempty"`
+}
+
+// ModelRateLimit overrides the per-key RequestsPerMinute/TokensPerMinute/
+// Burst settings for one specific model.
+type ModelRateLimit struct {
+	RequestsPerMinute int `yaml:"requests-per-minute,omitempty" json:"requests-per-minute,omitempty"`
+	TokensPerMinute   int `yaml:"tokens-per-minute,omitempty" json:"tokens-per-minute,omitempty"`
+	Burst             int `yaml:"burst,omitempty" json:"burst,omitempty"`
+}
+
+// RateLimitFor resolves the effective requests-per-minute, tokens-per-minute,
+// and burst settings for modelKey, preferring a ModelRateLimits entry over
+// the key-level defaults.
+func (p *APIKeyPolicy) RateLimitFor(modelKey string) (rpm, tpm, burst int) {
+	if p == nil {
+		return 0, 0, 0
+	}
+	rpm, tpm, burst = p.RequestsPerMinute, p.TokensPerMinute, p.Burst
+	key := strings.ToLower(strings.TrimSpace(modelKey))
+	if key == "" || len(p.ModelRateLimits) == 0 {
+		return rpm, tpm, burst
+	}
+	if override, ok := p.ModelRateLimits[key]; ok {
+		if override.RequestsPerMinute > 0 {
+			rpm = override.RequestsPerMinute
+		}
+		if override.TokensPerMinute > 0 {
+			tpm = override.TokensPerMinute
+		}
+		if override.Burst > 0 {
+			burst = override.Burst
+		}
+	}
+	return rpm, tpm, burst
 }
 
 // ProviderFailoverPolicy defines per-provider automatic failover settings.
@@ -40,11 +140,43 @@ type ProviderFailoverPolicy struct {
 	Enabled bool `yaml:"enabled,omitempty" json:"enabled,omitempty"`
 
 	// TargetModel is the model ID to retry when failover triggers (e.g. "gpt-5.2(high)").
+	// Deprecated in favor of Fallbacks, which supports more than one target and
+	// per-target trigger statuses; kept as the first Fallbacks entry's default
+	// when Fallbacks is empty so existing single-target configs keep working.
 	TargetModel string `yaml:"target-model,omitempty" json:"target-model,omitempty"`
 
 	// Rules optionally override the target model based on the requested model.
-	// Matching is case-insensitive and supports '*' wildcard.
+	// Matching is case-insensitive and supports '*' wildcard. Only consulted
+	// when Fallbacks is empty (the legacy single-target resolution path).
 	Rules []ModelFailoverRule `yaml:"rules,omitempty" json:"rules,omitempty"`
+
+	// Fallbacks is the ordered chain of targets to try in turn when this
+	// provider's request fails. The first target whose TriggerStatuses
+	// matches the failure's HTTP status is used; when its own attempts are
+	// exhausted (MaxAttempts), the next target in the list is tried. Takes
+	// precedence over TargetModel/Rules when non-empty.
+	Fallbacks []FailoverTarget `yaml:"fallbacks,omitempty" json:"fallbacks,omitempty"`
+}
+
+// FailoverTarget is one step in a provider's ordered fallback chain.
+type FailoverTarget struct {
+	// TargetModel is the model ID to retry with (e.g. "gpt-5.2(high)").
+	TargetModel string `yaml:"target-model,omitempty" json:"target-model,omitempty"`
+
+	// TargetProvider is the internal provider identifier this target routes
+	// to (e.g. "codex", "gemini"). Empty means "whatever provider serves
+	// TargetModel", resolved the same way the request's own model was.
+	TargetProvider string `yaml:"target-provider,omitempty" json:"target-provider,omitempty"`
+
+	// TriggerStatuses restricts this target to specific upstream HTTP status
+	// codes (e.g. [429, 529]). Empty means "use the provider's default
+	// failover-eligibility heuristic", matching the legacy single-target
+	// behaviour.
+	TriggerStatuses []int `yaml:"trigger-statuses,omitempty" json:"trigger-statuses,omitempty"`
+
+	// MaxAttempts caps how many times this specific target is retried before
+	// the chain moves on to the next target. Values <= 0 default to 1.
+	MaxAttempts int `yaml:"max-attempts,omitempty" json:"max-attempts,omitempty"`
 }
 
 // ModelFailoverRule defines a model-specific failover target.
@@ -56,8 +188,17 @@ type ModelFailoverRule struct {
 // APIKeyFailoverPolicy groups failover configuration for a client API key.
 // Provider keys match internal provider identifiers (e.g. "claude").
 type APIKeyFailoverPolicy struct {
-	// Claude controls failover behaviour when the request is routed to the Claude provider.
+	// Claude controls failover behaviour when the request is routed to the
+	// Claude provider. Deprecated in favor of Providers["claude"]; read by
+	// ClaudeFailoverTargetModel/ClaudeFailoverTargetModelFor as a fallback
+	// when Providers["claude"] isn't set, and folded into Providers["claude"]
+	// by SanitizeAPIKeyPolicies so old configs keep working unmodified.
 	Claude ProviderFailoverPolicy `yaml:"claude,omitempty" json:"claude,omitempty"`
+
+	// Providers generalizes Claude to an arbitrary set of providers, each
+	// with its own ordered Fallbacks chain. Key is the internal provider
+	// identifier the request was routed to (e.g. "claude", "codex").
+	Providers map[string]ProviderFailoverPolicy `yaml:"providers,omitempty" json:"providers,omitempty"`
 }
 
 func (p *APIKeyPolicy) AllowsClaudeOpus46() bool {
@@ -67,52 +208,180 @@ func (p *APIKeyPolicy) AllowsClaudeOpus46() bool {
 	return *p.AllowClaudeOpus46
 }
 
-// ClaudeFailoverTargetModel resolves the configured Claude failover target model.
-// Returns ("", false) when failover is disabled.
-// When enabled but target-model is empty, it returns a safe default.
-func (p *APIKeyPolicy) ClaudeFailoverTargetModel() (string, bool) {
+// providerFailoverPolicy returns the effective ProviderFailoverPolicy for
+// provider, preferring Providers[provider] and falling back to the legacy
+// Claude field for the "claude" provider so a policy built without going
+// through SanitizeAPIKeyPolicies (e.g. constructed directly in a test) still
+// resolves correctly.
+func (p *APIKeyPolicy) providerFailoverPolicy(provider string) (ProviderFailoverPolicy, bool) {
 	if p == nil {
-		return "", false
+		return ProviderFailoverPolicy{}, false
 	}
-	if !p.Failover.Claude.Enabled {
-		return "", false
+	if pol, ok := p.Failover.Providers[provider]; ok {
+		return pol, true
+	}
+	if provider == "claude" {
+		return p.Failover.Claude, true
+	}
+	return ProviderFailoverPolicy{}, false
+}
+
+// FailoverChainFor resolves the ordered chain of failover targets to try
+// when a request routed to provider fails, honoring provider-specific rules
+// for requestedModel. Returns nil when failover isn't enabled for provider.
+//
+// When the provider's policy sets Fallbacks, that ordered list is returned
+// as-is. Otherwise it falls back to the legacy single-target resolution
+// (Rules, then TargetModel, then - for "claude" specifically - the
+// "gpt-5.2(high)" default it has always used), wrapped as a one-target
+// chain so callers only ever need to walk FailoverChainFor's result.
+func (p *APIKeyPolicy) FailoverChainFor(provider, requestedModel string) []FailoverTarget {
+	pol, ok := p.providerFailoverPolicy(provider)
+	if !ok || !pol.Enabled {
+		return nil
+	}
+	if len(pol.Fallbacks) > 0 {
+		return pol.Fallbacks
+	}
+
+	target := ""
+	requestKey := policy.NormaliseModelKey(requestedModel)
+	if requestKey != "" && len(pol.Rules) > 0 {
+		for _, rule := range pol.Rules {
+			from := strings.ToLower(strings.TrimSpace(rule.FromModel))
+			if from == "" || !policy.MatchWildcard(from, requestKey) {
+				continue
+			}
+			if t := strings.TrimSpace(rule.TargetModel); t != "" {
+				target = t
+				break
+			}
+		}
 	}
-	target := strings.TrimSpace(p.Failover.Claude.TargetModel)
 	if target == "" {
+		target = strings.TrimSpace(pol.TargetModel)
+	}
+	if target == "" && provider == "claude" {
 		target = "gpt-5.2(high)"
 	}
-	return target, true
+	if target == "" {
+		return nil
+	}
+	return []FailoverTarget{{TargetModel: target, MaxAttempts: 1}}
+}
+
+// MatchesTriggerStatus reports whether status should trigger this target. An
+// empty TriggerStatuses list matches any status, deferring to the caller's
+// own failover-eligibility heuristic (e.g. handlers.isFailoverEligible).
+func (t FailoverTarget) MatchesTriggerStatus(status int) bool {
+	if len(t.TriggerStatuses) == 0 {
+		return true
+	}
+	for _, s := range t.TriggerStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Attempts returns t.MaxAttempts, defaulting to 1 when unset.
+func (t FailoverTarget) Attempts() int {
+	if t.MaxAttempts <= 0 {
+		return 1
+	}
+	return t.MaxAttempts
+}
+
+// ClaudeFailoverTargetModel resolves the configured Claude failover target model.
+// Returns ("", false) when failover is disabled.
+// When enabled but target-model is empty, it returns a safe default.
+func (p *APIKeyPolicy) ClaudeFailoverTargetModel() (string, bool) {
+	chain := p.FailoverChainFor("claude", "")
+	if len(chain) == 0 {
+		return "", false
+	}
+	return chain[0].TargetModel, true
 }
 
 // ClaudeFailoverTargetModelFor resolves the configured Claude failover target model for a specific request.
 // Rules are evaluated first; when no rules match, it falls back to ClaudeFailoverTargetModel().
 func (p *APIKeyPolicy) ClaudeFailoverTargetModelFor(requestedModel string) (string, bool) {
-	if p == nil {
-		return "", false
-	}
-	if !p.Failover.Claude.Enabled {
+	chain := p.FailoverChainFor("claude", requestedModel)
+	if len(chain) == 0 {
 		return "", false
 	}
+	return chain[0].TargetModel, true
+}
 
-	requestKey := policy.NormaliseModelKey(requestedModel)
-	if requestKey != "" && len(p.Failover.Claude.Rules) > 0 {
-		for _, rule := range p.Failover.Claude.Rules {
-			from := strings.ToLower(strings.TrimSpace(rule.FromModel))
-			if from == "" {
+// sanitizeProviderFailoverPolicy trims and drops invalid entries from a
+// single provider's failover policy, mirroring the legacy Claude.Rules
+// sanitization in SanitizeAPIKeyPolicies for the generalized Fallbacks chain.
+func sanitizeProviderFailoverPolicy(pol ProviderFailoverPolicy) ProviderFailoverPolicy {
+	pol.TargetModel = strings.TrimSpace(pol.TargetModel)
+	if len(pol.Rules) > 0 {
+		rules := make([]ModelFailoverRule, 0, len(pol.Rules))
+		for _, rule := range pol.Rules {
+			rule.FromModel = strings.TrimSpace(rule.FromModel)
+			rule.TargetModel = strings.TrimSpace(rule.TargetModel)
+			if rule.FromModel == "" || rule.TargetModel == "" {
 				continue
 			}
-			if !policy.MatchWildcard(from, requestKey) {
-				continue
-			}
-			target := strings.TrimSpace(rule.TargetModel)
-			if target == "" {
+			rules = append(rules, rule)
+		}
+		pol.Rules = rules
+	}
+	if len(pol.Fallbacks) > 0 {
+		targets := make([]FailoverTarget, 0, len(pol.Fallbacks))
+		for _, target := range pol.Fallbacks {
+			target.TargetModel = strings.TrimSpace(target.TargetModel)
+			target.TargetProvider = strings.ToLower(strings.TrimSpace(target.TargetProvider))
+			if target.TargetModel == "" && target.TargetProvider == "" {
 				continue
 			}
-			return target, true
+			targets = append(targets, target)
+		}
+		pol.Fallbacks = targets
+	}
+	return pol
+}
+
+// ValidateFailoverPolicy checks providers for cycles: a chain whose targets
+// eventually route back to a provider already visited earlier in the same
+// walk would retry forever without making progress. Returns the first
+// offending provider/target pair found, or nil if every chain is acyclic.
+func ValidateFailoverPolicy(providers map[string]ProviderFailoverPolicy) error {
+	for provider := range providers {
+		if err := validateFailoverChainAcyclic(providers, provider, map[string]bool{provider: true}); err != nil {
+			return err
 		}
 	}
+	return nil
+}
 
-	return p.ClaudeFailoverTargetModel()
+func validateFailoverChainAcyclic(providers map[string]ProviderFailoverPolicy, provider string, visited map[string]bool) error {
+	pol, ok := providers[provider]
+	if !ok || !pol.Enabled {
+		return nil
+	}
+	for _, target := range pol.Fallbacks {
+		next := strings.ToLower(strings.TrimSpace(target.TargetProvider))
+		if next == "" {
+			continue
+		}
+		if visited[next] {
+			return fmt.Errorf("failover cycle detected: provider %q falls back to already-attempted provider %q", provider, next)
+		}
+		nextVisited := make(map[string]bool, len(visited)+1)
+		for k, v := range visited {
+			nextVisited[k] = v
+		}
+		nextVisited[next] = true
+		if err := validateFailoverChainAcyclic(providers, next, nextVisited); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // FindAPIKeyPolicy returns the APIKeyPolicy matching the provided key.
@@ -154,6 +423,23 @@ func (cfg *Config) SanitizeAPIKeyPolicies() {
 
 		entry.ExcludedModels = NormalizeExcludedModels(entry.ExcludedModels)
 
+		entry.ClientCertCN = strings.TrimSpace(entry.ClientCertCN)
+		if len(entry.ClientCertFingerprints) > 0 {
+			fingerprints := make([]string, 0, len(entry.ClientCertFingerprints))
+			for _, fp := range entry.ClientCertFingerprints {
+				fp = strings.ToLower(strings.TrimSpace(fp))
+				if fp == "" {
+					continue
+				}
+				fingerprints = append(fingerprints, fp)
+			}
+			if len(fingerprints) > 0 {
+				entry.ClientCertFingerprints = fingerprints
+			} else {
+				entry.ClientCertFingerprints = nil
+			}
+		}
+
 		// Failover sanitization.
 		entry.Failover.Claude.TargetModel = strings.TrimSpace(entry.Failover.Claude.TargetModel)
 		if len(entry.Failover.Claude.Rules) > 0 {
@@ -169,6 +455,35 @@ func (cfg *Config) SanitizeAPIKeyPolicies() {
 			entry.Failover.Claude.Rules = rules
 		}
 
+		// Migrate the legacy Claude field into Providers["claude"] so
+		// FailoverChainFor and downstream consumers only ever need to look
+		// at Providers, then normalize every provider's Fallbacks chain.
+		if len(entry.Failover.Providers) > 0 || entry.Failover.Claude.Enabled {
+			normalizedProviders := make(map[string]ProviderFailoverPolicy, len(entry.Failover.Providers)+1)
+			for providerID, pol := range entry.Failover.Providers {
+				providerID = strings.ToLower(strings.TrimSpace(providerID))
+				if providerID == "" {
+					continue
+				}
+				normalizedProviders[providerID] = sanitizeProviderFailoverPolicy(pol)
+			}
+			if entry.Failover.Claude.Enabled {
+				if _, ok := normalizedProviders["claude"]; !ok {
+					normalizedProviders["claude"] = sanitizeProviderFailoverPolicy(entry.Failover.Claude)
+				}
+			}
+			if err := ValidateFailoverPolicy(normalizedProviders); err != nil {
+				// A cyclic chain can't be honored safely; drop the whole
+				// Providers map rather than risk an infinite failover loop.
+				normalizedProviders = nil
+			}
+			if len(normalizedProviders) > 0 {
+				entry.Failover.Providers = normalizedProviders
+			} else {
+				entry.Failover.Providers = nil
+			}
+		}
+
 		if len(entry.DailyLimits) > 0 {
 			normalized := make(map[string]int, len(entry.DailyLimits))
 			for modelID, limit := range entry.DailyLimits {
@@ -192,6 +507,56 @@ func (cfg *Config) SanitizeAPIKeyPolicies() {
 			entry.DailyBudgetUSD = 0
 		}
 
+		if entry.DailyBudgetReserveUSD <= 0 {
+			entry.DailyBudgetReserveUSD = 0
+		}
+
+		if entry.RequestsPerMinute <= 0 {
+			entry.RequestsPerMinute = 0
+		}
+		if entry.TokensPerMinute <= 0 {
+			entry.TokensPerMinute = 0
+		}
+		if entry.Burst <= 0 {
+			entry.Burst = 0
+		}
+		if entry.MaxConcurrent <= 0 {
+			entry.MaxConcurrent = 0
+		}
+		if entry.RequestsPerSecond <= 0 {
+			entry.RequestsPerSecond = 0
+		}
+		if entry.ConcurrencyBurst <= 0 {
+			entry.ConcurrencyBurst = 0
+		}
+		if entry.QueueTimeout < 0 {
+			entry.QueueTimeout = 0
+		}
+		if len(entry.ModelRateLimits) > 0 {
+			normalized := make(map[string]ModelRateLimit, len(entry.ModelRateLimits))
+			for modelID, limit := range entry.ModelRateLimits {
+				m := strings.ToLower(strings.TrimSpace(modelID))
+				if m == "" {
+					continue
+				}
+				if limit.RequestsPerMinute <= 0 {
+					limit.RequestsPerMinute = 0
+				}
+				if limit.TokensPerMinute <= 0 {
+					limit.TokensPerMinute = 0
+				}
+				if limit.Burst <= 0 {
+					limit.Burst = 0
+				}
+				normalized[m] = limit
+			}
+			if len(normalized) > 0 {
+				entry.ModelRateLimits = normalized
+			} else {
+				entry.ModelRateLimits = nil
+			}
+		}
+
 		key := entry.APIKey
 		if prior, ok := seen[key]; ok {
 			out[prior.idx] = entry
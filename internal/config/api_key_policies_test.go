@@ -0,0 +1,117 @@
+package config
+
+import "testing"
+
+func TestFailoverChainFor_FallsBackToLegacyClaudeField(t *testing.T) {
+	p := &APIKeyPolicy{
+		Failover: APIKeyFailoverPolicy{
+			Claude: ProviderFailoverPolicy{Enabled: true, TargetModel: "gpt-5.2(high)"},
+		},
+	}
+
+	chain := p.FailoverChainFor("claude", "claude-opus-4-6")
+	if len(chain) != 1 || chain[0].TargetModel != "gpt-5.2(high)" {
+		t.Fatalf("chain=%+v, want single gpt-5.2(high) target", chain)
+	}
+}
+
+func TestFailoverChainFor_PrefersProvidersFallbacksChain(t *testing.T) {
+	p := &APIKeyPolicy{
+		Failover: APIKeyFailoverPolicy{
+			Claude: ProviderFailoverPolicy{Enabled: true, TargetModel: "gpt-5.2(high)"},
+			Providers: map[string]ProviderFailoverPolicy{
+				"claude": {
+					Enabled: true,
+					Fallbacks: []FailoverTarget{
+						{TargetModel: "gpt-5-codex", TriggerStatuses: []int{429}},
+						{TargetModel: "gemini-2.5-pro"},
+					},
+				},
+			},
+		},
+	}
+
+	chain := p.FailoverChainFor("claude", "claude-opus-4-6")
+	if len(chain) != 2 || chain[0].TargetModel != "gpt-5-codex" || chain[1].TargetModel != "gemini-2.5-pro" {
+		t.Fatalf("chain=%+v, want the Providers[\"claude\"] Fallbacks chain", chain)
+	}
+	if !chain[0].MatchesTriggerStatus(429) || chain[0].MatchesTriggerStatus(500) {
+		t.Fatalf("MatchesTriggerStatus did not honor TriggerStatuses=[429]")
+	}
+	if !chain[1].MatchesTriggerStatus(500) {
+		t.Fatalf("an empty TriggerStatuses should match any status")
+	}
+}
+
+func TestFailoverChainFor_DisabledReturnsNil(t *testing.T) {
+	p := &APIKeyPolicy{}
+	if chain := p.FailoverChainFor("claude", "claude-opus-4-6"); chain != nil {
+		t.Fatalf("chain=%+v, want nil when failover isn't configured", chain)
+	}
+}
+
+func TestValidateFailoverPolicy_DetectsCycle(t *testing.T) {
+	providers := map[string]ProviderFailoverPolicy{
+		"claude": {Enabled: true, Fallbacks: []FailoverTarget{{TargetProvider: "codex"}}},
+		"codex":  {Enabled: true, Fallbacks: []FailoverTarget{{TargetProvider: "claude"}}},
+	}
+	if err := ValidateFailoverPolicy(providers); err == nil {
+		t.Fatal("expected a cycle error for claude -> codex -> claude")
+	}
+}
+
+func TestValidateFailoverPolicy_AcceptsAcyclicChain(t *testing.T) {
+	providers := map[string]ProviderFailoverPolicy{
+		"claude": {Enabled: true, Fallbacks: []FailoverTarget{{TargetProvider: "codex"}}},
+		"codex":  {Enabled: true, Fallbacks: []FailoverTarget{{TargetModel: "gemini-2.5-pro"}}},
+	}
+	if err := ValidateFailoverPolicy(providers); err != nil {
+		t.Fatalf("unexpected error for an acyclic chain: %v", err)
+	}
+}
+
+func TestSanitizeAPIKeyPolicies_MigratesLegacyClaudeIntoProviders(t *testing.T) {
+	cfg := &Config{
+		APIKeyPolicies: []APIKeyPolicy{
+			{
+				APIKey: " sk-test ",
+				Failover: APIKeyFailoverPolicy{
+					Claude: ProviderFailoverPolicy{Enabled: true, TargetModel: " gpt-5.2(high) "},
+				},
+			},
+		},
+	}
+	cfg.SanitizeAPIKeyPolicies()
+
+	if len(cfg.APIKeyPolicies) != 1 {
+		t.Fatalf("got %d policies, want 1", len(cfg.APIKeyPolicies))
+	}
+	pol, ok := cfg.APIKeyPolicies[0].Failover.Providers["claude"]
+	if !ok {
+		t.Fatal("expected legacy Claude field to be migrated into Providers[\"claude\"]")
+	}
+	if pol.TargetModel != "gpt-5.2(high)" {
+		t.Fatalf("TargetModel=%q, want trimmed gpt-5.2(high)", pol.TargetModel)
+	}
+}
+
+func TestSanitizeAPIKeyPolicies_DropsCyclicProviders(t *testing.T) {
+	cfg := &Config{
+		APIKeyPolicies: []APIKeyPolicy{
+			{
+				APIKey: "sk-test",
+				Failover: APIKeyFailoverPolicy{
+					Providers: map[string]ProviderFailoverPolicy{
+						"claude": {Enabled: true, Fallbacks: []FailoverTarget{{TargetProvider: "codex"}}},
+						"codex":  {Enabled: true, Fallbacks: []FailoverTarget{{TargetProvider: "claude"}}},
+					},
+				},
+			},
+		},
+	}
+	cfg.SanitizeAPIKeyPolicies()
+
+	if cfg.APIKeyPolicies[0].Failover.Providers != nil {
+		t.Fatalf("Providers=%+v, want nil after a detected cycle", cfg.APIKeyPolicies[0].Failover.Providers)
+	}
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/audit"
+)
+
+// AuditConfig selects and configures the audit.Log sink.
+type AuditConfig struct {
+	// Sink is one of "" (disabled), "file", "syslog", or "webhook".
+	Sink string `yaml:"sink,omitempty" json:"sink,omitempty"`
+
+	// File is the JSONL file path. Required when Sink is "file".
+	File string `yaml:"file,omitempty" json:"file,omitempty"`
+
+	// SyslogNetwork/SyslogAddress select how to dial the syslog daemon
+	// (e.g. "udp", "logs.example.com:514"); both empty dials the local
+	// syslog socket. SyslogTag labels forwarded entries. Used when Sink is
+	// "syslog".
+	SyslogNetwork string `yaml:"syslog-network,omitempty" json:"syslog-network,omitempty"`
+	SyslogAddress string `yaml:"syslog-address,omitempty" json:"syslog-address,omitempty"`
+	SyslogTag     string `yaml:"syslog-tag,omitempty" json:"syslog-tag,omitempty"`
+
+	// WebhookURL receives a POST per record. Used when Sink is "webhook".
+	WebhookURL string `yaml:"webhook-url,omitempty" json:"webhook-url,omitempty"`
+}
+
+// BuildAuditLog constructs an audit.Log from cfg, or (nil, nil) when
+// auditing is disabled (Sink unset). For the "file" sink, it resumes the
+// hash chain from the file's existing last record, if any, so restarting
+// the process does not silently start a new chain.
+func BuildAuditLog(cfg AuditConfig) (*audit.Log, error) {
+	switch strings.ToLower(strings.TrimSpace(cfg.Sink)) {
+	case "":
+		return nil, nil
+	case "file":
+		if strings.TrimSpace(cfg.File) == "" {
+			return nil, fmt.Errorf("audit: file sink requires a file path")
+		}
+		seedHash, err := audit.LastHash(cfg.File)
+		if err != nil {
+			return nil, err
+		}
+		sink, err := audit.NewFileSink(cfg.File)
+		if err != nil {
+			return nil, err
+		}
+		return audit.NewLog(sink, seedHash), nil
+	case "syslog":
+		sink, err := audit.NewSyslogSink(cfg.SyslogNetwork, cfg.SyslogAddress, cfg.SyslogTag)
+		if err != nil {
+			return nil, err
+		}
+		return audit.NewLog(sink, ""), nil
+	case "webhook":
+		if strings.TrimSpace(cfg.WebhookURL) == "" {
+			return nil, fmt.Errorf("audit: webhook sink requires a url")
+		}
+		return audit.NewLog(audit.NewWebhookSink(cfg.WebhookURL, nil), ""), nil
+	default:
+		return nil, fmt.Errorf("audit: unknown sink %q", cfg.Sink)
+	}
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"time"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// AuthRenewerConfig configures how Manager's background coreauth.Renewer
+// per registered auth schedules and retries proactive token refresh.
+// Mirrors AuthSelectorConfig's plain-knobs-plus-Build shape.
+type AuthRenewerConfig struct {
+	// RenewGrace is the fraction of a credential's remaining TTL that must
+	// elapse before Manager renews it ahead of expiry. Zero uses
+	// coreauth.Renewer's default of 2/3.
+	RenewGrace float64 `yaml:"renew-grace,omitempty" json:"renew-grace,omitempty"`
+
+	// MaxBackoff caps the exponential backoff between failed-refresh
+	// retries. Zero uses coreauth.Renewer's default of 5 minutes.
+	MaxBackoff time.Duration `yaml:"max-backoff,omitempty" json:"max-backoff,omitempty"`
+
+	// MaxAttempts caps how many consecutive refresh failures a Renewer
+	// tolerates before giving up. Zero uses coreauth.Renewer's default of 6.
+	MaxAttempts int `yaml:"max-attempts,omitempty" json:"max-attempts,omitempty"`
+
+	// DisableRenew turns off proactive renewal for this provider, leaving
+	// only the existing reactive failover path to recover an expired auth.
+	DisableRenew bool `yaml:"disable-renew,omitempty" json:"disable-renew,omitempty"`
+}
+
+// BuildRenewerConfig converts cfg to the coreauth.RenewerConfig Manager
+// passes to every Renewer it starts for this provider.
+func BuildRenewerConfig(cfg AuthRenewerConfig) coreauth.RenewerConfig {
+	return coreauth.RenewerConfig{
+		RenewGrace:   cfg.RenewGrace,
+		MaxBackoff:   cfg.MaxBackoff,
+		MaxAttempts:  cfg.MaxAttempts,
+		DisableRenew: cfg.DisableRenew,
+	}
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/billing"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// AuthSelectorConfig selects and configures how Manager picks among
+// multiple viable auths for a provider. Mirrors DailyLimiterConfig's
+// driver-string-plus-extra-fields shape.
+type AuthSelectorConfig struct {
+	// Strategy is one of "fill_first" (default), "round_robin",
+	// "least_loaded", or "least_cost".
+	Strategy string `yaml:"selector,omitempty" json:"selector,omitempty"`
+
+	// CostBias overrides LeastCostSelector's per-auth price multiplier,
+	// keyed by auth ID. Only consulted when Strategy is "least_cost".
+	CostBias map[string]float64 `yaml:"cost-bias,omitempty" json:"cost-bias,omitempty"`
+}
+
+// BuildAuthSelector constructs the coreauth.Selector implementation named by
+// cfg.Strategy. store is used only by the "least_cost" strategy and may be
+// nil for the others.
+func BuildAuthSelector(cfg AuthSelectorConfig, store billing.Store) (coreauth.Selector, error) {
+	switch strings.ToLower(strings.TrimSpace(cfg.Strategy)) {
+	case "", "fill_first":
+		return &coreauth.FillFirstSelector{}, nil
+	case "round_robin":
+		return &coreauth.RoundRobinSelector{}, nil
+	case "least_loaded":
+		return &coreauth.LeastLoadedSelector{}, nil
+	case "least_cost":
+		return coreauth.NewLeastCostSelector(store, cfg.CostBias), nil
+	default:
+		return nil, fmt.Errorf("auth selector: unknown strategy %q", cfg.Strategy)
+	}
+}
@@ -0,0 +1,26 @@
+package config
+
+import (
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/metrics"
+)
+
+// MetricsConfig is the on-disk (YAML/JSON) shape of the internal/metrics
+// Registry's cardinality-limiting options, so operators can keep label
+// explosion in check in a large deployment without a code change.
+type MetricsConfig struct {
+	// HashAPIKeys replaces the api_key label with a short, non-reversible
+	// hash instead of the raw key. Defaults to false.
+	HashAPIKeys bool `yaml:"hash-api-keys,omitempty" json:"hash-api-keys,omitempty"`
+
+	// ModelAllowlist, if non-empty, buckets any model not in the list into
+	// a single "other" series.
+	ModelAllowlist []string `yaml:"model-allowlist,omitempty" json:"model-allowlist,omitempty"`
+}
+
+// BuildMetricsRegistry constructs a metrics.Registry from cfg's cardinality limits.
+func BuildMetricsRegistry(cfg MetricsConfig) *metrics.Registry {
+	return metrics.NewRegistry(metrics.CardinalityLimits{
+		HashAPIKeys:    cfg.HashAPIKeys,
+		ModelAllowlist: cfg.ModelAllowlist,
+	})
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/policy"
+)
+
+// ModelRewriteRuleConfig is the on-disk (YAML/JSON) shape of a
+// policy.ModelRewriteRule, scoped to the provider whose requests it rewrites.
+// It lets operators add or change model downgrade/rewrite rules (e.g. when a
+// new model ships) without a code change.
+type ModelRewriteRuleConfig struct {
+	// Provider is the source provider this rule applies to, e.g. "claude".
+	Provider string `yaml:"provider" json:"provider"`
+
+	// Match is the source model pattern, e.g. "claude-opus-4-6*".
+	Match string `yaml:"match" json:"match"`
+
+	// ReplacePrefix replaces the literal (non-wildcard) prefix of Match.
+	ReplacePrefix string `yaml:"replace-prefix" json:"replace-prefix"`
+
+	// PreserveThinkingSuffix keeps the "(...)" thinking budget suffix across
+	// the rewrite. The rest of the model name after the matched prefix
+	// (e.g. "-thinking") is kept whether or not it is set.
+	PreserveThinkingSuffix bool `yaml:"preserve-thinking-suffix,omitempty" json:"preserve-thinking-suffix,omitempty"`
+
+	// AppliesWhen restricts the rule to a specific caller-supplied reason
+	// (e.g. "upstream_unavailable"). Empty means the rule always applies.
+	AppliesWhen string `yaml:"applies-when,omitempty" json:"applies-when,omitempty"`
+}
+
+// ModelRewriteRulesByProvider groups rules by Provider into the shape
+// policy.ModelRewriteRegistry.SetRules expects, dropping entries with a
+// blank Provider, Match, or ReplacePrefix.
+func ModelRewriteRulesByProvider(rules []ModelRewriteRuleConfig) map[string][]policy.ModelRewriteRule {
+	out := make(map[string][]policy.ModelRewriteRule, len(rules))
+	for _, rule := range rules {
+		provider := strings.ToLower(strings.TrimSpace(rule.Provider))
+		match := strings.TrimSpace(rule.Match)
+		replacePrefix := strings.TrimSpace(rule.ReplacePrefix)
+		if provider == "" || match == "" || replacePrefix == "" {
+			continue
+		}
+		out[provider] = append(out[provider], policy.ModelRewriteRule{
+			Match:                  match,
+			ReplacePrefix:          replacePrefix,
+			PreserveThinkingSuffix: rule.PreserveThinkingSuffix,
+			AppliesWhen:            strings.TrimSpace(rule.AppliesWhen),
+		})
+	}
+	return out
+}
+
+// ApplyModelRewriteRules loads rules into reg, replacing whatever rule set it
+// previously held. Call this from the application's config-reload hook
+// whenever ModelRewriteRuleConfig entries change, so new rules take effect
+// without a restart.
+func ApplyModelRewriteRules(reg *policy.ModelRewriteRegistry, rules []ModelRewriteRuleConfig) {
+	if reg == nil {
+		return
+	}
+	reg.SetRules(ModelRewriteRulesByProvider(rules))
+}
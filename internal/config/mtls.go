@@ -0,0 +1,68 @@
+package config
+
+import "strings"
+
+// MTLSConfig controls optional mutual-TLS client-certificate authentication
+// at the Gin server, resolving an APIKeyPolicy from the peer certificate
+// instead of an Authorization header.
+type MTLSConfig struct {
+	// Enabled turns on peer-certificate verification for requests that
+	// arrive without an Authorization header.
+	Enabled bool `yaml:"enabled,omitempty" json:"enabled,omitempty"`
+
+	// CAFile is the PEM-encoded CA bundle the server's TLS listener should
+	// use to verify client certificates. Read by the server's tls.Config,
+	// not by the resolution helpers in this file.
+	CAFile string `yaml:"ca-file,omitempty" json:"ca-file,omitempty"`
+
+	// RequireForKeys lists API keys (matched against the resolved
+	// APIKeyPolicy.APIKey) that must present a valid client certificate even
+	// when mTLS is otherwise optional for the listener. Empty means mTLS is
+	// opportunistic: used when presented, not required.
+	RequireForKeys []string `yaml:"require-for-keys,omitempty" json:"require-for-keys,omitempty"`
+}
+
+// RequiresCertFor reports whether apiKey must authenticate via client
+// certificate rather than falling back to another scheme.
+func (m MTLSConfig) RequiresCertFor(apiKey string) bool {
+	if !m.Enabled {
+		return false
+	}
+	key := strings.TrimSpace(apiKey)
+	for _, k := range m.RequireForKeys {
+		if strings.TrimSpace(k) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// FindAPIKeyPolicyByClientCert resolves the APIKeyPolicy whose
+// ClientCertFingerprints contains fingerprintHex (case-insensitive SHA-256
+// hex) or whose ClientCertCN matches cn, fingerprint taking precedence.
+// Returns nil when neither identifies a configured policy.
+func (cfg *Config) FindAPIKeyPolicyByClientCert(fingerprintHex, cn string) *APIKeyPolicy {
+	if cfg == nil || len(cfg.APIKeyPolicies) == 0 {
+		return nil
+	}
+	fingerprint := strings.ToLower(strings.TrimSpace(fingerprintHex))
+	if fingerprint != "" {
+		for i := range cfg.APIKeyPolicies {
+			for _, fp := range cfg.APIKeyPolicies[i].ClientCertFingerprints {
+				if strings.ToLower(strings.TrimSpace(fp)) == fingerprint {
+					return &cfg.APIKeyPolicies[i]
+				}
+			}
+		}
+	}
+	commonName := strings.TrimSpace(cn)
+	if commonName == "" {
+		return nil
+	}
+	for i := range cfg.APIKeyPolicies {
+		if strings.TrimSpace(cfg.APIKeyPolicies[i].ClientCertCN) == commonName {
+			return &cfg.APIKeyPolicies[i]
+		}
+	}
+	return nil
+}
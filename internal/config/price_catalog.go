@@ -0,0 +1,27 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/billing"
+)
+
+// PriceCatalogConfig selects the pluggable, effective-dated price catalog
+// (billing.PriceCatalog), separate from the flat per-model prices stored in
+// the billing.Store and from PriceCatalogSyncer's remote price-list sync.
+type PriceCatalogConfig struct {
+	// File is the YAML document of billing.PriceTier entries. Empty
+	// disables the price catalog; UsagePersistPlugin and Reconcile then
+	// fall back to the billing.Store's flat price resolution.
+	File string `yaml:"file,omitempty" json:"file,omitempty"`
+}
+
+// BuildPriceCatalog constructs a *billing.FilePriceCatalog from cfg, or nil
+// when no catalog file is configured.
+func BuildPriceCatalog(cfg PriceCatalogConfig) *billing.FilePriceCatalog {
+	path := strings.TrimSpace(cfg.File)
+	if path == "" {
+		return nil
+	}
+	return billing.NewFilePriceCatalog(path)
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/billing"
+)
+
+// PriceProviderConfig is the on-disk (YAML/JSON) shape of one
+// billing.PriceProvider source. Exactly one of File or URL should be set;
+// Kind selects which.
+type PriceProviderConfig struct {
+	// Kind selects the provider implementation: "file" or "http".
+	Kind string `yaml:"kind" json:"kind"`
+
+	// File is the local path to a price catalog document. Required when Kind is "file".
+	File string `yaml:"file,omitempty" json:"file,omitempty"`
+
+	// URL is the price catalog endpoint. Required when Kind is "http".
+	URL string `yaml:"url,omitempty" json:"url,omitempty"`
+}
+
+// PriceRefresherConfig is the on-disk shape of a billing.PriceRefresher:
+// the ordered list of provider sources plus how often to poll them. Sources
+// are listed lowest-precedence first; the admin-API override always wins
+// regardless of this order, since BuildPriceRefresher appends it last.
+type PriceRefresherConfig struct {
+	// IntervalSeconds is how often to poll every provider. <= 0 disables
+	// the background refresher; ResolvePrice still serves whatever was
+	// loaded by the initial refresh.
+	IntervalSeconds int `yaml:"interval-seconds,omitempty" json:"interval-seconds,omitempty"`
+
+	Sources []PriceProviderConfig `yaml:"sources,omitempty" json:"sources,omitempty"`
+}
+
+// BuildPriceRefresher constructs a billing.PriceRefresher from cfg, appending
+// override last so admin-API overrides always take precedence over file and
+// HTTP sources regardless of cfg.Sources order. Entries with an unrecognised
+// Kind or a blank path/URL are skipped.
+func BuildPriceRefresher(cfg PriceRefresherConfig, metrics *billing.MetricsRegistry, override *billing.OverridePriceProvider) *billing.PriceRefresher {
+	providers := make([]billing.PriceProvider, 0, len(cfg.Sources)+1)
+	for _, source := range cfg.Sources {
+		switch strings.ToLower(strings.TrimSpace(source.Kind)) {
+		case "file":
+			if path := strings.TrimSpace(source.File); path != "" {
+				providers = append(providers, billing.NewFilePriceProvider(path))
+			}
+		case "http":
+			if url := strings.TrimSpace(source.URL); url != "" {
+				providers = append(providers, billing.NewHTTPPriceProvider(url))
+			}
+		}
+	}
+	if override != nil {
+		providers = append(providers, override)
+	}
+	return billing.NewPriceRefresher(time.Duration(cfg.IntervalSeconds)*time.Second, metrics, providers...)
+}
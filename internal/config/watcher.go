@@ -0,0 +1,169 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/metrics"
+)
+
+// Watcher holds the live *Config snapshot for a running process and swaps it
+// in place on a SIGHUP, a poll-detected file change, or a management-API
+// edit, so APIKeyPolicies (and anything else read off Current()) can be
+// updated without restarting in-flight streams. It mirrors
+// billing.PriceRefresher's poll-and-swap-under-lock shape: callers read a
+// merged snapshot through an RWMutex while a background loop periodically
+// reloads and replaces it.
+//
+// Config itself has no field to hold a mutex or a "last known good"
+// snapshot, so Watcher owns both itself rather than growing Config. Wiring
+// the management API's PutAPIKeyPolicies/PatchAPIKeyPolicies/
+// DeleteAPIKeyPolicies handlers to call Publish instead of mutating h.cfg
+// directly is a follow-up change to the management.Handler type, which
+// isn't part of this package.
+type Watcher struct {
+	path    string
+	load    func(path string) (*Config, error)
+	metrics *metrics.Registry
+
+	mu      sync.RWMutex
+	cfg     *Config
+	modTime time.Time
+}
+
+// NewWatcher returns a Watcher seeded with initial, reloading from path via
+// load whenever Reload runs. metricsRegistry may be nil.
+func NewWatcher(path string, initial *Config, load func(path string) (*Config, error), metricsRegistry *metrics.Registry) *Watcher {
+	w := &Watcher{
+		path:    path,
+		load:    load,
+		metrics: metricsRegistry,
+		cfg:     initial,
+	}
+	if info, err := os.Stat(path); err == nil {
+		w.modTime = info.ModTime()
+	}
+	return w
+}
+
+// Current returns the live Config snapshot. Callers must not mutate the
+// returned value; APIKeyPolicies and every other field are swapped in as a
+// whole new *Config, never edited in place.
+func (w *Watcher) Current() *Config {
+	if w == nil {
+		return nil
+	}
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.cfg
+}
+
+// Reload reads and validates config from disk, swapping it in on success.
+// On a read or validation failure it leaves the previous snapshot in place
+// and returns the error, so a malformed edit never takes active streams
+// down.
+func (w *Watcher) Reload(ctx context.Context) error {
+	if w == nil {
+		return fmt.Errorf("config watcher: not initialized")
+	}
+	if w.load == nil {
+		return fmt.Errorf("config watcher: no load function configured")
+	}
+	next, err := w.load(w.path)
+	if err != nil {
+		w.observeReload(false)
+		log.WithError(err).WithField("path", w.path).Warn("config: reload failed, keeping previous config")
+		return fmt.Errorf("config watcher: reload: %w", err)
+	}
+	w.publish(next)
+	w.observeReload(true)
+	log.WithField("path", w.path).Info("config: reloaded")
+	return nil
+}
+
+// Publish swaps in cfg directly, without reading from disk, for callers
+// (e.g. a management-API handler applying an in-memory edit) that already
+// have a fully-formed, sanitized *Config to install. It applies the same
+// success/failure observability as Reload.
+func (w *Watcher) Publish(cfg *Config) error {
+	if w == nil {
+		return fmt.Errorf("config watcher: not initialized")
+	}
+	if cfg == nil {
+		w.observeReload(false)
+		return fmt.Errorf("config watcher: publish: config is nil")
+	}
+	w.publish(cfg)
+	w.observeReload(true)
+	return nil
+}
+
+func (w *Watcher) publish(cfg *Config) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.cfg = cfg
+	if info, err := os.Stat(w.path); err == nil {
+		w.modTime = info.ModTime()
+	}
+}
+
+func (w *Watcher) observeReload(success bool) {
+	if w.metrics == nil {
+		return
+	}
+	w.metrics.ObserveConfigReload(success)
+}
+
+// changed reports whether path's mtime has advanced since the last
+// successful Reload/Publish.
+func (w *Watcher) changed() bool {
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return false
+	}
+	w.mu.RLock()
+	last := w.modTime
+	w.mu.RUnlock()
+	return info.ModTime().After(last)
+}
+
+// Watch blocks, reloading whenever the config file's mtime advances (polled
+// every pollInterval) or the process receives SIGHUP, until ctx is
+// cancelled. A failed reload is logged and does not stop the loop. It is a
+// no-op if pollInterval <= 0.
+func (w *Watcher) Watch(ctx context.Context, pollInterval time.Duration) error {
+	if w == nil {
+		return fmt.Errorf("config watcher: not initialized")
+	}
+	if pollInterval <= 0 {
+		return nil
+	}
+
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	defer signal.Stop(sighup)
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-sighup:
+			log.Info("config: SIGHUP received, reloading")
+			_ = w.Reload(ctx)
+		case <-ticker.C:
+			if w.changed() {
+				_ = w.Reload(ctx)
+			}
+		}
+	}
+}
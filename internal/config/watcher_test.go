@@ -0,0 +1,105 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWatcher_ReloadSwapsSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte("v1"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	load := func(p string) (*Config, error) {
+		body, err := os.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		cfg := &Config{APIKeyPolicies: []APIKeyPolicy{{APIKey: string(body)}}}
+		return cfg, nil
+	}
+
+	w := NewWatcher(path, &Config{APIKeyPolicies: []APIKeyPolicy{{APIKey: "v0"}}}, load, nil)
+	if got := w.Current().APIKeyPolicies[0].APIKey; got != "v0" {
+		t.Fatalf("initial APIKey=%q, want v0", got)
+	}
+
+	if err := os.WriteFile(path, []byte("v2"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if err := w.Reload(context.Background()); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if got := w.Current().APIKeyPolicies[0].APIKey; got != "v2" {
+		t.Fatalf("APIKey after reload=%q, want v2", got)
+	}
+}
+
+func TestWatcher_ReloadFailureKeepsPreviousSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte("v1"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	load := func(p string) (*Config, error) {
+		return nil, fmt.Errorf("boom")
+	}
+
+	previous := &Config{APIKeyPolicies: []APIKeyPolicy{{APIKey: "v0"}}}
+	w := NewWatcher(path, previous, load, nil)
+
+	if err := w.Reload(context.Background()); err == nil {
+		t.Fatal("Reload: want error, got nil")
+	}
+	if got := w.Current(); got != previous {
+		t.Fatalf("Current() changed after a failed reload, want the same previous snapshot")
+	}
+}
+
+func TestWatcher_PublishInstallsConfigDirectly(t *testing.T) {
+	w := NewWatcher("", &Config{APIKeyPolicies: []APIKeyPolicy{{APIKey: "v0"}}}, nil, nil)
+	next := &Config{APIKeyPolicies: []APIKeyPolicy{{APIKey: "v1"}}}
+	if err := w.Publish(next); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if got := w.Current(); got != next {
+		t.Fatalf("Current() after Publish = %v, want %v", got, next)
+	}
+}
+
+func TestWatcher_ConcurrentCurrentDoesNotRaceWithReload(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte("v1"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	load := func(p string) (*Config, error) {
+		return &Config{APIKeyPolicies: []APIKeyPolicy{{APIKey: "v"}}}, nil
+	}
+	w := NewWatcher(path, &Config{}, load, nil)
+
+	var wg sync.WaitGroup
+	stop := time.Now().Add(100 * time.Millisecond)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for time.Now().Before(stop) {
+			_ = w.Current()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for time.Now().Before(stop) {
+			_ = w.Reload(context.Background())
+		}
+	}()
+	wg.Wait()
+}
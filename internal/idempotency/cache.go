@@ -0,0 +1,35 @@
+// Package idempotency caches completed non-streaming API responses by their
+// client-supplied Idempotency-Key so a retried request (e.g. after a client
+// timeout that actually succeeded server-side) replays the original
+// response instead of re-executing the upstream call a second time.
+package idempotency
+
+import (
+	"context"
+	"time"
+)
+
+// CachedResponse is the replayable shape of one completed response: enough
+// to reconstruct it byte-for-byte without re-running the handler.
+type CachedResponse struct {
+	StatusCode int
+	Body       []byte
+	// ContentType is recorded separately from Body so callers don't need to
+	// re-parse it; everything else about the response (status, body) is
+	// what BaseAPIHandler.ExecuteWithAuthManager already returns.
+	ContentType string
+}
+
+// Cache is the storage surface required for Idempotency-Key replay.
+// MemoryCache is the only backend today; a Redis- or SQLite-backed Cache can
+// implement the same interface for multi-replica deployments without
+// changing call sites, mirroring billing.Store's pluggable-backend shape.
+type Cache interface {
+	// Get returns the cached response for key, if one exists and has not
+	// expired.
+	Get(ctx context.Context, key string) (resp CachedResponse, ok bool, err error)
+	// Put stores resp under key for ttl. A zero or negative ttl stores it
+	// indefinitely.
+	Put(ctx context.Context, key string, resp CachedResponse, ttl time.Duration) error
+	Close() error
+}
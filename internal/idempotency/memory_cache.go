@@ -0,0 +1,56 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// entry is one stored response plus its expiry.
+type entry struct {
+	resp      CachedResponse
+	expiresAt time.Time // zero means "no expiry"
+}
+
+// MemoryCache is a process-local Cache backed by a plain map. It is the
+// default for single-process deployments; it does not survive a restart and
+// is not shared across replicas, same tradeoff as billing.MemoryStore.
+type MemoryCache struct {
+	mu      sync.Mutex
+	entries map[string]entry
+}
+
+// NewMemoryCache returns an empty MemoryCache.
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: make(map[string]entry)}
+}
+
+func (c *MemoryCache) Close() error { return nil }
+
+// Get returns the cached response for key, evicting it first if it has
+// already expired.
+func (c *MemoryCache) Get(_ context.Context, key string) (CachedResponse, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return CachedResponse{}, false, nil
+	}
+	if !e.expiresAt.IsZero() && time.Now().After(e.expiresAt) {
+		delete(c.entries, key)
+		return CachedResponse{}, false, nil
+	}
+	return e.resp, true, nil
+}
+
+// Put stores resp under key for ttl, replacing any existing entry.
+func (c *MemoryCache) Put(_ context.Context, key string, resp CachedResponse, ttl time.Duration) error {
+	e := entry{resp: resp}
+	if ttl > 0 {
+		e.expiresAt = time.Now().Add(ttl)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = e
+	return nil
+}
@@ -0,0 +1,46 @@
+package idempotency
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryCache_PutThenGetReplaysResponse(t *testing.T) {
+	c := NewMemoryCache()
+	ctx := context.Background()
+	want := CachedResponse{StatusCode: 200, Body: []byte(`{"ok":true}`), ContentType: "application/json"}
+
+	if err := c.Put(ctx, "k1", want, 0); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	got, ok, err := c.Get(ctx, "k1")
+	if err != nil || !ok {
+		t.Fatalf("Get: ok=%v err=%v", ok, err)
+	}
+	if got.StatusCode != want.StatusCode || string(got.Body) != string(want.Body) || got.ContentType != want.ContentType {
+		t.Fatalf("Get=%+v, want %+v", got, want)
+	}
+}
+
+func TestMemoryCache_GetMissReturnsFalse(t *testing.T) {
+	c := NewMemoryCache()
+	_, ok, err := c.Get(context.Background(), "missing")
+	if err != nil || ok {
+		t.Fatalf("Get: ok=%v err=%v, want ok=false", ok, err)
+	}
+}
+
+func TestMemoryCache_ExpiredEntryIsEvicted(t *testing.T) {
+	c := NewMemoryCache()
+	ctx := context.Background()
+	if err := c.Put(ctx, "k1", CachedResponse{StatusCode: 200}, time.Millisecond); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	_, ok, err := c.Get(ctx, "k1")
+	if err != nil || ok {
+		t.Fatalf("Get after expiry: ok=%v err=%v, want ok=false", ok, err)
+	}
+}
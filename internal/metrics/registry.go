@@ -0,0 +1,863 @@
+// Package metrics exposes request, quota, and upstream-latency telemetry as
+// a Prometheus/OpenMetrics text endpoint, separate from internal/billing's
+// own cost/token counters: this package covers what the request path and
+// API key policy enforcement observe, not what gets persisted to the
+// billing store.
+package metrics
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CardinalityLimits bounds the label values Registry will emit, so a large
+// deployment with many distinct API keys or models can't blow up the
+// exposition's series count.
+type CardinalityLimits struct {
+	// HashAPIKeys, if true, replaces the api_key label with a short,
+	// non-reversible hash (as internal/billing's metrics already do for the
+	// same reason: Prometheus series should carry no secret material).
+	HashAPIKeys bool
+
+	// ModelAllowlist, if non-empty, replaces any model label not in the list
+	// with "other" so an unbounded set of client-supplied model names can't
+	// each become their own series.
+	ModelAllowlist []string
+}
+
+func (c CardinalityLimits) allowlistSet() map[string]struct{} {
+	if len(c.ModelAllowlist) == 0 {
+		return nil
+	}
+	set := make(map[string]struct{}, len(c.ModelAllowlist))
+	for _, m := range c.ModelAllowlist {
+		set[strings.ToLower(strings.TrimSpace(m))] = struct{}{}
+	}
+	return set
+}
+
+func apiKeyLabel(hash bool, apiKey string) string {
+	if !hash {
+		return apiKey
+	}
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+func modelLabel(allowlist map[string]struct{}, model string) string {
+	if allowlist == nil {
+		return model
+	}
+	if _, ok := allowlist[strings.ToLower(strings.TrimSpace(model))]; ok {
+		return model
+	}
+	return "other"
+}
+
+type requestSeriesKey struct {
+	apiKey   string
+	model    string
+	provider string
+	status   string // "ok" | "failed"
+}
+
+type tokenSeriesKey struct {
+	apiKey string
+	model  string
+	kind   string // "prompt" | "completion" | "cached" | "reasoning"
+}
+
+type costSeriesKey struct {
+	apiKey string
+	model  string
+}
+
+type budgetGaugeKey struct {
+	apiKey string
+}
+
+type dailyLimitGaugeKey struct {
+	apiKey string
+	model  string
+}
+
+type downgradeSeriesKey struct {
+	apiKey string
+}
+
+type failoverSeriesKey struct {
+	fromModel string
+	toModel   string
+}
+
+type executorOutcomeKey struct {
+	provider   string
+	model      string
+	statusCode string
+}
+
+type clientLimiterGaugeKey struct {
+	apiKey string
+}
+
+type circuitBreakerKey struct {
+	provider string
+	model    string
+}
+
+type circuitBreakerTransitionKey struct {
+	provider string
+	model    string
+	toState  string
+}
+
+// clientQueueWaitBucketBoundsMs are the handlers.ClientLimiter queue-wait
+// histogram bucket upper bounds, in milliseconds: fine-grained near zero
+// (the common case, a slot was already free) out through the multi-second
+// waits that precede a queue-timeout rejection.
+var clientQueueWaitBucketBoundsMs = []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000, 5000, 30000}
+
+// latencyBucketBoundsSeconds are the upstream-latency histogram bucket
+// upper bounds, covering fast JSON calls up through slow streaming-model
+// first-byte latency.
+var latencyBucketBoundsSeconds = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+type latencySeriesKey struct {
+	provider string
+	model    string
+	status   string // "ok" | "failed"
+}
+
+type latencyHistogram struct {
+	buckets []int64 // cumulative counts, parallel to latencyBucketBoundsSeconds, plus one +Inf bucket
+	sum     float64
+	count   int64
+}
+
+// Registry accumulates request/quota/latency counters and gauges in process
+// memory and renders them as Prometheus/OpenMetrics exposition text.
+type Registry struct {
+	limits CardinalityLimits
+
+	mu              sync.Mutex
+	requests        map[requestSeriesKey]int64
+	requestFailures map[requestSeriesKey]int64
+	tokens          map[tokenSeriesKey]int64
+	costMicroUSD    map[costSeriesKey]int64
+	budgetRemaining map[budgetGaugeKey]float64
+	limitRemaining  map[dailyLimitGaugeKey]int64
+	latency         map[latencySeriesKey]*latencyHistogram
+
+	dailyLimitRejections  map[dailyLimitGaugeKey]int64
+	dailyBudgetRejections map[budgetGaugeKey]int64
+	opus46Downgrades      map[downgradeSeriesKey]int64
+	failoverTriggers      map[failoverSeriesKey]int64
+	executorOutcomes      map[executorOutcomeKey]int64
+	configReloads         map[bool]int64
+
+	clientInFlight          map[clientLimiterGaugeKey]int64
+	clientLimiterRejections map[clientLimiterGaugeKey]int64
+	clientQueueWait         map[clientLimiterGaugeKey]*latencyHistogram
+
+	circuitBreakerState       map[circuitBreakerKey]int64
+	circuitBreakerTransitions map[circuitBreakerTransitionKey]int64
+}
+
+// NewRegistry returns an empty Registry, applying limits to every label
+// Observe*/Set* call records from here on.
+func NewRegistry(limits CardinalityLimits) *Registry {
+	return &Registry{
+		limits:          limits,
+		requests:        make(map[requestSeriesKey]int64),
+		requestFailures: make(map[requestSeriesKey]int64),
+		tokens:          make(map[tokenSeriesKey]int64),
+		costMicroUSD:    make(map[costSeriesKey]int64),
+		budgetRemaining: make(map[budgetGaugeKey]float64),
+		limitRemaining:  make(map[dailyLimitGaugeKey]int64),
+		latency:         make(map[latencySeriesKey]*latencyHistogram),
+
+		dailyLimitRejections:  make(map[dailyLimitGaugeKey]int64),
+		dailyBudgetRejections: make(map[budgetGaugeKey]int64),
+		opus46Downgrades:      make(map[downgradeSeriesKey]int64),
+		failoverTriggers:      make(map[failoverSeriesKey]int64),
+		executorOutcomes:      make(map[executorOutcomeKey]int64),
+		configReloads:         make(map[bool]int64),
+
+		clientInFlight:          make(map[clientLimiterGaugeKey]int64),
+		clientLimiterRejections: make(map[clientLimiterGaugeKey]int64),
+		clientQueueWait:         make(map[clientLimiterGaugeKey]*latencyHistogram),
+
+		circuitBreakerState:       make(map[circuitBreakerKey]int64),
+		circuitBreakerTransitions: make(map[circuitBreakerTransitionKey]int64),
+	}
+}
+
+// ObserveRequest records one completed request: its outcome, token usage by
+// kind, and cost. model and apiKey are subject to the Registry's
+// CardinalityLimits.
+func (r *Registry) ObserveRequest(apiKey, model, provider string, failed bool, costMicroUSD int64, promptTokens, completionTokens, cachedTokens, reasoningTokens int64) {
+	if r == nil {
+		return
+	}
+	apiKey = apiKeyLabel(r.limits.HashAPIKeys, apiKey)
+	model = modelLabel(r.limits.allowlistSet(), model)
+	status := "ok"
+	if failed {
+		status = "failed"
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rk := requestSeriesKey{apiKey: apiKey, model: model, provider: provider, status: status}
+	r.requests[rk]++
+	if failed {
+		r.requestFailures[rk]++
+	}
+	r.tokens[tokenSeriesKey{apiKey: apiKey, model: model, kind: "prompt"}] += promptTokens
+	r.tokens[tokenSeriesKey{apiKey: apiKey, model: model, kind: "completion"}] += completionTokens
+	r.tokens[tokenSeriesKey{apiKey: apiKey, model: model, kind: "cached"}] += cachedTokens
+	r.tokens[tokenSeriesKey{apiKey: apiKey, model: model, kind: "reasoning"}] += reasoningTokens
+	r.costMicroUSD[costSeriesKey{apiKey: apiKey, model: model}] += costMicroUSD
+}
+
+// SetDailyBudgetRemainingUSD records the USD remaining in apiKey's daily
+// budget before the next request would be rejected.
+func (r *Registry) SetDailyBudgetRemainingUSD(apiKey string, remainingUSD float64) {
+	if r == nil {
+		return
+	}
+	apiKey = apiKeyLabel(r.limits.HashAPIKeys, apiKey)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.budgetRemaining[budgetGaugeKey{apiKey: apiKey}] = remainingUSD
+}
+
+// SetDailyLimitRemaining records how many more requests apiKey can make
+// against model's daily limit before being rejected.
+func (r *Registry) SetDailyLimitRemaining(apiKey, model string, remaining int) {
+	if r == nil {
+		return
+	}
+	apiKey = apiKeyLabel(r.limits.HashAPIKeys, apiKey)
+	model = modelLabel(r.limits.allowlistSet(), model)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.limitRemaining[dailyLimitGaugeKey{apiKey: apiKey, model: model}] = int64(remaining)
+}
+
+// ObserveUpstreamLatency records how long one upstream executor call took.
+func (r *Registry) ObserveUpstreamLatency(provider, model string, failed bool, seconds float64) {
+	if r == nil {
+		return
+	}
+	model = modelLabel(r.limits.allowlistSet(), model)
+	status := "ok"
+	if failed {
+		status = "failed"
+	}
+	key := latencySeriesKey{provider: provider, model: model, status: status}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	h, ok := r.latency[key]
+	if !ok {
+		h = &latencyHistogram{buckets: make([]int64, len(latencyBucketBoundsSeconds)+1)}
+		r.latency[key] = h
+	}
+	for i, bound := range latencyBucketBoundsSeconds {
+		if seconds <= bound {
+			h.buckets[i]++
+		}
+	}
+	h.buckets[len(latencyBucketBoundsSeconds)]++ // +Inf bucket
+	h.sum += seconds
+	h.count++
+}
+
+// ObserveDailyLimitRejection records one request rejected by
+// SQLiteDailyLimiter.Consume (or any other policy.DailyLimiter backend)
+// because apiKey's daily request limit for model was already reached.
+func (r *Registry) ObserveDailyLimitRejection(apiKey, model string) {
+	if r == nil {
+		return
+	}
+	apiKey = apiKeyLabel(r.limits.HashAPIKeys, apiKey)
+	model = modelLabel(r.limits.allowlistSet(), model)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.dailyLimitRejections[dailyLimitGaugeKey{apiKey: apiKey, model: model}]++
+}
+
+// ObserveDailyBudgetRejection records one request rejected by
+// APIKeyPolicyMiddleware because apiKey's daily budget was already spent.
+func (r *Registry) ObserveDailyBudgetRejection(apiKey string) {
+	if r == nil {
+		return
+	}
+	apiKey = apiKeyLabel(r.limits.HashAPIKeys, apiKey)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.dailyBudgetRejections[budgetGaugeKey{apiKey: apiKey}]++
+}
+
+// ObserveOpus46Downgrade records one request whose model was transparently
+// rewritten by policy.DowngradeClaudeOpus46 because apiKey's policy disallows
+// claude-opus-4-6.
+func (r *Registry) ObserveOpus46Downgrade(apiKey string) {
+	if r == nil {
+		return
+	}
+	apiKey = apiKeyLabel(r.limits.HashAPIKeys, apiKey)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.opus46Downgrades[downgradeSeriesKey{apiKey: apiKey}]++
+}
+
+// ObserveFailoverTriggered records one automatic Claude -> target-model
+// failover, broken down by the model the client originally requested and the
+// model failover actually routed to.
+func (r *Registry) ObserveFailoverTriggered(fromModel, toModel string) {
+	if r == nil {
+		return
+	}
+	allow := r.limits.allowlistSet()
+	fromModel = modelLabel(allow, fromModel)
+	toModel = modelLabel(allow, toModel)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failoverTriggers[failoverSeriesKey{fromModel: fromModel, toModel: toModel}]++
+}
+
+// ObserveExecutorOutcome records one completed upstream executor call,
+// keyed by the HTTP status it resolved to (200 for success, the mapped
+// status for a failure), alongside ObserveUpstreamLatency's ok/failed
+// breakdown.
+func (r *Registry) ObserveExecutorOutcome(provider, model string, httpStatus int) {
+	if r == nil {
+		return
+	}
+	model = modelLabel(r.limits.allowlistSet(), model)
+	key := executorOutcomeKey{provider: provider, model: model, statusCode: fmt.Sprint(httpStatus)}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.executorOutcomes[key]++
+}
+
+// ObserveConfigReload records the outcome of one config.Watcher reload or
+// Publish call, success/failure broken down so a deployment can alert on a
+// rising failure rate (e.g. a management-API edit that keeps failing
+// validation) without scraping logs.
+func (r *Registry) ObserveConfigReload(success bool) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.configReloads[success]++
+}
+
+// SetClientInFlight records how many of apiKey's requests handlers.ClientLimiter
+// currently has an acquired concurrency slot for, so operators can see a
+// client's real occupancy alongside the MaxConcurrent limit it's configured
+// against.
+func (r *Registry) SetClientInFlight(apiKey string, inFlight int64) {
+	if r == nil {
+		return
+	}
+	apiKey = apiKeyLabel(r.limits.HashAPIKeys, apiKey)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.clientInFlight[clientLimiterGaugeKey{apiKey: apiKey}] = inFlight
+}
+
+// ObserveClientLimiterRejection records one request handlers.ClientLimiter
+// rejected with a 429 after apiKey's queue-timeout elapsed waiting for a
+// concurrency slot or rate-limit token.
+func (r *Registry) ObserveClientLimiterRejection(apiKey string) {
+	if r == nil {
+		return
+	}
+	apiKey = apiKeyLabel(r.limits.HashAPIKeys, apiKey)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.clientLimiterRejections[clientLimiterGaugeKey{apiKey: apiKey}]++
+}
+
+// ObserveClientQueueWait records how long one request waited on
+// handlers.ClientLimiter for a concurrency slot or rate-limit token before
+// either proceeding or being rejected.
+func (r *Registry) ObserveClientQueueWait(apiKey string, waitMs float64) {
+	if r == nil {
+		return
+	}
+	apiKey = apiKeyLabel(r.limits.HashAPIKeys, apiKey)
+	key := clientLimiterGaugeKey{apiKey: apiKey}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	h, ok := r.clientQueueWait[key]
+	if !ok {
+		h = &latencyHistogram{buckets: make([]int64, len(clientQueueWaitBucketBoundsMs)+1)}
+		r.clientQueueWait[key] = h
+	}
+	for i, bound := range clientQueueWaitBucketBoundsMs {
+		if waitMs <= bound {
+			h.buckets[i]++
+		}
+	}
+	h.buckets[len(clientQueueWaitBucketBoundsMs)]++ // +Inf bucket
+	h.sum += waitMs
+	h.count++
+}
+
+// SetCircuitBreakerState records handlers.CircuitBreaker's current state for
+// a provider/model pair, as an integer code (0=closed, 1=half-open,
+// 2=open), so operators can graph which pairs are currently short-circuited
+// alongside ObserveCircuitBreakerTransition's transition history.
+func (r *Registry) SetCircuitBreakerState(provider, model string, state int64) {
+	if r == nil {
+		return
+	}
+	model = modelLabel(r.limits.allowlistSet(), model)
+	key := circuitBreakerKey{provider: provider, model: model}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.circuitBreakerState[key] = state
+}
+
+// ObserveCircuitBreakerTransition records one handlers.CircuitBreaker state
+// transition for a provider/model pair, broken down by the state it
+// transitioned into.
+func (r *Registry) ObserveCircuitBreakerTransition(provider, model, toState string) {
+	if r == nil {
+		return
+	}
+	model = modelLabel(r.limits.allowlistSet(), model)
+	key := circuitBreakerTransitionKey{provider: provider, model: model, toState: toState}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.circuitBreakerTransitions[key]++
+}
+
+// WriteExposition renders every series in Prometheus text exposition format.
+func (r *Registry) WriteExposition(w io.Writer) error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err := io.WriteString(w, "# HELP cliproxy_requests_total Total requests, by outcome.\n"+
+		"# TYPE cliproxy_requests_total counter\n"); err != nil {
+		return err
+	}
+	requestKeys := make([]requestSeriesKey, 0, len(r.requests))
+	for k := range r.requests {
+		requestKeys = append(requestKeys, k)
+	}
+	sort.Slice(requestKeys, func(i, j int) bool { return requestSeriesLess(requestKeys[i], requestKeys[j]) })
+	for _, k := range requestKeys {
+		if _, err := fmt.Fprintf(w, "cliproxy_requests_total{api_key=%q,model=%q,provider=%q,status=%q} %d\n", k.apiKey, k.model, k.provider, k.status, r.requests[k]); err != nil {
+			return err
+		}
+	}
+
+	if _, err := io.WriteString(w, "# HELP cliproxy_request_failures_total Total failed requests.\n"+
+		"# TYPE cliproxy_request_failures_total counter\n"); err != nil {
+		return err
+	}
+	failureKeys := make([]requestSeriesKey, 0, len(r.requestFailures))
+	for k := range r.requestFailures {
+		failureKeys = append(failureKeys, k)
+	}
+	sort.Slice(failureKeys, func(i, j int) bool { return requestSeriesLess(failureKeys[i], failureKeys[j]) })
+	for _, k := range failureKeys {
+		if _, err := fmt.Fprintf(w, "cliproxy_request_failures_total{api_key=%q,model=%q,provider=%q} %d\n", k.apiKey, k.model, k.provider, r.requestFailures[k]); err != nil {
+			return err
+		}
+	}
+
+	if _, err := io.WriteString(w, "# HELP cliproxy_tokens_total Total tokens processed, by kind.\n"+
+		"# TYPE cliproxy_tokens_total counter\n"); err != nil {
+		return err
+	}
+	tokenKeys := make([]tokenSeriesKey, 0, len(r.tokens))
+	for k := range r.tokens {
+		tokenKeys = append(tokenKeys, k)
+	}
+	sort.Slice(tokenKeys, func(i, j int) bool { return tokenSeriesLess(tokenKeys[i], tokenKeys[j]) })
+	for _, k := range tokenKeys {
+		if _, err := fmt.Fprintf(w, "cliproxy_tokens_total{api_key=%q,model=%q,kind=%q} %d\n", k.apiKey, k.model, k.kind, r.tokens[k]); err != nil {
+			return err
+		}
+	}
+
+	if _, err := io.WriteString(w, "# HELP cliproxy_cost_microusd_total Total billed cost in micro-USD.\n"+
+		"# TYPE cliproxy_cost_microusd_total counter\n"); err != nil {
+		return err
+	}
+	costKeys := make([]costSeriesKey, 0, len(r.costMicroUSD))
+	for k := range r.costMicroUSD {
+		costKeys = append(costKeys, k)
+	}
+	sort.Slice(costKeys, func(i, j int) bool { return costSeriesLess(costKeys[i], costKeys[j]) })
+	for _, k := range costKeys {
+		if _, err := fmt.Fprintf(w, "cliproxy_cost_microusd_total{api_key=%q,model=%q} %d\n", k.apiKey, k.model, r.costMicroUSD[k]); err != nil {
+			return err
+		}
+	}
+
+	if _, err := io.WriteString(w, "# HELP cliproxy_daily_budget_remaining_usd USD remaining in the API key's daily budget.\n"+
+		"# TYPE cliproxy_daily_budget_remaining_usd gauge\n"); err != nil {
+		return err
+	}
+	budgetKeys := make([]budgetGaugeKey, 0, len(r.budgetRemaining))
+	for k := range r.budgetRemaining {
+		budgetKeys = append(budgetKeys, k)
+	}
+	sort.Slice(budgetKeys, func(i, j int) bool { return budgetKeys[i].apiKey < budgetKeys[j].apiKey })
+	for _, k := range budgetKeys {
+		if _, err := fmt.Fprintf(w, "cliproxy_daily_budget_remaining_usd{api_key=%q} %g\n", k.apiKey, r.budgetRemaining[k]); err != nil {
+			return err
+		}
+	}
+
+	if _, err := io.WriteString(w, "# HELP cliproxy_daily_limit_remaining Requests remaining before the API key's daily model limit is hit.\n"+
+		"# TYPE cliproxy_daily_limit_remaining gauge\n"); err != nil {
+		return err
+	}
+	limitKeys := make([]dailyLimitGaugeKey, 0, len(r.limitRemaining))
+	for k := range r.limitRemaining {
+		limitKeys = append(limitKeys, k)
+	}
+	sort.Slice(limitKeys, func(i, j int) bool {
+		if limitKeys[i].apiKey != limitKeys[j].apiKey {
+			return limitKeys[i].apiKey < limitKeys[j].apiKey
+		}
+		return limitKeys[i].model < limitKeys[j].model
+	})
+	for _, k := range limitKeys {
+		if _, err := fmt.Fprintf(w, "cliproxy_daily_limit_remaining{api_key=%q,model=%q} %d\n", k.apiKey, k.model, r.limitRemaining[k]); err != nil {
+			return err
+		}
+	}
+
+	if _, err := io.WriteString(w, "# HELP cliproxy_daily_limit_rejections_total Requests rejected for exceeding a daily model limit.\n"+
+		"# TYPE cliproxy_daily_limit_rejections_total counter\n"); err != nil {
+		return err
+	}
+	limitRejectionKeys := make([]dailyLimitGaugeKey, 0, len(r.dailyLimitRejections))
+	for k := range r.dailyLimitRejections {
+		limitRejectionKeys = append(limitRejectionKeys, k)
+	}
+	sort.Slice(limitRejectionKeys, func(i, j int) bool {
+		if limitRejectionKeys[i].apiKey != limitRejectionKeys[j].apiKey {
+			return limitRejectionKeys[i].apiKey < limitRejectionKeys[j].apiKey
+		}
+		return limitRejectionKeys[i].model < limitRejectionKeys[j].model
+	})
+	for _, k := range limitRejectionKeys {
+		if _, err := fmt.Fprintf(w, "cliproxy_daily_limit_rejections_total{api_key=%q,model=%q} %d\n", k.apiKey, k.model, r.dailyLimitRejections[k]); err != nil {
+			return err
+		}
+	}
+
+	if _, err := io.WriteString(w, "# HELP cliproxy_daily_budget_rejections_total Requests rejected for exceeding a daily USD budget.\n"+
+		"# TYPE cliproxy_daily_budget_rejections_total counter\n"); err != nil {
+		return err
+	}
+	budgetRejectionKeys := make([]budgetGaugeKey, 0, len(r.dailyBudgetRejections))
+	for k := range r.dailyBudgetRejections {
+		budgetRejectionKeys = append(budgetRejectionKeys, k)
+	}
+	sort.Slice(budgetRejectionKeys, func(i, j int) bool { return budgetRejectionKeys[i].apiKey < budgetRejectionKeys[j].apiKey })
+	for _, k := range budgetRejectionKeys {
+		if _, err := fmt.Fprintf(w, "cliproxy_daily_budget_rejections_total{api_key=%q} %d\n", k.apiKey, r.dailyBudgetRejections[k]); err != nil {
+			return err
+		}
+	}
+
+	if _, err := io.WriteString(w, "# HELP cliproxy_opus46_downgrades_total Requests transparently downgraded from claude-opus-4-6 by policy.\n"+
+		"# TYPE cliproxy_opus46_downgrades_total counter\n"); err != nil {
+		return err
+	}
+	downgradeKeys := make([]downgradeSeriesKey, 0, len(r.opus46Downgrades))
+	for k := range r.opus46Downgrades {
+		downgradeKeys = append(downgradeKeys, k)
+	}
+	sort.Slice(downgradeKeys, func(i, j int) bool { return downgradeKeys[i].apiKey < downgradeKeys[j].apiKey })
+	for _, k := range downgradeKeys {
+		if _, err := fmt.Fprintf(w, "cliproxy_opus46_downgrades_total{api_key=%q} %d\n", k.apiKey, r.opus46Downgrades[k]); err != nil {
+			return err
+		}
+	}
+
+	if _, err := io.WriteString(w, "# HELP cliproxy_claude_failover_triggered_total Automatic Claude failovers, by requested and target model.\n"+
+		"# TYPE cliproxy_claude_failover_triggered_total counter\n"); err != nil {
+		return err
+	}
+	failoverKeys := make([]failoverSeriesKey, 0, len(r.failoverTriggers))
+	for k := range r.failoverTriggers {
+		failoverKeys = append(failoverKeys, k)
+	}
+	sort.Slice(failoverKeys, func(i, j int) bool {
+		if failoverKeys[i].fromModel != failoverKeys[j].fromModel {
+			return failoverKeys[i].fromModel < failoverKeys[j].fromModel
+		}
+		return failoverKeys[i].toModel < failoverKeys[j].toModel
+	})
+	for _, k := range failoverKeys {
+		if _, err := fmt.Fprintf(w, "cliproxy_claude_failover_triggered_total{from_model=%q,to_model=%q} %d\n", k.fromModel, k.toModel, r.failoverTriggers[k]); err != nil {
+			return err
+		}
+	}
+
+	if _, err := io.WriteString(w, "# HELP cliproxy_executor_outcomes_total Upstream executor calls, by resolved HTTP status.\n"+
+		"# TYPE cliproxy_executor_outcomes_total counter\n"); err != nil {
+		return err
+	}
+	executorKeys := make([]executorOutcomeKey, 0, len(r.executorOutcomes))
+	for k := range r.executorOutcomes {
+		executorKeys = append(executorKeys, k)
+	}
+	sort.Slice(executorKeys, func(i, j int) bool {
+		if executorKeys[i].provider != executorKeys[j].provider {
+			return executorKeys[i].provider < executorKeys[j].provider
+		}
+		if executorKeys[i].model != executorKeys[j].model {
+			return executorKeys[i].model < executorKeys[j].model
+		}
+		return executorKeys[i].statusCode < executorKeys[j].statusCode
+	})
+	for _, k := range executorKeys {
+		if _, err := fmt.Fprintf(w, "cliproxy_executor_outcomes_total{provider=%q,model=%q,status_code=%q} %d\n", k.provider, k.model, k.statusCode, r.executorOutcomes[k]); err != nil {
+			return err
+		}
+	}
+
+	if err := writeLatencyHistogram(w, r.latency); err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w, "# HELP cliproxy_config_reloads_total Config reload/publish attempts, by outcome.\n"+
+		"# TYPE cliproxy_config_reloads_total counter\n"); err != nil {
+		return err
+	}
+	for _, success := range []bool{true, false} {
+		status := "ok"
+		if !success {
+			status = "failed"
+		}
+		if _, err := fmt.Fprintf(w, "cliproxy_config_reloads_total{status=%q} %d\n", status, r.configReloads[success]); err != nil {
+			return err
+		}
+	}
+
+	if _, err := io.WriteString(w, "# HELP cliproxy_client_in_flight Requests currently holding a handlers.ClientLimiter concurrency slot.\n"+
+		"# TYPE cliproxy_client_in_flight gauge\n"); err != nil {
+		return err
+	}
+	inFlightKeys := make([]clientLimiterGaugeKey, 0, len(r.clientInFlight))
+	for k := range r.clientInFlight {
+		inFlightKeys = append(inFlightKeys, k)
+	}
+	sort.Slice(inFlightKeys, func(i, j int) bool { return inFlightKeys[i].apiKey < inFlightKeys[j].apiKey })
+	for _, k := range inFlightKeys {
+		if _, err := fmt.Fprintf(w, "cliproxy_client_in_flight{api_key=%q} %d\n", k.apiKey, r.clientInFlight[k]); err != nil {
+			return err
+		}
+	}
+
+	if _, err := io.WriteString(w, "# HELP cliproxy_client_limiter_rejections_total Requests rejected by handlers.ClientLimiter after a queue-timeout.\n"+
+		"# TYPE cliproxy_client_limiter_rejections_total counter\n"); err != nil {
+		return err
+	}
+	rejectionKeys := make([]clientLimiterGaugeKey, 0, len(r.clientLimiterRejections))
+	for k := range r.clientLimiterRejections {
+		rejectionKeys = append(rejectionKeys, k)
+	}
+	sort.Slice(rejectionKeys, func(i, j int) bool { return rejectionKeys[i].apiKey < rejectionKeys[j].apiKey })
+	for _, k := range rejectionKeys {
+		if _, err := fmt.Fprintf(w, "cliproxy_client_limiter_rejections_total{api_key=%q} %d\n", k.apiKey, r.clientLimiterRejections[k]); err != nil {
+			return err
+		}
+	}
+
+	if err := writeClientQueueWaitHistogram(w, r.clientQueueWait); err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w, "# HELP cliproxy_circuit_breaker_state handlers.CircuitBreaker's current state per provider/model (0=closed,1=half_open,2=open).\n"+
+		"# TYPE cliproxy_circuit_breaker_state gauge\n"); err != nil {
+		return err
+	}
+	stateKeys := make([]circuitBreakerKey, 0, len(r.circuitBreakerState))
+	for k := range r.circuitBreakerState {
+		stateKeys = append(stateKeys, k)
+	}
+	sort.Slice(stateKeys, func(i, j int) bool {
+		if stateKeys[i].provider != stateKeys[j].provider {
+			return stateKeys[i].provider < stateKeys[j].provider
+		}
+		return stateKeys[i].model < stateKeys[j].model
+	})
+	for _, k := range stateKeys {
+		if _, err := fmt.Fprintf(w, "cliproxy_circuit_breaker_state{provider=%q,model=%q} %d\n", k.provider, k.model, r.circuitBreakerState[k]); err != nil {
+			return err
+		}
+	}
+
+	if _, err := io.WriteString(w, "# HELP cliproxy_circuit_breaker_transitions_total handlers.CircuitBreaker state transitions per provider/model.\n"+
+		"# TYPE cliproxy_circuit_breaker_transitions_total counter\n"); err != nil {
+		return err
+	}
+	transitionKeys := make([]circuitBreakerTransitionKey, 0, len(r.circuitBreakerTransitions))
+	for k := range r.circuitBreakerTransitions {
+		transitionKeys = append(transitionKeys, k)
+	}
+	sort.Slice(transitionKeys, func(i, j int) bool {
+		if transitionKeys[i].provider != transitionKeys[j].provider {
+			return transitionKeys[i].provider < transitionKeys[j].provider
+		}
+		if transitionKeys[i].model != transitionKeys[j].model {
+			return transitionKeys[i].model < transitionKeys[j].model
+		}
+		return transitionKeys[i].toState < transitionKeys[j].toState
+	})
+	for _, k := range transitionKeys {
+		if _, err := fmt.Fprintf(w, "cliproxy_circuit_breaker_transitions_total{provider=%q,model=%q,to_state=%q} %d\n", k.provider, k.model, k.toState, r.circuitBreakerTransitions[k]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func requestSeriesLess(a, b requestSeriesKey) bool {
+	if a.apiKey != b.apiKey {
+		return a.apiKey < b.apiKey
+	}
+	if a.model != b.model {
+		return a.model < b.model
+	}
+	if a.provider != b.provider {
+		return a.provider < b.provider
+	}
+	return a.status < b.status
+}
+
+func tokenSeriesLess(a, b tokenSeriesKey) bool {
+	if a.apiKey != b.apiKey {
+		return a.apiKey < b.apiKey
+	}
+	if a.model != b.model {
+		return a.model < b.model
+	}
+	return a.kind < b.kind
+}
+
+func costSeriesLess(a, b costSeriesKey) bool {
+	if a.apiKey != b.apiKey {
+		return a.apiKey < b.apiKey
+	}
+	return a.model < b.model
+}
+
+func writeLatencyHistogram(w io.Writer, series map[latencySeriesKey]*latencyHistogram) error {
+	if _, err := io.WriteString(w, "# HELP cliproxy_upstream_latency_seconds Upstream executor call latency.\n"+
+		"# TYPE cliproxy_upstream_latency_seconds histogram\n"); err != nil {
+		return err
+	}
+	keys := make([]latencySeriesKey, 0, len(series))
+	for k := range series {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].provider != keys[j].provider {
+			return keys[i].provider < keys[j].provider
+		}
+		if keys[i].model != keys[j].model {
+			return keys[i].model < keys[j].model
+		}
+		return keys[i].status < keys[j].status
+	})
+	for _, k := range keys {
+		h := series[k]
+		for i, bound := range latencyBucketBoundsSeconds {
+			if _, err := fmt.Fprintf(w, "cliproxy_upstream_latency_seconds_bucket{provider=%q,model=%q,status=%q,le=%q} %d\n", k.provider, k.model, k.status, fmt.Sprint(bound), h.buckets[i]); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "cliproxy_upstream_latency_seconds_bucket{provider=%q,model=%q,status=%q,le=\"+Inf\"} %d\n", k.provider, k.model, k.status, h.buckets[len(latencyBucketBoundsSeconds)]); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "cliproxy_upstream_latency_seconds_sum{provider=%q,model=%q,status=%q} %g\n", k.provider, k.model, k.status, h.sum); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "cliproxy_upstream_latency_seconds_count{provider=%q,model=%q,status=%q} %d\n", k.provider, k.model, k.status, h.count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeClientQueueWaitHistogram(w io.Writer, series map[clientLimiterGaugeKey]*latencyHistogram) error {
+	if _, err := io.WriteString(w, "# HELP cliproxy_client_queue_wait_ms Time requests spent waiting on handlers.ClientLimiter for a concurrency slot or rate-limit token.\n"+
+		"# TYPE cliproxy_client_queue_wait_ms histogram\n"); err != nil {
+		return err
+	}
+	keys := make([]clientLimiterGaugeKey, 0, len(series))
+	for k := range series {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].apiKey < keys[j].apiKey })
+	for _, k := range keys {
+		h := series[k]
+		for i, bound := range clientQueueWaitBucketBoundsMs {
+			if _, err := fmt.Fprintf(w, "cliproxy_client_queue_wait_ms_bucket{api_key=%q,le=%q} %d\n", k.apiKey, fmt.Sprint(bound), h.buckets[i]); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "cliproxy_client_queue_wait_ms_bucket{api_key=%q,le=\"+Inf\"} %d\n", k.apiKey, h.buckets[len(clientQueueWaitBucketBoundsMs)]); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "cliproxy_client_queue_wait_ms_sum{api_key=%q} %g\n", k.apiKey, h.sum); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "cliproxy_client_queue_wait_ms_count{api_key=%q} %d\n", k.apiKey, h.count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Handler returns a gin.HandlerFunc that serves reg's counters in
+// Prometheus/OpenMetrics text exposition format, for registration alongside
+// the management API (e.g. GET /metrics).
+func Handler(reg *Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if reg == nil {
+			return
+		}
+		_ = reg.WriteExposition(c.Writer)
+	}
+}
@@ -0,0 +1,249 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegistry_ObserveRequestAndWriteExposition(t *testing.T) {
+	reg := NewRegistry(CardinalityLimits{})
+	reg.ObserveRequest("sk-test-key", "model-a", "codex", false, 1234, 10, 5, 2, 0)
+	reg.ObserveRequest("sk-test-key", "model-a", "codex", true, 1, 1, 1, 0, 0)
+
+	var buf strings.Builder
+	if err := reg.WriteExposition(&buf); err != nil {
+		t.Fatalf("WriteExposition: %v", err)
+	}
+	out := buf.String()
+
+	if !strings.Contains(out, `cliproxy_requests_total{api_key="sk-test-key",model="model-a",provider="codex",status="ok"} 1`) {
+		t.Fatalf("missing ok request series:\n%s", out)
+	}
+	if !strings.Contains(out, `cliproxy_request_failures_total{api_key="sk-test-key",model="model-a",provider="codex"} 1`) {
+		t.Fatalf("missing failure series:\n%s", out)
+	}
+	if !strings.Contains(out, `cliproxy_cost_microusd_total{api_key="sk-test-key",model="model-a"} 1235`) {
+		t.Fatalf("unexpected cost series:\n%s", out)
+	}
+	if !strings.Contains(out, `cliproxy_tokens_total{api_key="sk-test-key",model="model-a",kind="prompt"} 11`) {
+		t.Fatalf("unexpected token series:\n%s", out)
+	}
+}
+
+func TestRegistry_HashAPIKeysRedactsRawKey(t *testing.T) {
+	reg := NewRegistry(CardinalityLimits{HashAPIKeys: true})
+	reg.ObserveRequest("sk-secret-key", "model-a", "codex", false, 1, 1, 1, 0, 0)
+
+	var buf strings.Builder
+	if err := reg.WriteExposition(&buf); err != nil {
+		t.Fatalf("WriteExposition: %v", err)
+	}
+	out := buf.String()
+
+	if strings.Contains(out, "sk-secret-key") {
+		t.Fatal("exposition leaked raw api key")
+	}
+	label := apiKeyLabel(true, "sk-secret-key")
+	if !strings.Contains(out, `api_key="`+label+`"`) {
+		t.Fatalf("missing hashed label in output:\n%s", out)
+	}
+}
+
+func TestRegistry_ModelAllowlistBucketsUnknownModels(t *testing.T) {
+	reg := NewRegistry(CardinalityLimits{ModelAllowlist: []string{"model-a"}})
+	reg.ObserveRequest("key", "model-a", "codex", false, 1, 1, 1, 0, 0)
+	reg.ObserveRequest("key", "some-weird-client-supplied-name", "codex", false, 1, 1, 1, 0, 0)
+
+	var buf strings.Builder
+	if err := reg.WriteExposition(&buf); err != nil {
+		t.Fatalf("WriteExposition: %v", err)
+	}
+	out := buf.String()
+
+	if !strings.Contains(out, `model="model-a"`) {
+		t.Fatalf("expected allowlisted model label preserved:\n%s", out)
+	}
+	if strings.Contains(out, "some-weird-client-supplied-name") {
+		t.Fatal("exposition leaked a non-allowlisted model name")
+	}
+	if !strings.Contains(out, `model="other"`) {
+		t.Fatalf("expected non-allowlisted model bucketed as other:\n%s", out)
+	}
+}
+
+func TestRegistry_BudgetAndDailyLimitGauges(t *testing.T) {
+	reg := NewRegistry(CardinalityLimits{})
+	reg.SetDailyBudgetRemainingUSD("key", 4.5)
+	reg.SetDailyLimitRemaining("key", "model-a", 7)
+
+	var buf strings.Builder
+	if err := reg.WriteExposition(&buf); err != nil {
+		t.Fatalf("WriteExposition: %v", err)
+	}
+	out := buf.String()
+
+	if !strings.Contains(out, `cliproxy_daily_budget_remaining_usd{api_key="key"} 4.5`) {
+		t.Fatalf("missing budget gauge:\n%s", out)
+	}
+	if !strings.Contains(out, `cliproxy_daily_limit_remaining{api_key="key",model="model-a"} 7`) {
+		t.Fatalf("missing daily limit gauge:\n%s", out)
+	}
+}
+
+func TestRegistry_UpstreamLatencyHistogram(t *testing.T) {
+	reg := NewRegistry(CardinalityLimits{})
+	reg.ObserveUpstreamLatency("codex", "model-a", false, 0.2)
+	reg.ObserveUpstreamLatency("codex", "model-a", false, 2.0)
+
+	var buf strings.Builder
+	if err := reg.WriteExposition(&buf); err != nil {
+		t.Fatalf("WriteExposition: %v", err)
+	}
+	out := buf.String()
+
+	if !strings.Contains(out, `cliproxy_upstream_latency_seconds_count{provider="codex",model="model-a",status="ok"} 2`) {
+		t.Fatalf("unexpected histogram count:\n%s", out)
+	}
+	if !strings.Contains(out, `cliproxy_upstream_latency_seconds_bucket{provider="codex",model="model-a",status="ok",le="0.25"} 1`) {
+		t.Fatalf("unexpected bucket count:\n%s", out)
+	}
+	if !strings.Contains(out, `cliproxy_upstream_latency_seconds_bucket{provider="codex",model="model-a",status="ok",le="+Inf"} 2`) {
+		t.Fatalf("unexpected +Inf bucket count:\n%s", out)
+	}
+}
+
+func TestRegistry_PolicyEnforcementCounters(t *testing.T) {
+	reg := NewRegistry(CardinalityLimits{})
+	reg.ObserveDailyLimitRejection("key", "model-a")
+	reg.ObserveDailyBudgetRejection("key")
+	reg.ObserveOpus46Downgrade("key")
+	reg.ObserveFailoverTriggered("claude-opus-4-6", "claude-opus-4-5-20251101")
+	reg.ObserveExecutorOutcome("claude", "model-a", 529)
+	reg.ObserveExecutorOutcome("claude", "model-a", 200)
+
+	var buf strings.Builder
+	if err := reg.WriteExposition(&buf); err != nil {
+		t.Fatalf("WriteExposition: %v", err)
+	}
+	out := buf.String()
+
+	if !strings.Contains(out, `cliproxy_daily_limit_rejections_total{api_key="key",model="model-a"} 1`) {
+		t.Fatalf("missing daily limit rejection counter:\n%s", out)
+	}
+	if !strings.Contains(out, `cliproxy_daily_budget_rejections_total{api_key="key"} 1`) {
+		t.Fatalf("missing daily budget rejection counter:\n%s", out)
+	}
+	if !strings.Contains(out, `cliproxy_opus46_downgrades_total{api_key="key"} 1`) {
+		t.Fatalf("missing opus46 downgrade counter:\n%s", out)
+	}
+	if !strings.Contains(out, `cliproxy_claude_failover_triggered_total{from_model="claude-opus-4-6",to_model="claude-opus-4-5-20251101"} 1`) {
+		t.Fatalf("missing failover counter:\n%s", out)
+	}
+	if !strings.Contains(out, `cliproxy_executor_outcomes_total{provider="claude",model="model-a",status_code="529"} 1`) {
+		t.Fatalf("missing executor outcome counter for 529:\n%s", out)
+	}
+	if !strings.Contains(out, `cliproxy_executor_outcomes_total{provider="claude",model="model-a",status_code="200"} 1`) {
+		t.Fatalf("missing executor outcome counter for 200:\n%s", out)
+	}
+}
+
+// TestRegistry_ScrapeCardinalityStaysBoundedUnderModelAllowlist simulates a
+// scrape target fed client-supplied model names across every counter/gauge
+// that takes a model label, and checks the exposition never grows a series
+// per distinct raw model name once a ModelAllowlist is configured - this is
+// what keeps an operator's Prometheus from being overwhelmed by cardinality
+// explosion from unnormalized, client-controlled label values.
+func TestRegistry_ScrapeCardinalityStaysBoundedUnderModelAllowlist(t *testing.T) {
+	reg := NewRegistry(CardinalityLimits{ModelAllowlist: []string{"model-a"}})
+	for i := 0; i < 50; i++ {
+		clientModel := "client-supplied-model-" + string(rune('a'+i%26))
+		reg.ObserveRequest("key", clientModel, "codex", false, 1, 1, 1, 0, 0)
+		reg.ObserveDailyLimitRejection("key", clientModel)
+		reg.ObserveExecutorOutcome("codex", clientModel, 200)
+		reg.ObserveFailoverTriggered(clientModel, "model-a")
+	}
+	reg.ObserveRequest("key", "model-a", "codex", false, 1, 1, 1, 0, 0)
+
+	var buf strings.Builder
+	if err := reg.WriteExposition(&buf); err != nil {
+		t.Fatalf("WriteExposition: %v", err)
+	}
+	out := buf.String()
+
+	if strings.Contains(out, "client-supplied-model-") {
+		t.Fatalf("unnormalized client model name leaked into exposition:\n%s", out)
+	}
+	if got := strings.Count(out, `model="other"`); got == 0 {
+		t.Fatalf("expected non-allowlisted models bucketed under \"other\":\n%s", out)
+	}
+	if !strings.Contains(out, `model="model-a"`) {
+		t.Fatalf("expected allowlisted model label preserved:\n%s", out)
+	}
+}
+
+func TestRegistry_ClientLimiterMetrics(t *testing.T) {
+	reg := NewRegistry(CardinalityLimits{})
+	reg.SetClientInFlight("key", 3)
+	reg.ObserveClientLimiterRejection("key")
+	reg.ObserveClientQueueWait("key", 2)
+	reg.ObserveClientQueueWait("key", 200)
+
+	var buf strings.Builder
+	if err := reg.WriteExposition(&buf); err != nil {
+		t.Fatalf("WriteExposition: %v", err)
+	}
+	out := buf.String()
+
+	if !strings.Contains(out, `cliproxy_client_in_flight{api_key="key"} 3`) {
+		t.Fatalf("missing in-flight gauge:\n%s", out)
+	}
+	if !strings.Contains(out, `cliproxy_client_limiter_rejections_total{api_key="key"} 1`) {
+		t.Fatalf("missing rejection counter:\n%s", out)
+	}
+	if !strings.Contains(out, `cliproxy_client_queue_wait_ms_count{api_key="key"} 2`) {
+		t.Fatalf("unexpected queue-wait histogram count:\n%s", out)
+	}
+	if !strings.Contains(out, `cliproxy_client_queue_wait_ms_bucket{api_key="key",le="5"} 1`) {
+		t.Fatalf("unexpected queue-wait bucket count:\n%s", out)
+	}
+	if !strings.Contains(out, `cliproxy_client_queue_wait_ms_bucket{api_key="key",le="+Inf"} 2`) {
+		t.Fatalf("unexpected queue-wait +Inf bucket count:\n%s", out)
+	}
+}
+
+func TestRegistry_CircuitBreakerMetrics(t *testing.T) {
+	reg := NewRegistry(CardinalityLimits{})
+	reg.SetCircuitBreakerState("claude", "claude-model", 2)
+	reg.ObserveCircuitBreakerTransition("claude", "claude-model", "open")
+	reg.ObserveCircuitBreakerTransition("claude", "claude-model", "half_open")
+
+	var buf strings.Builder
+	if err := reg.WriteExposition(&buf); err != nil {
+		t.Fatalf("WriteExposition: %v", err)
+	}
+	out := buf.String()
+
+	if !strings.Contains(out, `cliproxy_circuit_breaker_state{provider="claude",model="claude-model"} 2`) {
+		t.Fatalf("missing circuit breaker state gauge:\n%s", out)
+	}
+	if !strings.Contains(out, `cliproxy_circuit_breaker_transitions_total{provider="claude",model="claude-model",to_state="open"} 1`) {
+		t.Fatalf("missing open transition counter:\n%s", out)
+	}
+	if !strings.Contains(out, `cliproxy_circuit_breaker_transitions_total{provider="claude",model="claude-model",to_state="half_open"} 1`) {
+		t.Fatalf("missing half_open transition counter:\n%s", out)
+	}
+}
+
+func TestApiKeyLabel_StableAndShort(t *testing.T) {
+	a := apiKeyLabel(true, "same-key")
+	b := apiKeyLabel(true, "same-key")
+	if a != b {
+		t.Fatalf("hash not stable: %q vs %q", a, b)
+	}
+	if len(a) != 12 {
+		t.Fatalf("label length=%d", len(a))
+	}
+	if apiKeyLabel(true, "different-key") == a {
+		t.Fatal("distinct keys hashed to the same label")
+	}
+}
@@ -0,0 +1,163 @@
+package policy
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// BudgetTracker records USD spend per (api_key, day) and reports how much of
+// a configured daily budget remains. It is the per-key-budget counterpart to
+// DailyLimiter: a narrower, embeddable charge/remaining interface for
+// deployments that want APIKeyPolicy.DailyBudgetUSD enforcement without
+// standing up the full billing.Store (usage rows, price catalog, invoicing).
+// Deployments that already run billing.Store get the same enforcement from
+// APIKeyPolicyMiddleware's GetDailyCostMicroUSD check; BudgetTracker exists
+// for the simpler case where only the cap itself needs to be persisted.
+type BudgetTracker interface {
+	// Charge adds usd (which may be fractional cents) to apiKey's running
+	// total for dayKey and returns the new total spent.
+	Charge(ctx context.Context, apiKey, dayKey string, usd float64) (spent float64, err error)
+
+	// Remaining returns budgetUSD minus the amount already charged for
+	// (apiKey, dayKey). Never negative.
+	Remaining(ctx context.Context, apiKey, dayKey string, budgetUSD float64) (remaining float64, err error)
+
+	Close() error
+}
+
+var _ BudgetTracker = (*SQLiteBudgetTracker)(nil)
+
+// SQLiteBudgetTracker is a BudgetTracker backed by a SQLite file, mirroring
+// SQLiteDailyLimiter's schema/connection conventions so the two can share an
+// operational model (single-writer, WAL, busy-timeout).
+type SQLiteBudgetTracker struct {
+	db   *sql.DB
+	path string
+}
+
+// NewSQLiteBudgetTracker opens (creating if needed) the SQLite database at path.
+func NewSQLiteBudgetTracker(path string) (*SQLiteBudgetTracker, error) {
+	trimmed := strings.TrimSpace(path)
+	if trimmed == "" {
+		return nil, fmt.Errorf("sqlite budget tracker: path is required")
+	}
+	abs, err := filepath.Abs(trimmed)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite budget tracker: resolve path: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(abs), 0o700); err != nil {
+		return nil, fmt.Errorf("sqlite budget tracker: create directory: %w", err)
+	}
+
+	dsn := fmt.Sprintf("file:%s?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(ON)", abs)
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite budget tracker: open database: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("sqlite budget tracker: ping database: %w", err)
+	}
+
+	tracker := &SQLiteBudgetTracker{db: db, path: abs}
+	if err := tracker.ensureSchema(ctx); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return tracker, nil
+}
+
+func (t *SQLiteBudgetTracker) Close() error {
+	if t == nil || t.db == nil {
+		return nil
+	}
+	return t.db.Close()
+}
+
+func (t *SQLiteBudgetTracker) ensureSchema(ctx context.Context) error {
+	if t == nil || t.db == nil {
+		return fmt.Errorf("sqlite budget tracker: not initialized")
+	}
+	_, err := t.db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS api_key_daily_budget (
+			api_key TEXT NOT NULL,
+			day TEXT NOT NULL,
+			spent_usd REAL NOT NULL,
+			updated_at INTEGER NOT NULL,
+			PRIMARY KEY (api_key, day)
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("sqlite budget tracker: create table: %w", err)
+	}
+	return nil
+}
+
+// Charge adds usd to apiKey's running total for dayKey and returns the new total spent.
+func (t *SQLiteBudgetTracker) Charge(ctx context.Context, apiKey, dayKey string, usd float64) (float64, error) {
+	if t == nil || t.db == nil {
+		return 0, fmt.Errorf("sqlite budget tracker: not initialized")
+	}
+	apiKey = strings.TrimSpace(apiKey)
+	dayKey = strings.TrimSpace(dayKey)
+	if apiKey == "" || dayKey == "" {
+		return 0, fmt.Errorf("sqlite budget tracker: invalid inputs")
+	}
+	if usd < 0 {
+		usd = 0
+	}
+
+	const stmt = `
+		INSERT INTO api_key_daily_budget (api_key, day, spent_usd, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(api_key, day)
+		DO UPDATE SET spent_usd = spent_usd + excluded.spent_usd, updated_at = excluded.updated_at
+		RETURNING spent_usd
+	`
+	var spent float64
+	row := t.db.QueryRowContext(ctx, stmt, apiKey, dayKey, usd, time.Now().UTC().Unix())
+	if err := row.Scan(&spent); err != nil {
+		return 0, fmt.Errorf("sqlite budget tracker: charge failed: %w", err)
+	}
+	return spent, nil
+}
+
+// Remaining returns budgetUSD minus the amount already charged for (apiKey, dayKey).
+func (t *SQLiteBudgetTracker) Remaining(ctx context.Context, apiKey, dayKey string, budgetUSD float64) (float64, error) {
+	if t == nil || t.db == nil {
+		return 0, fmt.Errorf("sqlite budget tracker: not initialized")
+	}
+	apiKey = strings.TrimSpace(apiKey)
+	dayKey = strings.TrimSpace(dayKey)
+	if apiKey == "" || dayKey == "" {
+		return 0, fmt.Errorf("sqlite budget tracker: invalid inputs")
+	}
+
+	var spent float64
+	row := t.db.QueryRowContext(ctx, `SELECT spent_usd FROM api_key_daily_budget WHERE api_key = ? AND day = ?`, apiKey, dayKey)
+	switch err := row.Scan(&spent); err {
+	case nil, sql.ErrNoRows:
+		// sql.ErrNoRows leaves spent at its zero value, which is correct:
+		// nothing has been charged yet for this key/day.
+	default:
+		return 0, fmt.Errorf("sqlite budget tracker: remaining failed: %w", err)
+	}
+
+	remaining := budgetUSD - spent
+	if remaining < 0 {
+		remaining = 0
+	}
+	return remaining, nil
+}
@@ -0,0 +1,79 @@
+package policy
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSQLiteBudgetTracker_ChargePersistsAcrossReopen(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "budget.sqlite")
+
+	tracker, err := NewSQLiteBudgetTracker(dbPath)
+	if err != nil {
+		t.Fatalf("NewSQLiteBudgetTracker: %v", err)
+	}
+	defer tracker.Close()
+
+	dayKey := DayKeyChina(time.Date(2026, 2, 8, 0, 0, 0, 0, time.UTC))
+	ctx := context.Background()
+
+	if spent, err := tracker.Charge(ctx, "k1", dayKey, 1.5); err != nil || spent != 1.5 {
+		t.Fatalf("charge #1: spent=%v err=%v", spent, err)
+	}
+	if spent, err := tracker.Charge(ctx, "k1", dayKey, 2.0); err != nil || spent != 3.5 {
+		t.Fatalf("charge #2: spent=%v err=%v", spent, err)
+	}
+	if remaining, err := tracker.Remaining(ctx, "k1", dayKey, 5.0); err != nil || remaining != 1.5 {
+		t.Fatalf("remaining: remaining=%v err=%v", remaining, err)
+	}
+
+	// Reopen and ensure the running total is persisted.
+	if err := tracker.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	tracker, err = NewSQLiteBudgetTracker(dbPath)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer tracker.Close()
+
+	if remaining, err := tracker.Remaining(ctx, "k1", dayKey, 5.0); err != nil || remaining != 1.5 {
+		t.Fatalf("remaining after reopen: remaining=%v err=%v", remaining, err)
+	}
+}
+
+func TestSQLiteBudgetTracker_RemainingNeverNegative(t *testing.T) {
+	dir := t.TempDir()
+	tracker, err := NewSQLiteBudgetTracker(filepath.Join(dir, "budget.sqlite"))
+	if err != nil {
+		t.Fatalf("NewSQLiteBudgetTracker: %v", err)
+	}
+	defer tracker.Close()
+
+	ctx := context.Background()
+	dayKey := DayKeyChina(time.Now())
+
+	if _, err := tracker.Charge(ctx, "k1", dayKey, 10.0); err != nil {
+		t.Fatalf("Charge: %v", err)
+	}
+	if remaining, err := tracker.Remaining(ctx, "k1", dayKey, 5.0); err != nil || remaining != 0 {
+		t.Fatalf("remaining=%v err=%v, want 0 when spend exceeds budget", remaining, err)
+	}
+}
+
+func TestSQLiteBudgetTracker_RemainingForUnknownKeyIsFullBudget(t *testing.T) {
+	dir := t.TempDir()
+	tracker, err := NewSQLiteBudgetTracker(filepath.Join(dir, "budget.sqlite"))
+	if err != nil {
+		t.Fatalf("NewSQLiteBudgetTracker: %v", err)
+	}
+	defer tracker.Close()
+
+	remaining, err := tracker.Remaining(context.Background(), "never-charged", DayKeyChina(time.Now()), 7.5)
+	if err != nil || remaining != 7.5 {
+		t.Fatalf("remaining=%v err=%v, want full budget for an uncharged key", remaining, err)
+	}
+}
@@ -0,0 +1,52 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// DailyLimiter provides atomic per-day request counters keyed by
+// (api_key, model, day). SQLiteDailyLimiter, PostgresDailyLimiter, and
+// MemoryDailyLimiter all implement it so the backend can be selected via
+// config without changing call sites.
+type DailyLimiter interface {
+	// Consume increments the counter for (apiKey, model, dayKey) by 1 if doing
+	// so does not exceed limit. When the counter cannot be incremented due to
+	// the limit, allowed=false and count is the counter value that would have
+	// resulted from consuming (i.e. the limit itself).
+	Consume(ctx context.Context, apiKey, model, dayKey string, limit int) (count int, allowed bool, err error)
+	Close() error
+}
+
+var (
+	_ DailyLimiter = (*SQLiteDailyLimiter)(nil)
+	_ DailyLimiter = (*PostgresDailyLimiter)(nil)
+	_ DailyLimiter = (*MemoryDailyLimiter)(nil)
+)
+
+// DailyLimiterConfig selects and configures the daily-limiter backend.
+type DailyLimiterConfig struct {
+	// Driver is one of "sqlite" (default), "postgres", "cockroach", or "memory".
+	Driver string
+
+	// Path is the SQLite database file path. Required when Driver is "sqlite".
+	Path string
+
+	// DSN is the connection string for Driver "postgres"/"cockroach".
+	DSN string
+}
+
+// NewDailyLimiter builds the DailyLimiter backend selected by cfg.Driver.
+func NewDailyLimiter(cfg DailyLimiterConfig) (DailyLimiter, error) {
+	switch strings.ToLower(strings.TrimSpace(cfg.Driver)) {
+	case "", "sqlite":
+		return NewSQLiteDailyLimiter(cfg.Path)
+	case "postgres", "postgresql", "cockroach", "cockroachdb":
+		return NewPostgresDailyLimiter(cfg.DSN)
+	case "memory", "in-memory", "mem":
+		return NewMemoryDailyLimiter(), nil
+	default:
+		return nil, fmt.Errorf("daily limiter: unknown driver %q", cfg.Driver)
+	}
+}
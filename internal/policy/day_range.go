@@ -0,0 +1,30 @@
+package policy
+
+import "time"
+
+// WeekRangeChina returns the [from, to] YYYY-MM-DD day keys (inclusive) of
+// the Mon-Sun week containing now, in China Standard Time. It is used by
+// billing rollups that aggregate daily usage into weekly totals.
+func WeekRangeChina(now time.Time) (from, to string) {
+	if now.IsZero() {
+		now = time.Now()
+	}
+	local := now.In(chinaLocation)
+	// time.Weekday: Sunday=0 .. Saturday=6. Treat Monday as the first day.
+	offset := (int(local.Weekday()) + 6) % 7
+	monday := local.AddDate(0, 0, -offset)
+	sunday := monday.AddDate(0, 0, 6)
+	return monday.Format("2006-01-02"), sunday.Format("2006-01-02")
+}
+
+// MonthRangeChina returns the [from, to] YYYY-MM-DD day keys (inclusive) of
+// the calendar month containing now, in China Standard Time.
+func MonthRangeChina(now time.Time) (from, to string) {
+	if now.IsZero() {
+		now = time.Now()
+	}
+	local := now.In(chinaLocation)
+	first := time.Date(local.Year(), local.Month(), 1, 0, 0, 0, 0, chinaLocation)
+	last := first.AddDate(0, 1, -1)
+	return first.Format("2006-01-02"), last.Format("2006-01-02")
+}
@@ -0,0 +1,23 @@
+package policy
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWeekRangeChina(t *testing.T) {
+	// 2026-02-11 is a Wednesday in China Standard Time.
+	now := time.Date(2026, 2, 11, 3, 0, 0, 0, time.UTC)
+	from, to := WeekRangeChina(now)
+	if from != "2026-02-09" || to != "2026-02-15" {
+		t.Fatalf("from=%q to=%q", from, to)
+	}
+}
+
+func TestMonthRangeChina(t *testing.T) {
+	now := time.Date(2026, 2, 28, 20, 0, 0, 0, time.UTC) // 2026-03-01 04:00 CST
+	from, to := MonthRangeChina(now)
+	if from != "2026-03-01" || to != "2026-03-31" {
+		t.Fatalf("from=%q to=%q", from, to)
+	}
+}
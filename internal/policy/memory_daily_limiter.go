@@ -0,0 +1,48 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// MemoryDailyLimiter is a process-local DailyLimiter implementation backed
+// by a plain map. It is useful for tests and single-process deployments
+// that do not need counters to survive a restart.
+type MemoryDailyLimiter struct {
+	mu     sync.Mutex
+	counts map[string]int // key: apiKey + "\x00" + model + "\x00" + day
+}
+
+// NewMemoryDailyLimiter returns an empty MemoryDailyLimiter.
+func NewMemoryDailyLimiter() *MemoryDailyLimiter {
+	return &MemoryDailyLimiter{counts: make(map[string]int)}
+}
+
+func (l *MemoryDailyLimiter) Close() error { return nil }
+
+// Consume mirrors SQLiteDailyLimiter.Consume's semantics under a single mutex.
+func (l *MemoryDailyLimiter) Consume(ctx context.Context, apiKey, model, dayKey string, limit int) (count int, allowed bool, err error) {
+	apiKey = strings.TrimSpace(apiKey)
+	model = strings.ToLower(strings.TrimSpace(model))
+	dayKey = strings.TrimSpace(dayKey)
+	if apiKey == "" || model == "" || dayKey == "" {
+		return 0, false, fmt.Errorf("memory limiter: invalid inputs")
+	}
+	if limit <= 0 {
+		return 0, false, nil
+	}
+
+	key := apiKey + "\x00" + model + "\x00" + dayKey
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	current := l.counts[key]
+	if current >= limit {
+		return limit, false, nil
+	}
+	current++
+	l.counts[key] = current
+	return current, true, nil
+}
@@ -0,0 +1,23 @@
+package policy
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryDailyLimiter_Consume(t *testing.T) {
+	limiter := NewMemoryDailyLimiter()
+	ctx := context.Background()
+	dayKey := DayKeyChina(time.Date(2026, 2, 8, 0, 0, 0, 0, time.UTC))
+
+	if count, allowed, err := limiter.Consume(ctx, "k1", "claude-opus-4-6", dayKey, 2); err != nil || !allowed || count != 1 {
+		t.Fatalf("consume #1: count=%d allowed=%v err=%v", count, allowed, err)
+	}
+	if count, allowed, err := limiter.Consume(ctx, "k1", "claude-opus-4-6", dayKey, 2); err != nil || !allowed || count != 2 {
+		t.Fatalf("consume #2: count=%d allowed=%v err=%v", count, allowed, err)
+	}
+	if _, allowed, err := limiter.Consume(ctx, "k1", "claude-opus-4-6", dayKey, 2); err != nil || allowed {
+		t.Fatalf("consume #3: allowed=%v err=%v", allowed, err)
+	}
+}
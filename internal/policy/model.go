@@ -20,29 +20,13 @@ func NormaliseModelKey(model string) string {
 
 // DowngradeClaudeOpus46 rewrites claude-opus-4-6* to claude-opus-4-5-20251101* while preserving
 // any suffix segments (e.g., "-thinking") and thinking budget suffix "(...)".
+//
+// It is a thin wrapper around the "claude" provider's entry in
+// DefaultModelRewriteRegistry: new downgrades (Opus 4.7, Gemini 2.5 -> 2.0,
+// gpt-5.2 -> gpt-5.1, ...) should be added as ModelRewriteRules via
+// SetRules rather than as new hardcoded functions.
 func DowngradeClaudeOpus46(model string) (string, bool) {
-	trimmed := strings.TrimSpace(model)
-	if trimmed == "" {
-		return model, false
-	}
-	parsed := thinking.ParseSuffix(trimmed)
-	base := parsed.ModelName
-	baseLower := strings.ToLower(strings.TrimSpace(base))
-	if !strings.HasPrefix(baseLower, claudeOpus46Prefix) {
-		return model, false
-	}
-
-	// Preserve the remainder after the opus-4-6 prefix (e.g., "-thinking").
-	remainder := ""
-	if len(base) >= len(claudeOpus46Prefix) {
-		remainder = base[len(claudeOpus46Prefix):]
-	}
-
-	rewritten := claudeOpus45FallbackPrefix + remainder
-	if parsed.HasSuffix {
-		rewritten = rewritten + "(" + parsed.RawSuffix + ")"
-	}
-	return rewritten, true
+	return defaultModelRewriteRegistry.ApplyRewrite("claude", model, "")
 }
 
 // IsClaudeOpus46 returns true when the model name (after stripping "(...)") starts with claude-opus-4-6.
@@ -0,0 +1,106 @@
+package policy
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// PostgresDailyLimiter is a DailyLimiter backend for PostgreSQL and
+// CockroachDB, so daily request counters can be shared across multiple
+// proxy replicas behind a load balancer instead of living per-process.
+type PostgresDailyLimiter struct {
+	db *sql.DB
+}
+
+// NewPostgresDailyLimiter opens dsn and ensures the limiter schema exists.
+func NewPostgresDailyLimiter(dsn string) (*PostgresDailyLimiter, error) {
+	trimmed := strings.TrimSpace(dsn)
+	if trimmed == "" {
+		return nil, fmt.Errorf("postgres limiter: dsn is required")
+	}
+	db, err := sql.Open("pgx", trimmed)
+	if err != nil {
+		return nil, fmt.Errorf("postgres limiter: open database: %w", err)
+	}
+
+	ctx := context.Background()
+	if err := db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("postgres limiter: ping database: %w", err)
+	}
+
+	limiter := &PostgresDailyLimiter{db: db}
+	if err := limiter.ensureSchema(ctx); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return limiter, nil
+}
+
+func (l *PostgresDailyLimiter) Close() error {
+	if l == nil || l.db == nil {
+		return nil
+	}
+	return l.db.Close()
+}
+
+func (l *PostgresDailyLimiter) ensureSchema(ctx context.Context) error {
+	_, err := l.db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS api_model_daily_usage (
+			api_key TEXT NOT NULL,
+			model TEXT NOT NULL,
+			day TEXT NOT NULL,
+			count BIGINT NOT NULL,
+			updated_at BIGINT NOT NULL,
+			PRIMARY KEY (api_key, model, day)
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("postgres limiter: create table: %w", err)
+	}
+	return nil
+}
+
+// Consume mirrors SQLiteDailyLimiter.Consume's atomic conditional-increment
+// semantics using a single `INSERT ... ON CONFLICT DO UPDATE ... WHERE count
+// < $limit RETURNING count` statement.
+func (l *PostgresDailyLimiter) Consume(ctx context.Context, apiKey, model, dayKey string, limit int) (count int, allowed bool, err error) {
+	if l == nil || l.db == nil {
+		return 0, false, fmt.Errorf("postgres limiter: not initialized")
+	}
+	apiKey = strings.TrimSpace(apiKey)
+	model = strings.ToLower(strings.TrimSpace(model))
+	dayKey = strings.TrimSpace(dayKey)
+	if apiKey == "" || model == "" || dayKey == "" {
+		return 0, false, fmt.Errorf("postgres limiter: invalid inputs")
+	}
+	if limit <= 0 {
+		return 0, false, nil
+	}
+
+	nowUnix := time.Now().UTC().Unix()
+
+	const stmt = `
+		INSERT INTO api_model_daily_usage (api_key, model, day, count, updated_at)
+		VALUES ($1, $2, $3, 1, $4)
+		ON CONFLICT (api_key, model, day)
+		DO UPDATE SET count = api_model_daily_usage.count + 1, updated_at = excluded.updated_at
+		WHERE api_model_daily_usage.count < $5
+		RETURNING count
+	`
+
+	row := l.db.QueryRowContext(ctx, stmt, apiKey, model, dayKey, nowUnix, limit)
+	if err := row.Scan(&count); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return limit, false, nil
+		}
+		return 0, false, fmt.Errorf("postgres limiter: consume failed: %w", err)
+	}
+	return count, true, nil
+}
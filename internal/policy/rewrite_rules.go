@@ -0,0 +1,130 @@
+package policy
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+)
+
+// ModelRewriteRule describes one data-driven model rewrite: when a requested
+// model matches Match (case-insensitive, '*' wildcard via MatchWildcard), the
+// matched pattern's literal prefix is replaced with ReplacePrefix. This
+// generalises the formerly hardcoded claude-opus-4-6 -> claude-opus-4-5-20251101
+// swap so new downgrades (Opus 4.7, Gemini 2.5 -> 2.0, gpt-5.2 -> gpt-5.1, ...)
+// are config additions rather than new named functions.
+type ModelRewriteRule struct {
+	// Match is the source model pattern, e.g. "claude-opus-4-6*".
+	Match string
+	// ReplacePrefix replaces the literal (non-wildcard) prefix of Match.
+	ReplacePrefix string
+	// PreserveThinkingSuffix keeps the "(...)" thinking budget suffix across
+	// the rewrite, the same as DowngradeClaudeOpus46 always did. The rest of
+	// the model name after the matched prefix (e.g. "-thinking") is kept
+	// whether or not it is set.
+	PreserveThinkingSuffix bool
+	// AppliesWhen restricts the rule to a specific caller-supplied reason
+	// (e.g. "upstream_unavailable"). Empty means the rule always applies.
+	AppliesWhen string
+}
+
+// ModelRewriteRegistry holds ModelRewriteRules grouped by source provider and
+// applies them via ApplyRewrite. It is safe for concurrent use; SetRules is
+// intended to be called from a config-reload hook so operators can add rules
+// without a code change.
+type ModelRewriteRegistry struct {
+	mu    sync.RWMutex
+	rules map[string][]ModelRewriteRule
+}
+
+// NewModelRewriteRegistry returns a registry seeded with rules, keyed by
+// source provider (e.g. "claude", "gemini").
+func NewModelRewriteRegistry(rules map[string][]ModelRewriteRule) *ModelRewriteRegistry {
+	r := &ModelRewriteRegistry{}
+	r.SetRules(rules)
+	return r
+}
+
+// SetRules atomically replaces the registry's rule set.
+func (r *ModelRewriteRegistry) SetRules(rules map[string][]ModelRewriteRule) {
+	cloned := make(map[string][]ModelRewriteRule, len(rules))
+	for provider, providerRules := range rules {
+		key := strings.ToLower(strings.TrimSpace(provider))
+		if key == "" {
+			continue
+		}
+		cloned[key] = append([]ModelRewriteRule(nil), providerRules...)
+	}
+	r.mu.Lock()
+	r.rules = cloned
+	r.mu.Unlock()
+}
+
+// ApplyRewrite applies the first matching rule registered for provider to
+// model, preserving thinking-suffix handling the same way
+// DowngradeClaudeOpus46 did. reason is compared against each candidate
+// rule's AppliesWhen (a rule with an empty AppliesWhen always applies). It
+// returns (model, false) when no rule matches.
+func (r *ModelRewriteRegistry) ApplyRewrite(provider, model, reason string) (string, bool) {
+	if r == nil {
+		return model, false
+	}
+	trimmed := strings.TrimSpace(model)
+	if trimmed == "" {
+		return model, false
+	}
+
+	r.mu.RLock()
+	candidates := r.rules[strings.ToLower(strings.TrimSpace(provider))]
+	r.mu.RUnlock()
+	if len(candidates) == 0 {
+		return model, false
+	}
+
+	parsed := thinking.ParseSuffix(trimmed)
+	base := parsed.ModelName
+	baseKey := strings.ToLower(strings.TrimSpace(base))
+
+	for _, rule := range candidates {
+		if rule.AppliesWhen != "" && rule.AppliesWhen != reason {
+			continue
+		}
+		pattern := strings.ToLower(strings.TrimSpace(rule.Match))
+		if pattern == "" || !MatchWildcard(pattern, baseKey) {
+			continue
+		}
+
+		prefix := strings.TrimSuffix(pattern, "*")
+		remainder := ""
+		if len(base) >= len(prefix) {
+			remainder = base[len(prefix):]
+		}
+		rewritten := rule.ReplacePrefix + remainder
+		if rule.PreserveThinkingSuffix && parsed.HasSuffix {
+			rewritten += "(" + parsed.RawSuffix + ")"
+		}
+		return rewritten, true
+	}
+	return model, false
+}
+
+// defaultModelRewriteRegistry seeds the rewrite registry with the rule this
+// package used to hardcode in DowngradeClaudeOpus46, so it keeps working
+// unchanged when no operator-configured rules have been loaded yet.
+var defaultModelRewriteRegistry = NewModelRewriteRegistry(map[string][]ModelRewriteRule{
+	"claude": {
+		{
+			Match:                  claudeOpus46Prefix + "*",
+			ReplacePrefix:          claudeOpus45FallbackPrefix,
+			PreserveThinkingSuffix: true,
+		},
+	},
+})
+
+// DefaultModelRewriteRegistry returns the package-level registry backing
+// DowngradeClaudeOpus46. Config loading calls SetRules on it (directly, or
+// via a dedicated *ModelRewriteRegistry passed through the application) to
+// add or replace rules without a code change.
+func DefaultModelRewriteRegistry() *ModelRewriteRegistry {
+	return defaultModelRewriteRegistry
+}
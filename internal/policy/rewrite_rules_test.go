@@ -0,0 +1,77 @@
+package policy
+
+import "testing"
+
+func TestModelRewriteRegistry_ApplyRewrite(t *testing.T) {
+	reg := NewModelRewriteRegistry(map[string][]ModelRewriteRule{
+		"claude": {
+			{Match: "claude-opus-4-7*", ReplacePrefix: "claude-opus-4-6", PreserveThinkingSuffix: true},
+		},
+		"gemini": {
+			{Match: "gemini-2.5*", ReplacePrefix: "gemini-2.0"},
+		},
+	})
+
+	tests := []struct {
+		provider string
+		in       string
+		want     string
+		changed  bool
+	}{
+		{"claude", "claude-opus-4-7", "claude-opus-4-6", true},
+		{"claude", "claude-opus-4-7-thinking(8192)", "claude-opus-4-6-thinking(8192)", true},
+		{"claude", "claude-sonnet-4-5", "claude-sonnet-4-5", false},
+		{"gemini", "gemini-2.5-pro", "gemini-2.0-pro", true},
+		{"gemini", "gemini-2.5-pro-thinking", "gemini-2.0-pro-thinking", true},
+		{"codex", "claude-opus-4-7", "claude-opus-4-7", false},
+	}
+	for _, tt := range tests {
+		got, changed := reg.ApplyRewrite(tt.provider, tt.in, "")
+		if changed != tt.changed {
+			t.Fatalf("ApplyRewrite(%q,%q) changed=%v, want %v", tt.provider, tt.in, changed, tt.changed)
+		}
+		if got != tt.want {
+			t.Fatalf("ApplyRewrite(%q,%q)=%q, want %q", tt.provider, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestModelRewriteRegistry_AppliesWhen(t *testing.T) {
+	reg := NewModelRewriteRegistry(map[string][]ModelRewriteRule{
+		"claude": {
+			{Match: "claude-opus-4-6*", ReplacePrefix: "claude-opus-4-5-20251101", AppliesWhen: "upstream_unavailable"},
+		},
+	})
+
+	if _, changed := reg.ApplyRewrite("claude", "claude-opus-4-6", ""); changed {
+		t.Fatalf("expected no rewrite when reason does not match AppliesWhen")
+	}
+	if got, changed := reg.ApplyRewrite("claude", "claude-opus-4-6", "upstream_unavailable"); !changed || got != "claude-opus-4-5-20251101" {
+		t.Fatalf("ApplyRewrite with matching reason = %q, %v", got, changed)
+	}
+}
+
+func TestModelRewriteRegistry_SetRulesReplacesAtomically(t *testing.T) {
+	reg := NewModelRewriteRegistry(map[string][]ModelRewriteRule{
+		"claude": {{Match: "claude-opus-4-6*", ReplacePrefix: "claude-opus-4-5-20251101"}},
+	})
+	reg.SetRules(map[string][]ModelRewriteRule{
+		"claude": {{Match: "claude-opus-4-7*", ReplacePrefix: "claude-opus-4-6"}},
+	})
+
+	if _, changed := reg.ApplyRewrite("claude", "claude-opus-4-6", ""); changed {
+		t.Fatalf("expected old rule to be gone after SetRules")
+	}
+	if got, changed := reg.ApplyRewrite("claude", "claude-opus-4-7", ""); !changed || got != "claude-opus-4-6" {
+		t.Fatalf("ApplyRewrite after SetRules = %q, %v", got, changed)
+	}
+}
+
+// DowngradeClaudeOpus46 must keep behaving exactly as before now that it
+// delegates to DefaultModelRewriteRegistry.
+func TestDowngradeClaudeOpus46_StillWorksAfterRewriteRegistryMigration(t *testing.T) {
+	got, changed := DowngradeClaudeOpus46("claude-opus-4-6-thinking(high)")
+	if !changed || got != "claude-opus-4-5-20251101-thinking(high)" {
+		t.Fatalf("DowngradeClaudeOpus46 regressed: got %q changed=%v", got, changed)
+	}
+}
@@ -0,0 +1,236 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TokenBucketLimiter enforces smoothed short-window rate limits (requests
+// per minute and tokens per minute) on top of the coarser DailyLimiter. It
+// is consulted once per request for a request-count token, and again after
+// the response is known to debit the actual token usage.
+//
+// RateLimitBackend abstracts the bucket storage so an in-memory
+// implementation can be swapped for a shared one (e.g. Redis) without
+// changing call sites, mirroring how DailyLimiter abstracts its own
+// storage backend.
+type RateLimitBackend interface {
+	// Take attempts to withdraw cost units from the bucket identified by key,
+	// whose capacity is burst and which refills at ratePerSecond units/sec.
+	// It returns whether the withdrawal was allowed, the remaining capacity
+	// after the attempt, and how long the caller should wait before the next
+	// unit becomes available (0 when allowed or when the bucket is already
+	// full of headroom).
+	Take(ctx context.Context, key string, cost float64, ratePerSecond float64, burst float64) (allowed bool, remaining float64, retryAfter time.Duration, err error)
+
+	// Refund returns previously-withdrawn units to the bucket, used when an
+	// optimistic pre-request debit turns out to have overcharged (e.g. a
+	// token-count bucket debited with an estimate, refunded once actual
+	// usage is known). Callers only ever refund at most what they
+	// previously withdrew, so Refund does not need (and does not enforce)
+	// the bucket's burst cap.
+	Refund(ctx context.Context, key string, cost float64) error
+
+	Close() error
+}
+
+var _ RateLimitBackend = (*MemoryRateLimitBackend)(nil)
+
+// bucketShardCount controls how many independent mutexes MemoryRateLimitBackend
+// spreads its buckets across, so unrelated API keys don't contend on the same lock.
+const bucketShardCount = 32
+
+type tokenBucketState struct {
+	tokens     float64
+	lastRefill time.Time
+}
+
+type bucketShard struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucketState
+}
+
+// MemoryRateLimitBackend is a process-local RateLimitBackend implementation.
+// Buckets are sharded by key hash to reduce lock contention under
+// concurrent load from many distinct API keys.
+type MemoryRateLimitBackend struct {
+	shards [bucketShardCount]*bucketShard
+}
+
+// NewMemoryRateLimitBackend returns an empty MemoryRateLimitBackend.
+func NewMemoryRateLimitBackend() *MemoryRateLimitBackend {
+	b := &MemoryRateLimitBackend{}
+	for i := range b.shards {
+		b.shards[i] = &bucketShard{buckets: make(map[string]*tokenBucketState)}
+	}
+	return b
+}
+
+func (b *MemoryRateLimitBackend) Close() error { return nil }
+
+func (b *MemoryRateLimitBackend) shardFor(key string) *bucketShard {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return b.shards[h.Sum32()%bucketShardCount]
+}
+
+// Take refills the bucket for the elapsed time since its last access, then
+// withdraws cost units if the result would not go negative.
+func (b *MemoryRateLimitBackend) Take(ctx context.Context, key string, cost, ratePerSecond, burst float64) (allowed bool, remaining float64, retryAfter time.Duration, err error) {
+	if strings.TrimSpace(key) == "" {
+		return false, 0, 0, fmt.Errorf("rate limit backend: empty key")
+	}
+	if burst <= 0 {
+		return false, 0, 0, fmt.Errorf("rate limit backend: burst must be positive")
+	}
+
+	shard := b.shardFor(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	now := time.Now()
+	state, ok := shard.buckets[key]
+	if !ok {
+		state = &tokenBucketState{tokens: burst, lastRefill: now}
+		shard.buckets[key] = state
+	} else if elapsed := now.Sub(state.lastRefill); elapsed > 0 && ratePerSecond > 0 {
+		state.tokens = minFloat(burst, state.tokens+elapsed.Seconds()*ratePerSecond)
+		state.lastRefill = now
+	}
+
+	if state.tokens >= cost {
+		state.tokens -= cost
+		return true, state.tokens, 0, nil
+	}
+
+	var wait time.Duration
+	if ratePerSecond > 0 {
+		deficit := cost - state.tokens
+		wait = time.Duration(deficit / ratePerSecond * float64(time.Second))
+	}
+	return false, state.tokens, wait, nil
+}
+
+// Refund credits cost units back to the bucket.
+func (b *MemoryRateLimitBackend) Refund(ctx context.Context, key string, cost float64) error {
+	if strings.TrimSpace(key) == "" {
+		return fmt.Errorf("rate limit backend: empty key")
+	}
+	shard := b.shardFor(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	state, ok := shard.buckets[key]
+	if !ok {
+		shard.buckets[key] = &tokenBucketState{tokens: cost, lastRefill: time.Now()}
+		return nil
+	}
+	state.tokens += cost
+	return nil
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// DefaultTokenReservationEstimate is the token cost a TokenBucketLimiter
+// reserves against the tokens-per-minute bucket before the actual prompt
+// and completion size is known. UsagePersistPlugin settles the difference
+// via SettleTokens once real usage is recorded, so both call sites must
+// agree on this value rather than threading it through the usage record.
+const DefaultTokenReservationEstimate = 4096
+
+// RateLimitDecision reports the outcome of a TokenBucketLimiter check,
+// including the header values the caller should surface to the client.
+type RateLimitDecision struct {
+	Allowed    bool
+	Limit      float64
+	Remaining  float64
+	RetryAfter time.Duration
+}
+
+// TokenBucketLimiter enforces per-minute request and token budgets for an
+// API key, optionally scoped per model. It wraps a RateLimitBackend so the
+// bucket state can live in-process or in a shared store.
+type TokenBucketLimiter struct {
+	backend RateLimitBackend
+}
+
+// NewTokenBucketLimiter wraps backend. A nil backend causes every check to
+// be skipped (treated as allowed), mirroring how a nil DailyLimiter is
+// handled by the middleware.
+func NewTokenBucketLimiter(backend RateLimitBackend) *TokenBucketLimiter {
+	return &TokenBucketLimiter{backend: backend}
+}
+
+func (l *TokenBucketLimiter) Close() error {
+	if l == nil || l.backend == nil {
+		return nil
+	}
+	return l.backend.Close()
+}
+
+// AllowRequest withdraws one request token from the (apiKey, model) request
+// bucket, whose capacity is burst and which refills at rpm/60 per second.
+func (l *TokenBucketLimiter) AllowRequest(ctx context.Context, apiKey, model string, rpm int, burst int) (RateLimitDecision, error) {
+	if l == nil || l.backend == nil || rpm <= 0 {
+		return RateLimitDecision{Allowed: true}, nil
+	}
+	effectiveBurst := float64(burst)
+	if effectiveBurst <= 0 {
+		effectiveBurst = float64(rpm)
+	}
+	key := "req\x00" + strings.TrimSpace(apiKey) + "\x00" + strings.ToLower(strings.TrimSpace(model))
+	allowed, remaining, retryAfter, err := l.backend.Take(ctx, key, 1, float64(rpm)/60.0, effectiveBurst)
+	if err != nil {
+		return RateLimitDecision{}, err
+	}
+	return RateLimitDecision{Allowed: allowed, Limit: effectiveBurst, Remaining: remaining, RetryAfter: retryAfter}, nil
+}
+
+// ReserveTokens withdraws an estimated token cost from the (apiKey, model)
+// token bucket ahead of the request, so a burst of large requests cannot
+// all start before any of them are accounted for. Call SettleTokens once
+// the actual usage is known to true up the estimate.
+func (l *TokenBucketLimiter) ReserveTokens(ctx context.Context, apiKey, model string, tpm int, burst int, estimate int) (RateLimitDecision, error) {
+	if l == nil || l.backend == nil || tpm <= 0 {
+		return RateLimitDecision{Allowed: true}, nil
+	}
+	effectiveBurst := float64(burst)
+	if effectiveBurst <= 0 {
+		effectiveBurst = float64(tpm)
+	}
+	key := tokenBucketKey(apiKey, model)
+	allowed, remaining, retryAfter, err := l.backend.Take(ctx, key, float64(estimate), float64(tpm)/60.0, effectiveBurst)
+	if err != nil {
+		return RateLimitDecision{}, err
+	}
+	return RateLimitDecision{Allowed: allowed, Limit: effectiveBurst, Remaining: remaining, RetryAfter: retryAfter}, nil
+}
+
+// SettleTokens refunds the difference between a prior ReserveTokens
+// estimate and the actual token count, so the bucket reflects real usage
+// rather than the worst-case estimate. actual may be larger than estimate,
+// in which case the refund is simply zero (the overage is absorbed; it
+// will be caught by the next request's reservation).
+func (l *TokenBucketLimiter) SettleTokens(ctx context.Context, apiKey, model string, estimate, actual int) error {
+	if l == nil || l.backend == nil {
+		return nil
+	}
+	if actual >= estimate {
+		return nil
+	}
+	key := tokenBucketKey(apiKey, model)
+	return l.backend.Refund(ctx, key, float64(estimate-actual))
+}
+
+func tokenBucketKey(apiKey, model string) string {
+	return "tok\x00" + strings.TrimSpace(apiKey) + "\x00" + strings.ToLower(strings.TrimSpace(model))
+}
@@ -0,0 +1,116 @@
+package policy
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMemoryRateLimitBackend_AllowsUpToBurstThenRejects(t *testing.T) {
+	b := NewMemoryRateLimitBackend()
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		allowed, _, _, err := b.Take(ctx, "k", 1, 0, 3)
+		if err != nil {
+			t.Fatalf("Take: %v", err)
+		}
+		if !allowed {
+			t.Fatalf("request %d: expected allowed", i)
+		}
+	}
+
+	allowed, remaining, _, err := b.Take(ctx, "k", 1, 0, 3)
+	if err != nil {
+		t.Fatalf("Take: %v", err)
+	}
+	if allowed {
+		t.Fatal("expected burst to be exhausted")
+	}
+	if remaining != 0 {
+		t.Fatalf("remaining=%v", remaining)
+	}
+}
+
+func TestMemoryRateLimitBackend_RefundCreditsBackUpToWithdrawn(t *testing.T) {
+	b := NewMemoryRateLimitBackend()
+	ctx := context.Background()
+
+	allowed, remaining, _, err := b.Take(ctx, "k", 100, 0, 100)
+	if err != nil || !allowed {
+		t.Fatalf("Take: allowed=%v err=%v", allowed, err)
+	}
+	if remaining != 0 {
+		t.Fatalf("remaining=%v", remaining)
+	}
+
+	if err := b.Refund(ctx, "k", 40); err != nil {
+		t.Fatalf("Refund: %v", err)
+	}
+
+	allowed, _, _, err = b.Take(ctx, "k", 40, 0, 100)
+	if err != nil || !allowed {
+		t.Fatalf("expected refunded tokens to be spendable: allowed=%v err=%v", allowed, err)
+	}
+}
+
+func TestTokenBucketLimiter_AllowRequestDisabledWhenRPMZero(t *testing.T) {
+	l := NewTokenBucketLimiter(NewMemoryRateLimitBackend())
+	d, err := l.AllowRequest(context.Background(), "key", "model", 0, 0)
+	if err != nil {
+		t.Fatalf("AllowRequest: %v", err)
+	}
+	if !d.Allowed {
+		t.Fatal("expected requests to be allowed when rpm<=0")
+	}
+}
+
+func TestTokenBucketLimiter_AllowRequestEnforcesBurst(t *testing.T) {
+	l := NewTokenBucketLimiter(NewMemoryRateLimitBackend())
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		d, err := l.AllowRequest(ctx, "key", "model", 60, 2)
+		if err != nil || !d.Allowed {
+			t.Fatalf("request %d: allowed=%v err=%v", i, d.Allowed, err)
+		}
+	}
+
+	d, err := l.AllowRequest(ctx, "key", "model", 60, 2)
+	if err != nil {
+		t.Fatalf("AllowRequest: %v", err)
+	}
+	if d.Allowed {
+		t.Fatal("expected third request to exceed burst of 2")
+	}
+}
+
+func TestTokenBucketLimiter_SettleTokensRefundsUnusedEstimate(t *testing.T) {
+	l := NewTokenBucketLimiter(NewMemoryRateLimitBackend())
+	ctx := context.Background()
+
+	if _, err := l.ReserveTokens(ctx, "key", "model", 60, 1000, 1000); err != nil {
+		t.Fatalf("ReserveTokens: %v", err)
+	}
+	if err := l.SettleTokens(ctx, "key", "model", 1000, 100); err != nil {
+		t.Fatalf("SettleTokens: %v", err)
+	}
+
+	d, err := l.ReserveTokens(ctx, "key", "model", 60, 1000, 900)
+	if err != nil {
+		t.Fatalf("ReserveTokens: %v", err)
+	}
+	if !d.Allowed {
+		t.Fatal("expected refunded capacity from SettleTokens to make the next reservation succeed")
+	}
+}
+
+func TestTokenBucketLimiter_NilLimiterAllowsEverything(t *testing.T) {
+	var l *TokenBucketLimiter
+	d, err := l.AllowRequest(context.Background(), "key", "model", 10, 1)
+	if err != nil || !d.Allowed {
+		t.Fatalf("expected nil limiter to allow: allowed=%v err=%v", d.Allowed, err)
+	}
+	if err := l.SettleTokens(context.Background(), "key", "model", 100, 10); err != nil {
+		t.Fatalf("SettleTokens on nil limiter: %v", err)
+	}
+}
@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/metrics"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/interfaces"
+)
+
+// circuitState is one state in CircuitBreaker's standard three-state model.
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitHalfOpen
+	circuitOpen
+)
+
+// String renders s the way it is logged and exported as a metric label.
+func (s circuitState) String() string {
+	switch s {
+	case circuitOpen:
+		return "open"
+	case circuitHalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
+const (
+	// circuitBreakerFailureThreshold is how many classified failures within
+	// circuitBreakerWindow trip a closed breaker open.
+	circuitBreakerFailureThreshold = 5
+
+	// circuitBreakerWindow bounds how far back a closed breaker's sliding
+	// failure count looks; failures older than this are forgotten.
+	circuitBreakerWindow = 30 * time.Second
+
+	// circuitBreakerOpenDuration is how long an open breaker rejects calls
+	// before allowing a single half-open probe.
+	circuitBreakerOpenDuration = 30 * time.Second
+)
+
+// circuitBreakerEntry holds one (provider, model) pair's breaker state.
+type circuitBreakerEntry struct {
+	mu sync.Mutex
+
+	state        circuitState
+	failureTimes []time.Time
+	openedAt     time.Time
+	probing      bool
+}
+
+// CircuitBreaker short-circuits execOnce/execStream against a (provider,
+// model) pair that is failing repeatedly, so a broken upstream stops
+// consuming latency budget on every request instead of only after
+// ExecuteWithAuthManager/ExecuteStreamWithAuthManager's own fallback chain
+// gives up. It implements the standard three-state model: closed (counts
+// failures in a sliding window), open (rejects immediately with a synthetic
+// 503 that is itself failover-eligible, so the fallback chain still
+// triggers), half-open (lets exactly one probe through; a success closes
+// the breaker, a failure reopens it).
+type CircuitBreaker struct {
+	reg *metrics.Registry
+
+	mu      sync.Mutex
+	entries map[circuitBreakerKey]*circuitBreakerEntry
+}
+
+type circuitBreakerKey struct {
+	provider string
+	model    string
+}
+
+// NewCircuitBreaker returns a CircuitBreaker that reports state and
+// transitions to reg, which may be nil to disable metrics.
+func NewCircuitBreaker(reg *metrics.Registry) *CircuitBreaker {
+	return &CircuitBreaker{
+		reg:     reg,
+		entries: make(map[circuitBreakerKey]*circuitBreakerEntry),
+	}
+}
+
+func (b *CircuitBreaker) entry(provider, model string) *circuitBreakerEntry {
+	key := circuitBreakerKey{provider: provider, model: model}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	e, ok := b.entries[key]
+	if !ok {
+		e = &circuitBreakerEntry{}
+		b.entries[key] = e
+	}
+	return e
+}
+
+// Check consults provider/model's breaker ahead of dispatch. A non-nil
+// *interfaces.ErrorMessage means the breaker is open: the caller must not
+// call the upstream executor and should return this synthetic error
+// instead (StatusCode 503, classified as failover-eligible by
+// isFailoverEligible so a configured fallback chain still takes over). A
+// nil return means the call may proceed, either because the breaker is
+// closed or because this is the single probe a half-open breaker allows
+// through.
+func (b *CircuitBreaker) Check(provider, model string) *interfaces.ErrorMessage {
+	if b == nil {
+		return nil
+	}
+	e := b.entry(provider, model)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	switch e.state {
+	case circuitOpen:
+		if time.Since(e.openedAt) < circuitBreakerOpenDuration {
+			return &interfaces.ErrorMessage{
+				StatusCode: http.StatusServiceUnavailable,
+				Error:      fmt.Errorf("circuit breaker: %s/%s is open", provider, model),
+			}
+		}
+		e.state = circuitHalfOpen
+		e.probing = true
+		b.logTransition(provider, model, circuitOpen, circuitHalfOpen)
+		return nil
+	case circuitHalfOpen:
+		if e.probing {
+			return &interfaces.ErrorMessage{
+				StatusCode: http.StatusServiceUnavailable,
+				Error:      fmt.Errorf("circuit breaker: %s/%s probe already in flight", provider, model),
+			}
+		}
+		e.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// RecordResult reports the outcome of a call Check allowed through, so the
+// breaker can update its failure window and state. status/err are
+// classified with isFailoverEligible: eligible failures (auth errors,
+// 5xx/timeouts) count against the breaker, while a plain user 4xx (400,
+// 404, ...) does not.
+func (b *CircuitBreaker) RecordResult(provider, model string, status int, err error) {
+	if b == nil {
+		return
+	}
+	e := b.entry(provider, model)
+	failed := err != nil && isFailoverEligible(status, err)
+
+	e.mu.Lock()
+	from := e.state
+	switch e.state {
+	case circuitHalfOpen:
+		e.probing = false
+		if failed {
+			e.state = circuitOpen
+			e.openedAt = time.Now()
+			e.failureTimes = nil
+		} else {
+			e.state = circuitClosed
+			e.failureTimes = nil
+		}
+	default:
+		if failed {
+			now := time.Now()
+			e.failureTimes = append(pruneCircuitBreakerFailures(e.failureTimes, now), now)
+			if len(e.failureTimes) >= circuitBreakerFailureThreshold {
+				e.state = circuitOpen
+				e.openedAt = now
+				e.failureTimes = nil
+			}
+		} else {
+			e.failureTimes = nil
+		}
+	}
+	to := e.state
+	e.mu.Unlock()
+
+	if to != from {
+		b.logTransition(provider, model, from, to)
+	}
+	if b.reg != nil {
+		b.reg.SetCircuitBreakerState(provider, model, int64(to))
+	}
+}
+
+// pruneCircuitBreakerFailures drops failure timestamps older than
+// circuitBreakerWindow relative to now, so the sliding window forgets
+// failures that happened long enough ago not to indicate an ongoing
+// outage.
+func pruneCircuitBreakerFailures(times []time.Time, now time.Time) []time.Time {
+	cutoff := now.Add(-circuitBreakerWindow)
+	out := times[:0]
+	for _, t := range times {
+		if t.After(cutoff) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// logTransition logs one breaker state change and, if reg is configured,
+// counts it for cliproxy_circuit_breaker_transitions_total.
+func (b *CircuitBreaker) logTransition(provider, model string, from, to circuitState) {
+	log.WithFields(log.Fields{
+		"component":  "circuit_breaker",
+		"provider":   provider,
+		"model":      model,
+		"from_state": from.String(),
+		"to_state":   to.String(),
+	}).Warn("circuit breaker state transition")
+	if b.reg != nil {
+		b.reg.ObserveCircuitBreakerTransition(provider, model, to.String())
+	}
+}
+
+// Snapshot returns the current state of every (provider, model) pair the
+// breaker has seen a call for, for the management API's circuit-breaker
+// status endpoint.
+func (b *CircuitBreaker) Snapshot() []CircuitBreakerStatus {
+	b.mu.Lock()
+	keys := make([]circuitBreakerKey, 0, len(b.entries))
+	entries := make([]*circuitBreakerEntry, 0, len(b.entries))
+	for k, e := range b.entries {
+		keys = append(keys, k)
+		entries = append(entries, e)
+	}
+	b.mu.Unlock()
+
+	out := make([]CircuitBreakerStatus, 0, len(keys))
+	for i, k := range keys {
+		e := entries[i]
+		e.mu.Lock()
+		status := CircuitBreakerStatus{
+			Provider: k.provider,
+			Model:    k.model,
+			State:    e.state.String(),
+			OpenedAt: e.openedAt,
+		}
+		e.mu.Unlock()
+		out = append(out, status)
+	}
+	return out
+}
+
+// CircuitBreakerStatus is one (provider, model) pair's breaker state, as
+// reported by CircuitBreaker.Snapshot for the management API.
+type CircuitBreakerStatus struct {
+	Provider string    `json:"provider"`
+	Model    string    `json:"model"`
+	State    string    `json:"state"`
+	OpenedAt time.Time `json:"opened_at,omitempty"`
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestCircuitBreaker_OpensAfterThresholdThenHalfOpenProbe(t *testing.T) {
+	b := NewCircuitBreaker(nil)
+	failErr := errors.New("upstream unavailable")
+
+	for i := 0; i < circuitBreakerFailureThreshold; i++ {
+		if errMsg := b.Check("gemini", "gemini-model"); errMsg != nil {
+			t.Fatalf("unexpected open breaker before threshold, attempt %d: %+v", i, errMsg)
+		}
+		b.RecordResult("gemini", "gemini-model", http.StatusServiceUnavailable, failErr)
+	}
+
+	errMsg := b.Check("gemini", "gemini-model")
+	if errMsg == nil || errMsg.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected breaker open with 503, got: %+v", errMsg)
+	}
+
+	e := b.entry("gemini", "gemini-model")
+	e.mu.Lock()
+	e.openedAt = e.openedAt.Add(-circuitBreakerOpenDuration)
+	e.mu.Unlock()
+
+	if errMsg := b.Check("gemini", "gemini-model"); errMsg != nil {
+		t.Fatalf("expected half-open probe to be allowed, got: %+v", errMsg)
+	}
+	if errMsg := b.Check("gemini", "gemini-model"); errMsg == nil {
+		t.Fatal("expected a second concurrent half-open call to be rejected")
+	}
+
+	b.RecordResult("gemini", "gemini-model", http.StatusOK, nil)
+	if errMsg := b.Check("gemini", "gemini-model"); errMsg != nil {
+		t.Fatalf("expected breaker closed after successful probe, got: %+v", errMsg)
+	}
+}
+
+func TestCircuitBreaker_PlainUserErrorDoesNotTripBreaker(t *testing.T) {
+	b := NewCircuitBreaker(nil)
+	badRequest := errors.New("invalid request")
+
+	for i := 0; i < circuitBreakerFailureThreshold*2; i++ {
+		b.RecordResult("claude", "claude-model", http.StatusBadRequest, badRequest)
+	}
+
+	if errMsg := b.Check("claude", "claude-model"); errMsg != nil {
+		t.Fatalf("plain 400s should never trip the breaker, got: %+v", errMsg)
+	}
+}
+
+func TestCircuitBreaker_HalfOpenFailureReopens(t *testing.T) {
+	b := NewCircuitBreaker(nil)
+	failErr := errors.New("still down")
+
+	for i := 0; i < circuitBreakerFailureThreshold; i++ {
+		b.RecordResult("codex", "codex-model", http.StatusServiceUnavailable, failErr)
+	}
+	e := b.entry("codex", "codex-model")
+	e.mu.Lock()
+	e.openedAt = e.openedAt.Add(-circuitBreakerOpenDuration)
+	e.mu.Unlock()
+
+	if errMsg := b.Check("codex", "codex-model"); errMsg != nil {
+		t.Fatalf("expected half-open probe to be allowed, got: %+v", errMsg)
+	}
+	b.RecordResult("codex", "codex-model", http.StatusServiceUnavailable, failErr)
+
+	if errMsg := b.Check("codex", "codex-model"); errMsg == nil {
+		t.Fatal("expected breaker to reopen after a failed probe")
+	}
+}
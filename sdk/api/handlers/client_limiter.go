@@ -0,0 +1,343 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/metrics"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/policy"
+)
+
+// ErrClientQueueTimeout is returned by ClientLimiter.Acquire when a request
+// is still waiting for a concurrency slot or rate-limit token once its
+// QueueTimeout elapses.
+var ErrClientQueueTimeout = errors.New("client limiter: queue timeout waiting for a slot")
+
+// clientLimiterIdleAfter is how long a client API key's entry may sit with
+// no in-flight requests before ClientLimiter.GC reclaims it.
+const clientLimiterIdleAfter = 10 * time.Minute
+
+// ClientLimiterConfig configures the concurrency cap and token-bucket rate
+// limit ClientLimiter enforces for one client API key. It is resolved fresh
+// from the key's APIKeyPolicy on every request via
+// clientLimiterConfigFromPolicy, so a config reload takes effect on the
+// next request without needing to recreate the limiter's entries.
+type ClientLimiterConfig struct {
+	// MaxConcurrent caps how many requests this key may have in flight at
+	// once. Values <= 0 disable the concurrency cap.
+	MaxConcurrent int
+
+	// RPS is the token-bucket refill rate for this key's request rate,
+	// independent of MaxConcurrent. Values <= 0 disable the rate limit.
+	RPS float64
+
+	// Burst is the token-bucket capacity. Values <= 0 default to RPS.
+	Burst float64
+
+	// QueueTimeout bounds how long Acquire will wait for a concurrency slot
+	// or rate-limit token. Values <= 0 mean wait indefinitely, bounded only
+	// by ctx.
+	QueueTimeout time.Duration
+}
+
+// clientLimiterConfigFromPolicy resolves pol's concurrency/rate-limit knobs
+// into a ClientLimiterConfig. A nil pol (no policy configured for this
+// client API key) disables both limits.
+func clientLimiterConfigFromPolicy(pol *internalconfig.APIKeyPolicy) ClientLimiterConfig {
+	if pol == nil {
+		return ClientLimiterConfig{}
+	}
+	burst := pol.ConcurrencyBurst
+	if burst <= 0 {
+		burst = pol.RequestsPerSecond
+	}
+	return ClientLimiterConfig{
+		MaxConcurrent: pol.MaxConcurrent,
+		RPS:           pol.RequestsPerSecond,
+		Burst:         burst,
+		QueueTimeout:  pol.QueueTimeout,
+	}
+}
+
+// clientLimiterWaiter is one acquireConcurrency call's place in line. ch is
+// buffered size 1 so releaseConcurrency's handoff send never blocks, even if
+// the waiter has already given up by the time it arrives.
+type clientLimiterWaiter struct {
+	ch chan struct{}
+}
+
+// clientLimiterEntry holds one client API key's concurrency waiters and
+// rate-limit bucket state. Entries are created lazily by ClientLimiter and
+// reclaimed by GC once idle.
+type clientLimiterEntry struct {
+	mu       sync.Mutex
+	inFlight int
+	waiters  []*clientLimiterWaiter
+
+	lastUsed time.Time
+}
+
+// acquireConcurrency blocks until e has room for one more in-flight request
+// under maxConcurrent, ctx is done, or deadline elapses, whichever comes
+// first. maxConcurrent <= 0 disables the cap (always succeeds immediately).
+func (e *clientLimiterEntry) acquireConcurrency(ctx context.Context, maxConcurrent int, deadline time.Time) error {
+	if maxConcurrent <= 0 {
+		return nil
+	}
+	for {
+		e.mu.Lock()
+		if e.inFlight < maxConcurrent {
+			e.inFlight++
+			e.lastUsed = time.Now()
+			e.mu.Unlock()
+			return nil
+		}
+		w := &clientLimiterWaiter{ch: make(chan struct{}, 1)}
+		e.waiters = append(e.waiters, w)
+		e.mu.Unlock()
+
+		var timeout <-chan time.Time
+		if !deadline.IsZero() {
+			if d := time.Until(deadline); d > 0 {
+				timer := time.NewTimer(d)
+				defer timer.Stop()
+				timeout = timer.C
+			} else {
+				if !e.abandonWaiter(w) {
+					e.releaseConcurrency()
+				}
+				return ErrClientQueueTimeout
+			}
+		}
+		var giveUpErr error
+		select {
+		case <-w.ch:
+			return nil
+		case <-ctx.Done():
+			giveUpErr = ctx.Err()
+		case <-timeout:
+			giveUpErr = ErrClientQueueTimeout
+		}
+		if e.abandonWaiter(w) {
+			// w was still queued: releaseConcurrency never handed it the
+			// slot, so none is held and there's nothing to give back.
+			return giveUpErr
+		}
+		// releaseConcurrency already popped w and transferred the slot (and
+		// its inFlight count) to it before this goroutine gave up, racing
+		// the handoff send against ctx.Done/timeout. The slot is ours, but
+		// we don't want it any more - release it back so it reaches the
+		// next waiter instead of leaking.
+		e.releaseConcurrency()
+		return giveUpErr
+	}
+}
+
+// abandonWaiter removes w from e.waiters if it is still queued there, and
+// reports whether it found it. false means releaseConcurrency already
+// popped w and transferred ownership of a slot to it - concurrently with the
+// caller giving up - so the caller now holds a slot it must release itself.
+func (e *clientLimiterEntry) abandonWaiter(w *clientLimiterWaiter) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for i, ww := range e.waiters {
+		if ww == w {
+			e.waiters = append(e.waiters[:i], e.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// releaseConcurrency returns one in-flight slot and wakes the oldest waiter,
+// if any, by transferring the freed slot's inFlight count directly to it
+// while still holding e.mu - so the handoff is atomic with respect to
+// abandonWaiter, and can never land on a waiter that has already walked away
+// with nobody to receive it.
+func (e *clientLimiterEntry) releaseConcurrency() {
+	e.mu.Lock()
+	if e.inFlight > 0 {
+		e.inFlight--
+	}
+	var next *clientLimiterWaiter
+	if len(e.waiters) > 0 {
+		next = e.waiters[0]
+		e.waiters = e.waiters[1:]
+		e.inFlight++
+	}
+	e.lastUsed = time.Now()
+	e.mu.Unlock()
+	if next != nil {
+		next.ch <- struct{}{}
+	}
+}
+
+// inFlightCount reports e's current in-flight count, for metrics.
+func (e *clientLimiterEntry) inFlightCount() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.inFlight
+}
+
+// idleSince reports whether e has had no activity since cutoff, i.e. it is
+// safe for ClientLimiter.GC to drop.
+func (e *clientLimiterEntry) idleSince(cutoff time.Time) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.inFlight == 0 && len(e.waiters) == 0 && e.lastUsed.Before(cutoff)
+}
+
+// ClientLimiter bounds how many requests a single client API key may have
+// in flight at once and, optionally, how fast it may issue them, so that
+// one misbehaving client can't alone saturate the shared auth pool and
+// trigger "no auth available" errors for every other client. It is
+// consulted by BaseAPIHandler.ExecuteWithAuthManager and
+// ExecuteCountWithAuthManager ahead of dispatch.
+type ClientLimiter struct {
+	reg     *metrics.Registry
+	backend policy.RateLimitBackend
+
+	mu      sync.Mutex
+	entries map[string]*clientLimiterEntry
+}
+
+// NewClientLimiter returns a ClientLimiter that reports to reg, which may
+// be nil to disable metrics.
+func NewClientLimiter(reg *metrics.Registry) *ClientLimiter {
+	return &ClientLimiter{
+		reg:     reg,
+		backend: policy.NewMemoryRateLimitBackend(),
+		entries: make(map[string]*clientLimiterEntry),
+	}
+}
+
+func (l *ClientLimiter) entry(apiKey string) *clientLimiterEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e, ok := l.entries[apiKey]
+	if !ok {
+		e = &clientLimiterEntry{lastUsed: time.Now()}
+		l.entries[apiKey] = e
+	}
+	return e
+}
+
+// Acquire waits for a concurrency slot and a rate-limit token for apiKey
+// under cfg, in that order, and returns how long the caller waited in
+// total. On success the caller must call Release(apiKey, cfg) exactly once
+// when the request completes. On failure (ctx done or QueueTimeout
+// elapsed), no slot is held and Release must not be called.
+func (l *ClientLimiter) Acquire(ctx context.Context, apiKey string, cfg ClientLimiterConfig) (time.Duration, error) {
+	apiKey = strings.TrimSpace(apiKey)
+	if apiKey == "" || (cfg.MaxConcurrent <= 0 && cfg.RPS <= 0) {
+		return 0, nil
+	}
+	start := time.Now()
+	var deadline time.Time
+	if cfg.QueueTimeout > 0 {
+		deadline = start.Add(cfg.QueueTimeout)
+	}
+
+	e := l.entry(apiKey)
+	if err := e.acquireConcurrency(ctx, cfg.MaxConcurrent, deadline); err != nil {
+		l.observeWait(apiKey, start, err)
+		return time.Since(start), err
+	}
+	if l.reg != nil {
+		l.reg.SetClientInFlight(apiKey, int64(e.inFlightCount()))
+	}
+
+	if cfg.RPS > 0 {
+		burst := cfg.Burst
+		if burst <= 0 {
+			burst = cfg.RPS
+		}
+		for {
+			allowed, _, retryAfter, err := l.backend.Take(ctx, rateLimitKey(apiKey), 1, cfg.RPS, burst)
+			if err != nil {
+				e.releaseConcurrency()
+				l.observeWait(apiKey, start, err)
+				return time.Since(start), err
+			}
+			if allowed {
+				break
+			}
+			wait := retryAfter
+			if !deadline.IsZero() {
+				if remaining := time.Until(deadline); remaining <= 0 {
+					e.releaseConcurrency()
+					l.observeWait(apiKey, start, ErrClientQueueTimeout)
+					return time.Since(start), ErrClientQueueTimeout
+				} else if remaining < wait {
+					wait = remaining
+				}
+			}
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				e.releaseConcurrency()
+				l.observeWait(apiKey, start, ctx.Err())
+				return time.Since(start), ctx.Err()
+			}
+		}
+	}
+
+	l.observeWait(apiKey, start, nil)
+	return time.Since(start), nil
+}
+
+// Release returns apiKey's concurrency slot acquired by a successful
+// Acquire call. Safe to defer once Acquire has succeeded; a no-op when cfg
+// never held a slot (MaxConcurrent <= 0).
+func (l *ClientLimiter) Release(apiKey string, cfg ClientLimiterConfig) {
+	apiKey = strings.TrimSpace(apiKey)
+	if apiKey == "" || cfg.MaxConcurrent <= 0 {
+		return
+	}
+	e := l.entry(apiKey)
+	e.releaseConcurrency()
+	if l.reg != nil {
+		l.reg.SetClientInFlight(apiKey, int64(e.inFlightCount()))
+	}
+}
+
+// GC drops any entry that has been idle (no in-flight requests or waiters)
+// since idleAfter ago, bounding ClientLimiter's memory to recently-active
+// client API keys.
+func (l *ClientLimiter) GC(idleAfter time.Duration) {
+	if idleAfter <= 0 {
+		idleAfter = clientLimiterIdleAfter
+	}
+	cutoff := time.Now().Add(-idleAfter)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for apiKey, e := range l.entries {
+		if e.idleSince(cutoff) {
+			delete(l.entries, apiKey)
+		}
+	}
+}
+
+// observeWait reports how long apiKey waited on l, and - when err is
+// ErrClientQueueTimeout - counts the rejection, for cliproxy_client_queue_wait_ms
+// and cliproxy_client_limiter_rejections_total.
+func (l *ClientLimiter) observeWait(apiKey string, start time.Time, err error) {
+	if l.reg == nil {
+		return
+	}
+	waitMs := float64(time.Since(start).Microseconds()) / 1000
+	l.reg.ObserveClientQueueWait(apiKey, waitMs)
+	if errors.Is(err, ErrClientQueueTimeout) {
+		l.reg.ObserveClientLimiterRejection(apiKey)
+	}
+}
+
+func rateLimitKey(apiKey string) string {
+	return "client-limiter\x00" + apiKey
+}
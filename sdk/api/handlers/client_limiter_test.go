@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func TestClientLimiter_EnforcesMaxConcurrent(t *testing.T) {
+	l := NewClientLimiter(nil)
+	cfg := ClientLimiterConfig{MaxConcurrent: 1}
+
+	if _, err := l.Acquire(context.Background(), "key", cfg); err != nil {
+		t.Fatalf("first Acquire: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := l.Acquire(ctx, "key", cfg); err == nil {
+		t.Fatal("expected second Acquire to block until context deadline, got nil error")
+	}
+
+	l.Release("key", cfg)
+	if _, err := l.Acquire(context.Background(), "key", cfg); err != nil {
+		t.Fatalf("Acquire after Release: %v", err)
+	}
+}
+
+func TestClientLimiter_QueueTimeoutReturnsErrClientQueueTimeout(t *testing.T) {
+	l := NewClientLimiter(nil)
+	cfg := ClientLimiterConfig{MaxConcurrent: 1, QueueTimeout: 20 * time.Millisecond}
+
+	if _, err := l.Acquire(context.Background(), "key", cfg); err != nil {
+		t.Fatalf("first Acquire: %v", err)
+	}
+
+	if _, err := l.Acquire(context.Background(), "key", cfg); err != ErrClientQueueTimeout {
+		t.Fatalf("second Acquire error = %v, want ErrClientQueueTimeout", err)
+	}
+}
+
+func TestClientLimiter_ReleasesWakeQueuedWaiterInOrder(t *testing.T) {
+	l := NewClientLimiter(nil)
+	cfg := ClientLimiterConfig{MaxConcurrent: 1}
+
+	if _, err := l.Acquire(context.Background(), "key", cfg); err != nil {
+		t.Fatalf("first Acquire: %v", err)
+	}
+
+	var acquired int32
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := l.Acquire(context.Background(), "key", cfg); err != nil {
+				t.Errorf("queued Acquire: %v", err)
+				return
+			}
+			atomic.AddInt32(&acquired, 1)
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	if got := atomic.LoadInt32(&acquired); got != 0 {
+		t.Fatalf("queued waiters acquired early: %d", got)
+	}
+
+	l.Release("key", cfg)
+	l.Release("key", cfg)
+	l.Release("key", cfg)
+	wg.Wait()
+	if got := atomic.LoadInt32(&acquired); got != 3 {
+		t.Fatalf("acquired = %d, want 3", got)
+	}
+}
+
+func TestClientLimiter_AbandonedWaiterDoesNotBlockLaterWaiter(t *testing.T) {
+	l := NewClientLimiter(nil)
+	cfg := ClientLimiterConfig{MaxConcurrent: 1}
+
+	if _, err := l.Acquire(context.Background(), "key", cfg); err != nil {
+		t.Fatalf("first Acquire: %v", err)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := l.Acquire(timeoutCtx, "key", cfg); err == nil {
+		t.Fatal("expected timed-out Acquire to return an error")
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := l.Acquire(context.Background(), "key", cfg)
+		done <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	l.Release("key", cfg)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("later Acquire: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("later Acquire was never woken after the abandoned waiter's release")
+	}
+}
+
+// TestClientLimiter_AbandonSlotHandoffStillWakesNextWaiter covers the
+// narrower race a check-and-requeue design has to get right: a waiter that
+// gives up on ctx/QueueTimeout strictly after releaseConcurrency has already
+// transferred it the freed slot. abandonWaiter must see it's no longer
+// queued and hand that slot on again, rather than the waiter silently
+// walking away with it and starving whoever is still behind it in line.
+func TestClientLimiter_AbandonSlotHandoffStillWakesNextWaiter(t *testing.T) {
+	l := NewClientLimiter(nil)
+	cfg := ClientLimiterConfig{MaxConcurrent: 1}
+	entry := l.entry("key")
+
+	if _, err := l.Acquire(context.Background(), "key", cfg); err != nil {
+		t.Fatalf("first Acquire: %v", err)
+	}
+
+	w := &clientLimiterWaiter{ch: make(chan struct{}, 1)}
+	entry.mu.Lock()
+	entry.waiters = append(entry.waiters, w)
+	entry.mu.Unlock()
+
+	// Simulate releaseConcurrency transferring the slot to w before w gives
+	// up: pop it from the queue and count it in inFlight, exactly as
+	// releaseConcurrency does under its lock, without sending on w.ch yet.
+	entry.mu.Lock()
+	entry.inFlight--
+	entry.waiters = entry.waiters[1:]
+	entry.inFlight++
+	entry.mu.Unlock()
+
+	if found := entry.abandonWaiter(w); found {
+		t.Fatal("abandonWaiter found w still queued, want it already claimed by the simulated handoff")
+	}
+	entry.releaseConcurrency()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := l.Acquire(context.Background(), "key", cfg)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("waiter behind the abandoned handoff: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("handed-off slot was lost instead of being passed to the next waiter")
+	}
+}
+
+func TestClientLimiter_NoLimitsConfiguredIsNoOp(t *testing.T) {
+	l := NewClientLimiter(nil)
+	wait, err := l.Acquire(context.Background(), "key", ClientLimiterConfig{})
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	if wait != 0 {
+		t.Fatalf("wait = %v, want 0", wait)
+	}
+	l.Release("key", ClientLimiterConfig{})
+}
+
+func TestClientLimiter_RPSRejectsBurstBeyondCapacity(t *testing.T) {
+	l := NewClientLimiter(nil)
+	cfg := ClientLimiterConfig{RPS: 1, Burst: 1, QueueTimeout: 10 * time.Millisecond}
+
+	if _, err := l.Acquire(context.Background(), "key", cfg); err != nil {
+		t.Fatalf("first Acquire: %v", err)
+	}
+	if _, err := l.Acquire(context.Background(), "key", cfg); err != ErrClientQueueTimeout {
+		t.Fatalf("second Acquire error = %v, want ErrClientQueueTimeout", err)
+	}
+}
+
+func TestClientLimiter_GCDropsOnlyIdleEntries(t *testing.T) {
+	l := NewClientLimiter(nil)
+	cfg := ClientLimiterConfig{MaxConcurrent: 1}
+
+	if _, err := l.Acquire(context.Background(), "busy", cfg); err != nil {
+		t.Fatalf("Acquire busy: %v", err)
+	}
+	if _, err := l.Acquire(context.Background(), "idle", cfg); err != nil {
+		t.Fatalf("Acquire idle: %v", err)
+	}
+	l.Release("idle", cfg)
+
+	l.GC(-time.Nanosecond)
+
+	l.mu.Lock()
+	_, busyStillTracked := l.entries["busy"]
+	_, idleStillTracked := l.entries["idle"]
+	l.mu.Unlock()
+
+	if !busyStillTracked {
+		t.Fatal("expected in-flight entry to survive GC")
+	}
+	if idleStillTracked {
+		t.Fatal("expected idle entry to be reclaimed by GC")
+	}
+}
+
+func TestClientLimiterConfigFromPolicy(t *testing.T) {
+	if got := clientLimiterConfigFromPolicy(nil); got != (ClientLimiterConfig{}) {
+		t.Fatalf("nil policy should disable all limits, got %+v", got)
+	}
+
+	pol := &internalconfig.APIKeyPolicy{MaxConcurrent: 4, RequestsPerSecond: 2.5, QueueTimeout: time.Second}
+	got := clientLimiterConfigFromPolicy(pol)
+	want := ClientLimiterConfig{MaxConcurrent: 4, RPS: 2.5, Burst: 2.5, QueueTimeout: time.Second}
+	if got != want {
+		t.Fatalf("clientLimiterConfigFromPolicy() = %+v, want %+v", got, want)
+	}
+
+	pol.ConcurrencyBurst = 10
+	got = clientLimiterConfigFromPolicy(pol)
+	if got.Burst != 10 {
+		t.Fatalf("Burst = %v, want explicit ConcurrencyBurst 10", got.Burst)
+	}
+}
@@ -7,20 +7,29 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"math"
+	"math/rand"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/billing"
 	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/idempotency"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/metrics"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/policy"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	execmw "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor/middleware"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/failover"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
 	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
 	log "github.com/sirupsen/logrus"
@@ -51,6 +60,35 @@ type ErrorDetail struct {
 
 const idempotencyKeyMetadataKey = "idempotency_key"
 
+// failoverHopsMetadataKey counts how many Engine-driven failover hops a
+// request has already taken, so a misconfigured cycle of rules (A -> B -> A)
+// can't retry forever. It lives in reqMeta rather than a local variable
+// because ExecuteWithAuthManager's retry calls execOnce recursively via a
+// fresh Options each hop.
+const failoverHopsMetadataKey = "failover_hops"
+
+func failoverHopsFromMetadata(meta map[string]any) int {
+	hops, _ := meta[failoverHopsMetadataKey].(int)
+	return hops
+}
+
+// apiKeyMetadataKey carries the client's API key into execution metadata for
+// streaming requests with a mid-stream budget guard enabled (see
+// ExecuteStreamWithAuthManager), so downstream executor/auth-selector code
+// that only sees coreexecutor.Options can attribute cost to the right key
+// without a new Options field.
+const apiKeyMetadataKey = "api_key"
+
+// executorUnaryChain wraps every non-streaming executor call made below with
+// panic recovery, so a panic inside an Execute/ExecuteCount implementation
+// becomes a coreauth.Error{Code: "internal_panic", HTTPStatus: 500} that the
+// Claude-failover branches below can react to, instead of crashing the
+// server or leaking a raw stacktrace to the client.
+var executorUnaryChain = execmw.Chain(execmw.Recovery())
+
+// executorStreamChain is executorUnaryChain's streaming counterpart.
+var executorStreamChain = execmw.ChainStream(execmw.RecoveryStream())
+
 const (
 	defaultStreamingKeepAliveSeconds = 0
 	defaultStreamingBootstrapRetries = 0
@@ -106,6 +144,194 @@ func BuildErrorResponseBody(status int, errText string) []byte {
 	return payload
 }
 
+// BuildStreamingErrorEvent formats status/respErr as SSE bytes for a stream
+// that has already sent its headers, so a mid-stream failure can no longer
+// be reported as a plain HTTP error response the way BuildErrorResponseBody
+// is. It reuses BuildErrorResponseBody for the JSON payload, adding
+// error.retry_after_ms when addon carries a Retry-After header, then wraps
+// that JSON in whichever SSE framing handlerType's client expects:
+//   - "claude": Anthropic Messages streams report failures as a named
+//     "error" SSE event.
+//   - "gemini": alt=sse streams are bare `data: {...}` frames with no
+//     terminator frame.
+//   - anything else (OpenAI chat completions, the default streaming
+//     format): a `data: {...}` frame followed by the `data: [DONE]`
+//     sentinel OpenAI clients wait for before closing the connection.
+func BuildStreamingErrorEvent(handlerType string, status int, respErr error, addon http.Header) []byte {
+	errText := ""
+	if respErr != nil {
+		errText = strings.TrimSpace(respErr.Error())
+	}
+	body := BuildErrorResponseBody(status, errText)
+	if retryAfterMs := retryAfterMillis(addon); retryAfterMs > 0 {
+		if withRetry, setErr := sjson.SetBytes(body, "error.retry_after_ms", retryAfterMs); setErr == nil {
+			body = withRetry
+		}
+	}
+
+	switch strings.ToLower(strings.TrimSpace(handlerType)) {
+	case "claude":
+		return []byte(fmt.Sprintf("event: error\ndata: %s\n\n", body))
+	case "gemini":
+		return []byte(fmt.Sprintf("data: %s\n\n", body))
+	default:
+		return []byte(fmt.Sprintf("data: %s\n\ndata: [DONE]\n\n", body))
+	}
+}
+
+// retryAfterMillis parses addon's Retry-After header, in the delta-seconds
+// form upstreams in this codebase emit, into milliseconds. It returns 0 if
+// addon carries no usable Retry-After value, which callers treat as "omit
+// retry_after_ms".
+func retryAfterMillis(addon http.Header) int64 {
+	if addon == nil {
+		return 0
+	}
+	raw := strings.TrimSpace(addon.Get("Retry-After"))
+	if raw == "" {
+		return 0
+	}
+	seconds, err := strconv.Atoi(raw)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return int64(seconds) * 1000
+}
+
+// RetryPolicy configures the jittered exponential backoff applied before any
+// upstream response has been accepted for a request: ExecuteWithAuthManager's
+// initial execOnce call, and ExecuteStreamWithAuthManager's pre-first-byte
+// bootstrap retries. The zero value is filled in by withDefaults, so leaving
+// h.RetryPolicy unset retries with defaultRetryPolicy rather than with no
+// backoff at all.
+type RetryPolicy struct {
+	// InitialBackoff is the wait before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the backoff computed from InitialBackoff and
+	// Multiplier; Jitter is applied after the cap, so a single wait can
+	// exceed it by up to the Jitter fraction.
+	MaxBackoff time.Duration
+	// Multiplier scales InitialBackoff after each failed attempt.
+	Multiplier float64
+	// Jitter is the fraction (0-1) of each computed backoff randomly added
+	// or subtracted, so concurrent retries against the same rate-limited
+	// upstream don't all land on the same instant.
+	Jitter float64
+	// MaxElapsed bounds the total time spent retrying, measured from the
+	// first failure; once exceeded, the last failure is returned as-is.
+	MaxElapsed time.Duration
+}
+
+var defaultRetryPolicy = RetryPolicy{
+	InitialBackoff: 200 * time.Millisecond,
+	MaxBackoff:     5 * time.Second,
+	Multiplier:     2,
+	Jitter:         0.2,
+	MaxElapsed:     30 * time.Second,
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = defaultRetryPolicy.InitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = defaultRetryPolicy.MaxBackoff
+	}
+	if p.Multiplier <= 1 {
+		p.Multiplier = defaultRetryPolicy.Multiplier
+	}
+	if p.Jitter < 0 {
+		p.Jitter = 0
+	}
+	if p.MaxElapsed <= 0 {
+		p.MaxElapsed = defaultRetryPolicy.MaxElapsed
+	}
+	return p
+}
+
+// backoff returns how long to wait before retry attempt n (0-based: the
+// first retry is attempt 0), honoring retryAfter - parsed from a failed
+// attempt's Retry-After header, if any - ahead of p's own computed value.
+func (p RetryPolicy) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return retryAfter
+	}
+	d := float64(p.InitialBackoff)
+	for i := 0; i < attempt; i++ {
+		d *= p.Multiplier
+	}
+	if max := float64(p.MaxBackoff); d > max {
+		d = max
+	}
+	if p.Jitter > 0 {
+		delta := d * p.Jitter
+		d += (rand.Float64()*2 - 1) * delta
+		if d < 0 {
+			d = 0
+		}
+	}
+	return time.Duration(d)
+}
+
+// perAttemptDeadline bounds a single attempt to whatever remains of p's
+// MaxElapsed budget, so one stuck upstream call cannot by itself consume
+// time the rest of the retry loop (and its sleeps) still needs.
+func (p RetryPolicy) perAttemptDeadline(ctx context.Context, remaining time.Duration) (context.Context, context.CancelFunc) {
+	if remaining <= 0 {
+		remaining = p.InitialBackoff
+	}
+	return context.WithTimeout(ctx, remaining)
+}
+
+// waitForRetry blocks for d, or until ctx is done, whichever comes first. It
+// returns false when ctx ended the wait early, in which case the caller must
+// stop retrying rather than attempt again after an unexpectedly short wait.
+func waitForRetry(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	if ctx == nil {
+		<-timer.C
+		return true
+	}
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// retryAfterDuration is retryAfterMillis converted to a time.Duration, for
+// callers (RetryPolicy.backoff) that want it as a wait rather than a
+// response field.
+func retryAfterDuration(addon http.Header) time.Duration {
+	return time.Duration(retryAfterMillis(addon)) * time.Millisecond
+}
+
+// bootstrapRetryEligible reports whether err, seen before any response bytes
+// have reached the client, is worth retrying at all: auth/billing failures
+// that a credential rotation might clear, rate limiting, request timeouts,
+// and 5xx upstream errors. A status-less err (e.g. a network-level failure
+// with no HTTP response at all) is always eligible, since it's usually
+// transient. Shared by ExecuteWithAuthManager's initial retry and
+// ExecuteStreamWithAuthManager's bootstrap retries.
+func bootstrapRetryEligible(err error) bool {
+	status := statusFromError(err)
+	if status == 0 {
+		return true
+	}
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusPaymentRequired,
+		http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return true
+	default:
+		return status >= http.StatusInternalServerError
+	}
+}
+
 // StreamingKeepAliveInterval returns the SSE keep-alive interval for this server.
 // Returning 0 disables keep-alives (default when unset).
 func StreamingKeepAliveInterval(cfg *config.SDKConfig) time.Duration {
@@ -144,6 +370,17 @@ func StreamingBootstrapRetries(cfg *config.SDKConfig) int {
 	return retries
 }
 
+// StreamCheckpointFailoverEnabled reports whether ExecuteStreamWithAuthManager
+// may attempt a mid-stream failover hop (carrying the partial assistant turn
+// forward via StreamCheckpoint) instead of treating any error after the
+// first payload byte as fatal. Off by default: this changes the client-
+// visible stream's semantics (the continuation provider sees a synthesized
+// assistant prefix/continuation message it never actually generated), so an
+// operator must opt in.
+func StreamCheckpointFailoverEnabled(cfg *config.SDKConfig) bool {
+	return cfg != nil && cfg.Streaming.CheckpointFailover
+}
+
 func requestExecutionMetadata(ctx context.Context) map[string]any {
 	// Idempotency-Key is an optional client-supplied header used to correlate retries.
 	// It is forwarded as execution metadata; when absent we generate a UUID.
@@ -159,6 +396,28 @@ func requestExecutionMetadata(ctx context.Context) map[string]any {
 	return map[string]any{idempotencyKeyMetadataKey: key}
 }
 
+// clientIdempotencyKeyFromContext returns the raw Idempotency-Key header the
+// client sent, or "" if it sent none. Unlike requestExecutionMetadata, it
+// never substitutes a generated UUID: only a key the client actually chose
+// to reuse across retries is eligible for IdempotencyCache replay.
+func clientIdempotencyKeyFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil || ginCtx.Request == nil {
+		return ""
+	}
+	return strings.TrimSpace(ginCtx.GetHeader("Idempotency-Key"))
+}
+
+// idempotencyCacheKey scopes a client Idempotency-Key to the requesting API
+// key and handler format, so two different clients (or two different
+// endpoints) that happen to reuse the same header value don't collide.
+func idempotencyCacheKey(ctx context.Context, handlerType, clientKey string) string {
+	return clientAPIKeyFromContext(ctx) + "\x00" + handlerType + "\x00" + clientKey
+}
+
 func apiKeyPolicyFromContext(ctx context.Context) *internalconfig.APIKeyPolicy {
 	if ctx == nil {
 		return nil
@@ -246,19 +505,34 @@ func extractErrorMessage(raw string) string {
 	return raw
 }
 
-func isClaudeFailoverEligible(status int, err error) bool {
+// isFailoverEligible reports whether a failed call with the given status
+// code and error is eligible to retry against a configured fallback chain
+// hop. It is deliberately conservative: most status codes never trigger
+// failover, and the ones that can (500/502/400) additionally require the
+// error message to look like an auth/provider-availability problem rather
+// than e.g. a client payload mistake. 503 is always eligible, since it is
+// the status CircuitBreaker.Check synthesizes for an open breaker.
+func isFailoverEligible(status int, err error) bool {
 	switch status {
 	case http.StatusTooManyRequests, http.StatusUnauthorized, http.StatusPaymentRequired, http.StatusForbidden:
 		return true
+	case http.StatusServiceUnavailable:
+		// Always eligible: this is the status CircuitBreaker.Check uses for
+		// its synthetic rejection when a (provider, model) pair is open, and
+		// an open breaker should route to the next fallback just like a real
+		// upstream failure would.
+		return true
 	case http.StatusInternalServerError:
 		msg := strings.ToLower(extractErrorMessage(errString(err)))
 		if msg == "" {
 			return false
 		}
-		// When no Claude auth is currently selectable (all cooled down / unavailable),
-		// the core auth manager can return an internal error like:
+		// When no auth is currently selectable for a provider (all cooled
+		// down / unavailable), the core auth manager can return an internal
+		// error like:
 		//   "auth_unavailable: no auth available"
-		// Treat this as failover eligible so clients can transparently route to Codex.
+		// Treat this as failover eligible so clients can transparently route
+		// to the next configured fallback model.
 		if strings.Contains(msg, "auth_unavailable") || strings.Contains(msg, "auth_not_found") || strings.Contains(msg, "no auth available") {
 			return true
 		}
@@ -306,24 +580,37 @@ func errString(err error) string {
 	return strings.TrimSpace(err.Error())
 }
 
-func seemsClaudeModel(modelName string) bool {
+// modelHintProvider makes a best-effort guess at the provider a model name
+// belongs to, for use when getRequestDetails itself fails for the client's
+// originally requested model: the real provider is unknown in that case, but
+// a fallback chain is still keyed by provider, so a hint is needed to look
+// one up at all. Returns "" when no known provider's naming convention
+// matches.
+func modelHintProvider(modelName string) string {
 	resolved := util.ResolveAutoModel(modelName)
 	parsed := thinking.ParseSuffix(resolved)
 	base := strings.ToLower(strings.TrimSpace(parsed.ModelName))
-	return strings.HasPrefix(base, "claude-")
+	switch {
+	case strings.HasPrefix(base, "claude-"):
+		return "claude"
+	case strings.HasPrefix(base, "gemini-"):
+		return "gemini"
+	case strings.HasPrefix(base, "gpt-"), strings.HasPrefix(base, "o1"), strings.HasPrefix(base, "o3"), strings.HasPrefix(base, "codex"):
+		return "codex"
+	default:
+		return ""
+	}
 }
 
-func containsProvider(providers []string, provider string) bool {
-	provider = strings.TrimSpace(strings.ToLower(provider))
-	if provider == "" || len(providers) == 0 {
-		return false
-	}
-	for _, p := range providers {
-		if strings.EqualFold(strings.TrimSpace(p), provider) {
-			return true
-		}
+// firstProvider returns providers[0], or "unknown" if providers is empty,
+// for use as the upstream-latency histogram's provider label: a request may
+// be eligible for several providers, but only the first one is actually
+// tried before failover logic picks a different candidate list.
+func firstProvider(providers []string) string {
+	if len(providers) == 0 {
+		return "unknown"
 	}
-	return false
+	return providers[0]
 }
 
 func rewriteModelField(body []byte, model string) []byte {
@@ -341,6 +628,152 @@ func rewriteModelField(body []byte, model string) []byte {
 	return out
 }
 
+// responseModelFieldPaths lists every JSON path a provider's response body
+// may carry the model identifier in. A ".#." segment means "every element of
+// this array" rather than a literal gjson/sjson path, and is expanded by
+// setResponseModelFieldPath. This covers Anthropic messages, OpenAI Chat
+// Completions (including its streaming deltas), the OpenAI Responses API,
+// and Gemini, so a cross-provider failover never leaks the failover target's
+// real model identifier back to the client.
+var responseModelFieldPaths = []string{
+	"model",                     // Anthropic / OpenAI Chat Completions top level
+	"message.model",             // Anthropic message_start envelope
+	"response.model",            // OpenAI Responses API response.created/response.completed
+	"modelVersion",              // Gemini top level
+	"choices.#.model",           // OpenAI Chat Completions streaming deltas
+	"candidates.#.modelVersion", // Gemini candidates
+}
+
+// rewriteResponseModelFields rewrites every known model-identifier field in
+// a (non-streaming or single-chunk) JSON response body to targetModel. It is
+// used after a cross-provider failover so the client sees the model it
+// originally requested rather than the failover target's real model name.
+func rewriteResponseModelFields(data []byte, targetModel string) []byte {
+	targetModel = strings.TrimSpace(targetModel)
+	if len(data) == 0 || targetModel == "" {
+		return data
+	}
+	out := data
+	for _, path := range responseModelFieldPaths {
+		out = setResponseModelFieldPath(out, path, targetModel)
+	}
+	return out
+}
+
+// setResponseModelFieldPath rewrites the field at path in data to
+// targetModel, expanding a ".#." segment into one sjson.SetBytes call per
+// array element. Paths (or array elements) that don't exist in data are left
+// untouched.
+func setResponseModelFieldPath(data []byte, path, targetModel string) []byte {
+	arrayPath, fieldPath, isArrayPath := strings.Cut(path, ".#.")
+	if !isArrayPath {
+		if !gjson.GetBytes(data, path).Exists() {
+			return data
+		}
+		out, err := sjson.SetBytes(data, path, targetModel)
+		if err != nil {
+			return data
+		}
+		return out
+	}
+
+	arr := gjson.GetBytes(data, arrayPath)
+	if !arr.IsArray() {
+		return data
+	}
+	out := data
+	for i := range arr.Array() {
+		elemPath := fmt.Sprintf("%s.%d.%s", arrayPath, i, fieldPath)
+		if !gjson.GetBytes(out, elemPath).Exists() {
+			continue
+		}
+		if updated, err := sjson.SetBytes(out, elemPath, targetModel); err == nil {
+			out = updated
+		}
+	}
+	return out
+}
+
+// rewriteStreamChunkModelFields applies rewriteResponseModelFields to a
+// streaming chunk, which may be either a raw JSON payload or one or more
+// "data: <json>" SSE lines. Non-JSON SSE data (e.g. "data: [DONE]") and lines
+// without a model field are left untouched.
+func rewriteStreamChunkModelFields(data []byte, targetModel string) []byte {
+	if data == nil {
+		return nil
+	}
+	if strings.TrimSpace(targetModel) == "" {
+		return data
+	}
+	if !bytes.Contains(data, []byte("data: ")) {
+		return rewriteResponseModelFields(data, targetModel)
+	}
+
+	lines := bytes.Split(data, []byte("\n"))
+	for i, line := range lines {
+		if !bytes.HasPrefix(line, []byte("data: ")) {
+			continue
+		}
+		payload := bytes.TrimPrefix(line, []byte("data: "))
+		if !json.Valid(payload) {
+			continue
+		}
+		lines[i] = append([]byte("data: "), rewriteResponseModelFields(payload, targetModel)...)
+	}
+	return bytes.Join(lines, []byte("\n"))
+}
+
+// streamChunkTotalTokenPaths are the gjson paths carrying a cumulative
+// total-token count across the streaming formats this proxy translates:
+// OpenAI's trailing "usage" object, Claude's message_start/message_delta
+// usage fields, and Gemini's usageMetadata. A chunk can match more than one
+// of them; estimateStreamChunkTotalTokens sums every hit.
+var streamChunkTotalTokenPaths = []string{
+	"usage.total_tokens",
+	"message.usage.input_tokens",
+	"message.usage.output_tokens",
+	"usage.input_tokens",
+	"usage.output_tokens",
+	"usageMetadata.totalTokenCount",
+}
+
+// estimateStreamChunkTotalTokens best-effort-parses a cumulative total-token
+// count out of one streaming chunk, for the mid-stream budget guard in
+// ExecuteStreamWithAuthManager. It is necessarily approximate: providers
+// report usage at different granularities and this does not parse a
+// particular wire format, only scan a few well-known field paths common
+// across them. A chunk with no recognizable usage field returns ok=false,
+// leaving the previous estimate (if any) in place.
+func estimateStreamChunkTotalTokens(data []byte) (int64, bool) {
+	if len(data) == 0 {
+		return 0, false
+	}
+	var total int64
+	found := false
+	scan := func(payload []byte) {
+		for _, path := range streamChunkTotalTokenPaths {
+			if v := gjson.GetBytes(payload, path); v.Exists() && v.Type == gjson.Number {
+				total += v.Int()
+				found = true
+			}
+		}
+	}
+	if bytes.Contains(data, []byte("data: ")) {
+		for _, line := range bytes.Split(data, []byte("\n")) {
+			if !bytes.HasPrefix(line, []byte("data: ")) {
+				continue
+			}
+			payload := bytes.TrimPrefix(line, []byte("data: "))
+			if json.Valid(payload) {
+				scan(payload)
+			}
+		}
+	} else if json.Valid(data) {
+		scan(data)
+	}
+	return total, found
+}
+
 // BaseAPIHandler contains the handlers for API endpoints.
 // It holds a pool of clients to interact with the backend service and manages
 // load balancing, client selection, and configuration.
@@ -350,6 +783,148 @@ type BaseAPIHandler struct {
 
 	// Cfg holds the current application configuration.
 	Cfg *config.SDKConfig
+
+	// Metrics, if non-nil, records upstream executor latency for every
+	// AuthManager.Execute call made through this handler.
+	Metrics *metrics.Registry
+
+	// BillingStore, if non-nil, backs the mid-stream budget guard in
+	// ExecuteStreamWithAuthManager: a streaming request whose client API key
+	// has a DailyBudgetUSD policy is periodically checked against it as
+	// chunks arrive, rather than only once before the request starts.
+	BillingStore billing.Store
+
+	// InFlightTracker holds each in-progress streaming request's running
+	// cost estimate (parsed from usage fields in its own SSE chunks) so the
+	// budget guard can project total spend before UsagePersistPlugin
+	// persists the final, authoritative cost. Safe to leave nil; a nil
+	// *billing.InFlightTracker behaves as an always-empty tracker.
+	InFlightTracker *billing.InFlightTracker
+
+	// IdempotencyCache, if non-nil, lets ExecuteWithAuthManager replay a
+	// previously completed response for a client-supplied Idempotency-Key
+	// instead of re-executing the upstream call.
+	// ExecuteCountWithAuthManager does not consult it. Streaming requests (ExecuteStreamWithAuthManager) are not
+	// replayed: an SSE response is a sequence of chunks, not a single body,
+	// and is not a good fit for this cache's byte-for-byte replay model.
+	IdempotencyCache idempotency.Cache
+
+	// IdempotencyTTL bounds how long a cached response stays replayable.
+	// Zero means IdempotencyCache entries never expire.
+	IdempotencyTTL time.Duration
+
+	// FailoverEngine, if non-nil, is consulted by ExecuteWithAuthManager
+	// for a failed call on which the per-API-key fallback chain (see
+	// APIKeyPolicy.FailoverChainFor and runPolicyFailoverChain) attempted no
+	// hop; a call the chain retried and still lost is not passed to it. It covers provider-pair rules that aren't
+	// tied to a specific API key's policy, e.g. Gemini -> Vertex or Codex ->
+	// Claude. Nil preserves
+	// exactly today's behavior (no generic rules applied). Set it via
+	// SetFailoverEngine, typically with failover.NewDefaultEngine() plus any
+	// operator-supplied rules.
+	FailoverEngine *failover.Engine
+
+	// ClientLimiter, if non-nil, caps per-client-API-key concurrency and
+	// request rate ahead of dispatch in ExecuteWithAuthManager and
+	// ExecuteCountWithAuthManager, per APIKeyPolicy's MaxConcurrent/
+	// RequestsPerSecond/ConcurrencyBurst/QueueTimeout. Nil preserves
+	// exactly today's behavior (no client-side limiting). Set it via
+	// SetClientLimiter, typically with NewClientLimiter(h.Metrics).
+	ClientLimiter *ClientLimiter
+
+	// CircuitBreaker, if non-nil, is consulted by ExecuteWithAuthManager,
+	// ExecuteCountWithAuthManager, and ExecuteStreamWithAuthManager ahead of
+	// every h.AuthManager.Execute*/ExecuteStream call - including fallback
+	// chain hops - and short-circuits a (provider, model) pair that is
+	// failing repeatedly with a synthetic, failover-eligible 503 instead of
+	// dispatching upstream. Nil preserves exactly today's behavior (every
+	// call is attempted). Set it via SetCircuitBreaker, typically with
+	// NewCircuitBreaker(h.Metrics).
+	CircuitBreaker *CircuitBreaker
+
+	// RetryPolicy configures the jittered exponential backoff applied to
+	// ExecuteWithAuthManager's initial execOnce call and
+	// ExecuteStreamWithAuthManager's pre-first-byte bootstrap retries. The
+	// zero value (the default for a struct literal like this one) is filled
+	// in with defaultRetryPolicy by retryPolicy(), so leaving it unset does
+	// not disable retrying - set a non-zero field to override just that
+	// knob.
+	RetryPolicy RetryPolicy
+}
+
+// retryPolicy returns h.RetryPolicy with every unset field filled in from
+// defaultRetryPolicy.
+func (h *BaseAPIHandler) retryPolicy() RetryPolicy {
+	return h.RetryPolicy.withDefaults()
+}
+
+// SetClientLimiter installs limiter as h's ClientLimiter. Call with nil to
+// disable per-client-API-key concurrency/rate limiting.
+func (h *BaseAPIHandler) SetClientLimiter(limiter *ClientLimiter) {
+	h.ClientLimiter = limiter
+}
+
+// SetCircuitBreaker installs breaker as h's CircuitBreaker. Call with nil to
+// disable per-(provider, model) circuit breaking.
+func (h *BaseAPIHandler) SetCircuitBreaker(breaker *CircuitBreaker) {
+	h.CircuitBreaker = breaker
+}
+
+// checkCircuitBreaker consults h.CircuitBreaker for provider/model ahead of
+// an upstream executor call, returning a non-nil *interfaces.ErrorMessage
+// the caller must return immediately instead of dispatching.
+func (h *BaseAPIHandler) checkCircuitBreaker(provider, model string) *interfaces.ErrorMessage {
+	if h == nil || h.CircuitBreaker == nil {
+		return nil
+	}
+	return h.CircuitBreaker.Check(provider, model)
+}
+
+// recordCircuitBreakerResult reports an upstream executor call's outcome to
+// h.CircuitBreaker, if configured.
+func (h *BaseAPIHandler) recordCircuitBreakerResult(provider, model string, status int, err error) {
+	if h == nil || h.CircuitBreaker == nil {
+		return
+	}
+	h.CircuitBreaker.RecordResult(provider, model, status, err)
+}
+
+// acquireClientSlot applies h.ClientLimiter's per-API-key concurrency cap
+// and rate limit, if configured, ahead of dispatching ctx's request. It
+// returns a release func that is always safe to defer, even when no limit
+// applied, and, on queue timeout, an *interfaces.ErrorMessage the caller
+// should return immediately instead of proceeding.
+func (h *BaseAPIHandler) acquireClientSlot(ctx context.Context) (func(), *interfaces.ErrorMessage) {
+	noop := func() {}
+	if h == nil || h.ClientLimiter == nil {
+		return noop, nil
+	}
+	apiKey := clientAPIKeyFromContext(ctx)
+	if apiKey == "" {
+		return noop, nil
+	}
+	cfg := clientLimiterConfigFromPolicy(apiKeyPolicyFromContext(ctx))
+	if cfg.MaxConcurrent <= 0 && cfg.RPS <= 0 {
+		return noop, nil
+	}
+
+	wait, err := h.ClientLimiter.Acquire(ctx, apiKey, cfg)
+	if err != nil {
+		queueErr := &statusHeadersError{
+			err:   fmt.Errorf("client limiter: %w", err),
+			code:  http.StatusTooManyRequests,
+			addon: http.Header{"X-Queue-Wait-Ms": []string{strconv.FormatInt(wait.Milliseconds(), 10)}},
+		}
+		return noop, &interfaces.ErrorMessage{StatusCode: queueErr.StatusCode(), Error: queueErr, Addon: queueErr.Headers()}
+	}
+	return func() { h.ClientLimiter.Release(apiKey, cfg) }, nil
+}
+
+// SetFailoverEngine installs engine as h's failover.Engine. Call with nil to
+// disable generic rule-based failover and rely only on the per-API-key
+// fallback chain.
+func (h *BaseAPIHandler) SetFailoverEngine(engine *failover.Engine) {
+	h.FailoverEngine = engine
 }
 
 // NewBaseAPIHandlers creates a new API handlers instance.
@@ -557,41 +1132,37 @@ func appendAPIResponse(c *gin.Context, data []byte) {
 // ExecuteWithAuthManager executes a non-streaming request via the core auth manager.
 // This path is the only supported execution route.
 func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, *interfaces.ErrorMessage) {
+	releaseClientSlot, limitErr := h.acquireClientSlot(ctx)
+	defer releaseClientSlot()
+	if limitErr != nil {
+		return nil, limitErr
+	}
+
+	var idemCacheKey string
+	if h.IdempotencyCache != nil {
+		if raw := clientIdempotencyKeyFromContext(ctx); raw != "" {
+			idemCacheKey = idempotencyCacheKey(ctx, handlerType, raw)
+			if cached, ok, err := h.IdempotencyCache.Get(ctx, idemCacheKey); err == nil && ok {
+				return cached.Body, nil
+			}
+		}
+	}
+
 	reqMeta := requestExecutionMetadata(ctx)
+	// masqueradeModel is set to the client's originally requested model
+	// whenever a fallback-chain hop swaps the provider/model, so the
+	// response can be rewritten back to it before it reaches the client.
+	masqueradeModel := ""
 	providers, normalizedModel, errMsg := h.getRequestDetails(modelName)
 	if errMsg != nil {
-		if policy := apiKeyPolicyFromContext(ctx); policy != nil {
-			targetModel, enabled := policy.ClaudeFailoverTargetModel()
-			if enabled && strings.TrimSpace(targetModel) != "" && targetModel != modelName && seemsClaudeModel(modelName) && isClaudeFailoverEligible(errMsg.StatusCode, errMsg.Error) {
-				failoverPayload := rewriteModelField(rawJSON, targetModel)
-				failoverProviders, failoverModel, detailErr := h.getRequestDetails(targetModel)
-				if detailErr == nil {
-					clientKey := util.HideAPIKey(clientAPIKeyFromContext(ctx))
-					log.WithFields(log.Fields{
-						"component":       "failover",
-						"client_api_key":  clientKey,
-						"from_provider":   "claude",
-						"from_model":      modelName,
-						"to_model":        failoverModel,
-						"status_code":     errMsg.StatusCode,
-						"error_message":   extractErrorMessage(errString(errMsg.Error)),
-						"handler_format":  handlerType,
-						"idempotency_key": reqMeta[idempotencyKeyMetadataKey],
-						"reason":          "unknown_provider",
-					}).Warn("triggering automatic failover for Claude request (unknown provider)")
-
-					rawJSON = failoverPayload
-					providers = failoverProviders
-					normalizedModel = failoverModel
-				} else {
-					return nil, detailErr
-				}
-			} else {
-				return nil, errMsg
-			}
-		} else {
-			return nil, errMsg
+		failoverProviders, failoverModel, masquerade, failoverErr := h.resolvePreflightFailoverChain(ctx, handlerType, modelName, reqMeta, errMsg)
+		if failoverErr != nil {
+			return nil, failoverErr
 		}
+		rawJSON = rewriteModelField(rawJSON, failoverModel)
+		providers = failoverProviders
+		normalizedModel = failoverModel
+		masqueradeModel = masquerade
 	}
 	reqMeta[coreexecutor.RequestedModelMetadataKey] = normalizedModel
 	payload := rawJSON
@@ -611,7 +1182,13 @@ func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType
 	opts.Metadata = reqMeta
 
 	execOnce := func(execProviders []string, execReq coreexecutor.Request, execOpts coreexecutor.Options) ([]byte, *interfaces.ErrorMessage) {
-		resp, err := h.AuthManager.Execute(ctx, execProviders, execReq, execOpts)
+		provider := firstProvider(execProviders)
+		if breakerErr := h.checkCircuitBreaker(provider, execReq.Model); breakerErr != nil {
+			return nil, breakerErr
+		}
+		start := time.Now()
+		resp, err := executorUnaryChain(ctx, execProviders, execReq, execOpts, h.AuthManager.Execute)
+		h.Metrics.ObserveUpstreamLatency(provider, execReq.Model, err != nil, time.Since(start).Seconds())
 		if err != nil {
 			status := http.StatusInternalServerError
 			if se, ok := err.(interface{ StatusCode() int }); ok && se != nil {
@@ -619,6 +1196,8 @@ func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType
 					status = code
 				}
 			}
+			h.Metrics.ObserveExecutorOutcome(provider, execReq.Model, status)
+			h.recordCircuitBreakerResult(provider, execReq.Model, status, err)
 			var addon http.Header
 			if he, ok := err.(interface{ Headers() http.Header }); ok && he != nil {
 				if hdr := he.Headers(); hdr != nil {
@@ -627,104 +1206,308 @@ func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType
 			}
 			return nil, &interfaces.ErrorMessage{StatusCode: status, Error: err, Addon: addon}
 		}
+		h.Metrics.ObserveExecutorOutcome(provider, execReq.Model, http.StatusOK)
+		h.recordCircuitBreakerResult(provider, execReq.Model, http.StatusOK, nil)
 		return resp.Payload, nil
 	}
 
 	out, execErr := execOnce(providers, req, opts)
+	if execErr != nil && bootstrapRetryEligible(execErr.Error) {
+		// Same jittered exponential backoff (and Retry-After/per-attempt
+		// deadline handling) as ExecuteStreamWithAuthManager's pre-first-byte
+		// bootstrap retries, applied here since this initial attempt is the
+		// non-streaming equivalent: nothing has been returned to the caller
+		// yet, so a transient or rate-limited failure is safe to retry in
+		// place before falling through to fallback-chain failover below.
+		retryPolicy := h.retryPolicy()
+		retryDeadline := time.Now().Add(retryPolicy.MaxElapsed)
+		originalCtx := ctx
+		for attempt := 0; execErr != nil && bootstrapRetryEligible(execErr.Error) && time.Now().Before(retryDeadline); attempt++ {
+			wait := retryPolicy.backoff(attempt, retryAfterDuration(execErr.Addon))
+			if !waitForRetry(originalCtx, wait) {
+				break
+			}
+			attemptCtx, cancel := retryPolicy.perAttemptDeadline(originalCtx, time.Until(retryDeadline))
+			ctx = attemptCtx
+			out, execErr = execOnce(providers, req, opts)
+			cancel()
+			ctx = originalCtx
+		}
+	}
 	if execErr == nil {
-		return out, nil
+		result := rewriteResponseModelFields(out, masqueradeModel)
+		h.storeIdempotentResponse(ctx, idemCacheKey, result)
+		return result, nil
 	}
 
-	// Optional per-client API key failover: Claude -> configured target model.
-	policy := apiKeyPolicyFromContext(ctx)
-	targetModel := ""
-	enabled := false
-	if policy != nil {
-		targetModel, enabled = policy.ClaudeFailoverTargetModel()
+	// Optional per-client API key failover: walk the configured fallback
+	// chain for this provider/model (see APIKeyPolicy.FailoverChainFor).
+	status := execErr.StatusCode
+	if status <= 0 {
+		status = statusFromError(execErr.Error)
 	}
-	if enabled && containsProvider(providers, "claude") && strings.TrimSpace(targetModel) != "" && targetModel != normalizedModel {
-		status := execErr.StatusCode
-		if status <= 0 {
-			status = statusFromError(execErr.Error)
+	if failoverOut, failoverErr, handled := h.runPolicyFailoverChain(ctx, handlerType, firstProvider(providers), normalizedModel, rawJSON, reqMeta, opts, status, execErr, execOnce); handled {
+		if failoverErr == nil {
+			result := rewriteResponseModelFields(failoverOut, normalizedModel)
+			h.storeIdempotentResponse(ctx, idemCacheKey, result)
+			return result, nil
 		}
-		if isClaudeFailoverEligible(status, execErr.Error) {
-			failoverPayload := rewriteModelField(rawJSON, targetModel)
-			failoverProviders, failoverModel, detailErr := h.getRequestDetails(targetModel)
-			if detailErr == nil {
-				failoverReqMeta := make(map[string]any, len(reqMeta)+1)
-				for k, v := range reqMeta {
-					failoverReqMeta[k] = v
-				}
-				failoverReqMeta[coreexecutor.RequestedModelMetadataKey] = failoverModel
-				failoverReq := coreexecutor.Request{Model: failoverModel, Payload: failoverPayload}
-				failoverOpts := opts
-				failoverOpts.OriginalRequest = failoverPayload
-				failoverOpts.Metadata = failoverReqMeta
-
-				clientKey := util.HideAPIKey(clientAPIKeyFromContext(ctx))
-				log.WithFields(log.Fields{
-					"component":       "failover",
-					"client_api_key":  clientKey,
-					"from_provider":   "claude",
-					"from_model":      normalizedModel,
-					"to_model":        failoverModel,
-					"status_code":     status,
-					"error_message":   extractErrorMessage(errString(execErr.Error)),
-					"handler_format":  handlerType,
-					"idempotency_key": reqMeta[idempotencyKeyMetadataKey],
-				}).Warn("triggering automatic failover for Claude request")
-
-				failoverOut, failoverErr := execOnce(failoverProviders, failoverReq, failoverOpts)
-				if failoverErr == nil {
-					return failoverOut, nil
+		return nil, failoverErr
+	}
+
+	if h.FailoverEngine != nil {
+		if failoverOut, failoverErr, rewroteModel, handled := h.runEngineFailover(ctx, handlerType, normalizedModel, rawJSON, providers, reqMeta, opts, status, execErr, execOnce); handled {
+			if failoverErr == nil {
+				masquerade := ""
+				if rewroteModel {
+					masquerade = normalizedModel
 				}
-				return nil, failoverErr
+				result := rewriteResponseModelFields(failoverOut, masquerade)
+				h.storeIdempotentResponse(ctx, idemCacheKey, result)
+				return result, nil
 			}
-			_ = detailErr
+			return nil, failoverErr
 		}
 	}
 
 	return nil, execErr
 }
 
+// runEngineFailover consults h.FailoverEngine for a generic provider-pair
+// rule (e.g. Gemini -> Vertex, Codex -> Claude) matching the failed call,
+// and retries once against its Action if one hits and the request's
+// Engine-hop budget (failoverHopsMetadataKey) isn't exhausted. handled is
+// false when no rule matched or the hop budget was spent, in which case the
+// caller should keep its own original error.
+func (h *BaseAPIHandler) runEngineFailover(
+	ctx context.Context,
+	handlerType, normalizedModel string,
+	rawJSON []byte,
+	providers []string,
+	reqMeta map[string]any,
+	opts coreexecutor.Options,
+	status int,
+	execErr *interfaces.ErrorMessage,
+	execOnce func([]string, coreexecutor.Request, coreexecutor.Options) ([]byte, *interfaces.ErrorMessage),
+) (out []byte, failoverErr *interfaces.ErrorMessage, rewroteModel bool, handled bool) {
+	action, ruleName, ok := h.FailoverEngine.Resolve(firstProvider(providers), normalizedModel, status, extractErrorMessage(errString(execErr.Error)))
+	if !ok || strings.TrimSpace(action.ToProvider) == "" {
+		return nil, nil, false, false
+	}
+	maxHops := action.MaxHops
+	if maxHops <= 0 {
+		maxHops = 1
+	}
+	if failoverHopsFromMetadata(reqMeta) >= maxHops {
+		return nil, nil, false, false
+	}
+
+	targetModel := normalizedModel
+	failoverPayload := rawJSON
+	if action.RewriteModel && strings.TrimSpace(action.ToModel) != "" {
+		targetModel = action.ToModel
+		failoverPayload = rewriteModelField(rawJSON, targetModel)
+	}
+
+	failoverReqMeta := map[string]any{failoverHopsMetadataKey: failoverHopsFromMetadata(reqMeta) + 1}
+	if action.PreserveMetadata {
+		for k, v := range reqMeta {
+			failoverReqMeta[k] = v
+		}
+		failoverReqMeta[failoverHopsMetadataKey] = failoverHopsFromMetadata(reqMeta) + 1
+	}
+	failoverReqMeta[coreexecutor.RequestedModelMetadataKey] = targetModel
+
+	clientKey := util.HideAPIKey(clientAPIKeyFromContext(ctx))
+	log.WithFields(log.Fields{
+		"component":      "failover",
+		"client_api_key": clientKey,
+		"from_provider":  firstProvider(providers),
+		"to_provider":    action.ToProvider,
+		"from_model":     normalizedModel,
+		"to_model":       targetModel,
+		"status_code":    status,
+		"error_message":  extractErrorMessage(errString(execErr.Error)),
+		"handler_format": handlerType,
+		"rule":           ruleName,
+	}).Warn("triggering engine-driven failover")
+	h.Metrics.ObserveFailoverTriggered(normalizedModel, targetModel)
+
+	failoverOpts := opts
+	failoverOpts.OriginalRequest = failoverPayload
+	failoverOpts.Metadata = failoverReqMeta
+	failoverReq := coreexecutor.Request{Model: targetModel, Payload: failoverPayload}
+	out, failoverErr = execOnce([]string{action.ToProvider}, failoverReq, failoverOpts)
+	return out, failoverErr, action.RewriteModel, true
+}
+
+// resolvePreflightFailoverChain is consulted when getRequestDetails itself
+// fails for modelName, i.e. before any request has actually been attempted
+// and the real provider is still unknown. It guesses a provider from
+// modelName via modelHintProvider, then walks that provider's configured
+// fallback chain (see APIKeyPolicy.FailoverChainFor) for the first target
+// model getRequestDetails can resolve, logging a component=failover entry
+// with chain_position for every hop tried. failoverErr is the original
+// errMsg, unchanged, when no policy/chain is configured, no hop resolves, or
+// errMsg isn't failover eligible at all.
+func (h *BaseAPIHandler) resolvePreflightFailoverChain(
+	ctx context.Context,
+	handlerType, modelName string,
+	reqMeta map[string]any,
+	errMsg *interfaces.ErrorMessage,
+) (providers []string, normalizedModel string, masqueradeModel string, failoverErr *interfaces.ErrorMessage) {
+	policy := apiKeyPolicyFromContext(ctx)
+	if policy == nil || !isFailoverEligible(errMsg.StatusCode, errMsg.Error) {
+		return nil, "", "", errMsg
+	}
+	provider := modelHintProvider(modelName)
+	if provider == "" {
+		return nil, "", "", errMsg
+	}
+	clientKey := util.HideAPIKey(clientAPIKeyFromContext(ctx))
+	for i, target := range policy.FailoverChainFor(provider, modelName) {
+		if !target.MatchesTriggerStatus(errMsg.StatusCode) {
+			continue
+		}
+		targetModel := strings.TrimSpace(target.TargetModel)
+		if targetModel == "" || targetModel == modelName {
+			continue
+		}
+		failoverProviders, failoverModel, detailErr := h.getRequestDetails(targetModel)
+		if detailErr != nil {
+			continue
+		}
+		log.WithFields(log.Fields{
+			"component":       "failover",
+			"client_api_key":  clientKey,
+			"from_provider":   provider,
+			"from_model":      modelName,
+			"to_model":        failoverModel,
+			"chain_position":  i,
+			"status_code":     errMsg.StatusCode,
+			"error_message":   extractErrorMessage(errString(errMsg.Error)),
+			"handler_format":  handlerType,
+			"idempotency_key": reqMeta[idempotencyKeyMetadataKey],
+			"reason":          "unknown_provider",
+		}).Warn("triggering automatic failover chain hop (unknown provider)")
+		h.Metrics.ObserveFailoverTriggered(modelName, failoverModel)
+		return failoverProviders, failoverModel, modelName, nil
+	}
+	return nil, "", "", errMsg
+}
+
+// runPolicyFailoverChain walks the full ordered fallback chain configured on
+// the client's APIKeyPolicy for provider/normalizedModel (see
+// APIKeyPolicy.FailoverChainFor), retrying execOnce against each hop in turn
+// - rewriting the model field via rewriteModelField and re-resolving
+// providers via getRequestDetails for each - until one succeeds or the chain
+// is exhausted. It replaces the old Claude-only, first-hop-only failover
+// special case with a general multi-provider, multi-hop one, emitting the
+// same component=failover log (with a chain_position field added) for every
+// hop attempted. handled reports whether any hop was actually attempted, so
+// the caller knows whether to fall through to h.FailoverEngine; when handled
+// is true, failoverErr is either nil (success) or the last attempted hop's
+// error.
+func (h *BaseAPIHandler) runPolicyFailoverChain(
+	ctx context.Context,
+	handlerType, provider, normalizedModel string,
+	rawJSON []byte,
+	reqMeta map[string]any,
+	opts coreexecutor.Options,
+	status int,
+	execErr *interfaces.ErrorMessage,
+	execOnce func([]string, coreexecutor.Request, coreexecutor.Options) ([]byte, *interfaces.ErrorMessage),
+) (out []byte, failoverErr *interfaces.ErrorMessage, handled bool) {
+	policy := apiKeyPolicyFromContext(ctx)
+	if policy == nil {
+		return nil, nil, false
+	}
+	clientKey := util.HideAPIKey(clientAPIKeyFromContext(ctx))
+	for i, target := range policy.FailoverChainFor(provider, normalizedModel) {
+		if !isFailoverEligible(status, execErr.Error) || !target.MatchesTriggerStatus(status) {
+			continue
+		}
+		targetModel := strings.TrimSpace(target.TargetModel)
+		if targetModel == "" || targetModel == normalizedModel {
+			continue
+		}
+		failoverProviders, failoverModel, detailErr := h.getRequestDetails(targetModel)
+		if detailErr != nil {
+			continue
+		}
+		handled = true
+		failoverPayload := rewriteModelField(rawJSON, targetModel)
+		failoverReqMeta := make(map[string]any, len(reqMeta)+1)
+		for k, v := range reqMeta {
+			failoverReqMeta[k] = v
+		}
+		failoverReqMeta[coreexecutor.RequestedModelMetadataKey] = failoverModel
+		failoverReq := coreexecutor.Request{Model: failoverModel, Payload: failoverPayload}
+		failoverOpts := opts
+		failoverOpts.OriginalRequest = failoverPayload
+		failoverOpts.Metadata = failoverReqMeta
+
+		log.WithFields(log.Fields{
+			"component":       "failover",
+			"client_api_key":  clientKey,
+			"from_provider":   provider,
+			"from_model":      normalizedModel,
+			"to_model":        failoverModel,
+			"chain_position":  i,
+			"status_code":     status,
+			"error_message":   extractErrorMessage(errString(execErr.Error)),
+			"handler_format":  handlerType,
+			"idempotency_key": reqMeta[idempotencyKeyMetadataKey],
+		}).Warn("triggering automatic failover chain hop")
+		h.Metrics.ObserveFailoverTriggered(normalizedModel, failoverModel)
+
+		hopOut, hopErr := execOnce(failoverProviders, failoverReq, failoverOpts)
+		if hopErr == nil {
+			return hopOut, nil, true
+		}
+		execErr = hopErr
+		status = hopErr.StatusCode
+		if status <= 0 {
+			status = statusFromError(hopErr.Error)
+		}
+	}
+	return nil, execErr, handled
+}
+
+// storeIdempotentResponse saves result under idemCacheKey for future replay,
+// if this request's Idempotency-Key made it eligible (idemCacheKey != "")
+// and a cache is configured. Errors are logged, not returned: a failed cache
+// write must never fail the request it was trying to make retry-safe.
+func (h *BaseAPIHandler) storeIdempotentResponse(ctx context.Context, idemCacheKey string, result []byte) {
+	if h.IdempotencyCache == nil || idemCacheKey == "" {
+		return
+	}
+	if err := h.IdempotencyCache.Put(ctx, idemCacheKey, idempotency.CachedResponse{StatusCode: http.StatusOK, Body: result}, h.IdempotencyTTL); err != nil {
+		log.WithError(err).Warn("idempotency: failed to cache response for replay")
+	}
+}
+
 // ExecuteCountWithAuthManager executes a non-streaming request via the core auth manager.
 // This path is the only supported execution route.
 func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, *interfaces.ErrorMessage) {
+	releaseClientSlot, limitErr := h.acquireClientSlot(ctx)
+	defer releaseClientSlot()
+	if limitErr != nil {
+		return nil, limitErr
+	}
+
 	reqMeta := requestExecutionMetadata(ctx)
+	masqueradeModel := ""
 	providers, normalizedModel, errMsg := h.getRequestDetails(modelName)
 	if errMsg != nil {
-		if policy := apiKeyPolicyFromContext(ctx); policy != nil {
-			targetModel, enabled := policy.ClaudeFailoverTargetModel()
-			if enabled && strings.TrimSpace(targetModel) != "" && targetModel != modelName && seemsClaudeModel(modelName) && isClaudeFailoverEligible(errMsg.StatusCode, errMsg.Error) {
-				failoverPayload := rewriteModelField(rawJSON, targetModel)
-				failoverProviders, failoverModel, detailErr := h.getRequestDetails(targetModel)
-				if detailErr == nil {
-					clientKey := util.HideAPIKey(clientAPIKeyFromContext(ctx))
-					log.WithFields(log.Fields{
-						"component":       "failover",
-						"client_api_key":  clientKey,
-						"from_provider":   "claude",
-						"from_model":      modelName,
-						"to_model":        failoverModel,
-						"status_code":     errMsg.StatusCode,
-						"error_message":   extractErrorMessage(errString(errMsg.Error)),
-						"handler_format":  handlerType,
-						"idempotency_key": reqMeta[idempotencyKeyMetadataKey],
-						"reason":          "unknown_provider",
-					}).Warn("triggering automatic failover for Claude count request (unknown provider)")
-
-					rawJSON = failoverPayload
-					providers = failoverProviders
-					normalizedModel = failoverModel
-				} else {
-					return nil, detailErr
-				}
-			} else {
-				return nil, errMsg
-			}
-		} else {
-			return nil, errMsg
+		failoverProviders, failoverModel, masquerade, failoverErr := h.resolvePreflightFailoverChain(ctx, handlerType, modelName, reqMeta, errMsg)
+		if failoverErr != nil {
+			return nil, failoverErr
 		}
+		rawJSON = rewriteModelField(rawJSON, failoverModel)
+		providers = failoverProviders
+		normalizedModel = failoverModel
+		masqueradeModel = masquerade
 	}
 	reqMeta[coreexecutor.RequestedModelMetadataKey] = normalizedModel
 	payload := rawJSON
@@ -744,7 +1527,13 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 	opts.Metadata = reqMeta
 
 	execOnce := func(execProviders []string, execReq coreexecutor.Request, execOpts coreexecutor.Options) ([]byte, *interfaces.ErrorMessage) {
-		resp, err := h.AuthManager.ExecuteCount(ctx, execProviders, execReq, execOpts)
+		provider := firstProvider(execProviders)
+		if breakerErr := h.checkCircuitBreaker(provider, execReq.Model); breakerErr != nil {
+			return nil, breakerErr
+		}
+		start := time.Now()
+		resp, err := executorUnaryChain(ctx, execProviders, execReq, execOpts, h.AuthManager.ExecuteCount)
+		h.Metrics.ObserveUpstreamLatency(provider, execReq.Model, err != nil, time.Since(start).Seconds())
 		if err != nil {
 			status := http.StatusInternalServerError
 			if se, ok := err.(interface{ StatusCode() int }); ok && se != nil {
@@ -752,6 +1541,8 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 					status = code
 				}
 			}
+			h.Metrics.ObserveExecutorOutcome(provider, execReq.Model, status)
+			h.recordCircuitBreakerResult(provider, execReq.Model, status, err)
 			var addon http.Header
 			if he, ok := err.(interface{ Headers() http.Header }); ok && he != nil {
 				if hdr := he.Headers(); hdr != nil {
@@ -760,46 +1551,25 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 			}
 			return nil, &interfaces.ErrorMessage{StatusCode: status, Error: err, Addon: addon}
 		}
+		h.Metrics.ObserveExecutorOutcome(provider, execReq.Model, http.StatusOK)
+		h.recordCircuitBreakerResult(provider, execReq.Model, http.StatusOK, nil)
 		return resp.Payload, nil
 	}
 
 	out, execErr := execOnce(providers, req, opts)
 	if execErr == nil {
-		return out, nil
+		return rewriteResponseModelFields(out, masqueradeModel), nil
 	}
 
-	policy := apiKeyPolicyFromContext(ctx)
-	targetModel := ""
-	enabled := false
-	if policy != nil {
-		targetModel, enabled = policy.ClaudeFailoverTargetModel()
+	status := execErr.StatusCode
+	if status <= 0 {
+		status = statusFromError(execErr.Error)
 	}
-	if enabled && containsProvider(providers, "claude") && strings.TrimSpace(targetModel) != "" && targetModel != normalizedModel {
-		status := execErr.StatusCode
-		if status <= 0 {
-			status = statusFromError(execErr.Error)
-		}
-		if isClaudeFailoverEligible(status, execErr.Error) {
-			failoverPayload := rewriteModelField(rawJSON, targetModel)
-			failoverProviders, failoverModel, detailErr := h.getRequestDetails(targetModel)
-			if detailErr == nil {
-				failoverReqMeta := make(map[string]any, len(reqMeta)+1)
-				for k, v := range reqMeta {
-					failoverReqMeta[k] = v
-				}
-				failoverReqMeta[coreexecutor.RequestedModelMetadataKey] = failoverModel
-				failoverReq := coreexecutor.Request{Model: failoverModel, Payload: failoverPayload}
-				failoverOpts := opts
-				failoverOpts.OriginalRequest = failoverPayload
-				failoverOpts.Metadata = failoverReqMeta
-				failoverOut, failoverErr := execOnce(failoverProviders, failoverReq, failoverOpts)
-				if failoverErr == nil {
-					return failoverOut, nil
-				}
-				return nil, failoverErr
-			}
-			_ = detailErr
+	if failoverOut, failoverErr, handled := h.runPolicyFailoverChain(ctx, handlerType, firstProvider(providers), normalizedModel, rawJSON, reqMeta, opts, status, execErr, execOnce); handled {
+		if failoverErr == nil {
+			return rewriteResponseModelFields(failoverOut, normalizedModel), nil
 		}
+		return nil, failoverErr
 	}
 
 	return nil, execErr
@@ -809,49 +1579,55 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 // This path is the only supported execution route.
 func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) (<-chan []byte, <-chan *interfaces.ErrorMessage) {
 	reqMeta := requestExecutionMetadata(ctx)
+	// masqueradeModel is set to the client's originally requested model
+	// whenever a fallback-chain hop swaps the provider/model, so every
+	// streamed chunk can be rewritten back to it before it reaches the
+	// client.
+	masqueradeModel := ""
+
+	// Mid-stream budget guard: a streaming completion can run long enough to
+	// blow far past DailyBudgetUSD before APIKeyPolicyMiddleware's one-time,
+	// pre-request reservation is ever reconciled against real usage. When a
+	// budget applies, derive a cancellable context so the guard below can
+	// abort the upstream call as soon as the projected cost (committed spend
+	// plus this request's own running in-flight estimate) crosses it.
+	budgetAPIKey := clientAPIKeyFromContext(ctx)
+	var budgetMicroUSD int64
+	if budgetPolicy := apiKeyPolicyFromContext(ctx); budgetPolicy != nil {
+		budgetMicroUSD = int64(math.Round(budgetPolicy.DailyBudgetUSD * 1_000_000))
+	}
+	budgetGuardEnabled := h.BillingStore != nil && budgetMicroUSD > 0 && budgetAPIKey != ""
+	var cancelStream context.CancelFunc
+	if budgetGuardEnabled {
+		ctx, cancelStream = context.WithCancel(ctx)
+		defer cancelStream()
+		reqMeta[apiKeyMetadataKey] = budgetAPIKey
+	}
+	// budgetRequestToken identifies this request's own slot in
+	// h.InFlightTracker so concurrent streaming requests under budgetAPIKey
+	// each carry their own running estimate instead of clobbering one
+	// another; HandleUsage clears the same token once this request's usage
+	// is persisted. Falls back to budgetAPIKey itself (single-slot, the
+	// pre-request-ID behavior) on the rare request with no request ID.
+	budgetRequestToken := logging.GetRequestID(ctx)
+	if budgetRequestToken == "" {
+		budgetRequestToken = budgetAPIKey
+	}
+	budgetGuard := billing.NewStreamBudgetGuard(h.BillingStore, h.InFlightTracker)
+	budgetDayKey := policy.DayKeyChina(time.Now())
 	providers, normalizedModel, errMsg := h.getRequestDetails(modelName)
 	if errMsg != nil {
-		if policy := apiKeyPolicyFromContext(ctx); policy != nil {
-			targetModel, enabled := policy.ClaudeFailoverTargetModel()
-			if enabled && strings.TrimSpace(targetModel) != "" && targetModel != modelName && seemsClaudeModel(modelName) && isClaudeFailoverEligible(errMsg.StatusCode, errMsg.Error) {
-				failoverPayload := rewriteModelField(rawJSON, targetModel)
-				failoverProviders, failoverModel, detailErr := h.getRequestDetails(targetModel)
-				if detailErr == nil {
-					clientKey := util.HideAPIKey(clientAPIKeyFromContext(ctx))
-					log.WithFields(log.Fields{
-						"component":       "failover",
-						"client_api_key":  clientKey,
-						"from_provider":   "claude",
-						"from_model":      modelName,
-						"to_model":        failoverModel,
-						"status_code":     errMsg.StatusCode,
-						"error_message":   extractErrorMessage(errString(errMsg.Error)),
-						"handler_format":  handlerType,
-						"idempotency_key": reqMeta[idempotencyKeyMetadataKey],
-						"reason":          "unknown_provider",
-					}).Warn("triggering automatic failover for Claude streaming request (unknown provider)")
-
-					rawJSON = failoverPayload
-					providers = failoverProviders
-					normalizedModel = failoverModel
-				} else {
-					errChan := make(chan *interfaces.ErrorMessage, 1)
-					errChan <- detailErr
-					close(errChan)
-					return nil, errChan
-				}
-			} else {
-				errChan := make(chan *interfaces.ErrorMessage, 1)
-				errChan <- errMsg
-				close(errChan)
-				return nil, errChan
-			}
-		} else {
+		failoverProviders, failoverModel, masquerade, failoverErr := h.resolvePreflightFailoverChain(ctx, handlerType, modelName, reqMeta, errMsg)
+		if failoverErr != nil {
 			errChan := make(chan *interfaces.ErrorMessage, 1)
-			errChan <- errMsg
+			errChan <- failoverErr
 			close(errChan)
 			return nil, errChan
 		}
+		rawJSON = rewriteModelField(rawJSON, failoverModel)
+		providers = failoverProviders
+		normalizedModel = failoverModel
+		masqueradeModel = masquerade
 	}
 	reqMeta[coreexecutor.RequestedModelMetadataKey] = normalizedModel
 	payload := rawJSON
@@ -870,18 +1646,23 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 	}
 	opts.Metadata = reqMeta
 
-	var (
-		failoverTargetModel string
-		failoverEnabled     bool
-		failoverAttempted   bool
-	)
+	var failoverChain []internalconfig.FailoverTarget
 	if policy := apiKeyPolicyFromContext(ctx); policy != nil {
-		failoverTargetModel, failoverEnabled = policy.ClaudeFailoverTargetModel()
+		failoverChain = policy.FailoverChainFor(firstProvider(providers), normalizedModel)
 	}
+	failoverChainPos := 0
 
 	execStream := func(execProviders []string, execReq coreexecutor.Request, execOpts coreexecutor.Options) (<-chan coreexecutor.StreamChunk, *interfaces.ErrorMessage) {
-		stream, err := h.AuthManager.ExecuteStream(ctx, execProviders, execReq, execOpts)
+		provider := firstProvider(execProviders)
+		if breakerErr := h.checkCircuitBreaker(provider, execReq.Model); breakerErr != nil {
+			return nil, breakerErr
+		}
+		start := time.Now()
+		stream, err := executorStreamChain(ctx, execProviders, execReq, execOpts, h.AuthManager.ExecuteStream)
+		h.Metrics.ObserveUpstreamLatency(provider, execReq.Model, err != nil, time.Since(start).Seconds())
 		if err == nil {
+			h.Metrics.ObserveExecutorOutcome(provider, execReq.Model, http.StatusOK)
+			h.recordCircuitBreakerResult(provider, execReq.Model, http.StatusOK, nil)
 			return stream, nil
 		}
 		status := http.StatusInternalServerError
@@ -890,6 +1671,8 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 				status = code
 			}
 		}
+		h.Metrics.ObserveExecutorOutcome(provider, execReq.Model, status)
+		h.recordCircuitBreakerResult(provider, execReq.Model, status, err)
 		var addon http.Header
 		if he, ok := err.(interface{ Headers() http.Header }); ok && he != nil {
 			if hdr := he.Headers(); hdr != nil {
@@ -899,6 +1682,75 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 		return nil, &interfaces.ErrorMessage{StatusCode: status, Error: err, Addon: addon}
 	}
 
+	clientKey := util.HideAPIKey(clientAPIKeyFromContext(ctx))
+
+	// tryFailoverHop walks failoverChain starting at failoverChainPos,
+	// attempting the first eligible, resolvable hop against execStream and
+	// updating providers/normalizedModel/masqueradeModel/req/opts on success
+	// so the caller (and the streaming goroutine below, which closes over
+	// the same variables) continues against the new target. It is called
+	// both immediately after the initial execStream failure and, if that
+	// stream later errors before any payload byte is sent, again from inside
+	// the streaming goroutine - so a single request can walk multiple hops
+	// across both call sites. ok is false once the chain is exhausted or no
+	// remaining hop is eligible, in which case failMsg carries the last
+	// attempted (or original) failure for the caller to report.
+	tryFailoverHop := func(status int, err error) (newChunks <-chan coreexecutor.StreamChunk, failMsg *interfaces.ErrorMessage, ok bool) {
+		failMsg = &interfaces.ErrorMessage{StatusCode: status, Error: err}
+		for failoverChainPos < len(failoverChain) {
+			target := failoverChain[failoverChainPos]
+			failoverChainPos++
+			if !isFailoverEligible(failMsg.StatusCode, failMsg.Error) || !target.MatchesTriggerStatus(failMsg.StatusCode) {
+				continue
+			}
+			targetModel := strings.TrimSpace(target.TargetModel)
+			if targetModel == "" || targetModel == normalizedModel {
+				continue
+			}
+			failoverProviders, failoverModel, detailErr := h.getRequestDetails(targetModel)
+			if detailErr != nil {
+				continue
+			}
+			failoverPayload := rewriteModelField(rawJSON, targetModel)
+			failoverReqMeta := make(map[string]any, len(reqMeta)+1)
+			for k, v := range reqMeta {
+				failoverReqMeta[k] = v
+			}
+			failoverReqMeta[coreexecutor.RequestedModelMetadataKey] = failoverModel
+			failoverReq := coreexecutor.Request{Model: failoverModel, Payload: failoverPayload}
+			failoverOpts := opts
+			failoverOpts.OriginalRequest = failoverPayload
+			failoverOpts.Metadata = failoverReqMeta
+
+			log.WithFields(log.Fields{
+				"component":       "failover",
+				"client_api_key":  clientKey,
+				"from_provider":   firstProvider(providers),
+				"from_model":      normalizedModel,
+				"to_model":        failoverModel,
+				"chain_position":  failoverChainPos - 1,
+				"status_code":     failMsg.StatusCode,
+				"error_message":   extractErrorMessage(errString(failMsg.Error)),
+				"handler_format":  handlerType,
+				"idempotency_key": reqMeta[idempotencyKeyMetadataKey],
+			}).Warn("triggering automatic failover chain hop (streaming)")
+			h.Metrics.ObserveFailoverTriggered(normalizedModel, failoverModel)
+
+			retryChunks, retryExecErr := execStream(failoverProviders, failoverReq, failoverOpts)
+			if retryExecErr != nil {
+				failMsg = retryExecErr
+				continue
+			}
+			providers = failoverProviders
+			masqueradeModel = normalizedModel
+			normalizedModel = failoverModel
+			req = failoverReq
+			opts = failoverOpts
+			return retryChunks, nil, true
+		}
+		return nil, failMsg, false
+	}
+
 	chunks, execErr := execStream(providers, req, opts)
 	if execErr != nil {
 		// Immediate failure before any chunks are available - consider failover.
@@ -906,48 +1758,10 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 		if status <= 0 {
 			status = statusFromError(execErr.Error)
 		}
-		if failoverEnabled && containsProvider(providers, "claude") && failoverTargetModel != "" && failoverTargetModel != normalizedModel && isClaudeFailoverEligible(status, execErr.Error) {
-			failoverAttempted = true
-			failoverPayload := rewriteModelField(rawJSON, failoverTargetModel)
-			failoverProviders, failoverModel, detailErr := h.getRequestDetails(failoverTargetModel)
-			if detailErr == nil {
-				failoverReqMeta := make(map[string]any, len(reqMeta)+1)
-				for k, v := range reqMeta {
-					failoverReqMeta[k] = v
-				}
-				failoverReqMeta[coreexecutor.RequestedModelMetadataKey] = failoverModel
-				failoverReq := coreexecutor.Request{Model: failoverModel, Payload: failoverPayload}
-				failoverOpts := opts
-				failoverOpts.OriginalRequest = failoverPayload
-				failoverOpts.Metadata = failoverReqMeta
-
-				clientKey := util.HideAPIKey(clientAPIKeyFromContext(ctx))
-				log.WithFields(log.Fields{
-					"component":       "failover",
-					"client_api_key":  clientKey,
-					"from_provider":   "claude",
-					"from_model":      normalizedModel,
-					"to_model":        failoverModel,
-					"status_code":     status,
-					"error_message":   extractErrorMessage(errString(execErr.Error)),
-					"handler_format":  handlerType,
-					"idempotency_key": reqMeta[idempotencyKeyMetadataKey],
-				}).Warn("triggering automatic failover for Claude streaming request")
-
-				chunks, execErr = execStream(failoverProviders, failoverReq, failoverOpts)
-				if execErr != nil {
-					errChan := make(chan *interfaces.ErrorMessage, 1)
-					errChan <- execErr
-					close(errChan)
-					return nil, errChan
-				}
-				// Update live variables for below goroutine.
-				providers = failoverProviders
-				normalizedModel = failoverModel
-				req = failoverReq
-				opts = failoverOpts
-			}
-			_ = detailErr
+		if newChunks, failMsg, ok := tryFailoverHop(status, execErr.Error); ok {
+			chunks, execErr = newChunks, nil
+		} else {
+			execErr = failMsg
 		}
 		if execErr != nil {
 			errChan := make(chan *interfaces.ErrorMessage, 1)
@@ -965,6 +1779,17 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 		sentPayload := false
 		bootstrapRetries := 0
 		maxBootstrapRetries := StreamingBootstrapRetries(h.Cfg)
+		retryPolicy := h.retryPolicy()
+		bootstrapDeadline := time.Now().Add(retryPolicy.MaxElapsed)
+
+		// checkpointEnabled gates the mid-stream failover carried out below: off
+		// by default (see StreamCheckpointFailoverEnabled), a post-first-byte
+		// error is always fatal, exactly as before this was introduced.
+		checkpointEnabled := StreamCheckpointFailoverEnabled(h.Cfg)
+		var checkpoint *StreamCheckpoint
+		if checkpointEnabled {
+			checkpoint = NewStreamCheckpoint(handlerType)
+		}
 
 		sendErr := func(msg *interfaces.ErrorMessage) bool {
 			if ctx == nil {
@@ -992,20 +1817,6 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 			}
 		}
 
-		bootstrapEligible := func(err error) bool {
-			status := statusFromError(err)
-			if status == 0 {
-				return true
-			}
-			switch status {
-			case http.StatusUnauthorized, http.StatusForbidden, http.StatusPaymentRequired,
-				http.StatusRequestTimeout, http.StatusTooManyRequests:
-				return true
-			default:
-				return status >= http.StatusInternalServerError
-			}
-		}
-
 	outer:
 		for {
 			for {
@@ -1026,64 +1837,46 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 				if chunk.Err != nil {
 					streamErr := chunk.Err
 					// Safe bootstrap recovery: if the upstream fails before any payload bytes are sent,
-					// retry a few times (to allow auth rotation / transient recovery) and then attempt model fallback.
+					// retry with jittered exponential backoff (to allow auth rotation / transient
+					// recovery, without hammering a rate-limited upstream) and then attempt model
+					// fallback.
 					if !sentPayload {
-						if bootstrapRetries < maxBootstrapRetries && bootstrapEligible(streamErr) {
+						if bootstrapRetries < maxBootstrapRetries && bootstrapRetryEligible(streamErr) && time.Now().Before(bootstrapDeadline) {
+							wait := retryPolicy.backoff(bootstrapRetries, retryAfterDuration(addonFromError(streamErr)))
 							bootstrapRetries++
-							retryChunks, retryExecErr := execStream(providers, req, opts)
-							if retryExecErr == nil {
-								chunks = retryChunks
-								continue outer
-							}
-							streamErr = &statusHeadersError{err: retryExecErr.Error, code: retryExecErr.StatusCode, addon: retryExecErr.Addon}
-						}
-					}
-
-					// Optional failover: only before any payload bytes are sent.
-					if !sentPayload && !failoverAttempted && failoverEnabled && containsProvider(providers, "claude") && failoverTargetModel != "" && failoverTargetModel != normalizedModel {
-						status := statusFromError(streamErr)
-						if isClaudeFailoverEligible(status, streamErr) {
-							failoverAttempted = true
-							failoverPayload := rewriteModelField(rawJSON, failoverTargetModel)
-							failoverProviders, failoverModel, detailErr := h.getRequestDetails(failoverTargetModel)
-							if detailErr == nil {
-								failoverReqMeta := make(map[string]any, len(reqMeta)+1)
-								for k, v := range reqMeta {
-									failoverReqMeta[k] = v
-								}
-								failoverReqMeta[coreexecutor.RequestedModelMetadataKey] = failoverModel
-								failoverReq := coreexecutor.Request{Model: failoverModel, Payload: failoverPayload}
-								failoverOpts := opts
-								failoverOpts.OriginalRequest = failoverPayload
-								failoverOpts.Metadata = failoverReqMeta
-
-								clientKey := util.HideAPIKey(clientAPIKeyFromContext(ctx))
-								log.WithFields(log.Fields{
-									"component":       "failover",
-									"client_api_key":  clientKey,
-									"from_provider":   "claude",
-									"from_model":      normalizedModel,
-									"to_model":        failoverModel,
-									"status_code":     status,
-									"error_message":   extractErrorMessage(errString(streamErr)),
-									"handler_format":  handlerType,
-									"idempotency_key": reqMeta[idempotencyKeyMetadataKey],
-								}).Warn("triggering automatic failover for Claude streaming request (pre-first-byte)")
-
-								retryChunks, retryExecErr := execStream(failoverProviders, failoverReq, failoverOpts)
+							if waitForRetry(ctx, wait) {
+								attemptCtx, cancel := retryPolicy.perAttemptDeadline(ctx, time.Until(bootstrapDeadline))
+								originalCtx := ctx
+								ctx = attemptCtx
+								retryChunks, retryExecErr := execStream(providers, req, opts)
+								cancel()
+								ctx = originalCtx
 								if retryExecErr == nil {
-									// Swap state and restart outer loop on new chunks.
-									providers = failoverProviders
-									normalizedModel = failoverModel
-									req = failoverReq
-									opts = failoverOpts
 									chunks = retryChunks
-									bootstrapRetries = 0
 									continue outer
 								}
 								streamErr = &statusHeadersError{err: retryExecErr.Error, code: retryExecErr.StatusCode, addon: retryExecErr.Addon}
 							}
-							_ = detailErr
+						}
+					}
+
+					// Optional failover: before any payload bytes are sent, or, when
+					// checkpointEnabled, after - provided the checkpoint has a
+					// partial assistant turn to carry forward via
+					// InjectContinuation so the next hop continues the turn
+					// instead of the client seeing it restart.
+					checkpointedMidStream := checkpointEnabled && !checkpoint.Empty()
+					if !sentPayload || checkpointedMidStream {
+						status := statusFromError(streamErr)
+						if checkpointedMidStream {
+							rawJSON = checkpoint.InjectContinuation(rawJSON)
+						}
+						if newChunks, failMsg, ok := tryFailoverHop(status, streamErr); ok {
+							chunks = newChunks
+							bootstrapRetries = 0
+							continue outer
+						} else if failMsg != nil && failMsg.Error != nil {
+							streamErr = failMsg.Error
 						}
 					}
 
@@ -1099,12 +1892,50 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 							addon = hdr.Clone()
 						}
 					}
+					// The stream was recorded as healthy (http.StatusOK) the
+					// moment it opened in execStream; since, outside
+					// checkpoint failover, this goroutine never calls back
+					// into execStream once payload bytes have been sent, a
+					// post-first-byte failure that falls
+					// through here (not failover-eligible, or the chain is
+					// exhausted) must record its own outcome, or a provider
+					// that opens streams and then dies mid-response would
+					// look permanently healthy to the circuit breaker.
+					h.recordCircuitBreakerResult(firstProvider(providers), normalizedModel, status, streamErr)
 					_ = sendErr(&interfaces.ErrorMessage{StatusCode: status, Error: streamErr, Addon: addon})
 					return
 				}
 				if len(chunk.Payload) > 0 {
 					sentPayload = true
-					if okSendData := sendData(cloneBytes(chunk.Payload)); !okSendData {
+					payload := cloneBytes(chunk.Payload)
+					if masqueradeModel != "" {
+						payload = rewriteStreamChunkModelFields(payload, masqueradeModel)
+					}
+					if checkpointEnabled {
+						checkpoint.Append(payload)
+					}
+
+					if budgetGuardEnabled {
+						if totalTokens, ok := estimateStreamChunkTotalTokens(payload); ok {
+							if price, _, _, err := h.BillingStore.ResolvePriceMicro(ctx, normalizedModel); err == nil {
+								h.InFlightTracker.Set(budgetAPIKey, budgetRequestToken, billing.UsageCostMicroUSD(price, 0, totalTokens, 0, 0))
+							}
+						}
+						exceeded, projectedMicro, err := budgetGuard.ProjectedCostExceedsBudget(ctx, budgetAPIKey, budgetDayKey, budgetMicroUSD)
+						if err == nil && exceeded {
+							log.WithFields(log.Fields{
+								"component":       "billing",
+								"client_api_key":  util.HideAPIKey(budgetAPIKey),
+								"projected_micro": projectedMicro,
+								"budget_micro":    budgetMicroUSD,
+							}).Warn("aborting streaming request: projected cost crossed daily budget")
+							_ = sendData([]byte("event: error\ndata: {\"error\":\"daily budget exceeded\"}\n\n"))
+							cancelStream()
+							return
+						}
+					}
+
+					if okSendData := sendData(payload); !okSendData {
 						return
 					}
 				}
@@ -1126,6 +1957,15 @@ func statusFromError(err error) int {
 	return 0
 }
 
+// addonFromError extracts err's http.Header, if it carries one (e.g. a
+// Retry-After upstreams in this codebase set), or nil otherwise.
+func addonFromError(err error) http.Header {
+	if he, ok := err.(interface{ Headers() http.Header }); ok && he != nil {
+		return he.Headers()
+	}
+	return nil
+}
+
 func (h *BaseAPIHandler) getRequestDetails(modelName string) (providers []string, normalizedModel string, err *interfaces.ErrorMessage) {
 	resolvedModelName := modelName
 	initialSuffix := thinking.ParseSuffix(modelName)
@@ -1242,6 +2082,57 @@ func (h *BaseAPIHandler) WriteErrorResponse(c *gin.Context, msg *interfaces.Erro
 	_, _ = c.Writer.Write(body)
 }
 
+// WriteStreamingErrorResponse is WriteErrorResponse's counterpart for a
+// stream that has already started: headers (and often a keep-alive or
+// partial payload) have already been written, so the failure must be
+// framed as an SSE event via BuildStreamingErrorEvent rather than a plain
+// JSON body. stopKeepAlive is the stop function StartNonStreamingKeepAlive
+// returned for this request, or a no-op for a stream that never started
+// one; it is always called first so the keep-alive goroutine can't
+// interleave a blank-line tick with the error frame being written.
+func (h *BaseAPIHandler) WriteStreamingErrorResponse(c *gin.Context, handlerType string, msg *interfaces.ErrorMessage, stopKeepAlive func()) {
+	if stopKeepAlive != nil {
+		stopKeepAlive()
+	}
+	if c == nil {
+		return
+	}
+
+	status := http.StatusInternalServerError
+	var addon http.Header
+	var respErr error
+	if msg != nil {
+		if msg.StatusCode > 0 {
+			status = msg.StatusCode
+		}
+		addon = msg.Addon
+		respErr = msg.Error
+	}
+	if addon != nil {
+		for key, values := range addon {
+			if len(values) == 0 {
+				continue
+			}
+			c.Writer.Header().Del(key)
+			for _, value := range values {
+				c.Writer.Header().Add(key, value)
+			}
+		}
+	}
+
+	event := BuildStreamingErrorEvent(handlerType, status, respErr, addon)
+	appendAPIResponse(c, event)
+
+	if !c.Writer.Written() {
+		c.Writer.Header().Set("Content-Type", "text/event-stream")
+		c.Status(status)
+	}
+	_, _ = c.Writer.Write(event)
+	if flusher, ok := c.Writer.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
 func (h *BaseAPIHandler) LoggingAPIResponseError(ctx context.Context, err *interfaces.ErrorMessage) {
 	if h.Cfg.RequestLog {
 		if ginContext, ok := ctx.Value("gin").(*gin.Context); ok {
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/billing"
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+// usageChunkExecutor streams a fixed sequence of chunks, each optionally
+// carrying a cumulative OpenAI-shaped "usage.total_tokens" field, so tests
+// can exercise the mid-stream budget guard without a real upstream.
+type usageChunkExecutor struct {
+	id     string
+	chunks [][]byte
+}
+
+func (e *usageChunkExecutor) Identifier() string { return e.id }
+
+func (e *usageChunkExecutor) Execute(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, nil
+}
+
+func (e *usageChunkExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error) {
+	ch := make(chan coreexecutor.StreamChunk, len(e.chunks))
+	for _, c := range e.chunks {
+		ch <- coreexecutor.StreamChunk{Payload: bytes.Clone(c)}
+	}
+	close(ch)
+	return ch, nil
+}
+
+func (e *usageChunkExecutor) Refresh(ctx context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *usageChunkExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, nil
+}
+
+func (e *usageChunkExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "HttpRequest not implemented", HTTPStatus: http.StatusNotImplemented}
+}
+
+func TestExecuteStreamWithAuthManager_AbortsWhenProjectedCostCrossesBudget(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(&usageChunkExecutor{
+		id: "openai",
+		chunks: [][]byte{
+			[]byte(`data: {"usage":{"total_tokens":1000}}` + "\n\n"),
+			[]byte(`data: {"usage":{"total_tokens":2000000}}` + "\n\n"),
+			[]byte(`data: {"usage":{"total_tokens":3000000}}` + "\n\n"),
+		},
+	})
+
+	auth := &coreauth.Auth{ID: "openai-auth", Provider: "openai", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "gpt-5-high"}})
+	t.Cleanup(func() {
+		registry.GetGlobalRegistry().UnregisterClient(auth.ID)
+	})
+
+	store := billing.NewMemoryStore()
+	if err := store.UpsertModelPrice(context.Background(), "gpt-5-high", billing.PriceMicroUSDPer1M{Completion: 1_000_000}); err != nil {
+		t.Fatalf("UpsertModelPrice: %v", err)
+	}
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager)
+	handler.BillingStore = store
+	handler.InFlightTracker = billing.NewInFlightTracker()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`{}`)))
+	c.Set("apiKey", "client-key")
+	c.Set("apiKeyPolicy", &internalconfig.APIKeyPolicy{
+		APIKey:         "client-key",
+		DailyBudgetUSD: 1,
+	})
+
+	ctx := context.WithValue(context.Background(), "gin", c)
+	payload := []byte(`{"model":"gpt-5-high","stream":true}`)
+	dataChan, errChan := handler.ExecuteStreamWithAuthManager(ctx, "openai", "gpt-5-high", payload, "")
+
+	var got []byte
+	for chunk := range dataChan {
+		got = append(got, chunk...)
+	}
+	for msg := range errChan {
+		if msg != nil {
+			t.Fatalf("unexpected error: %+v", msg)
+		}
+	}
+
+	if !bytes.Contains(got, []byte(`"error":"daily budget exceeded"`)) {
+		t.Fatalf("expected a budget-exceeded SSE event, got %q", got)
+	}
+	if bytes.Contains(got, []byte("3000000")) {
+		t.Fatal("expected the stream to abort before the third chunk was sent")
+	}
+}
+
+func TestExecuteStreamWithAuthManager_NoBudgetPolicyNeverAborts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(&usageChunkExecutor{
+		id:     "openai",
+		chunks: [][]byte{[]byte(`data: {"usage":{"total_tokens":5000000}}` + "\n\n")},
+	})
+
+	auth := &coreauth.Auth{ID: "openai-auth", Provider: "openai", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "gpt-5-high"}})
+	t.Cleanup(func() {
+		registry.GetGlobalRegistry().UnregisterClient(auth.ID)
+	})
+
+	store := billing.NewMemoryStore()
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager)
+	handler.BillingStore = store
+	handler.InFlightTracker = billing.NewInFlightTracker()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`{}`)))
+	c.Set("apiKey", "client-key")
+	// No apiKeyPolicy set at all: budgetGuardEnabled must stay false.
+
+	ctx := context.WithValue(context.Background(), "gin", c)
+	payload := []byte(`{"model":"gpt-5-high","stream":true}`)
+	dataChan, errChan := handler.ExecuteStreamWithAuthManager(ctx, "openai", "gpt-5-high", payload, "")
+
+	var got []byte
+	for chunk := range dataChan {
+		got = append(got, chunk...)
+	}
+	for msg := range errChan {
+		if msg != nil {
+			t.Fatalf("unexpected error: %+v", msg)
+		}
+	}
+	if bytes.Contains(got, []byte("daily budget exceeded")) {
+		t.Fatal("expected no abort when the caller has no daily budget policy")
+	}
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/failover"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func TestExecuteWithAuthManager_FailoverEngineRetriesOnMatchingRule(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(&failStatusExecutor{id: "gemini", status: http.StatusTooManyRequests, msg: "rate limited"})
+	manager.RegisterExecutor(&okExecutor{id: "vertex", payload: []byte("ok")})
+
+	geminiAuth := &coreauth.Auth{ID: "gemini-auth", Provider: "gemini", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), geminiAuth); err != nil {
+		t.Fatalf("manager.Register(gemini): %v", err)
+	}
+	vertexAuth := &coreauth.Auth{ID: "vertex-auth", Provider: "vertex", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), vertexAuth); err != nil {
+		t.Fatalf("manager.Register(vertex): %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(geminiAuth.ID, geminiAuth.Provider, []*registry.ModelInfo{{ID: "gemini-2.5-pro"}})
+	registry.GetGlobalRegistry().RegisterClient(vertexAuth.ID, vertexAuth.Provider, []*registry.ModelInfo{{ID: "gemini-2.5-pro"}})
+	t.Cleanup(func() {
+		registry.GetGlobalRegistry().UnregisterClient(geminiAuth.ID)
+		registry.GetGlobalRegistry().UnregisterClient(vertexAuth.ID)
+	})
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager)
+	handler.SetFailoverEngine(failover.NewDefaultEngine())
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`{}`)))
+	c.Set("apiKey", "client-key")
+
+	ctx := context.WithValue(context.Background(), "gin", c)
+	payload := []byte(`{"model":"gemini-2.5-pro","stream":false}`)
+	resp, errMsg := handler.ExecuteWithAuthManager(ctx, "gemini", "gemini-2.5-pro", payload, "")
+	if errMsg != nil {
+		t.Fatalf("expected nil error, got: %+v", errMsg)
+	}
+	if string(resp) != "ok" {
+		t.Fatalf("expected ok, got %q", string(resp))
+	}
+}
+
+func TestExecuteWithAuthManager_NilFailoverEngineLeavesErrorUnchanged(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(&failStatusExecutor{id: "gemini", status: http.StatusTooManyRequests, msg: "rate limited"})
+
+	geminiAuth := &coreauth.Auth{ID: "gemini-auth-2", Provider: "gemini", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), geminiAuth); err != nil {
+		t.Fatalf("manager.Register(gemini): %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(geminiAuth.ID, geminiAuth.Provider, []*registry.ModelInfo{{ID: "gemini-2.5-pro"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(geminiAuth.ID) })
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager)
+	// FailoverEngine intentionally left nil: existing deployments that never
+	// call SetFailoverEngine must see the same 429 they always did.
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`{}`)))
+	c.Set("apiKey", "client-key")
+
+	ctx := context.WithValue(context.Background(), "gin", c)
+	payload := []byte(`{"model":"gemini-2.5-pro","stream":false}`)
+	_, errMsg := handler.ExecuteWithAuthManager(ctx, "gemini", "gemini-2.5-pro", payload, "")
+	if errMsg == nil || errMsg.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 error, got: %+v", errMsg)
+	}
+}
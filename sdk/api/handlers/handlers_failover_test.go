@@ -192,6 +192,67 @@ func TestExecuteWithAuthManager_ClaudeFailoverDisabled(t *testing.T) {
 	}
 }
 
+func TestExecuteWithAuthManager_MultiHopFallbackChainWalksPastFirstFailure(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(&failStatusExecutor{id: "gemini", status: http.StatusTooManyRequests, msg: "rate limited"})
+	manager.RegisterExecutor(&failStatusExecutor{id: "claude", status: http.StatusTooManyRequests, msg: "weekly cap"})
+	manager.RegisterExecutor(&okExecutor{id: "codex", payload: []byte("chained-ok")})
+
+	geminiAuth := &coreauth.Auth{ID: "gemini-auth", Provider: "gemini", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), geminiAuth); err != nil {
+		t.Fatalf("manager.Register(gemini): %v", err)
+	}
+	claudeAuth := &coreauth.Auth{ID: "claude-auth", Provider: "claude", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), claudeAuth); err != nil {
+		t.Fatalf("manager.Register(claude): %v", err)
+	}
+	codexAuth := &coreauth.Auth{ID: "codex-auth", Provider: "codex", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), codexAuth); err != nil {
+		t.Fatalf("manager.Register(codex): %v", err)
+	}
+
+	registry.GetGlobalRegistry().RegisterClient(geminiAuth.ID, geminiAuth.Provider, []*registry.ModelInfo{{ID: "gemini-model"}})
+	registry.GetGlobalRegistry().RegisterClient(claudeAuth.ID, claudeAuth.Provider, []*registry.ModelInfo{{ID: "claude-mid"}})
+	registry.GetGlobalRegistry().RegisterClient(codexAuth.ID, codexAuth.Provider, []*registry.ModelInfo{{ID: "codex-ok"}})
+	t.Cleanup(func() {
+		registry.GetGlobalRegistry().UnregisterClient(geminiAuth.ID)
+		registry.GetGlobalRegistry().UnregisterClient(claudeAuth.ID)
+		registry.GetGlobalRegistry().UnregisterClient(codexAuth.ID)
+	})
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`{}`)))
+	c.Set("apiKey", "client-key")
+	c.Set("apiKeyPolicy", &internalconfig.APIKeyPolicy{
+		APIKey: "client-key",
+		Failover: internalconfig.APIKeyFailoverPolicy{
+			Providers: map[string]internalconfig.ProviderFailoverPolicy{
+				"gemini": {
+					Enabled: true,
+					Fallbacks: []internalconfig.FailoverTarget{
+						{TargetModel: "claude-mid", TriggerStatuses: []int{http.StatusTooManyRequests}},
+						{TargetModel: "codex-ok", TriggerStatuses: []int{http.StatusTooManyRequests}},
+					},
+				},
+			},
+		},
+	})
+
+	ctx := context.WithValue(context.Background(), "gin", c)
+	payload := []byte(`{"model":"gemini-model","stream":false}`)
+	resp, errMsg := handler.ExecuteWithAuthManager(ctx, "gemini", "gemini-model", payload, "")
+	if errMsg != nil {
+		t.Fatalf("expected nil error, got: %+v", errMsg)
+	}
+	if string(resp) != "chained-ok" {
+		t.Fatalf("expected chained-ok, got %q", string(resp))
+	}
+}
+
 func TestExecuteStreamWithAuthManager_ClaudeFailoverBeforeFirstByte(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	manager := coreauth.NewManager(nil, nil, nil)
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/idempotency"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+// countingExecutor wraps okExecutor, counting how many times Execute ran so
+// a test can assert a replayed request never reached the upstream call.
+type countingExecutor struct {
+	okExecutor
+	calls atomic.Int64
+}
+
+func (e *countingExecutor) Execute(ctx context.Context, auth *coreauth.Auth, req coreexecutor.Request, opts coreexecutor.Options) (coreexecutor.Response, error) {
+	e.calls.Add(1)
+	return e.okExecutor.Execute(ctx, auth, req, opts)
+}
+
+func newIdempotencyTestHandler(t *testing.T, executor *countingExecutor) (*BaseAPIHandler, *gin.Context) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+
+	auth := &coreauth.Auth{ID: "codex-auth", Provider: "codex", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "gpt-5.2"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager)
+	handler.IdempotencyCache = idempotency.NewMemoryCache()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`{}`)))
+	c.Request.Header.Set("Idempotency-Key", "retry-1")
+	c.Set("apiKey", "client-key")
+	return handler, c
+}
+
+func TestExecuteWithAuthManager_IdempotencyKeyReplaysWithoutReexecuting(t *testing.T) {
+	executor := &countingExecutor{okExecutor: okExecutor{id: "codex", payload: []byte(`{"id":"resp-1"}`)}}
+	handler, c := newIdempotencyTestHandler(t, executor)
+	ctx := context.WithValue(context.Background(), "gin", c)
+	payload := []byte(`{"model":"gpt-5.2","stream":false}`)
+
+	first, errMsg := handler.ExecuteWithAuthManager(ctx, "openai", "gpt-5.2", payload, "")
+	if errMsg != nil {
+		t.Fatalf("first call: expected nil error, got %+v", errMsg)
+	}
+
+	second, errMsg := handler.ExecuteWithAuthManager(ctx, "openai", "gpt-5.2", payload, "")
+	if errMsg != nil {
+		t.Fatalf("second call: expected nil error, got %+v", errMsg)
+	}
+	if string(second) != string(first) {
+		t.Fatalf("replayed response=%q, want %q", second, first)
+	}
+	if got := executor.calls.Load(); got != 1 {
+		t.Fatalf("executor.calls=%d, want 1 (second request should replay from cache)", got)
+	}
+}
+
+func TestExecuteWithAuthManager_DifferentApiKeySameHeaderDoesNotReplay(t *testing.T) {
+	executor := &countingExecutor{okExecutor: okExecutor{id: "codex", payload: []byte(`{"id":"resp-1"}`)}}
+	handler, c := newIdempotencyTestHandler(t, executor)
+	ctx := context.WithValue(context.Background(), "gin", c)
+	payload := []byte(`{"model":"gpt-5.2","stream":false}`)
+
+	if _, errMsg := handler.ExecuteWithAuthManager(ctx, "openai", "gpt-5.2", payload, ""); errMsg != nil {
+		t.Fatalf("first call: expected nil error, got %+v", errMsg)
+	}
+
+	c.Set("apiKey", "other-client-key")
+	if _, errMsg := handler.ExecuteWithAuthManager(ctx, "openai", "gpt-5.2", payload, ""); errMsg != nil {
+		t.Fatalf("second call: expected nil error, got %+v", errMsg)
+	}
+	if got := executor.calls.Load(); got != 2 {
+		t.Fatalf("executor.calls=%d, want 2 (different api key must not share a cache entry)", got)
+	}
+}
@@ -395,3 +395,139 @@ func TestExecuteWithAuthManager_NoFailoverModelUntouched(t *testing.T) {
 		t.Errorf("expected model=claude-opus-4-6 (no failover), got %q", gotModel)
 	}
 }
+
+// ---------------------------------------------------------------------------
+// Integration: Claude -> Gemini failover rewrites Gemini's modelVersion shape
+// ---------------------------------------------------------------------------
+
+func TestExecuteWithAuthManager_FailoverToGeminiRewritesModelVersion(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager := coreauth.NewManager(nil, nil, nil)
+
+	// Claude executor fails with 429
+	manager.RegisterExecutor(&failStatusExecutor{id: "claude", status: http.StatusTooManyRequests, msg: "weekly cap"})
+	// Gemini executor succeeds with a response in Gemini's own shape: top-level
+	// modelVersion plus a per-candidate modelVersion.
+	failoverResp := []byte(`{"modelVersion":"gemini-2.0-flash","candidates":[{"modelVersion":"gemini-2.0-flash","content":{"parts":[{"text":"hi"}]}}]}`)
+	manager.RegisterExecutor(&okExecutor{id: "gemini", payload: failoverResp})
+
+	claudeAuth := &coreauth.Auth{ID: "claude-auth-gmr", Provider: "claude", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), claudeAuth); err != nil {
+		t.Fatalf("register claude: %v", err)
+	}
+	geminiAuth := &coreauth.Auth{ID: "gemini-auth-gmr", Provider: "gemini", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), geminiAuth); err != nil {
+		t.Fatalf("register gemini: %v", err)
+	}
+
+	registry.GetGlobalRegistry().RegisterClient(claudeAuth.ID, claudeAuth.Provider, []*registry.ModelInfo{{ID: "claude-opus-4-6"}})
+	registry.GetGlobalRegistry().RegisterClient(geminiAuth.ID, geminiAuth.Provider, []*registry.ModelInfo{{ID: "gemini-2.0-flash"}})
+	t.Cleanup(func() {
+		registry.GetGlobalRegistry().UnregisterClient(claudeAuth.ID)
+		registry.GetGlobalRegistry().UnregisterClient(geminiAuth.ID)
+	})
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`{}`)))
+	c.Set("apiKey", "client-key")
+	c.Set("apiKeyPolicy", &internalconfig.APIKeyPolicy{
+		APIKey: "client-key",
+		Failover: internalconfig.APIKeyFailoverPolicy{
+			Claude: internalconfig.ProviderFailoverPolicy{
+				Enabled:     true,
+				TargetModel: "gemini-2.0-flash",
+			},
+		},
+	})
+
+	ctx := context.WithValue(context.Background(), "gin", c)
+	payload := []byte(`{"model":"claude-opus-4-6","stream":false}`)
+	resp, errMsg := handler.ExecuteWithAuthManager(ctx, "claude", "claude-opus-4-6", payload, "")
+	if errMsg != nil {
+		t.Fatalf("expected nil error, got: %+v", errMsg)
+	}
+
+	if got := gjson.GetBytes(resp, "modelVersion").String(); got != "claude-opus-4-6" {
+		t.Errorf("expected top-level modelVersion=claude-opus-4-6, got %q (failover model leaked)", got)
+	}
+	if got := gjson.GetBytes(resp, "candidates.0.modelVersion").String(); got != "claude-opus-4-6" {
+		t.Errorf("expected candidates.0.modelVersion=claude-opus-4-6, got %q (failover model leaked)", got)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Integration: Claude -> OpenAI failover rewrites streamed Chat Completions
+// per-choice model field
+// ---------------------------------------------------------------------------
+
+func TestExecuteStreamWithAuthManager_FailoverRewritesChatCompletionsChoices(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager := coreauth.NewManager(nil, nil, nil)
+
+	// Claude executor fails with 429
+	manager.RegisterExecutor(&failStatusExecutor{id: "claude", status: http.StatusTooManyRequests, msg: "rolling cap"})
+	// Codex executor succeeds with an OpenAI Chat Completions streaming delta,
+	// which carries the model on each choice rather than (or in addition to)
+	// the top level.
+	streamChunk := []byte(`{"id":"chatcmpl-1","model":"gpt-5.2","choices":[{"index":0,"delta":{"content":"hi"},"model":"gpt-5.2"}]}`)
+	manager.RegisterExecutor(&okExecutor{id: "codex", payload: streamChunk})
+
+	claudeAuth := &coreauth.Auth{ID: "claude-auth-cc", Provider: "claude", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), claudeAuth); err != nil {
+		t.Fatalf("register claude: %v", err)
+	}
+	codexAuth := &coreauth.Auth{ID: "codex-auth-cc", Provider: "codex", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), codexAuth); err != nil {
+		t.Fatalf("register codex: %v", err)
+	}
+
+	registry.GetGlobalRegistry().RegisterClient(claudeAuth.ID, claudeAuth.Provider, []*registry.ModelInfo{{ID: "claude-opus-4-6"}})
+	registry.GetGlobalRegistry().RegisterClient(codexAuth.ID, codexAuth.Provider, []*registry.ModelInfo{{ID: "gpt-5.2"}})
+	t.Cleanup(func() {
+		registry.GetGlobalRegistry().UnregisterClient(claudeAuth.ID)
+		registry.GetGlobalRegistry().UnregisterClient(codexAuth.ID)
+	})
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`{}`)))
+	c.Set("apiKey", "client-key")
+	c.Set("apiKeyPolicy", &internalconfig.APIKeyPolicy{
+		APIKey: "client-key",
+		Failover: internalconfig.APIKeyFailoverPolicy{
+			Claude: internalconfig.ProviderFailoverPolicy{
+				Enabled:     true,
+				TargetModel: "gpt-5.2(high)",
+			},
+		},
+	})
+
+	ctx := context.WithValue(context.Background(), "gin", c)
+	payload := []byte(`{"model":"claude-opus-4-6","stream":true}`)
+	dataChan, errChan := handler.ExecuteStreamWithAuthManager(ctx, "claude", "claude-opus-4-6", payload, "")
+	if dataChan == nil || errChan == nil {
+		t.Fatalf("expected non-nil channels")
+	}
+
+	var got []byte
+	for chunk := range dataChan {
+		got = append(got, chunk...)
+	}
+	for msg := range errChan {
+		if msg != nil {
+			t.Fatalf("unexpected error: %+v", msg)
+		}
+	}
+
+	if gotModel := gjson.GetBytes(got, "model").String(); gotModel != "claude-opus-4-6" {
+		t.Errorf("expected streamed model=claude-opus-4-6, got %q (failover model leaked)", gotModel)
+	}
+	if gotModel := gjson.GetBytes(got, "choices.0.model").String(); gotModel != "claude-opus-4-6" {
+		t.Errorf("expected streamed choices.0.model=claude-opus-4-6, got %q (failover model leaked)", gotModel)
+	}
+}
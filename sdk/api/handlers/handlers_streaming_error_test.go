@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/tidwall/gjson"
+)
+
+// ---------------------------------------------------------------------------
+// Unit tests for BuildStreamingErrorEvent / WriteStreamingErrorResponse
+// ---------------------------------------------------------------------------
+
+func TestBuildStreamingErrorEvent_Claude(t *testing.T) {
+	event := BuildStreamingErrorEvent("claude", http.StatusTooManyRequests, errors.New("rate limited"), nil)
+
+	s := string(event)
+	if !strings.HasPrefix(s, "event: error\ndata: ") {
+		t.Fatalf("unexpected framing: %q", s)
+	}
+	if !strings.HasSuffix(s, "\n\n") {
+		t.Fatalf("expected frame to end with a blank line, got %q", s)
+	}
+	data := strings.TrimSuffix(strings.TrimPrefix(s, "event: error\ndata: "), "\n\n")
+	if got := gjson.Get(data, "error.message").String(); got != "rate limited" {
+		t.Errorf("error.message = %q, want %q", got, "rate limited")
+	}
+}
+
+func TestBuildStreamingErrorEvent_Gemini(t *testing.T) {
+	event := BuildStreamingErrorEvent("gemini", http.StatusInternalServerError, errors.New("upstream failure"), nil)
+
+	s := string(event)
+	if strings.Contains(s, "event:") {
+		t.Fatalf("gemini framing must not carry an event: line, got %q", s)
+	}
+	if strings.Contains(s, "[DONE]") {
+		t.Fatalf("gemini framing must not carry an OpenAI [DONE] sentinel, got %q", s)
+	}
+	if !strings.HasPrefix(s, "data: ") || !strings.HasSuffix(s, "\n\n") {
+		t.Fatalf("unexpected framing: %q", s)
+	}
+}
+
+func TestBuildStreamingErrorEvent_OpenAIChatCompletionsEndsWithDone(t *testing.T) {
+	event := BuildStreamingErrorEvent("openai", http.StatusBadGateway, errors.New("boom"), nil)
+
+	s := string(event)
+	if !strings.HasSuffix(s, "data: [DONE]\n\n") {
+		t.Fatalf("expected stream to terminate with the [DONE] sentinel, got %q", s)
+	}
+}
+
+func TestBuildStreamingErrorEvent_RetryAfterHeaderBecomesRetryAfterMs(t *testing.T) {
+	addon := http.Header{"Retry-After": []string{"3"}}
+	event := BuildStreamingErrorEvent("openai", http.StatusTooManyRequests, errors.New("slow down"), addon)
+
+	data := strings.TrimSuffix(strings.TrimPrefix(string(event), "data: "), "\ndata: [DONE]\n\n")
+	if got := gjson.Get(data, "error.retry_after_ms").Int(); got != 3000 {
+		t.Errorf("error.retry_after_ms = %d, want 3000", got)
+	}
+}
+
+func TestBuildStreamingErrorEvent_NoRetryAfterOmitsField(t *testing.T) {
+	event := BuildStreamingErrorEvent("openai", http.StatusInternalServerError, errors.New("boom"), nil)
+
+	data := strings.TrimSuffix(strings.TrimPrefix(string(event), "data: "), "\ndata: [DONE]\n\n")
+	if gjson.Get(data, "error.retry_after_ms").Exists() {
+		t.Errorf("expected no retry_after_ms field, got %q", data)
+	}
+}
+
+func TestWriteStreamingErrorResponse_StopsKeepAliveBeforeWriting(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", nil)
+
+	var stopped bool
+	h := &BaseAPIHandler{}
+	h.WriteStreamingErrorResponse(c, "claude", &interfaces.ErrorMessage{
+		StatusCode: http.StatusServiceUnavailable,
+		Error:      errors.New("no auth available"),
+	}, func() { stopped = true })
+
+	if !stopped {
+		t.Fatal("expected stopKeepAlive to be called")
+	}
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+	if !strings.HasPrefix(w.Body.String(), "event: error\ndata: ") {
+		t.Errorf("unexpected body: %q", w.Body.String())
+	}
+}
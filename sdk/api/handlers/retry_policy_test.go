@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func TestRetryPolicy_BackoffGrowsByMultiplierAndRespectsMaxBackoff(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond, Multiplier: 2, Jitter: 0}
+	if got := p.backoff(0, 0); got != 100*time.Millisecond {
+		t.Fatalf("attempt 0 backoff = %v, want 100ms", got)
+	}
+	if got := p.backoff(1, 0); got != 200*time.Millisecond {
+		t.Fatalf("attempt 1 backoff = %v, want 200ms", got)
+	}
+	if got := p.backoff(3, 0); got != 300*time.Millisecond {
+		t.Fatalf("attempt 3 backoff = %v, want capped at 300ms", got)
+	}
+}
+
+func TestRetryPolicy_BackoffPrefersRetryAfter(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 2}
+	if got := p.backoff(0, 5*time.Second); got != 5*time.Second {
+		t.Fatalf("backoff = %v, want the Retry-After value of 5s", got)
+	}
+}
+
+func TestRetryPolicy_WithDefaultsFillsUnsetFields(t *testing.T) {
+	got := RetryPolicy{}.withDefaults()
+	if got != defaultRetryPolicy {
+		t.Fatalf("withDefaults() = %+v, want %+v", got, defaultRetryPolicy)
+	}
+}
+
+func TestBootstrapRetryEligible(t *testing.T) {
+	cases := []struct {
+		status int
+		want   bool
+	}{
+		{0, true},
+		{http.StatusTooManyRequests, true},
+		{http.StatusUnauthorized, true},
+		{http.StatusServiceUnavailable, true},
+		{http.StatusBadRequest, false},
+		{http.StatusNotFound, false},
+	}
+	for _, tc := range cases {
+		err := &statusHeadersError{err: context.DeadlineExceeded, code: tc.status}
+		if tc.status == 0 {
+			err = nil
+		}
+		if got := bootstrapRetryEligible(err); got != tc.want {
+			t.Errorf("bootstrapRetryEligible(status=%d) = %v, want %v", tc.status, got, tc.want)
+		}
+	}
+}
+
+type flakyExecutor struct {
+	id        string
+	failTimes int
+	status    int
+	msg       string
+	payload   []byte
+	attempts  atomic.Int32
+}
+
+func (e *flakyExecutor) Identifier() string { return e.id }
+
+func (e *flakyExecutor) Execute(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	n := e.attempts.Add(1)
+	if int(n) <= e.failTimes {
+		return coreexecutor.Response{}, &coreauth.Error{Code: "upstream_error", Message: e.msg, HTTPStatus: e.status}
+	}
+	return coreexecutor.Response{Payload: e.payload}, nil
+}
+
+func (e *flakyExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "ExecuteStream not implemented", HTTPStatus: http.StatusNotImplemented}
+}
+
+func (e *flakyExecutor) Refresh(ctx context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *flakyExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, nil
+}
+
+func (e *flakyExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "HttpRequest not implemented", HTTPStatus: http.StatusNotImplemented}
+}
+
+func TestExecuteWithAuthManager_RetriesBootstrapEligibleFailureBeforeFailover(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager := coreauth.NewManager(nil, nil, nil)
+	exec := &flakyExecutor{
+		id:        "gemini",
+		failTimes: 2,
+		status:    http.StatusTooManyRequests,
+		msg:       "rate limited",
+		payload:   []byte("recovered"),
+	}
+	manager.RegisterExecutor(exec)
+
+	geminiAuth := &coreauth.Auth{ID: "gemini-auth", Provider: "gemini", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), geminiAuth); err != nil {
+		t.Fatalf("manager.Register(gemini): %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(geminiAuth.ID, geminiAuth.Provider, []*registry.ModelInfo{{ID: "gemini-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(geminiAuth.ID) })
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager)
+	handler.RetryPolicy = RetryPolicy{InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond, Multiplier: 2, MaxElapsed: time.Second}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`{}`)))
+	c.Set("apiKey", "client-key")
+
+	ctx := context.WithValue(context.Background(), "gin", c)
+	payload := []byte(`{"model":"gemini-model","stream":false}`)
+	resp, errMsg := handler.ExecuteWithAuthManager(ctx, "gemini", "gemini-model", payload, "")
+	if errMsg != nil {
+		t.Fatalf("expected nil error after bootstrap retries, got: %+v", errMsg)
+	}
+	if string(resp) != "recovered" {
+		t.Fatalf("resp = %q, want %q", string(resp), "recovered")
+	}
+	if got := exec.attempts.Load(); got != 3 {
+		t.Fatalf("attempts = %d, want 3 (2 failures + 1 success)", got)
+	}
+}
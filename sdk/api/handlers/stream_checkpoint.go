@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// streamCheckpointTextPaths is the gjson path, per client-facing
+// handlerType, that one SSE "data: " line's incremental assistant text
+// lives at. Only the shapes StreamCheckpoint.InjectContinuation knows how
+// to carry forward are listed; any other handlerType is simply never
+// checkpointed (Append and InjectContinuation become no-ops).
+var streamCheckpointTextPaths = map[string]string{
+	"claude": "delta.text",
+	"openai": "choices.0.delta.content",
+}
+
+// StreamCheckpoint accumulates the assistant text one streaming request has
+// already sent to the client, so ExecuteStreamWithAuthManager can carry it
+// forward across a mid-stream, failover-eligible error (see
+// InjectContinuation) instead of treating any error after the first payload
+// byte as fatal. Only meaningful when StreamCheckpointFailoverEnabled; a nil
+// *StreamCheckpoint is always a safe no-op.
+type StreamCheckpoint struct {
+	handlerType string
+	text        strings.Builder
+
+	// injectedCount is how many trailing messages the last InjectContinuation
+	// call appended to carry c's partial turn forward: 1 for "claude"
+	// (prefilled assistant turn), 2 for every other handlerType (assistant
+	// turn plus a user continuation instruction), or 0 before the first
+	// call. A second consecutive mid-stream failover strips exactly this
+	// many messages back off before re-injecting, so the next hop's request
+	// ends with one up-to-date continuation block instead of accumulating a
+	// stale one from every prior hop.
+	injectedCount int
+}
+
+// NewStreamCheckpoint returns a StreamCheckpoint that extracts assistant
+// text from chunks already rendered in handlerType's client-facing SSE
+// shape.
+func NewStreamCheckpoint(handlerType string) *StreamCheckpoint {
+	return &StreamCheckpoint{handlerType: handlerType}
+}
+
+// Append records one outgoing SSE chunk's incremental assistant text, if
+// any. Chunks that carry no text at the known path for c's handlerType
+// (e.g. a message_start/message_stop envelope) are ignored.
+func (c *StreamCheckpoint) Append(chunk []byte) {
+	if c == nil || len(chunk) == 0 {
+		return
+	}
+	path, ok := streamCheckpointTextPaths[c.handlerType]
+	if !ok {
+		return
+	}
+	for _, line := range bytes.Split(chunk, []byte("\n")) {
+		line = bytes.TrimPrefix(line, []byte("data: "))
+		if len(line) == 0 || !gjson.ValidBytes(line) {
+			continue
+		}
+		if v := gjson.GetBytes(line, path); v.Exists() && v.String() != "" {
+			c.text.WriteString(v.String())
+		}
+	}
+}
+
+// Text returns every assistant text fragment recorded so far, concatenated
+// in arrival order.
+func (c *StreamCheckpoint) Text() string {
+	if c == nil {
+		return ""
+	}
+	return c.text.String()
+}
+
+// Empty reports whether no text has been recorded yet, in which case a
+// mid-stream error has nothing to carry forward and should be handled
+// exactly like a pre-first-byte one.
+func (c *StreamCheckpoint) Empty() bool {
+	return c == nil || c.text.Len() == 0
+}
+
+// InjectContinuation rewrites rawJSON's message list so the next provider
+// in the fallback chain continues c's partial assistant turn instead of
+// restarting it: the partial text is set as a trailing assistant message,
+// which Anthropic (handlerType "claude") treats as a prefilled turn to
+// complete. Every other handlerType doesn't support a prefilled assistant
+// turn, so it is approximated with the same trailing assistant message
+// followed by a short user continuation instruction. If a previous hop
+// already called InjectContinuation on this same checkpoint, that hop's
+// trailing message(s) are stripped before the new ones are written, so a
+// second (or later) consecutive mid-stream failover rewrites the
+// continuation in place instead of appending another one on top of it.
+// Returns rawJSON unchanged if c has nothing recorded, or if rawJSON has no
+// "messages" array to rewrite.
+func (c *StreamCheckpoint) InjectContinuation(rawJSON []byte) []byte {
+	if c.Empty() || len(rawJSON) == 0 {
+		return rawJSON
+	}
+	messages := gjson.GetBytes(rawJSON, "messages")
+	if !messages.IsArray() {
+		return rawJSON
+	}
+	all := messages.Array()
+	priorInjected := c.injectedCount
+	if priorInjected > len(all) {
+		priorInjected = 0
+	}
+	base := all[:len(all)-priorInjected]
+	rewritten := make([]any, len(base), len(base)+2)
+	for i, m := range base {
+		rewritten[i] = m.Value()
+	}
+	rewritten = append(rewritten, map[string]any{
+		"role":    "assistant",
+		"content": c.Text(),
+	})
+	injectedCount := 1
+	if c.handlerType != "claude" {
+		rewritten = append(rewritten, map[string]any{
+			"role":    "user",
+			"content": "Continue your previous response exactly where it left off. Do not repeat any text already written.",
+		})
+		injectedCount = 2
+	}
+	out, err := sjson.SetBytes(rawJSON, "messages", rewritten)
+	if err != nil {
+		return rawJSON
+	}
+	c.injectedCount = injectedCount
+	return out
+}
@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func TestStreamCheckpoint_AppendAccumulatesClaudeDeltaText(t *testing.T) {
+	c := NewStreamCheckpoint("claude")
+	if !c.Empty() {
+		t.Fatal("expected new checkpoint to be empty")
+	}
+	c.Append([]byte(`data: {"delta":{"text":"Hello, "}}` + "\n\n"))
+	c.Append([]byte(`data: {"type":"message_stop"}` + "\n\n"))
+	c.Append([]byte(`data: {"delta":{"text":"world"}}` + "\n\n"))
+	if c.Empty() {
+		t.Fatal("expected checkpoint to be non-empty after appending delta text")
+	}
+	if got := c.Text(); got != "Hello, world" {
+		t.Fatalf("Text() = %q, want %q", got, "Hello, world")
+	}
+}
+
+func TestStreamCheckpoint_InjectContinuationClaudePrefillsAssistantTurn(t *testing.T) {
+	c := NewStreamCheckpoint("claude")
+	c.Append([]byte(`data: {"delta":{"text":"partial answer"}}` + "\n\n"))
+
+	out := c.InjectContinuation([]byte(`{"model":"claude-model","messages":[{"role":"user","content":"hi"}]}`))
+
+	last := gjsonLastMessage(t, out)
+	if last.Get("role").String() != "assistant" || last.Get("content").String() != "partial answer" {
+		t.Fatalf("unexpected trailing message: %s", last.Raw)
+	}
+}
+
+func TestStreamCheckpoint_InjectContinuationOpenAIAppendsContinuationPrompt(t *testing.T) {
+	c := NewStreamCheckpoint("openai")
+	c.Append([]byte(`data: {"choices":[{"delta":{"content":"partial"}}]}` + "\n\n"))
+
+	out := c.InjectContinuation([]byte(`{"model":"gpt","messages":[{"role":"user","content":"hi"}]}`))
+
+	last := gjsonLastMessage(t, out)
+	if last.Get("role").String() != "user" {
+		t.Fatalf("expected a trailing user continuation message, got: %s", last.Raw)
+	}
+}
+
+func TestStreamCheckpoint_InjectContinuationSecondFailoverRewritesInPlace(t *testing.T) {
+	c := NewStreamCheckpoint("claude")
+	c.Append([]byte(`data: {"delta":{"text":"first hop "}}` + "\n\n"))
+	raw := []byte(`{"model":"claude-model","messages":[{"role":"user","content":"hi"}]}`)
+
+	afterFirstHop := c.InjectContinuation(raw)
+	if messages := gjson.GetBytes(afterFirstHop, "messages").Array(); len(messages) != 2 {
+		t.Fatalf("after first hop, messages = %d, want 2 (original user + 1 injected)", len(messages))
+	}
+
+	c.Append([]byte(`data: {"delta":{"text":"second hop"}}` + "\n\n"))
+	afterSecondHop := c.InjectContinuation(afterFirstHop)
+
+	messages := gjson.GetBytes(afterSecondHop, "messages").Array()
+	if len(messages) != 2 {
+		t.Fatalf("after second consecutive failover, messages = %d, want 2 (original user + 1 rewritten), got: %s", len(messages), afterSecondHop)
+	}
+	last := messages[len(messages)-1]
+	want := "first hop second hop"
+	if last.Get("role").String() != "assistant" || last.Get("content").String() != want {
+		t.Fatalf("trailing message = %s, want role=assistant content=%q", last.Raw, want)
+	}
+}
+
+func TestStreamCheckpoint_InjectContinuationNoopWhenEmpty(t *testing.T) {
+	c := NewStreamCheckpoint("claude")
+	raw := []byte(`{"messages":[{"role":"user","content":"hi"}]}`)
+	if out := c.InjectContinuation(raw); !bytes.Equal(out, raw) {
+		t.Fatalf("expected unchanged rawJSON for an empty checkpoint, got: %s", out)
+	}
+}
+
+func gjsonLastMessage(t *testing.T, rawJSON []byte) gjson.Result {
+	t.Helper()
+	messages := gjson.GetBytes(rawJSON, "messages")
+	if !messages.IsArray() {
+		t.Fatalf("expected messages array in %s", rawJSON)
+	}
+	arr := messages.Array()
+	if len(arr) == 0 {
+		t.Fatalf("expected non-empty messages array in %s", rawJSON)
+	}
+	return arr[len(arr)-1]
+}
+
+type midStreamFailExecutor struct {
+	id      string
+	payload []byte
+	status  int
+	msg     string
+}
+
+func (e *midStreamFailExecutor) Identifier() string { return e.id }
+
+func (e *midStreamFailExecutor) Execute(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "upstream_error", Message: e.msg, HTTPStatus: e.status}
+}
+
+func (e *midStreamFailExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error) {
+	ch := make(chan coreexecutor.StreamChunk, 2)
+	ch <- coreexecutor.StreamChunk{Payload: bytes.Clone(e.payload)}
+	ch <- coreexecutor.StreamChunk{Err: &coreauth.Error{Code: "upstream_error", Message: e.msg, HTTPStatus: e.status}}
+	close(ch)
+	return ch, nil
+}
+
+func (e *midStreamFailExecutor) Refresh(ctx context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *midStreamFailExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, nil
+}
+
+func (e *midStreamFailExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "HttpRequest not implemented", HTTPStatus: http.StatusNotImplemented}
+}
+
+func TestExecuteStreamWithAuthManager_CheckpointFailoverSurvivesMidStreamError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(&midStreamFailExecutor{
+		id:      "claude",
+		payload: []byte(`data: {"delta":{"text":"partial"}}` + "\n\n"),
+		status:  http.StatusServiceUnavailable,
+		msg:     "upstream reset mid-stream",
+	})
+	manager.RegisterExecutor(&okExecutor{id: "codex", payload: []byte("resumed")})
+
+	claudeAuth := &coreauth.Auth{ID: "claude-auth", Provider: "claude", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), claudeAuth); err != nil {
+		t.Fatalf("manager.Register(claude): %v", err)
+	}
+	codexAuth := &coreauth.Auth{ID: "codex-auth", Provider: "codex", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), codexAuth); err != nil {
+		t.Fatalf("manager.Register(codex): %v", err)
+	}
+
+	registry.GetGlobalRegistry().RegisterClient(claudeAuth.ID, claudeAuth.Provider, []*registry.ModelInfo{{ID: "claude-model"}})
+	registry.GetGlobalRegistry().RegisterClient(codexAuth.ID, codexAuth.Provider, []*registry.ModelInfo{{ID: "gpt-5.2"}})
+	t.Cleanup(func() {
+		registry.GetGlobalRegistry().UnregisterClient(claudeAuth.ID)
+		registry.GetGlobalRegistry().UnregisterClient(codexAuth.ID)
+	})
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{
+		Streaming: sdkconfig.StreamingConfig{CheckpointFailover: true},
+	}, manager)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`{}`)))
+	c.Set("apiKey", "client-key")
+	c.Set("apiKeyPolicy", &internalconfig.APIKeyPolicy{
+		APIKey: "client-key",
+		Failover: internalconfig.APIKeyFailoverPolicy{
+			Claude: internalconfig.ProviderFailoverPolicy{
+				Enabled:     true,
+				TargetModel: "gpt-5.2",
+			},
+		},
+	})
+
+	ctx := context.WithValue(context.Background(), "gin", c)
+	payload := []byte(`{"model":"claude-model","stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+	dataChan, errChan := handler.ExecuteStreamWithAuthManager(ctx, "claude", "claude-model", payload, "")
+	if dataChan == nil || errChan == nil {
+		t.Fatalf("expected non-nil channels")
+	}
+
+	var got []byte
+	for chunk := range dataChan {
+		got = append(got, chunk...)
+	}
+	for msg := range errChan {
+		if msg != nil {
+			t.Fatalf("unexpected error: %+v", msg)
+		}
+	}
+
+	want := "data: {\"delta\":{\"text\":\"partial\"}}\n\nresumed"
+	if string(got) != want {
+		t.Fatalf("got %q, want %q", string(got), want)
+	}
+}
@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"math/rand"
+	"time"
+)
+
+// CredentialStore is the minimal view of wherever provider credentials are
+// persisted that CredentialLifetimeWatcher needs: the current remaining
+// lifetime of one credential, read fresh at watcher construction time so a
+// store-side change (e.g. a concurrent manual rotation) is picked up
+// without the caller having to compute TTL itself.
+type CredentialStore interface {
+	// CredentialTTL returns authID's current remaining lifetime. A
+	// non-positive duration means the credential has no known expiry (or is
+	// already expired), and the watcher schedules an immediate renewal.
+	CredentialTTL(authID string) (time.Duration, error)
+}
+
+// CredentialLifetimeWatcherConfig configures the jitter window
+// NewCredentialLifetimeWatcher subtracts from a credential's TTL to decide
+// when to renew, on top of the retry knobs it forwards to the underlying
+// Renewer.
+type CredentialLifetimeWatcherConfig struct {
+	// JitterMin and JitterMax bound the fraction of the credential's TTL
+	// subtracted before scheduling a renewal: an expiry E is renewed at
+	// E - rand[JitterMin,JitterMax]*E. Defaults (zero values) are 0.10 and
+	// 0.20, i.e. a credential is renewed somewhere in its last 10-20% of
+	// remaining lifetime, mirroring Vault LifetimeWatcher's randomized
+	// early-renewal window.
+	JitterMin float64
+	JitterMax float64
+
+	// Renewer is forwarded to the underlying Renewer for its retry backoff
+	// and max-attempts behavior. Its RenewGrace is ignored: the renewal
+	// point here is derived from JitterMin/JitterMax instead.
+	Renewer RenewerConfig
+}
+
+const (
+	defaultLifetimeJitterMin = 0.10
+	defaultLifetimeJitterMax = 0.20
+)
+
+func (c CredentialLifetimeWatcherConfig) withDefaults() CredentialLifetimeWatcherConfig {
+	if c.JitterMin <= 0 {
+		c.JitterMin = defaultLifetimeJitterMin
+	}
+	if c.JitterMax <= c.JitterMin {
+		c.JitterMax = defaultLifetimeJitterMax
+	}
+	return c
+}
+
+// NewCredentialLifetimeWatcher reads authID's current TTL from store and
+// returns a Renewer scheduled to first renew at a randomized point in the
+// credential's last JitterMin-JitterMax fraction of that TTL, retrying on
+// failure with the same exponential backoff as any other Renewer, up to its
+// MaxAttempts; those retries are not bounded by the credential's expiry,
+// so they can stop while it is still valid or go on past it. The returned Renewer is used exactly
+// like any other - drain its Events (directly, or via a RenewerSet) to
+// learn when authID renews, cools down, or is given up on and should be
+// marked unhealthy/removed from the pool getRequestDetails hands back.
+func NewCredentialLifetimeWatcher(store CredentialStore, authID string, refresh RefreshFunc, cfg CredentialLifetimeWatcherConfig) (*Renewer, error) {
+	cfg = cfg.withDefaults()
+	ttl, err := store.CredentialTTL(authID)
+	if err != nil {
+		return nil, err
+	}
+	if ttl < 0 {
+		ttl = 0
+	}
+
+	renewerCfg := cfg.Renewer
+	if ttl > 0 {
+		jitterFrac := cfg.JitterMin + rand.Float64()*(cfg.JitterMax-cfg.JitterMin)
+		renewerCfg.RenewGrace = 1 - jitterFrac
+	} else {
+		renewerCfg.RenewGrace = 0
+	}
+	return NewRenewer(authID, ttl, refresh, renewerCfg), nil
+}
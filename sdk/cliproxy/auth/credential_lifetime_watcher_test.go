@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type stubCredentialStore struct {
+	ttl time.Duration
+	err error
+}
+
+func (s stubCredentialStore) CredentialTTL(string) (time.Duration, error) {
+	return s.ttl, s.err
+}
+
+func TestNewCredentialLifetimeWatcher_DerivesRenewGraceFromJitterWindow(t *testing.T) {
+	store := stubCredentialStore{ttl: time.Hour}
+	r, err := NewCredentialLifetimeWatcher(store, "a", func(ctx context.Context) (time.Duration, error) {
+		return time.Hour, nil
+	}, CredentialLifetimeWatcherConfig{JitterMin: 0.10, JitterMax: 0.20})
+	if err != nil {
+		t.Fatalf("NewCredentialLifetimeWatcher: %v", err)
+	}
+	if r.cfg.RenewGrace < 0.80 || r.cfg.RenewGrace > 0.90 {
+		t.Fatalf("RenewGrace = %v, want in [0.80, 0.90] for a 10-20%% jitter window", r.cfg.RenewGrace)
+	}
+}
+
+func TestNewCredentialLifetimeWatcher_PropagatesStoreError(t *testing.T) {
+	wantErr := errors.New("credential store unavailable")
+	store := stubCredentialStore{err: wantErr}
+	if _, err := NewCredentialLifetimeWatcher(store, "a", nil, CredentialLifetimeWatcherConfig{}); !errors.Is(err, wantErr) {
+		t.Fatalf("err = %v, want %v", err, wantErr)
+	}
+}
+
+func TestNewCredentialLifetimeWatcher_ZeroOrNegativeTTLRenewsImmediately(t *testing.T) {
+	store := stubCredentialStore{ttl: -time.Second}
+	renewed := make(chan struct{}, 1)
+	r, err := NewCredentialLifetimeWatcher(store, "a", func(ctx context.Context) (time.Duration, error) {
+		renewed <- struct{}{}
+		return time.Hour, nil
+	}, CredentialLifetimeWatcherConfig{})
+	if err != nil {
+		t.Fatalf("NewCredentialLifetimeWatcher: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	r.Start(ctx)
+
+	select {
+	case <-renewed:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for immediate renewal of an already-expired credential")
+	}
+	r.Stop()
+}
@@ -0,0 +1,234 @@
+package auth
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// RenewEventType identifies which transition a RenewEvent reports.
+type RenewEventType string
+
+const (
+	// RenewEventRenewed reports a successful refresh; NextTTL is the
+	// credential's new remaining lifetime.
+	RenewEventRenewed RenewEventType = "renewed"
+	// RenewEventCooldownStarted reports one failed refresh attempt that will
+	// be retried after Backoff, mirroring Vault Renewer's behavior of
+	// surfacing each failed renewal attempt rather than only the final one.
+	RenewEventCooldownStarted RenewEventType = "cooldown_started"
+	// RenewEventDoneErr reports that every retry attempt was exhausted
+	// without a successful refresh; the Renewer's loop has stopped and the
+	// credential should be treated as no longer auto-renewing.
+	RenewEventDoneErr RenewEventType = "done_err"
+)
+
+// RenewEvent is one state transition a Renewer emits on its Events channel.
+type RenewEvent struct {
+	Type    RenewEventType
+	AuthID  string
+	NextTTL time.Duration // set on RenewEventRenewed
+	Backoff time.Duration // set on RenewEventCooldownStarted
+	Err     error         // set on RenewEventCooldownStarted and RenewEventDoneErr
+}
+
+// RefreshFunc performs one refresh attempt for a single auth, returning the
+// refreshed credential's new remaining TTL.
+type RefreshFunc func(ctx context.Context) (time.Duration, error)
+
+// RenewerConfig holds the per-provider knobs requested for Renewer: how
+// early to renew relative to the credential's TTL, and how hard to retry a
+// failed attempt before giving up.
+type RenewerConfig struct {
+	// RenewGrace is the fraction of the credential's TTL that must elapse
+	// before a renewal is attempted. Default (zero value) is 2/3: a
+	// credential with a 90-minute TTL is renewed after 60 minutes, leaving a
+	// 30-minute safety margin before it actually expires.
+	RenewGrace float64
+	// MaxBackoff caps the exponential backoff between failed-refresh retries.
+	// Default (zero value) is 5 minutes.
+	MaxBackoff time.Duration
+	// MaxAttempts caps how many consecutive refresh failures a Renewer
+	// tolerates before giving up and emitting RenewEventDoneErr. Default
+	// (zero value) is 6.
+	MaxAttempts int
+	// DisableRenew turns the Renewer into a no-op: Start returns immediately
+	// without scheduling anything, letting a provider opt out while keeping
+	// the same call site.
+	DisableRenew bool
+}
+
+const (
+	defaultRenewGrace  = 2.0 / 3.0
+	defaultMaxBackoff  = 5 * time.Minute
+	defaultMaxAttempts = 6
+	initialBackoff     = 5 * time.Second
+)
+
+func (c RenewerConfig) withDefaults() RenewerConfig {
+	if c.RenewGrace <= 0 || c.RenewGrace >= 1 {
+		c.RenewGrace = defaultRenewGrace
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = defaultMaxBackoff
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = defaultMaxAttempts
+	}
+	return c
+}
+
+// Renewer proactively refreshes a single auth's credential at a configured
+// fraction of its remaining TTL, modeled on HashiCorp Vault's
+// api.Renewer/LifetimeWatcher: instead of waiting for a request to discover
+// an expired credential (today's only signal, surfaced as an
+// "auth_unavailable" error that the failover path then has to react to),
+// it renews ahead of expiry and reports every transition on Events so a
+// caller can mark the auth healthy, cooling-down, or dead without user
+// traffic ever seeing the gap.
+type Renewer struct {
+	authID     string
+	initialTTL time.Duration
+	refresh    RefreshFunc
+	cfg        RenewerConfig
+
+	events chan RenewEvent
+	stopCh chan struct{}
+	doneCh chan struct{}
+}
+
+// NewRenewer returns a Renewer for authID with initialTTL as the
+// credential's current remaining lifetime. refresh is called to perform
+// each renewal attempt. Call Start to begin the loop and Events to consume
+// its state transitions.
+func NewRenewer(authID string, initialTTL time.Duration, refresh RefreshFunc, cfg RenewerConfig) *Renewer {
+	return &Renewer{
+		authID:     authID,
+		initialTTL: initialTTL,
+		refresh:    refresh,
+		cfg:        cfg.withDefaults(),
+		events:     make(chan RenewEvent, 8),
+		stopCh:     make(chan struct{}),
+		doneCh:     make(chan struct{}),
+	}
+}
+
+// Events returns the channel Renewer reports state transitions on. It is
+// closed once the loop stops, either because Stop was called, ctx was
+// cancelled, or MaxAttempts was exhausted (RenewEventDoneErr).
+func (r *Renewer) Events() <-chan RenewEvent {
+	return r.events
+}
+
+// Start runs the renew loop in its own goroutine until ctx is cancelled,
+// Stop is called, or retries are exhausted. It is a no-op if cfg.DisableRenew
+// was set.
+func (r *Renewer) Start(ctx context.Context) {
+	if r.cfg.DisableRenew {
+		close(r.doneCh)
+		close(r.events)
+		return
+	}
+	go r.run(ctx, r.initialTTL)
+}
+
+// Stop signals the renew loop to exit and blocks until it has.
+func (r *Renewer) Stop() {
+	select {
+	case <-r.stopCh:
+	default:
+		close(r.stopCh)
+	}
+	<-r.doneCh
+}
+
+func (r *Renewer) run(ctx context.Context, ttl time.Duration) {
+	defer close(r.doneCh)
+	defer close(r.events)
+
+	for {
+		wait := time.Duration(float64(ttl) * r.cfg.RenewGrace)
+		if wait < 0 {
+			wait = 0
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.stopCh:
+			return
+		case <-time.After(wait):
+		}
+
+		newTTL, err := r.attemptWithBackoff(ctx)
+		if err != nil {
+			if err != errRenewerStopped {
+				r.emit(RenewEvent{Type: RenewEventDoneErr, AuthID: r.authID, Err: err})
+			}
+			return
+		}
+		r.emit(RenewEvent{Type: RenewEventRenewed, AuthID: r.authID, NextTTL: newTTL})
+		ttl = newTTL
+	}
+}
+
+var errRenewerStopped = context.Canceled
+
+// attemptWithBackoff calls refresh, retrying with jittered exponential
+// backoff on failure until it succeeds, MaxAttempts is exhausted, or the
+// Renewer is stopped.
+func (r *Renewer) attemptWithBackoff(ctx context.Context) (time.Duration, error) {
+	backoff := initialBackoff
+	if backoff > r.cfg.MaxBackoff {
+		backoff = r.cfg.MaxBackoff
+	}
+	var lastErr error
+	for attempt := 0; attempt < r.cfg.MaxAttempts; attempt++ {
+		newTTL, err := r.refresh(ctx)
+		if err == nil {
+			return newTTL, nil
+		}
+		lastErr = err
+		if attempt == r.cfg.MaxAttempts-1 {
+			break
+		}
+
+		jittered := jitter(backoff)
+		r.emit(RenewEvent{Type: RenewEventCooldownStarted, AuthID: r.authID, Backoff: jittered, Err: err})
+
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-r.stopCh:
+			return 0, errRenewerStopped
+		case <-time.After(jittered):
+		}
+
+		backoff *= 2
+		if backoff > r.cfg.MaxBackoff {
+			backoff = r.cfg.MaxBackoff
+		}
+	}
+	return 0, lastErr
+}
+
+// jitter returns d plus up to +/-25% random variation, so many Renewers
+// retrying the same failure (e.g. an upstream outage) don't all wake up on
+// the exact same tick.
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	spread := d / 4
+	offset := time.Duration(rand.Int63n(int64(spread)*2+1)) - spread
+	return d + offset
+}
+
+func (r *Renewer) emit(e RenewEvent) {
+	select {
+	case r.events <- e:
+	default:
+		// Events is an 8-deep buffer; a slow/absent consumer must not block
+		// the renew loop itself, so a full buffer drops the event rather
+		// than stalling renewal.
+	}
+}
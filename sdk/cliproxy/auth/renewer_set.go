@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RenewSink receives the state transitions RenewerSet collects across every
+// auth it manages. An implementation can use it to mark an auth healthy the
+// instant it renews, treat it as cooling-down without waiting for a request
+// to hit it and surface "auth_unavailable", and drop it once its Renewer
+// gives up — all without a user request ever having to discover the change
+// reactively.
+type RenewSink interface {
+	// MarkRenewed records that authID refreshed successfully and its new
+	// remaining lifetime is nextTTL.
+	MarkRenewed(authID string, nextTTL time.Duration)
+	// MarkCooldown records that authID failed one refresh attempt and will
+	// be retried after backoff; err is the failure that triggered it.
+	MarkCooldown(authID string, backoff time.Duration, err error)
+	// MarkDead records that authID exhausted every retry attempt without a
+	// successful refresh and its Renewer has stopped; err is the last
+	// failure. The existing reactive failover path remains the backstop
+	// for an auth in this state.
+	MarkDead(authID string, err error)
+}
+
+// RenewerSet runs one Renewer per registered auth and fans every auth's
+// Events into a single RenewSink, so one owner can run the goroutines
+// without every caller having to drain N channels itself.
+type RenewerSet struct {
+	sink RenewSink
+
+	mu       sync.Mutex
+	renewers map[string]*Renewer
+	wg       sync.WaitGroup
+}
+
+// NewRenewerSet returns a RenewerSet that reports every renewal transition
+// to sink.
+func NewRenewerSet(sink RenewSink) *RenewerSet {
+	return &RenewerSet{
+		sink:     sink,
+		renewers: make(map[string]*Renewer),
+	}
+}
+
+// Add registers a Renewer for authID and starts its loop under ctx,
+// draining its Events onto sink until the Renewer stops or ctx is done.
+// Add is a no-op if authID is already registered, so it is safe to call
+// again whenever an auth is (re-)registered at runtime.
+func (s *RenewerSet) Add(ctx context.Context, authID string, initialTTL time.Duration, refresh RefreshFunc, cfg RenewerConfig) {
+	s.mu.Lock()
+	if _, exists := s.renewers[authID]; exists {
+		s.mu.Unlock()
+		return
+	}
+	r := NewRenewer(authID, initialTTL, refresh, cfg)
+	s.renewers[authID] = r
+	s.mu.Unlock()
+
+	r.Start(ctx)
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for ev := range r.Events() {
+			switch ev.Type {
+			case RenewEventRenewed:
+				s.sink.MarkRenewed(ev.AuthID, ev.NextTTL)
+			case RenewEventCooldownStarted:
+				s.sink.MarkCooldown(ev.AuthID, ev.Backoff, ev.Err)
+			case RenewEventDoneErr:
+				s.sink.MarkDead(ev.AuthID, ev.Err)
+			}
+		}
+	}()
+}
+
+// Remove stops authID's Renewer, if any, and waits for its run loop to exit
+// before returning; events it already emitted may still reach sink after
+// Remove returns. Call it when an auth is deregistered so its Renewer
+// doesn't keep retrying a credential nothing uses anymore.
+func (s *RenewerSet) Remove(authID string) {
+	s.mu.Lock()
+	r, ok := s.renewers[authID]
+	if ok {
+		delete(s.renewers, authID)
+	}
+	s.mu.Unlock()
+	if ok {
+		r.Stop()
+	}
+}
+
+// Stop stops every registered Renewer and blocks until all of their event
+// loops have drained onto sink.
+func (s *RenewerSet) Stop() {
+	s.mu.Lock()
+	renewers := make([]*Renewer, 0, len(s.renewers))
+	for id, r := range s.renewers {
+		renewers = append(renewers, r)
+		delete(s.renewers, id)
+	}
+	s.mu.Unlock()
+
+	for _, r := range renewers {
+		r.Stop()
+	}
+	s.wg.Wait()
+}
@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingSink struct {
+	mu       sync.Mutex
+	renewed  []string
+	cooldown []string
+	dead     []string
+}
+
+func (s *recordingSink) MarkRenewed(authID string, nextTTL time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.renewed = append(s.renewed, authID)
+}
+
+func (s *recordingSink) MarkCooldown(authID string, backoff time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cooldown = append(s.cooldown, authID)
+}
+
+func (s *recordingSink) MarkDead(authID string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dead = append(s.dead, authID)
+}
+
+func (s *recordingSink) renewedCount(authID string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, id := range s.renewed {
+		if id == authID {
+			n++
+		}
+	}
+	return n
+}
+
+func TestRenewerSet_FansEventsToSink(t *testing.T) {
+	sink := &recordingSink{}
+	set := NewRenewerSet(sink)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	set.Add(ctx, "a", time.Millisecond, func(ctx context.Context) (time.Duration, error) {
+		return time.Minute, nil
+	}, RenewerConfig{RenewGrace: 0.5})
+
+	deadline := time.Now().Add(time.Second)
+	for sink.renewedCount("a") == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for sink.MarkRenewed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	set.Stop()
+}
+
+func TestRenewerSet_AddIsIdempotentPerAuthID(t *testing.T) {
+	sink := &recordingSink{}
+	set := NewRenewerSet(sink)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var calls int32
+	refresh := func(ctx context.Context) (time.Duration, error) {
+		calls++
+		return time.Minute, nil
+	}
+	set.Add(ctx, "a", time.Hour, refresh, RenewerConfig{})
+	set.Add(ctx, "a", time.Hour, refresh, RenewerConfig{})
+
+	if got := len(set.renewers); got != 1 {
+		t.Fatalf("registered renewers = %d, want 1", got)
+	}
+	set.Stop()
+}
+
+func TestRenewerSet_RemoveStopsRenewer(t *testing.T) {
+	sink := &recordingSink{}
+	set := NewRenewerSet(sink)
+
+	set.Add(context.Background(), "a", time.Hour, func(ctx context.Context) (time.Duration, error) {
+		return time.Minute, nil
+	}, RenewerConfig{})
+
+	set.Remove("a")
+
+	if _, ok := set.renewers["a"]; ok {
+		t.Fatal("expected auth a to be removed from the set")
+	}
+}
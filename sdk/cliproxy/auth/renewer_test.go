@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRenewer_RenewsAfterGraceAndReportsNextTTL(t *testing.T) {
+	r := NewRenewer("a", 40*time.Millisecond, func(ctx context.Context) (time.Duration, error) {
+		return time.Minute, nil
+	}, RenewerConfig{RenewGrace: 0.5})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	r.Start(ctx)
+
+	select {
+	case ev := <-r.Events():
+		if ev.Type != RenewEventRenewed {
+			t.Fatalf("event type = %v, want RenewEventRenewed", ev.Type)
+		}
+		if ev.NextTTL != time.Minute {
+			t.Fatalf("NextTTL = %v, want 1m", ev.NextTTL)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for renewal event")
+	}
+	r.Stop()
+}
+
+func TestRenewer_CooldownThenDoneErrAfterMaxAttempts(t *testing.T) {
+	wantErr := errors.New("upstream unavailable")
+	r := NewRenewer("a", time.Millisecond, func(ctx context.Context) (time.Duration, error) {
+		return 0, wantErr
+	}, RenewerConfig{RenewGrace: 0.5, MaxAttempts: 2, MaxBackoff: 10 * time.Millisecond})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	r.Start(ctx)
+
+	var sawCooldown bool
+	var sawDone bool
+	deadline := time.After(time.Second)
+	for !sawDone {
+		select {
+		case ev, ok := <-r.Events():
+			if !ok {
+				t.Fatal("events channel closed before RenewEventDoneErr")
+			}
+			switch ev.Type {
+			case RenewEventCooldownStarted:
+				sawCooldown = true
+				if ev.Err != wantErr {
+					t.Fatalf("CooldownStarted Err = %v, want %v", ev.Err, wantErr)
+				}
+			case RenewEventDoneErr:
+				sawDone = true
+				if ev.Err != wantErr {
+					t.Fatalf("DoneErr Err = %v, want %v", ev.Err, wantErr)
+				}
+			}
+		case <-deadline:
+			t.Fatal("timed out waiting for RenewEventDoneErr")
+		}
+	}
+	if !sawCooldown {
+		t.Fatal("expected at least one RenewEventCooldownStarted before giving up")
+	}
+	r.Stop()
+}
+
+func TestRenewer_DisableRenewIsNoOp(t *testing.T) {
+	r := NewRenewer("a", time.Minute, func(ctx context.Context) (time.Duration, error) {
+		t.Fatal("refresh should never be called when DisableRenew is set")
+		return 0, nil
+	}, RenewerConfig{DisableRenew: true})
+
+	r.Start(context.Background())
+
+	if _, ok := <-r.Events(); ok {
+		t.Fatal("expected Events to be closed immediately")
+	}
+}
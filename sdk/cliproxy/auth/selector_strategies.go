@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/billing"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// errNoViableAuth is returned when a selector is asked to choose among zero
+// candidates; Manager is expected to treat this the same as any other
+// provider-exhausted error from the existing selector implementations.
+var errNoViableAuth = errors.New("auth: no viable auth candidates")
+
+// RoundRobinSelector cycles through candidates in order, advancing one
+// position per call regardless of which candidate a prior call returned.
+// Unlike FillFirstSelector, which always prefers the first viable
+// candidate (and so concentrates load on whichever auth sorts first),
+// RoundRobinSelector spreads requests evenly across every auth the caller
+// passes in. Manager is expected to have already dropped cooled-down auths
+// from candidates before calling Select, so every strategy here honors
+// cool-down for free by only ever choosing from what it's given.
+type RoundRobinSelector struct {
+	next uint64
+}
+
+// Select returns the candidate at the next round-robin position.
+func (s *RoundRobinSelector) Select(ctx context.Context, candidates []*Auth, req cliproxyexecutor.Request) (*Auth, error) {
+	if len(candidates) == 0 {
+		return nil, errNoViableAuth
+	}
+	idx := atomic.AddUint64(&s.next, 1) - 1
+	return candidates[idx%uint64(len(candidates))], nil
+}
+
+// LeastLoadedSelector prefers whichever candidate currently has the fewest
+// in-flight requests. In-flight counts are tracked here, keyed by Auth.ID,
+// rather than as a field on Auth itself, since Auth's instance fields are
+// defined and owned by this package's Manager/registration code; keying by
+// ID gets the same load-balancing effect without requiring Auth to carry
+// bookkeeping state it doesn't otherwise need. Callers that want Select's
+// choice to actually reflect concurrency must call Acquire before dispatching
+// to the chosen auth and Release (typically via defer) once that request
+// completes.
+type LeastLoadedSelector struct {
+	counts sync.Map // auth ID -> *int64
+}
+
+func (s *LeastLoadedSelector) counter(authID string) *int64 {
+	v, _ := s.counts.LoadOrStore(authID, new(int64))
+	return v.(*int64)
+}
+
+// Acquire records that a request is about to be dispatched to authID.
+func (s *LeastLoadedSelector) Acquire(authID string) {
+	atomic.AddInt64(s.counter(authID), 1)
+}
+
+// Release records that a request previously dispatched to authID has
+// finished (successfully or not).
+func (s *LeastLoadedSelector) Release(authID string) {
+	atomic.AddInt64(s.counter(authID), -1)
+}
+
+// Select returns the candidate with the lowest in-flight count, breaking
+// ties by candidate order so behavior stays deterministic in tests.
+func (s *LeastLoadedSelector) Select(ctx context.Context, candidates []*Auth, req cliproxyexecutor.Request) (*Auth, error) {
+	if len(candidates) == 0 {
+		return nil, errNoViableAuth
+	}
+	best := candidates[0]
+	bestLoad := atomic.LoadInt64(s.counter(best.ID))
+	for _, cand := range candidates[1:] {
+		load := atomic.LoadInt64(s.counter(cand.ID))
+		if load < bestLoad {
+			best, bestLoad = cand, load
+		}
+	}
+	return best, nil
+}
+
+// LeastCostSelector prefers whichever viable candidate resolves to the
+// cheapest price for the requested model, using the billing store's
+// catalog/default price table. Weights lets an operator bias the choice
+// per auth ID (e.g. to prefer a credential with more remaining free quota
+// even when its catalog price is nominally higher); a weight is a
+// multiplier applied to the resolved price, so a CostBias below 1 makes an
+// auth look cheaper than its catalog price and above 1 makes it look more
+// expensive. Auths missing from Weights use a neutral bias of 1.
+type LeastCostSelector struct {
+	Store   billing.Store
+	Weights map[string]float64
+}
+
+// NewLeastCostSelector builds a LeastCostSelector backed by store, with
+// per-auth cost bias multipliers from weights (may be nil).
+func NewLeastCostSelector(store billing.Store, weights map[string]float64) *LeastCostSelector {
+	return &LeastCostSelector{Store: store, Weights: weights}
+}
+
+func (s *LeastCostSelector) costBias(authID string) float64 {
+	if s.Weights == nil {
+		return 1
+	}
+	if w, ok := s.Weights[authID]; ok && w > 0 {
+		return w
+	}
+	return 1
+}
+
+// Select returns the candidate whose biased price for req.Model is lowest.
+// When the store is nil or every price lookup fails, it falls back to the
+// first candidate so a billing-store outage degrades to FillFirstSelector
+// behavior rather than failing requests outright.
+func (s *LeastCostSelector) Select(ctx context.Context, candidates []*Auth, req cliproxyexecutor.Request) (*Auth, error) {
+	if len(candidates) == 0 {
+		return nil, errNoViableAuth
+	}
+	if s.Store == nil {
+		return candidates[0], nil
+	}
+
+	var best *Auth
+	bestCost := float64(0)
+	for _, cand := range candidates {
+		price, _, _, err := s.Store.ResolvePriceMicro(ctx, req.Model)
+		if err != nil {
+			continue
+		}
+		cost := float64(price.Prompt+price.Completion) * s.costBias(cand.ID)
+		if best == nil || cost < bestCost {
+			best, bestCost = cand, cost
+		}
+	}
+	if best == nil {
+		return candidates[0], nil
+	}
+	return best, nil
+}
@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/billing"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+func TestRoundRobinSelector_CyclesThroughCandidates(t *testing.T) {
+	s := &RoundRobinSelector{}
+	candidates := []*Auth{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+
+	var got []string
+	for i := 0; i < 4; i++ {
+		picked, err := s.Select(context.Background(), candidates, cliproxyexecutor.Request{})
+		if err != nil {
+			t.Fatalf("Select: %v", err)
+		}
+		got = append(got, picked.ID)
+	}
+	want := []string{"a", "b", "c", "a"}
+	for i, id := range want {
+		if got[i] != id {
+			t.Fatalf("pick %d = %q, want %q (sequence %v)", i, got[i], id, got)
+		}
+	}
+}
+
+func TestRoundRobinSelector_NoCandidates(t *testing.T) {
+	s := &RoundRobinSelector{}
+	if _, err := s.Select(context.Background(), nil, cliproxyexecutor.Request{}); err == nil {
+		t.Fatal("expected error for empty candidates")
+	}
+}
+
+func TestLeastLoadedSelector_PrefersFewestInFlight(t *testing.T) {
+	s := &LeastLoadedSelector{}
+	candidates := []*Auth{{ID: "a"}, {ID: "b"}}
+
+	s.Acquire("a")
+	s.Acquire("a")
+	s.Acquire("b")
+
+	picked, err := s.Select(context.Background(), candidates, cliproxyexecutor.Request{})
+	if err != nil {
+		t.Fatalf("Select: %v", err)
+	}
+	if picked.ID != "b" {
+		t.Fatalf("picked=%q, want b", picked.ID)
+	}
+
+	s.Release("b")
+	s.Release("b")
+	picked, err = s.Select(context.Background(), candidates, cliproxyexecutor.Request{})
+	if err != nil {
+		t.Fatalf("Select: %v", err)
+	}
+	if picked.ID != "b" {
+		t.Fatalf("picked=%q, want b after release", picked.ID)
+	}
+}
+
+func TestLeastCostSelector_FallsBackToFirstWithoutStore(t *testing.T) {
+	s := NewLeastCostSelector(nil, nil)
+	candidates := []*Auth{{ID: "a"}, {ID: "b"}}
+	picked, err := s.Select(context.Background(), candidates, cliproxyexecutor.Request{Model: "m"})
+	if err != nil {
+		t.Fatalf("Select: %v", err)
+	}
+	if picked.ID != "a" {
+		t.Fatalf("picked=%q, want a (first candidate fallback)", picked.ID)
+	}
+}
+
+func TestLeastCostSelector_WeightsBiasTheChoice(t *testing.T) {
+	store := billing.NewMemoryStore()
+	defer store.Close()
+
+	s := NewLeastCostSelector(store, map[string]float64{"a": 0.01, "b": 100})
+	candidates := []*Auth{{ID: "a"}, {ID: "b"}}
+	picked, err := s.Select(context.Background(), candidates, cliproxyexecutor.Request{Model: "claude-opus-4-5-20251101"})
+	if err != nil {
+		t.Fatalf("Select: %v", err)
+	}
+	if picked.ID != "a" {
+		t.Fatalf("picked=%q, want a (lower cost bias)", picked.ID)
+	}
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"context"
+
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// TargetResolver maps a failover target model name to the providers and
+// normalized model name that should serve the retry, mirroring
+// BaseAPIHandler.getRequestDetails. ok is false when the target model isn't
+// recognized at all, in which case the original error is returned unchanged.
+type TargetResolver func(ctx context.Context, targetModel string) (providers []string, model string, ok bool)
+
+// Eligible reports whether a failed call's outcome should trigger failover,
+// mirroring the handler's isFailoverEligible.
+type Eligible func(err error) bool
+
+// ClaudeFailover returns a UnaryInterceptor that retries a failed call once
+// against a different target model/provider: the same Claude ->
+// configured-target-model behavior BaseAPIHandlers.ExecuteWithAuthManager
+// and ExecuteStreamWithAuthManager implement today as hard-coded branches,
+// expressed as a composable interceptor for callers who build their own
+// chain. targetModel resolves the policy-configured failover target for the
+// request (ok=false when failover isn't configured or doesn't apply);
+// resolve maps that target model to providers; eligible decides whether a
+// given failure warrants retrying at all. onFailover, if non-nil, is called
+// with the requested and target model right before the retry, e.g. to log
+// or record metrics.
+func ClaudeFailover(targetModel func(ctx context.Context, requestedModel string) (string, bool), resolve TargetResolver, eligible Eligible, onFailover func(fromModel, toModel string)) UnaryInterceptor {
+	return func(ctx context.Context, providers []string, req coreexecutor.Request, opts coreexecutor.Options, next UnaryHandler) (coreexecutor.Response, error) {
+		resp, err := next(ctx, providers, req, opts)
+		if err == nil || targetModel == nil || resolve == nil {
+			return resp, err
+		}
+		target, enabled := targetModel(ctx, req.Model)
+		if !enabled || target == "" || target == req.Model {
+			return resp, err
+		}
+		if eligible != nil && !eligible(err) {
+			return resp, err
+		}
+		failoverProviders, failoverModel, ok := resolve(ctx, target)
+		if !ok {
+			return resp, err
+		}
+		if onFailover != nil {
+			onFailover(req.Model, failoverModel)
+		}
+		failoverReq := req
+		failoverReq.Model = failoverModel
+		return next(ctx, failoverProviders, failoverReq, opts)
+	}
+}
+
+// ClaudeFailoverStream is ClaudeFailover's streaming counterpart.
+func ClaudeFailoverStream(targetModel func(ctx context.Context, requestedModel string) (string, bool), resolve TargetResolver, eligible Eligible, onFailover func(fromModel, toModel string)) StreamInterceptor {
+	return func(ctx context.Context, providers []string, req coreexecutor.Request, opts coreexecutor.Options, next StreamHandler) (<-chan coreexecutor.StreamChunk, error) {
+		chunks, err := next(ctx, providers, req, opts)
+		if err == nil || targetModel == nil || resolve == nil {
+			return chunks, err
+		}
+		target, enabled := targetModel(ctx, req.Model)
+		if !enabled || target == "" || target == req.Model {
+			return chunks, err
+		}
+		if eligible != nil && !eligible(err) {
+			return chunks, err
+		}
+		failoverProviders, failoverModel, ok := resolve(ctx, target)
+		if !ok {
+			return chunks, err
+		}
+		if onFailover != nil {
+			onFailover(req.Model, failoverModel)
+		}
+		failoverReq := req
+		failoverReq.Model = failoverModel
+		return next(ctx, failoverProviders, failoverReq, opts)
+	}
+}
@@ -0,0 +1,69 @@
+// Package middleware provides a chainable interceptor pipeline around the
+// executor calls BaseAPIHandlers.ExecuteWithAuthManager and
+// ExecuteStreamWithAuthManager make through sdk/cliproxy/auth.Manager,
+// modeled on go-grpc-middleware's chained unary/stream interceptors: each
+// interceptor wraps the next handler in the chain, so cross-cutting concerns
+// - panic recovery, request tagging, failover, per-provider rate limiting -
+// can be composed instead of hard-coded as branches in the handler.
+package middleware
+
+import (
+	"context"
+
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// UnaryHandler executes one non-streaming request across providers, as
+// sdk/cliproxy/auth.Manager.Execute/ExecuteCount do today.
+type UnaryHandler func(ctx context.Context, providers []string, req coreexecutor.Request, opts coreexecutor.Options) (coreexecutor.Response, error)
+
+// UnaryInterceptor wraps a UnaryHandler, calling next to continue the chain
+// (or invoke the terminal Manager call).
+type UnaryInterceptor func(ctx context.Context, providers []string, req coreexecutor.Request, opts coreexecutor.Options, next UnaryHandler) (coreexecutor.Response, error)
+
+// StreamHandler executes one streaming request across providers, as
+// sdk/cliproxy/auth.Manager.ExecuteStream does today.
+type StreamHandler func(ctx context.Context, providers []string, req coreexecutor.Request, opts coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error)
+
+// StreamInterceptor is UnaryInterceptor's streaming counterpart.
+type StreamInterceptor func(ctx context.Context, providers []string, req coreexecutor.Request, opts coreexecutor.Options, next StreamHandler) (<-chan coreexecutor.StreamChunk, error)
+
+// Chain composes interceptors into a single UnaryInterceptor that runs them
+// outermost-first: Chain(a, b)(...) calls a, which calls b, which calls the
+// handler passed to the result - the same order
+// grpc_middleware.ChainUnaryServer uses. An empty Chain() is the identity
+// interceptor: it calls the handler directly.
+func Chain(interceptors ...UnaryInterceptor) UnaryInterceptor {
+	return func(ctx context.Context, providers []string, req coreexecutor.Request, opts coreexecutor.Options, final UnaryHandler) (coreexecutor.Response, error) {
+		return chainUnary(interceptors, final)(ctx, providers, req, opts)
+	}
+}
+
+func chainUnary(interceptors []UnaryInterceptor, final UnaryHandler) UnaryHandler {
+	if len(interceptors) == 0 {
+		return final
+	}
+	head, rest := interceptors[0], interceptors[1:]
+	next := chainUnary(rest, final)
+	return func(ctx context.Context, providers []string, req coreexecutor.Request, opts coreexecutor.Options) (coreexecutor.Response, error) {
+		return head(ctx, providers, req, opts, next)
+	}
+}
+
+// ChainStream is Chain's streaming counterpart.
+func ChainStream(interceptors ...StreamInterceptor) StreamInterceptor {
+	return func(ctx context.Context, providers []string, req coreexecutor.Request, opts coreexecutor.Options, final StreamHandler) (<-chan coreexecutor.StreamChunk, error) {
+		return chainStream(interceptors, final)(ctx, providers, req, opts)
+	}
+}
+
+func chainStream(interceptors []StreamInterceptor, final StreamHandler) StreamHandler {
+	if len(interceptors) == 0 {
+		return final
+	}
+	head, rest := interceptors[0], interceptors[1:]
+	next := chainStream(rest, final)
+	return func(ctx context.Context, providers []string, req coreexecutor.Request, opts coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error) {
+		return head(ctx, providers, req, opts, next)
+	}
+}
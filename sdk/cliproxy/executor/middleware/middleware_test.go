@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+func TestChain_RunsInterceptorsOutermostFirst(t *testing.T) {
+	var order []string
+	record := func(name string) UnaryInterceptor {
+		return func(ctx context.Context, providers []string, req coreexecutor.Request, opts coreexecutor.Options, next UnaryHandler) (coreexecutor.Response, error) {
+			order = append(order, name+":before")
+			resp, err := next(ctx, providers, req, opts)
+			order = append(order, name+":after")
+			return resp, err
+		}
+	}
+	final := func(ctx context.Context, providers []string, req coreexecutor.Request, opts coreexecutor.Options) (coreexecutor.Response, error) {
+		order = append(order, "final")
+		return coreexecutor.Response{}, nil
+	}
+
+	chain := Chain(record("a"), record("b"))
+	if _, err := chain(context.Background(), nil, coreexecutor.Request{}, coreexecutor.Options{}, final); err != nil {
+		t.Fatalf("chain: %v", err)
+	}
+
+	want := []string{"a:before", "b:before", "final", "b:after", "a:after"}
+	if len(order) != len(want) {
+		t.Fatalf("order=%v, want %v", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("order=%v, want %v", order, want)
+		}
+	}
+}
+
+func TestRecovery_ConvertsPanicToInternalPanicError(t *testing.T) {
+	panicking := func(ctx context.Context, providers []string, req coreexecutor.Request, opts coreexecutor.Options) (coreexecutor.Response, error) {
+		panic("boom")
+	}
+
+	chain := Chain(Recovery())
+	_, err := chain(context.Background(), nil, coreexecutor.Request{}, coreexecutor.Options{}, panicking)
+	if err == nil {
+		t.Fatal("expected an error recovered from the panic")
+	}
+	authErr, ok := err.(*coreauth.Error)
+	if !ok {
+		t.Fatalf("err=%T, want *coreauth.Error", err)
+	}
+	if authErr.Code != "internal_panic" || authErr.HTTPStatus != 500 {
+		t.Fatalf("unexpected recovered error: %+v", authErr)
+	}
+}
+
+func TestClaudeFailover_RetriesOnceAgainstTargetModel(t *testing.T) {
+	calls := 0
+	handler := func(ctx context.Context, providers []string, req coreexecutor.Request, opts coreexecutor.Options) (coreexecutor.Response, error) {
+		calls++
+		if req.Model == "claude-opus-4-6" {
+			return coreexecutor.Response{}, errors.New("unknown provider")
+		}
+		return coreexecutor.Response{Payload: []byte(req.Model)}, nil
+	}
+
+	interceptor := ClaudeFailover(
+		func(ctx context.Context, requestedModel string) (string, bool) { return "gpt-5-codex", true },
+		func(ctx context.Context, targetModel string) ([]string, string, bool) { return []string{"codex"}, targetModel, true },
+		func(err error) bool { return err != nil },
+		nil,
+	)
+
+	chain := Chain(interceptor)
+	resp, err := chain(context.Background(), []string{"claude"}, coreexecutor.Request{Model: "claude-opus-4-6"}, coreexecutor.Options{}, handler)
+	if err != nil {
+		t.Fatalf("unexpected error after failover: %v", err)
+	}
+	if string(resp.Payload) != "gpt-5-codex" {
+		t.Fatalf("payload=%q, want failover model echoed back", resp.Payload)
+	}
+	if calls != 2 {
+		t.Fatalf("calls=%d, want 2 (original + failover)", calls)
+	}
+}
+
+func TestClaudeFailover_NoRetryWhenNotEligible(t *testing.T) {
+	calls := 0
+	handler := func(ctx context.Context, providers []string, req coreexecutor.Request, opts coreexecutor.Options) (coreexecutor.Response, error) {
+		calls++
+		return coreexecutor.Response{}, errors.New("boom")
+	}
+
+	interceptor := ClaudeFailover(
+		func(ctx context.Context, requestedModel string) (string, bool) { return "gpt-5-codex", true },
+		func(ctx context.Context, targetModel string) ([]string, string, bool) { return []string{"codex"}, targetModel, true },
+		func(err error) bool { return false },
+		nil,
+	)
+
+	chain := Chain(interceptor)
+	if _, err := chain(context.Background(), []string{"claude"}, coreexecutor.Request{Model: "claude-opus-4-6"}, coreexecutor.Options{}, handler); err == nil {
+		t.Fatal("expected the original error to propagate")
+	}
+	if calls != 1 {
+		t.Fatalf("calls=%d, want 1 (no failover retry)", calls)
+	}
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// Recovery returns a UnaryInterceptor that converts a panic raised anywhere
+// later in the chain into a coreauth.Error{Code: "internal_panic",
+// HTTPStatus: 500} instead of crashing the server or leaking a raw
+// stacktrace to the client. Put it first in a Chain(...) call so every other
+// interceptor's panics are recovered too.
+func Recovery() UnaryInterceptor {
+	return func(ctx context.Context, providers []string, req coreexecutor.Request, opts coreexecutor.Options, next UnaryHandler) (resp coreexecutor.Response, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = &coreauth.Error{Code: "internal_panic", Message: fmt.Sprintf("recovered panic: %v", r), HTTPStatus: 500}
+			}
+		}()
+		return next(ctx, providers, req, opts)
+	}
+}
+
+// RecoveryStream is Recovery's streaming counterpart. It only recovers a
+// panic raised while next sets up the stream; once next has returned a
+// channel, a panic in the goroutine feeding it is outside this defer's reach
+// and must be handled by that goroutine itself.
+func RecoveryStream() StreamInterceptor {
+	return func(ctx context.Context, providers []string, req coreexecutor.Request, opts coreexecutor.Options, next StreamHandler) (chunks <-chan coreexecutor.StreamChunk, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				chunks = nil
+				err = &coreauth.Error{Code: "internal_panic", Message: fmt.Sprintf("recovered panic: %v", r), HTTPStatus: 500}
+			}
+		}()
+		return next(ctx, providers, req, opts)
+	}
+}
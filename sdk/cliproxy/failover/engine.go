@@ -0,0 +1,212 @@
+// Package failover implements a data-driven replacement for the hard-coded
+// Claude -> target-model retry branches in
+// sdk/api/handlers.BaseAPIHandler.ExecuteWithAuthManager /
+// ExecuteCountWithAuthManager: a Rule list an Engine walks in order,
+// matching a failed call's provider/model/status/error against each Rule's
+// Match and, on the first hit, returning its Action describing where and
+// how to retry.
+//
+// This is deliberately narrower than internal/config.APIKeyPolicy's
+// FailoverChainFor: that one resolves a per-API-key, per-request-model
+// target (operators configure it in APIKeyPolicies.failover), while Engine
+// resolves process-wide, provider-pair rules that don't depend on which API
+// key is calling (e.g. "Gemini -> Vertex on any 429"). BaseAPIHandler's
+// ExecuteWithAuthManager consults both: the policy chain first (it is the
+// more specific, operator-configured one), then the Engine only when the
+// chain attempted no hop for the failed call. Unifying
+// the two onto one Action/Rule shape is left to a follow-up change, since
+// APIKeyPolicy's target model is resolved per-key from config the Engine has
+// no access to today.
+package failover
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/policy"
+)
+
+// Match selects which failed calls a Rule applies to. Every non-empty field
+// must match for the Rule to fire; a zero-value field is a wildcard.
+type Match struct {
+	// FromProvider is the provider the failed call was made against, e.g.
+	// "gemini". Empty matches any provider.
+	FromProvider string
+	// FromModelGlob is matched against the requested model via
+	// policy.MatchWildcard (case-insensitive, '*' wildcard). Empty matches
+	// any model.
+	FromModelGlob string
+	// StatusCodes, if non-empty, restricts the Rule to these HTTP statuses.
+	StatusCodes []int
+	// ErrorRegex, if non-empty, is matched against the error's message.
+	// Compiled once by Engine.AddRule; an invalid pattern is rejected there.
+	ErrorRegex string
+	// ErrorSubstrings, if non-empty, matches if the error message contains
+	// any one of these (case-insensitive).
+	ErrorSubstrings []string
+
+	compiledErrorRegex *regexp.Regexp
+}
+
+// Action describes how to retry a call a Match selected.
+type Action struct {
+	// ToProvider is the provider the retry should run against.
+	ToProvider string
+	// ToModel is the model name the retry should request. Required unless
+	// RewriteModel is false and the caller intends to reuse the original
+	// model against a different provider.
+	ToModel string
+	// RewriteModel controls whether the outgoing request payload's "model"
+	// field (and the response's, on the way back) is rewritten to ToModel.
+	RewriteModel bool
+	// PreserveMetadata copies the failed request's execution metadata
+	// (idempotency key, requested-model tag, etc.) onto the retry instead of
+	// starting a fresh map.
+	PreserveMetadata bool
+	// MaxHops caps how many times this Rule may fire for a single original
+	// request. Zero means 1. The caller (BaseAPIHandler) is responsible for
+	// tracking hops already taken and stopping once MaxHops is reached, so a
+	// misconfigured cycle of rules can't retry forever.
+	MaxHops int
+}
+
+// Rule pairs one Match with the Action to take when it hits.
+type Rule struct {
+	Name   string
+	Match  Match
+	Action Action
+}
+
+// Engine walks an ordered Rule list, returning the first match for a failed
+// call. It is safe for concurrent use: rules are only ever read after
+// construction.
+type Engine struct {
+	rules []Rule
+}
+
+// NewEngine returns an Engine with no rules. Use AddRule or
+// NewDefaultEngine to populate it.
+func NewEngine() *Engine {
+	return &Engine{}
+}
+
+// NewDefaultEngine returns an Engine preloaded with the built-in presets
+// that ship with this repo: Gemini -> Vertex on 429, and Codex -> Claude on
+// an "auth_unavailable" error. It does not include a Claude -> target-model
+// rule, since that target is resolved per-API-key from APIKeyPolicy and
+// BaseAPIHandler already applies it ahead of the Engine; existing
+// deployments see no behavior change from that path switching to an empty
+// default Engine underneath it.
+func NewDefaultEngine() *Engine {
+	e := NewEngine()
+	_ = e.AddRule(Rule{
+		Name: "gemini-to-vertex-on-429",
+		Match: Match{
+			FromProvider: "gemini",
+			StatusCodes:  []int{429},
+		},
+		Action: Action{
+			ToProvider:       "vertex",
+			RewriteModel:     false,
+			PreserveMetadata: true,
+			MaxHops:          1,
+		},
+	})
+	_ = e.AddRule(Rule{
+		Name: "codex-to-claude-on-auth-unavailable",
+		Match: Match{
+			FromProvider:    "codex",
+			ErrorSubstrings: []string{"auth_unavailable"},
+		},
+		Action: Action{
+			ToProvider:       "claude",
+			RewriteModel:     false,
+			PreserveMetadata: true,
+			MaxHops:          1,
+		},
+	})
+	return e
+}
+
+// AddRule appends rule to the engine's rule list, compiling its ErrorRegex
+// (if set) up front so Resolve never pays a compile cost. It returns a
+// compile error rather than panicking, since rules may come from
+// operator-supplied config.
+func (e *Engine) AddRule(rule Rule) error {
+	if strings.TrimSpace(rule.Match.ErrorRegex) != "" {
+		re, err := regexp.Compile(rule.Match.ErrorRegex)
+		if err != nil {
+			return err
+		}
+		rule.Match.compiledErrorRegex = re
+	}
+	e.rules = append(e.rules, rule)
+	return nil
+}
+
+// Rules returns a copy of the engine's current rule list, in match order.
+func (e *Engine) Rules() []Rule {
+	if e == nil {
+		return nil
+	}
+	out := make([]Rule, len(e.rules))
+	copy(out, e.rules)
+	return out
+}
+
+// Resolve returns the Action of the first rule whose Match selects
+// (fromProvider, fromModel, statusCode, errMsg), and the matching rule's
+// name. ok is false if no rule matches.
+func (e *Engine) Resolve(fromProvider, fromModel string, statusCode int, errMsg string) (action Action, ruleName string, ok bool) {
+	if e == nil {
+		return Action{}, "", false
+	}
+	for _, rule := range e.rules {
+		if !rule.Match.matches(fromProvider, fromModel, statusCode, errMsg) {
+			continue
+		}
+		return rule.Action, rule.Name, true
+	}
+	return Action{}, "", false
+}
+
+func (m Match) matches(provider, model string, statusCode int, errMsg string) bool {
+	if m.FromProvider != "" && !strings.EqualFold(m.FromProvider, provider) {
+		return false
+	}
+	if m.FromModelGlob != "" && !policy.MatchWildcard(strings.ToLower(m.FromModelGlob), policy.NormaliseModelKey(model)) {
+		return false
+	}
+	if len(m.StatusCodes) > 0 {
+		hit := false
+		for _, code := range m.StatusCodes {
+			if code == statusCode {
+				hit = true
+				break
+			}
+		}
+		if !hit {
+			return false
+		}
+	}
+	if m.compiledErrorRegex != nil && !m.compiledErrorRegex.MatchString(errMsg) {
+		return false
+	}
+	if len(m.ErrorSubstrings) > 0 {
+		lower := strings.ToLower(errMsg)
+		hit := false
+		for _, sub := range m.ErrorSubstrings {
+			if sub == "" {
+				continue
+			}
+			if strings.Contains(lower, strings.ToLower(sub)) {
+				hit = true
+				break
+			}
+		}
+		if !hit {
+			return false
+		}
+	}
+	return true
+}
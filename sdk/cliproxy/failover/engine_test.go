@@ -0,0 +1,92 @@
+package failover
+
+import "testing"
+
+func TestEngine_ResolveMatchesStatusCodeAndProvider(t *testing.T) {
+	e := NewEngine()
+	if err := e.AddRule(Rule{
+		Name:  "gemini-429",
+		Match: Match{FromProvider: "gemini", StatusCodes: []int{429}},
+		Action: Action{ToProvider: "vertex", MaxHops: 1},
+	}); err != nil {
+		t.Fatalf("AddRule: %v", err)
+	}
+
+	action, name, ok := e.Resolve("gemini", "gemini-2.5-pro", 429, "rate limited")
+	if !ok || name != "gemini-429" || action.ToProvider != "vertex" {
+		t.Fatalf("Resolve=%+v name=%q ok=%v, want vertex match", action, name, ok)
+	}
+
+	if _, _, ok := e.Resolve("gemini", "gemini-2.5-pro", 500, "server error"); ok {
+		t.Fatal("Resolve matched a status code the rule doesn't cover")
+	}
+	if _, _, ok := e.Resolve("codex", "gpt-5.2", 429, "rate limited"); ok {
+		t.Fatal("Resolve matched a provider the rule doesn't cover")
+	}
+}
+
+func TestEngine_ResolveMatchesErrorSubstring(t *testing.T) {
+	e := NewEngine()
+	if err := e.AddRule(Rule{
+		Name:   "codex-auth-unavailable",
+		Match:  Match{FromProvider: "codex", ErrorSubstrings: []string{"auth_unavailable"}},
+		Action: Action{ToProvider: "claude", MaxHops: 1},
+	}); err != nil {
+		t.Fatalf("AddRule: %v", err)
+	}
+
+	if _, _, ok := e.Resolve("codex", "gpt-5.2", 401, "AUTH_UNAVAILABLE: token expired"); !ok {
+		t.Fatal("Resolve: want case-insensitive substring match to hit")
+	}
+	if _, _, ok := e.Resolve("codex", "gpt-5.2", 401, "invalid request"); ok {
+		t.Fatal("Resolve matched an error message without the configured substring")
+	}
+}
+
+func TestEngine_ResolveMatchesErrorRegex(t *testing.T) {
+	e := NewEngine()
+	if err := e.AddRule(Rule{
+		Name:   "regex-rule",
+		Match:  Match{ErrorRegex: `^quota_exceeded:\d+$`},
+		Action: Action{ToProvider: "fallback"},
+	}); err != nil {
+		t.Fatalf("AddRule: %v", err)
+	}
+
+	if _, _, ok := e.Resolve("any", "any", 0, "quota_exceeded:42"); !ok {
+		t.Fatal("Resolve: want regex match to hit")
+	}
+	if _, _, ok := e.Resolve("any", "any", 0, "quota_exceeded:abc"); ok {
+		t.Fatal("Resolve matched an error message the regex doesn't fully match")
+	}
+}
+
+func TestEngine_AddRuleRejectsInvalidRegex(t *testing.T) {
+	e := NewEngine()
+	err := e.AddRule(Rule{Match: Match{ErrorRegex: "(("}})
+	if err == nil {
+		t.Fatal("AddRule: want error for invalid regex, got nil")
+	}
+}
+
+func TestEngine_FirstMatchingRuleWins(t *testing.T) {
+	e := NewEngine()
+	_ = e.AddRule(Rule{Name: "first", Match: Match{FromProvider: "gemini"}, Action: Action{ToProvider: "a"}})
+	_ = e.AddRule(Rule{Name: "second", Match: Match{FromProvider: "gemini"}, Action: Action{ToProvider: "b"}})
+
+	action, name, ok := e.Resolve("gemini", "model", 0, "")
+	if !ok || name != "first" || action.ToProvider != "a" {
+		t.Fatalf("Resolve=%+v name=%q ok=%v, want the first rule to win", action, name, ok)
+	}
+}
+
+func TestNewDefaultEngine_IncludesGeminiAndCodexPresets(t *testing.T) {
+	e := NewDefaultEngine()
+
+	if _, _, ok := e.Resolve("gemini", "gemini-2.5-pro", 429, "rate limited"); !ok {
+		t.Fatal("NewDefaultEngine: want a Gemini -> Vertex preset on 429")
+	}
+	if _, _, ok := e.Resolve("codex", "gpt-5.2", 401, "auth_unavailable"); !ok {
+		t.Fatal("NewDefaultEngine: want a Codex -> Claude preset on auth_unavailable")
+	}
+}